@@ -19,6 +19,7 @@ package apiclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -28,11 +29,13 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fluidkeys/api/v1structs"
-	"github.com/fluidkeys/crypto/openpgp/clearsign"
 	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
 	"github.com/fluidkeys/fluidkeys/pgpkey"
 	"github.com/fluidkeys/fluidkeys/team"
@@ -49,6 +52,19 @@ type Client struct {
 	client    *http.Client // HTTP client used to communicate with the API.
 	BaseURL   *url.URL     // Base URL for API requests
 	UserAgent string       // User agent used when communicating with the  API.
+
+	// NonceStore, if set, remembers the single use UUIDs in signed requests between runs
+	NonceStore NonceStore
+
+	capabilities      *ServerCapabilities // cached result of GetServerCapabilities
+	capabilitiesMutex sync.Mutex
+
+	usedNonces      map[string]bool // single use UUIDs used by this client, see useNonce
+	usedNoncesMutex sync.Mutex
+
+	retryConfig RetryConfig
+	after       func(time.Duration) <-chan time.Time // waits between retries, time.After if nil
+	random      func() float64                       // for retry jitter, rand.Float64 if nil
 }
 
 var (
@@ -58,13 +74,25 @@ var (
 	// ErrTeamNotFound means the response was OK, but no team was found
 	ErrTeamNotFound = fmt.Errorf("Team not found")
 
+	// ErrSecretNotFound means there's no secret with the given UUID waiting for the given key
+	ErrSecretNotFound = fmt.Errorf("Secret not found")
+
 	// ErrForbidden means the given user doesn't have access to the given resource, for example
 	// the requester key isn't a member of a requested team.
 	ErrForbidden = fmt.Errorf("Forbidden")
+
+	// ErrRevocationInvalid means the server rejected a revocation certificate, for example
+	// because it isn't validly signed by the key it revokes.
+	ErrRevocationInvalid = fmt.Errorf("Revocation certificate invalid")
+
+	// ErrKeyConflict means a conditional UpsertPublicKey was refused because the server has a
+	// different version of the key to the one the update was based on. Fetch the latest key,
+	// merge the changes and try again.
+	ErrKeyConflict = fmt.Errorf("Key has been changed on the server")
 )
 
 // New returns a new Fluidkeys Server API client.
-func New(fluidkeysVersion string) *Client {
+func New(fluidkeysVersion string, options ...ClientOption) *Client {
 	apiURL, got := os.LookupEnv("FLUIDKEYS_API_URL") // e.g. http://localhost:4747/v1/
 	if !got {
 		apiURL = defaultBaseURL
@@ -75,11 +103,24 @@ func New(fluidkeysVersion string) *Client {
 		log.Panic(fmt.Errorf("error parsing URL '%s': %v", apiURL, err))
 	}
 
-	return &Client{
-		client:    http.DefaultClient,
-		BaseURL:   parsedURL,
-		UserAgent: userAgent + "-" + fluidkeysVersion,
+	httpClient := &http.Client{Transport: newHTTPTransport()}
+	if pin, got := os.LookupEnv("FLUIDKEYS_TLS_PIN"); got { // see newPinnedHTTPClient
+		httpClient, err = newPinnedHTTPClient(pin, nil)
+		if err != nil {
+			log.Panic(fmt.Errorf("invalid FLUIDKEYS_TLS_PIN '%s': %v", pin, err))
+		}
+	}
+
+	client := &Client{
+		client:      httpClient,
+		BaseURL:     parsedURL,
+		UserAgent:   userAgent + "-" + fluidkeysVersion,
+		retryConfig: DefaultRetryConfig,
+	}
+	for _, option := range options {
+		option(client)
 	}
+	return client
 }
 
 // GetPublicKey attempts to get a single armored public key.
@@ -109,7 +150,7 @@ func (c *Client) GetPublicKeyByFingerprint(fingerprint fpr.Fingerprint) (*pgpkey
 		return nil, err
 	}
 
-	response, err := c.client.Do(request)
+	response, err := c.sendWithRetries(request)
 	if err != nil {
 		return nil, err
 	}
@@ -152,12 +193,27 @@ func (c *Client) GetPublicKeyByFingerprint(fingerprint fpr.Fingerprint) (*pgpkey
 	return retrievedKey, nil
 }
 
-// CreateSecret creates a secret for the given recipient
-func (c *Client) CreateSecret(recipientFingerprint fpr.Fingerprint, armoredEncryptedSecret string) error {
-	sendSecretRequest := v1structs.SendSecretRequest{
-		RecipientFingerprint:   recipientFingerprint.Uri(),
-		ArmoredEncryptedSecret: armoredEncryptedSecret,
+// CreateSecret creates a secret for the given recipient. Optional fields, such as an expiry
+// time, can be set with CreateSecretOptions.
+func (c *Client) CreateSecret(recipientFingerprint fpr.Fingerprint, armoredEncryptedSecret string,
+	options ...CreateSecretOption) error {
+
+	maxBytes := c.capabilitiesOrDefault().MaxSecretBytes
+	if maxBytes > 0 && len(armoredEncryptedSecret) > maxBytes {
+		return fmt.Errorf("secret is too large: %d bytes (server accepts up to %d)",
+			len(armoredEncryptedSecret), maxBytes)
+	}
+
+	sendSecretRequest := createSecretRequest{
+		SendSecretRequest: v1structs.SendSecretRequest{
+			RecipientFingerprint:   recipientFingerprint.Uri(),
+			ArmoredEncryptedSecret: armoredEncryptedSecret,
+		},
 	}
+	for _, option := range options {
+		option(&sendSecretRequest)
+	}
+
 	request, err := c.newRequest("POST", "secrets", sendSecretRequest)
 	if err != nil {
 		return err
@@ -167,6 +223,24 @@ func (c *Client) CreateSecret(recipientFingerprint fpr.Fingerprint, armoredEncry
 	return err
 }
 
+// CreateSecretOption sets an optional field on a secret created with CreateSecret
+type CreateSecretOption func(*createSecretRequest)
+
+// WithExpiresAt asks the server to delete the secret at expiresAt if it hasn't been received
+// by then. Check ServerCapabilities.SupportsSecretExpiry first: servers which don't support
+// expiry ignore it.
+func WithExpiresAt(expiresAt time.Time) CreateSecretOption {
+	return func(r *createSecretRequest) {
+		r.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	}
+}
+
+// createSecretRequest extends v1structs.SendSecretRequest with optional fields
+type createSecretRequest struct {
+	v1structs.SendSecretRequest
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
 // UpsertTeam takes a roster, signature and fingerprint to sign the request and attempts to
 // create a secret for the given recipient
 func (c *Client) UpsertTeam(roster string, rosterSignature string,
@@ -187,13 +261,13 @@ func (c *Client) UpsertTeam(roster string, rosterSignature string,
 }
 
 // ListSecrets for a particular fingerprint.
-func (c *Client) ListSecrets(fingerprint fpr.Fingerprint) ([]v1structs.Secret, error) {
+func (c *Client) ListSecrets(fingerprint fpr.Fingerprint) ([]Secret, error) {
 	request, err := c.newRequest("GET", "secrets", nil)
 	if err != nil {
 		return nil, err
 	}
 	request.Header.Add("authorization", authorization(fingerprint))
-	decodedJSON := new(v1structs.ListSecretsResponse)
+	decodedJSON := new(listSecretsResponse)
 	_, err = c.do(request, &decodedJSON)
 	if err != nil {
 		return nil, err
@@ -202,7 +276,22 @@ func (c *Client) ListSecrets(fingerprint fpr.Fingerprint) ([]v1structs.Secret, e
 	return decodedJSON.Secrets, nil
 }
 
-// DeleteSecret deletes a secret
+// Secret is a secret waiting to be received, as returned by ListSecrets. UUID is only set by
+// servers which report it.
+type Secret struct {
+	v1structs.Secret
+
+	// UUID identifies the secret without decrypting its metadata, or is empty if unknown
+	UUID string `json:"uuid,omitempty"`
+}
+
+// listSecretsResponse extends v1structs.ListSecretsResponse with the extra fields in Secret
+type listSecretsResponse struct {
+	Secrets []Secret `json:"secrets"`
+}
+
+// DeleteSecret deletes a secret. If there's no secret with the given UUID waiting for the key,
+// it returns ErrSecretNotFound.
 func (c *Client) DeleteSecret(fingerprint fpr.Fingerprint, uuid string) error {
 	path := fmt.Sprintf("secrets/%s", uuid)
 	request, err := c.newRequest("DELETE", path, nil)
@@ -210,15 +299,85 @@ func (c *Client) DeleteSecret(fingerprint fpr.Fingerprint, uuid string) error {
 		return err
 	}
 	request.Header.Add("authorization", authorization(fingerprint))
-	_, err = c.do(request, nil)
+	response, err := c.do(request, nil)
+	if err != nil && response != nil && response.StatusCode == http.StatusNotFound {
+		return ErrSecretNotFound
+	}
 	return err
 }
 
+// SecretMetadata describes a secret without its encrypted content, as returned by
+// GetSecretMetadata.
+type SecretMetadata struct {
+	// Size is the size of the armored encrypted secret in bytes
+	Size int
+
+	// CreatedAt is when the secret was sent, or the zero time if the server didn't say
+	CreatedAt time.Time
+}
+
+// GetSecretMetadata gets the size and creation time of a secret using a HEAD request, so the
+// encrypted content isn't downloaded.
+func (c *Client) GetSecretMetadata(fingerprint fpr.Fingerprint, uuid string) (
+	*SecretMetadata, error) {
+
+	path := fmt.Sprintf("secrets/%s", uuid)
+	request, err := c.newRequest("HEAD", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("authorization", authorization(fingerprint))
+	response, err := c.do(request, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseSecretMetadata(response.Header)
+}
+
+// parseSecretMetadata reads the X-Secret-Size and (optional) X-Created-At headers.
+func parseSecretMetadata(header http.Header) (*SecretMetadata, error) {
+	sizeHeader := header.Get("X-Secret-Size")
+	if sizeHeader == "" {
+		return nil, fmt.Errorf("missing X-Secret-Size header")
+	}
+	size, err := strconv.Atoi(sizeHeader)
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("invalid X-Secret-Size header '%s'", sizeHeader)
+	}
+
+	metadata := SecretMetadata{Size: size}
+
+	if createdAtHeader := header.Get("X-Created-At"); createdAtHeader != "" {
+		metadata.CreatedAt, err = time.Parse(time.RFC3339, createdAtHeader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid X-Created-At header '%s': %v", createdAtHeader, err)
+		}
+	}
+	return &metadata, nil
+}
+
 // UpsertPublicKey creates or updates a public key in the Fluidkeys Directory.
 // It requires privateKey to ensure that only the owner of the public key can
-// upload it.
-func (c *Client) UpsertPublicKey(armoredPublicKey string, privateKey *pgpkey.PgpKey) error {
-	armoredSignedJSON, err := makeUpsertPublicKeySignedData(armoredPublicKey, privateKey)
+// upload it. Pass IfMatch to only replace the key if the server's copy hasn't changed.
+func (c *Client) UpsertPublicKey(armoredPublicKey string, privateKey *pgpkey.PgpKey,
+	options ...UpsertPublicKeyOption) error {
+
+	if !privateKey.HasSigningCapability() {
+		return pgpkey.ErrNoSigningCapability
+	}
+
+	upsertOptions := upsertPublicKeyOptions{}
+	for _, option := range options {
+		option(&upsertOptions)
+	}
+
+	singleUseUUID, err := c.newSingleUseUUID()
+	if err != nil {
+		return err
+	}
+
+	armoredSignedJSON, err := makeUpsertPublicKeySignedData(
+		armoredPublicKey, privateKey, singleUseUUID)
 	if err != nil {
 		return fmt.Errorf("Failed to create ArmoredSignedJSON: %s", err)
 	}
@@ -230,9 +389,42 @@ func (c *Client) UpsertPublicKey(armoredPublicKey string, privateKey *pgpkey.Pgp
 	if err != nil {
 		return fmt.Errorf("Failed to upload key: %s", err)
 	}
+	if upsertOptions.ifMatch != "" && c.capabilitiesOrDefault().SupportsETag {
+		request.Header.Set("If-Match", `"`+upsertOptions.ifMatch+`"`)
+	}
+
 	decodedUpsertResponse := new(v1structs.UpsertPublicKeyResponse)
-	_, err = c.do(request, &decodedUpsertResponse)
-	return err
+	response, err := c.do(request, &decodedUpsertResponse)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusPreconditionFailed {
+			return ErrKeyConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// UpsertPublicKeyOption sets an optional condition on an upload made with UpsertPublicKey
+type UpsertPublicKeyOption func(*upsertPublicKeyOptions)
+
+// IfMatch makes the upload conditional on the server's copy of the key being the one whose
+// SHA-256 is keyETag (see KeyETag): if it's been changed since, UpsertPublicKey returns
+// ErrKeyConflict. Servers which don't support ETags (ServerCapabilities.SupportsETag) replace
+// the key unconditionally.
+func IfMatch(keyETag string) UpsertPublicKeyOption {
+	return func(o *upsertPublicKeyOptions) {
+		o.ifMatch = keyETag
+	}
+}
+
+type upsertPublicKeyOptions struct {
+	ifMatch string
+}
+
+// KeyETag returns the hex SHA-256 of an armored public key, which the server uses as the key's
+// ETag.
+func KeyETag(armoredPublicKey string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(armoredPublicKey)))
 }
 
 // GetTeamName attempts to get the team name
@@ -254,6 +446,49 @@ func (c *Client) GetTeamName(teamUUID uuid.UUID) (string, error) {
 	return decodedJSON.Name, nil
 }
 
+// TeamSummary describes a team returned by ListTeams
+type TeamSummary struct {
+	UUID        uuid.UUID
+	Name        string
+	MemberCount int
+}
+
+// ListTeams lists the teams whose roster includes the given fingerprint.
+func (c *Client) ListTeams(fingerprint fpr.Fingerprint) (teams []TeamSummary, err error) {
+	request, err := c.newRequest("GET", "teams", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("authorization", authorization(fingerprint))
+	decodedJSON := new(listTeamsResponse)
+	if _, err = c.do(request, &decodedJSON); err != nil {
+		return nil, err
+	}
+
+	for _, jsonTeam := range decodedJSON.Teams {
+		teamUUID, err := uuid.FromString(jsonTeam.UUID)
+		if err != nil {
+			log.Printf("ignoring team with invalid UUID '%s': %v", jsonTeam.UUID, err)
+			continue
+		}
+		teams = append(teams, TeamSummary{
+			UUID:        teamUUID,
+			Name:        jsonTeam.Name,
+			MemberCount: jsonTeam.MemberCount,
+		})
+	}
+	return teams, nil
+}
+
+// listTeamsResponse is the JSON structure returned by the list teams API endpoint
+type listTeamsResponse struct {
+	Teams []struct {
+		UUID        string `json:"uuid"`
+		Name        string `json:"name"`
+		MemberCount int    `json:"memberCount"`
+	} `json:"teams"`
+}
+
 // GetTeamRoster attempts to get the team roster and signature for the given UUID. The API
 // responds with encrypted JSON, so it tries to decrypt this using the requestingKey.
 func (c *Client) GetTeamRoster(teamUUID uuid.UUID, me fpr.Fingerprint) (
@@ -265,6 +500,9 @@ func (c *Client) GetTeamRoster(teamUUID uuid.UUID, me fpr.Fingerprint) (
 		return "", "", err
 	}
 	request.Header.Add("authorization", authorization(me))
+	// large rosters compress well. do decompresses the response before it's decoded, so the
+	// roster returned is byte-for-byte the one that was signed.
+	request.Header.Set("Accept-Encoding", "gzip")
 	decodedJSON := new(v1structs.GetTeamRosterResponse)
 	response, err := c.do(request, &decodedJSON)
 	if err != nil {
@@ -288,11 +526,16 @@ func (c *Client) GetTeamRoster(teamUUID uuid.UUID, me fpr.Fingerprint) (
 
 // RequestToJoinTeam posts a request to join the team identified by the UUID with the
 // given fingerprint and email
-func (c *Client) RequestToJoinTeam(
-	teamUUID uuid.UUID, fingerprint fpr.Fingerprint, email string) (err error) {
+func (c *Client) RequestToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fingerprint, email string,
+	options ...RequestToJoinTeamOption) (err error) {
 
 	path := fmt.Sprintf("team/%s/requests-to-join", teamUUID)
-	requestToJoinTeamRequest := v1structs.RequestToJoinTeamRequest{TeamEmail: email}
+	requestToJoinTeamRequest := requestToJoinTeamRequest{
+		RequestToJoinTeamRequest: v1structs.RequestToJoinTeamRequest{TeamEmail: email},
+	}
+	for _, option := range options {
+		option(&requestToJoinTeamRequest)
+	}
 
 	request, err := c.newRequest("POST", path, requestToJoinTeamRequest)
 	if err != nil {
@@ -311,6 +554,23 @@ func (c *Client) RequestToJoinTeam(
 	return nil
 }
 
+// RequestToJoinTeamOption sets an optional field on a request made with RequestToJoinTeam
+type RequestToJoinTeamOption func(*requestToJoinTeamRequest)
+
+// WithInvitationToken sends an invitation token made by `fk team invite` with the request. The
+// server stores it with the request so a team admin can approve it without prompting.
+func WithInvitationToken(token string) RequestToJoinTeamOption {
+	return func(r *requestToJoinTeamRequest) {
+		r.InvitationToken = token
+	}
+}
+
+// requestToJoinTeamRequest extends v1structs.RequestToJoinTeamRequest with optional fields
+type requestToJoinTeamRequest struct {
+	v1structs.RequestToJoinTeamRequest
+	InvitationToken string `json:"invitationToken,omitempty"`
+}
+
 // ListRequestsToJoinTeam for the team with the given UUID.
 func (c *Client) ListRequestsToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fingerprint) (
 	requestsToJoinTeam []team.RequestToJoinTeam, err error) {
@@ -321,7 +581,7 @@ func (c *Client) ListRequestsToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fing
 		return nil, err
 	}
 	request.Header.Add("authorization", authorization(fingerprint))
-	decodedJSON := new(v1structs.ListRequestsToJoinTeamResponse)
+	decodedJSON := new(listRequestsToJoinTeamResponse)
 	_, err = c.do(request, &decodedJSON)
 	if err != nil {
 		return nil, err
@@ -336,18 +596,35 @@ func (c *Client) ListRequestsToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fing
 			continue
 		}
 
+		requestedAt := time.Time{} // older servers don't return this
+		if jsonRequestToJoin.RequestedAt != nil {
+			requestedAt = *jsonRequestToJoin.RequestedAt
+		}
+
 		requestsToJoinTeam = append(requestsToJoinTeam, team.RequestToJoinTeam{
 			UUID:        requestUUID,
 			TeamUUID:    teamUUID,
 			Email:       jsonRequestToJoin.Email,
 			Fingerprint: requestFingerprint,
-			RequestedAt: time.Time{}, // API doesn't store this
+			RequestedAt: requestedAt,
+
+			InvitationToken: jsonRequestToJoin.InvitationToken,
 		})
 	}
 
 	return requestsToJoinTeam, nil
 }
 
+// listRequestsToJoinTeamResponse extends v1structs.ListRequestsToJoinTeamResponse with the
+// optional invitation token and time the request was made
+type listRequestsToJoinTeamResponse struct {
+	Requests []struct {
+		v1structs.RequestToJoinTeam
+		InvitationToken string     `json:"invitationToken,omitempty"`
+		RequestedAt     *time.Time `json:"requestedAt,omitempty"`
+	} `json:"requests"`
+}
+
 // DeleteRequestToJoinTeam deletes a request to join a team
 func (c *Client) DeleteRequestToJoinTeam(teamUUID uuid.UUID, requestUUID uuid.UUID) error {
 	path := fmt.Sprintf("team/%s/requests-to-join/%s", teamUUID, requestUUID)
@@ -401,17 +678,67 @@ func (c *Client) Log(event Event) error {
 	return err
 }
 
-func makeUpsertPublicKeySignedData(armoredPublicKey string, privateKey *pgpkey.PgpKey) (armoredSignedJSON string, err error) {
-	publicKeyHash := fmt.Sprintf("%X", sha256.Sum256([]byte(armoredPublicKey)))
+// MaxReportReasonLength is the longest reason, in characters, accepted by ReportKey
+const MaxReportReasonLength = 500
+
+// ReportKey reports a key to the Fluidkeys directory maintainers as compromised or abusive.
+func (c *Client) ReportKey(fingerprint fpr.Fingerprint, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("reason can't be empty")
+	}
+	if utf8.RuneCountInString(reason) > MaxReportReasonLength {
+		return fmt.Errorf("reason can't be longer than %d characters", MaxReportReasonLength)
+	}
 
-	singleTimeUUID, err := uuid.NewV4()
+	requestData := reportKeyRequest{
+		Fingerprint: fingerprint.Uri(),
+		Reason:      reason,
+	}
+	request, err := c.newRequest("POST", "reports", requestData)
 	if err != nil {
-		return "", fmt.Errorf("Couldn't generate UUID: %s", err)
+		return err
 	}
+	_, err = c.do(request, nil)
+	return err
+}
+
+type reportKeyRequest struct {
+	Fingerprint string `json:"fingerprint"`
+	Reason      string `json:"reason"`
+}
+
+// RevokeKey publishes the armored revocation certificate for the key with the given fingerprint,
+// for example made by pgpkey.PgpKey.Revoke. The server then serves the key with the revocation.
+// If the server rejects the certificate it returns ErrRevocationInvalid.
+func (c *Client) RevokeKey(fingerprint fpr.Fingerprint, revocationCert string) error {
+	requestData := revokeKeyRequest{
+		Fingerprint:                  fingerprint.Uri(),
+		ArmoredRevocationCertificate: revocationCert,
+	}
+	request, err := c.newRequest("POST", "revocations", requestData)
+	if err != nil {
+		return err
+	}
+	response, err := c.do(request, nil)
+	if err != nil && response != nil && (response.StatusCode == http.StatusBadRequest ||
+		response.StatusCode == http.StatusUnprocessableEntity) {
+		return ErrRevocationInvalid
+	}
+	return err
+}
+
+type revokeKeyRequest struct {
+	Fingerprint                  string `json:"fingerprint"`
+	ArmoredRevocationCertificate string `json:"armoredRevocationCertificate"`
+}
+
+func makeUpsertPublicKeySignedData(armoredPublicKey string, privateKey *pgpkey.PgpKey,
+	singleUseUUID uuid.UUID) (armoredSignedJSON string, err error) {
+	publicKeyHash := fmt.Sprintf("%X", sha256.Sum256([]byte(armoredPublicKey)))
 
 	publicKeyData := v1structs.UpsertPublicKeySignedData{
 		Timestamp:       time.Now(),
-		SingleUseUUID:   singleTimeUUID.String(),
+		SingleUseUUID:   singleUseUUID.String(),
 		PublicKeySHA256: publicKeyHash,
 	}
 
@@ -420,7 +747,7 @@ func makeUpsertPublicKeySignedData(armoredPublicKey string, privateKey *pgpkey.P
 		return "", fmt.Errorf("Couldn't marshal JSON: %s", err)
 	}
 
-	armoredSignedJSON, err = signText(jsonBytes, privateKey)
+	armoredSignedJSON, err = privateKey.MakeArmoredClearsignedText(jsonBytes)
 	if err != nil {
 		return "", fmt.Errorf("Couldn't marshal JSON: %s", err)
 	}
@@ -428,42 +755,44 @@ func makeUpsertPublicKeySignedData(armoredPublicKey string, privateKey *pgpkey.P
 	return armoredSignedJSON, nil
 }
 
-func signText(bytesToSign []byte, key *pgpkey.PgpKey) (armoredSigned string, err error) {
-	armorOutBuffer := bytes.NewBuffer(nil)
-	privKey := key.Entity.PrivateKey
-	if privKey == nil {
-		return "", fmt.Errorf("no private key provided for key %s", key.Fingerprint())
-	}
-	if privKey.Encrypted {
-		return "", fmt.Errorf("private key is encrypted %s", key.Fingerprint())
-	}
+// APIError is returned when the API responds with an unsuccessful HTTP status
+type APIError struct {
+	StatusCode int
 
-	armorWriteCloser, err := clearsign.Encode(armorOutBuffer, privKey, nil)
-	if err != nil {
-		return "", err
-	}
+	// Detail is the explanation given in the API's error response, if any
+	Detail string
 
-	_, err = armorWriteCloser.Write(bytesToSign)
-	if err != nil {
-		return "", err
+	// RequestID is taken from the X-Request-ID response header, if present, and identifies
+	// the request in the server logs
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	var message string
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		message = "Couldn't sign in to API"
+	case e.Detail != "":
+		message = fmt.Sprintf("API error: %d %s", e.StatusCode, e.Detail)
+	default:
+		message = fmt.Sprintf("API error: %d", e.StatusCode)
 	}
 
-	if err := armorWriteCloser.Close(); err != nil {
-		return "", err
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" (request ID %s)", e.RequestID)
 	}
-	return armorOutBuffer.String(), nil
+	return message
 }
 
-func makeErrorForAPIResponse(response *http.Response) error {
-	if response.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("Couldn't sign in to API")
+func makeErrorForAPIResponse(response *http.Response) *APIError {
+	apiError := &APIError{
+		StatusCode: response.StatusCode,
+		RequestID:  response.Header.Get("X-Request-ID"),
 	}
-
-	apiErrorResponseDetail := decodeErrorResponse(response)
-	if apiErrorResponseDetail != "" {
-		return fmt.Errorf("API error: %d %s", response.StatusCode, apiErrorResponseDetail)
+	if response.StatusCode != http.StatusUnauthorized {
+		apiError.Detail = decodeErrorResponse(response)
 	}
-	return fmt.Errorf("API error: %d", response.StatusCode)
+	return apiError
 }
 
 func decodeErrorResponse(response *http.Response) string {
@@ -517,10 +846,14 @@ func (c *Client) newRequest(method, relativePath string, requestData interface{}
 // do sends an API request and decodes the JSON response, storing it in the
 // value pointed to by responseData. If an API error occurs, it returns error.
 func (c *Client) do(req *http.Request, responseData interface{}) (response *http.Response, err error) {
-	response, err = c.client.Do(req)
+	response, err = c.sendWithRetries(req)
 	if err != nil {
 		return nil, err
 	}
+	if err := decompressBody(response); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
 	defer response.Body.Close()
 
 	if isSuccess(response.StatusCode) {
@@ -536,6 +869,36 @@ func (c *Client) do(req *http.Request, responseData interface{}) (response *http
 	return response, err
 }
 
+// decompressBody replaces a gzip encoded response body with the decompressed body.
+// http.Transport only does this itself if it added the Accept-Encoding header, not if the
+// request set it explicitly.
+func decompressBody(response *http.Response) error {
+	if response.Body == nil || !strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	gzipReader, err := gzip.NewReader(response.Body)
+	if err != nil {
+		return fmt.Errorf("error decompressing response: %v", err)
+	}
+	response.Body = &gzipReadCloser{Reader: gzipReader, body: response.Body}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+	return nil
+}
+
+// gzipReadCloser closes both the gzip reader and the compressed body underneath it
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
 func isJSON(response *http.Response) bool {
 	return response.Header.Get("Content-Type") == "application/json"
 }
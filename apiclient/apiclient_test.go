@@ -2,6 +2,7 @@ package apiclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -182,7 +183,7 @@ func TestGetPublicKeyByFingerprint(t *testing.T) {
 		_, err := client.GetPublicKeyByFingerprint(exampledata.ExampleFingerprint4)
 
 		assert.GotError(t, err)
-		assert.Equal(t, fmt.Errorf("API error: 500"), err)
+		assert.Equal(t, &APIError{StatusCode: http.StatusInternalServerError}, err)
 	})
 
 	t.Run("responds with junk", func(t *testing.T) {
@@ -268,6 +269,57 @@ func TestDecodeErrorResponse(t *testing.T) {
 	})
 }
 
+func TestMakeErrorForAPIResponse(t *testing.T) {
+	t.Run("extracts request ID from header", func(t *testing.T) {
+		httpResponse := http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Header:     http.Header{"X-Request-Id": []string{"7e4bd4a1"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"detail":"database down"}`)),
+		}
+		expected := &APIError{
+			StatusCode: http.StatusInternalServerError,
+			Detail:     "database down",
+			RequestID:  "7e4bd4a1",
+		}
+		got := makeErrorForAPIResponse(&httpResponse)
+		assert.Equal(t, *expected, *got)
+		assert.Equal(t, "API error: 500 database down (request ID 7e4bd4a1)", got.Error())
+	})
+
+	t.Run("without request ID or detail", func(t *testing.T) {
+		httpResponse := http.Response{StatusCode: http.StatusBadGateway}
+		got := makeErrorForAPIResponse(&httpResponse)
+		assert.Equal(t, APIError{StatusCode: http.StatusBadGateway}, *got)
+		assert.Equal(t, "API error: 502", got.Error())
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		httpResponse := http.Response{StatusCode: http.StatusUnauthorized}
+		got := makeErrorForAPIResponse(&httpResponse)
+		assert.Equal(t, "Couldn't sign in to API", got.Error())
+	})
+}
+
+func TestAPIErrorReturnedByClient(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/teams", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "c0ffee")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"detail":"missing authorization"}`)
+	})
+
+	_, err := client.ListTeams(exampledata.ExampleFingerprint4)
+	apiError, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	assert.Equal(t, http.StatusBadRequest, apiError.StatusCode)
+	assert.Equal(t, "missing authorization", apiError.Detail)
+	assert.Equal(t, "c0ffee", apiError.RequestID)
+}
+
 func TestUpsertTeam(t *testing.T) {
 	input := &v1structs.UpsertTeamRequest{
 		TeamRoster:               "# Fluidkeys team roster...",
@@ -321,7 +373,65 @@ func TestUpsertTeam(t *testing.T) {
 			fingerprint,
 		)
 
-		assert.Equal(t, fmt.Errorf("API error: 500 signing key not in roster"), err)
+		assert.Equal(t, &APIError{StatusCode: http.StatusInternalServerError, Detail: "signing key not in roster"}, err)
+	})
+}
+
+func TestListTeams(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+	uuid1 := uuid.Must(uuid.NewV4())
+	uuid2 := uuid.Must(uuid.NewV4())
+
+	t.Run("unmarshals multiple teams", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/teams", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "GET", r.Method)
+			assert.Equal(t, authorization(fingerprint), r.Header.Get("authorization"))
+
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"teams": [
+				{"uuid": "%s", "name": "Kiffix", "memberCount": 3},
+				{"uuid": "%s", "name": "Fluidkeys CIC", "memberCount": 12}
+			]}`, uuid1, uuid2)
+		})
+
+		got, err := client.ListTeams(fingerprint)
+		assert.NoError(t, err)
+		assert.Equal(t, []TeamSummary{
+			{UUID: uuid1, Name: "Kiffix", MemberCount: 3},
+			{UUID: uuid2, Name: "Fluidkeys CIC", MemberCount: 12},
+		}, got)
+	})
+
+	t.Run("skips teams with an invalid UUID", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/teams", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"teams": [
+				{"uuid": "not-a-uuid", "name": "Broken", "memberCount": 1},
+				{"uuid": "%s", "name": "Kiffix", "memberCount": 3}
+			]}`, uuid1)
+		})
+
+		got, err := client.ListTeams(fingerprint)
+		assert.NoError(t, err)
+		assert.Equal(t, []TeamSummary{{UUID: uuid1, Name: "Kiffix", MemberCount: 3}}, got)
+	})
+
+	t.Run("returns an error from the server", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/teams", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		_, err := client.ListTeams(fingerprint)
+		assert.GotError(t, err)
 	})
 }
 
@@ -395,7 +505,7 @@ func TestGetTeamName(t *testing.T) {
 		_, err := client.GetTeamName(teamUUID)
 
 		assert.GotError(t, err)
-		assert.Equal(t, fmt.Errorf("API error: 500"), err)
+		assert.Equal(t, &APIError{StatusCode: http.StatusInternalServerError}, err)
 	})
 }
 
@@ -440,6 +550,55 @@ func TestGetTeamRoster(t *testing.T) {
 		assert.Equal(t, expectedSignature, gotSignature)
 	})
 
+	t.Run("decompresses a gzipped roster before its signature is verified", func(t *testing.T) {
+		gzipUUID := uuid.Must(uuid.NewV4())
+
+		signedRoster := "# Fluidkeys team roster\n" + strings.Repeat("# padding\n", 100)
+		signature, err := requesterKey.MakeArmoredDetachedSignature([]byte(signedRoster))
+		assert.NoError(t, err)
+
+		responseJSON, err := json.Marshal(v1structs.GetTeamRosterResponse{
+			TeamRoster:               signedRoster,
+			ArmoredDetachedSignature: signature,
+		})
+		assert.NoError(t, err)
+
+		mux.HandleFunc(fmt.Sprintf("/team/%s/roster", gzipUUID),
+			func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "gzip", r.Header.Get("Accept-Encoding"))
+				w.Header().Add("Content-Type", "application/json")
+				w.Header().Add("Content-Encoding", "gzip")
+				w.WriteHeader(http.StatusOK)
+				gzipWriter := gzip.NewWriter(w)
+				gzipWriter.Write(responseJSON)
+				gzipWriter.Close()
+			},
+		)
+
+		gotRoster, gotSignature, err := client.GetTeamRoster(gzipUUID, requesterKey.Fingerprint())
+		assert.NoError(t, err)
+		assert.Equal(t, signedRoster, gotRoster)
+
+		err = team.VerifyRoster(gotRoster, []string{gotSignature},
+			[]*pgpkey.PgpKey{requesterKey}, 0, time.Now())
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns an error for a corrupt gzipped response", func(t *testing.T) {
+		corruptUUID := uuid.Must(uuid.NewV4())
+		mux.HandleFunc(fmt.Sprintf("/team/%s/roster", corruptUUID),
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Content-Type", "application/json")
+				w.Header().Add("Content-Encoding", "gzip")
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, string(teamRosterResponse))
+			},
+		)
+
+		_, _, err := client.GetTeamRoster(corruptUUID, requesterKey.Fingerprint())
+		assert.GotError(t, err)
+	})
+
 	t.Run("404 returns ErrTeamNotFound", func(t *testing.T) {
 		unknownUUID := uuid.Must(uuid.NewV4())
 		mockNotFoundResponseHandler := func(w http.ResponseWriter, r *http.Request) {
@@ -489,7 +648,7 @@ func TestGetTeamRoster(t *testing.T) {
 		_, _, err := client.GetTeamRoster(errorUUID, requesterKey.Fingerprint())
 
 		assert.GotError(t, err)
-		assert.Equal(t, fmt.Errorf("API error: 500"), err)
+		assert.Equal(t, &APIError{StatusCode: http.StatusInternalServerError}, err)
 	})
 }
 
@@ -572,7 +731,28 @@ func TestRequestToJoinTeam(t *testing.T) {
 			fingerprint,
 			"jane@example.com",
 		)
-		assert.Equal(t, fmt.Errorf("API error: 500 can't write to database"), err)
+		assert.Equal(t, &APIError{StatusCode: http.StatusInternalServerError, Detail: "can't write to database"}, err)
+	})
+
+	t.Run("sends an invitation token", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotBody map[string]interface{}
+		mux.HandleFunc(
+			fmt.Sprintf("/team/%s/requests-to-join", mockTeamUUID),
+			func(w http.ResponseWriter, r *http.Request) {
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+				w.WriteHeader(http.StatusCreated)
+			},
+		)
+
+		err = client.RequestToJoinTeam(
+			mockTeamUUID, fingerprint, "jane@example.com", WithInvitationToken("abc.def"),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "jane@example.com", gotBody["teamEmail"])
+		assert.Equal(t, "abc.def", gotBody["invitationToken"])
 	})
 }
 
@@ -640,6 +820,52 @@ func TestListRequestsToJoinTeam(t *testing.T) {
 		assert.Equal(t, expectedRequestsToJoin, got)
 	})
 
+	t.Run("includes invitation tokens", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc(
+			fmt.Sprintf("/team/%s/requests-to-join", teamUUID),
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Content-Type", "application/json")
+				fmt.Fprint(w, `{"requests": [{
+					"uuid": "8e26e4df0d474f7f9a07a37b2aa92104",
+					"email": "first@example.com",
+					"fingerprint": "OPENPGP4FPR:AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA",
+					"invitationToken": "abc.def"
+				}]}`)
+			},
+		)
+
+		got, err := client.ListRequestsToJoinTeam(teamUUID, authFingerprint)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(got))
+		assert.Equal(t, "abc.def", got[0].InvitationToken)
+	})
+
+	t.Run("includes the time the request was made", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc(
+			fmt.Sprintf("/team/%s/requests-to-join", teamUUID),
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Content-Type", "application/json")
+				fmt.Fprint(w, `{"requests": [{
+					"uuid": "8e26e4df0d474f7f9a07a37b2aa92104",
+					"email": "first@example.com",
+					"fingerprint": "OPENPGP4FPR:AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA",
+					"requestedAt": "2019-06-01T12:00:00Z"
+				}]}`)
+			},
+		)
+
+		got, err := client.ListRequestsToJoinTeam(teamUUID, authFingerprint)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(got))
+		assert.Equal(t, time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC), got[0].RequestedAt)
+	})
+
 	t.Run("drops any requests with invalid uuids", func(t *testing.T) {
 		client, mux, _, teardown := setup()
 		defer teardown()
@@ -781,10 +1007,308 @@ func TestDeleteRequestToJoinTeam(t *testing.T) {
 
 		err := client.DeleteRequestToJoinTeam(teamUUID, unknownRequestUUID)
 
-		assert.Equal(t, fmt.Errorf("API error: 404"), err)
+		assert.Equal(t, &APIError{StatusCode: http.StatusNotFound}, err)
+	})
+}
+
+func TestGetSecretMetadata(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+	secretUUID := "d8a4a3a6-e5a8-4f35-8b4f-8b3c6a1f2e01"
+
+	t.Run("reads size and creation time from the headers", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/secrets/"+secretUUID, func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "HEAD", r.Method)
+			assertClientSentValidAuthHeader(t, fingerprint, r.Header)
+			w.Header().Set("X-Secret-Size", "2048")
+			w.Header().Set("X-Created-At", "2019-06-20T16:35:00Z")
+		})
+
+		got, err := client.GetSecretMetadata(fingerprint, secretUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, &SecretMetadata{
+			Size:      2048,
+			CreatedAt: time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC),
+		}, got)
+	})
+
+	t.Run("passes up error codes", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/secrets/"+secretUUID, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		_, err := client.GetSecretMetadata(fingerprint, secretUUID)
+		assert.Equal(t, &APIError{StatusCode: http.StatusNotFound}, err)
 	})
 }
 
+func TestDeleteSecret(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+	secretUUID := "d8a4a3a6-e5a8-4f35-8b4f-8b3c6a1f2e01"
+
+	t.Run("sends DELETE for the secret", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/secrets/"+secretUUID, func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "DELETE", r.Method)
+			assertClientSentValidAuthHeader(t, fingerprint, r.Header)
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		assert.NoError(t, client.DeleteSecret(fingerprint, secretUUID))
+	})
+
+	t.Run("returns ErrSecretNotFound for 404", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/secrets/"+secretUUID, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		assert.Equal(t, ErrSecretNotFound, client.DeleteSecret(fingerprint, secretUUID))
+	})
+}
+
+func TestReportKey(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+
+	t.Run("sends the fingerprint and reason", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotRequest reportKeyRequest
+		mux.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "POST", r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&gotRequest))
+			w.WriteHeader(http.StatusCreated)
+		})
+
+		err := client.ReportKey(fingerprint, "private key was posted publicly")
+		assert.NoError(t, err)
+		assert.Equal(t, reportKeyRequest{
+			Fingerprint: fingerprint.Uri(),
+			Reason:      "private key was posted publicly",
+		}, gotRequest)
+	})
+
+	t.Run("passes up error codes", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		err := client.ReportKey(fingerprint, "spam")
+		assert.Equal(t, &APIError{StatusCode: http.StatusTooManyRequests}, err)
+	})
+
+	t.Run("validates the reason without calling the API", func(t *testing.T) {
+		client := New("vtest")
+		client.BaseURL = nil // would panic if a request were made
+
+		assert.Equal(t, fmt.Errorf("reason can't be empty"), client.ReportKey(fingerprint, " "))
+
+		tooLong := strings.Repeat("é", MaxReportReasonLength+1)
+		assert.Equal(t,
+			fmt.Errorf("reason can't be longer than 500 characters"),
+			client.ReportKey(fingerprint, tooLong),
+		)
+	})
+
+	t.Run("allows a reason of the maximum length", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+
+		err := client.ReportKey(fingerprint, strings.Repeat("é", MaxReportReasonLength))
+		assert.NoError(t, err)
+	})
+}
+
+func TestRevokeKey(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+	revocationCert := "-----BEGIN PGP PUBLIC KEY BLOCK-----\n...\n-----END PGP PUBLIC KEY BLOCK-----"
+
+	t.Run("sends the fingerprint and revocation certificate", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotRequest revokeKeyRequest
+		mux.HandleFunc("/revocations", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "POST", r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&gotRequest))
+			w.WriteHeader(http.StatusCreated)
+		})
+
+		err := client.RevokeKey(fingerprint, revocationCert)
+		assert.NoError(t, err)
+		assert.Equal(t, revokeKeyRequest{
+			Fingerprint:                  fingerprint.Uri(),
+			ArmoredRevocationCertificate: revocationCert,
+		}, gotRequest)
+	})
+
+	t.Run("returns ErrRevocationInvalid if the server rejects the certificate", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/revocations", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		})
+
+		err := client.RevokeKey(fingerprint, revocationCert)
+		assert.Equal(t, ErrRevocationInvalid, err)
+	})
+
+	t.Run("passes up other error codes", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/revocations", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		err := client.RevokeKey(fingerprint, revocationCert)
+		assert.Equal(t, &APIError{StatusCode: http.StatusTooManyRequests}, err)
+	})
+}
+
+func TestUpsertPublicKey(t *testing.T) {
+	privateKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	armoredPublicKey := exampledata.ExamplePublicKey4
+	keyETag := KeyETag(armoredPublicKey)
+
+	handleCapabilities := func(mux *http.ServeMux, supportsETag bool) {
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"supportsETag": %v}`, supportsETag)
+		})
+	}
+
+	// handleKeys responds to `POST /keys` with statusCode, recording the If-Match header
+	handleKeys := func(mux *http.ServeMux, statusCode int, gotIfMatch *string) {
+		mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "POST", r.Method)
+			*gotIfMatch = r.Header.Get("If-Match")
+			w.WriteHeader(statusCode)
+		})
+	}
+
+	t.Run("sends If-Match when the server supports ETags", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotIfMatch string
+		handleCapabilities(mux, true)
+		handleKeys(mux, http.StatusOK, &gotIfMatch)
+
+		err := client.UpsertPublicKey(armoredPublicKey, privateKey, IfMatch(keyETag))
+		assert.NoError(t, err)
+		assert.Equal(t, `"`+keyETag+`"`, gotIfMatch)
+	})
+
+	t.Run("returns ErrKeyConflict for 412 Precondition Failed", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotIfMatch string
+		handleCapabilities(mux, true)
+		handleKeys(mux, http.StatusPreconditionFailed, &gotIfMatch)
+
+		err := client.UpsertPublicKey(armoredPublicKey, privateKey, IfMatch(keyETag))
+		assert.Equal(t, ErrKeyConflict, err)
+	})
+
+	t.Run("doesn't send If-Match if the server doesn't support ETags", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotIfMatch string
+		handleCapabilities(mux, false)
+		handleKeys(mux, http.StatusOK, &gotIfMatch)
+
+		err := client.UpsertPublicKey(armoredPublicKey, privateKey, IfMatch(keyETag))
+		assert.NoError(t, err)
+		assert.Equal(t, "", gotIfMatch)
+	})
+
+	t.Run("doesn't send If-Match without the option", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotIfMatch string
+		handleCapabilities(mux, true)
+		handleKeys(mux, http.StatusOK, &gotIfMatch)
+
+		err := client.UpsertPublicKey(armoredPublicKey, privateKey)
+		assert.NoError(t, err)
+		assert.Equal(t, "", gotIfMatch)
+	})
+}
+
+func TestKeyETag(t *testing.T) {
+	assert.Equal(t,
+		"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		KeyETag("hello"),
+	)
+}
+
+func TestParseSecretMetadata(t *testing.T) {
+	t.Run("creation time is optional", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("X-Secret-Size", "10")
+
+		got, err := parseSecretMetadata(header)
+		assert.NoError(t, err)
+		assert.Equal(t, &SecretMetadata{Size: 10}, got)
+	})
+
+	tests := []struct {
+		name          string
+		size          string
+		createdAt     string
+		expectedError error
+	}{
+		{"missing size", "", "", fmt.Errorf("missing X-Secret-Size header")},
+		{"size isn't a number", "big", "", fmt.Errorf("invalid X-Secret-Size header 'big'")},
+		{"negative size", "-1", "", fmt.Errorf("invalid X-Secret-Size header '-1'")},
+		{
+			"bad creation time", "10", "yesterday",
+			fmt.Errorf("invalid X-Created-At header 'yesterday': parsing time \"yesterday\" " +
+				"as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			if test.size != "" {
+				header.Set("X-Secret-Size", test.size)
+			}
+			if test.createdAt != "" {
+				header.Set("X-Created-At", test.createdAt)
+			}
+
+			_, err := parseSecretMetadata(header)
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
 func TestLog(t *testing.T) {
 
 	teamUUID := uuid.Must(uuid.NewV4())
@@ -976,3 +1500,39 @@ func encryptToArmor(t *testing.T, decryptedMessage string, pgpKey *pgpkey.PgpKey
 	message.Close()
 	return buffer.String(), nil
 }
+
+func TestCreateSecretWithExpiresAt(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var gotBody map[string]interface{}
+	mux.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) {
+		assertClientSentVerb(t, "POST", r.Method)
+		gotBody = nil
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.WriteHeader(201)
+	})
+
+	fingerprint := fpr.MustParse("ABAB ABAB ABAB ABAB ABAB  ABAB ABAB ABAB ABAB ABAB")
+
+	t.Run("includes expiresAt as RFC3339 in UTC", func(t *testing.T) {
+		expiresAt := time.Date(2019, 6, 1, 12, 30, 0, 0, time.FixedZone("BST", 3600))
+
+		err := client.CreateSecret(fingerprint, "---- BEGIN PGP MESSAGE...",
+			WithExpiresAt(expiresAt))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "2019-06-01T11:30:00Z", gotBody["expiresAt"])
+		assert.Equal(t, "---- BEGIN PGP MESSAGE...", gotBody["armoredEncryptedSecret"])
+		assert.Equal(t, "OPENPGP4FPR:ABABABABABABABABABABABABABABABABABABABAB",
+			gotBody["recipientFingerprint"])
+	})
+
+	t.Run("omits expiresAt without the option", func(t *testing.T) {
+		err := client.CreateSecret(fingerprint, "---- BEGIN PGP MESSAGE...")
+		assert.NoError(t, err)
+
+		_, gotExpiresAt := gotBody["expiresAt"]
+		assert.Equal(t, false, gotExpiresAt)
+	})
+}
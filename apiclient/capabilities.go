@@ -0,0 +1,94 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"log"
+	"net/http"
+)
+
+// ServerCapabilities describes the optional features supported by the Fluidkeys Server API.
+type ServerCapabilities struct {
+	// APIVersion is the version of the API reported by the server, e.g. "1.2"
+	APIVersion string `json:"apiVersion"`
+
+	// SupportsETag is true if the server returns ETags and honours If-Match headers
+	SupportsETag bool `json:"supportsETag"`
+
+	// MaxSecretBytes is the largest armored secret the server will accept, or 0 if the server
+	// doesn't advertise a limit.
+	MaxSecretBytes int `json:"maxSecretBytes"`
+
+	// SupportsSecretExpiry is true if the server deletes secrets at the expiresAt time given
+	// when they're created
+	SupportsSecretExpiry bool `json:"supportsSecretExpiry"`
+
+	// SupportsKeysBatch is true if the server returns several public keys in one multipart
+	// response from `POST /keys/batch`
+	SupportsKeysBatch bool `json:"supportsKeysBatch"`
+
+	// SupportsRosterBundle is true if the server returns a team's roster, signature and member
+	// public keys together from `GET /team/<uuid>/bundle`
+	SupportsRosterBundle bool `json:"supportsRosterBundle"`
+
+	// SupportsSecretsBatchDelete is true if the server deletes several secrets in one
+	// `DELETE /secrets` request
+	SupportsSecretsBatchDelete bool `json:"supportsSecretsBatchDelete"`
+}
+
+// GetServerCapabilities asks the server which optional features it supports. The result is
+// cached for the lifetime of the client, so it's cheap to call before every request.
+// Servers which predate the capabilities endpoint are treated as supporting no optional
+// features.
+func (c *Client) GetServerCapabilities() (*ServerCapabilities, error) {
+	c.capabilitiesMutex.Lock()
+	defer c.capabilitiesMutex.Unlock()
+
+	if c.capabilities != nil {
+		return c.capabilities, nil
+	}
+
+	request, err := c.newRequest("GET", "capabilities", nil)
+	if err != nil {
+		return nil, err
+	}
+	decodedJSON := ServerCapabilities{}
+	response, err := c.do(request, &decodedJSON)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			c.capabilities = &ServerCapabilities{}
+			return c.capabilities, nil
+		}
+		return nil, err
+	}
+
+	c.capabilities = &decodedJSON
+	return c.capabilities, nil
+}
+
+// capabilitiesOrDefault returns the server capabilities, or no optional features if they
+// couldn't be fetched. It's used to decide whether to use a feature, where failing to get
+// the capabilities shouldn't stop the request itself from being attempted.
+func (c *Client) capabilitiesOrDefault() ServerCapabilities {
+	capabilities, err := c.GetServerCapabilities()
+	if err != nil {
+		log.Printf("failed to get server capabilities, assuming none: %v", err)
+		return ServerCapabilities{}
+	}
+	return *capabilities
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+func TestGetServerCapabilities(t *testing.T) {
+	t.Run("with all capabilities", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "GET", r.Method)
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"apiVersion": "1.2", "supportsETag": true, "maxSecretBytes": 1024}`)
+		})
+
+		got, err := client.GetServerCapabilities()
+		assert.NoError(t, err)
+		assert.Equal(t, ServerCapabilities{
+			APIVersion:     "1.2",
+			SupportsETag:   true,
+			MaxSecretBytes: 1024,
+		}, *got)
+	})
+
+	t.Run("with some capabilities missing from the response", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"apiVersion": "1.1"}`)
+		})
+
+		got, err := client.GetServerCapabilities()
+		assert.NoError(t, err)
+		assert.Equal(t, ServerCapabilities{APIVersion: "1.1"}, *got)
+	})
+
+	t.Run("with unknown capabilities in the response", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"apiVersion": "2.0", "supportsETag": true, "supportsTimeTravel": true}`)
+		})
+
+		got, err := client.GetServerCapabilities()
+		assert.NoError(t, err)
+		assert.Equal(t, ServerCapabilities{APIVersion: "2.0", SupportsETag: true}, *got)
+	})
+
+	t.Run("with a server that doesn't have the capabilities endpoint", func(t *testing.T) {
+		client, _, _, teardown := setup()
+		defer teardown()
+
+		got, err := client.GetServerCapabilities()
+		assert.NoError(t, err)
+		assert.Equal(t, ServerCapabilities{}, *got)
+	})
+
+	t.Run("with a server error", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		_, err := client.GetServerCapabilities()
+		assert.GotError(t, err)
+	})
+
+	t.Run("caches the response", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		requestCount := 0
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			requestCount++
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"apiVersion": "1.2"}`)
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := client.GetServerCapabilities()
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 1, requestCount)
+	})
+
+	t.Run("doesn't cache a server error", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		fail := true
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			if fail {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"apiVersion": "1.2"}`)
+		})
+
+		_, err := client.GetServerCapabilities()
+		assert.GotError(t, err)
+
+		fail = false
+		got, err := client.GetServerCapabilities()
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2", got.APIVersion)
+	})
+}
+
+func TestCreateSecretRespectsMaxSecretBytes(t *testing.T) {
+	fingerprint := fpr.MustParse("ABABABABABABABABABABABABABABABABABABABAB")
+
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"maxSecretBytes": 10}`)
+	})
+	secretsRequested := false
+	mux.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) {
+		secretsRequested = true
+		w.WriteHeader(201)
+	})
+
+	t.Run("secret under the limit is sent", func(t *testing.T) {
+		err := client.CreateSecret(fingerprint, "small")
+		assert.NoError(t, err)
+		assert.Equal(t, true, secretsRequested)
+	})
+
+	t.Run("secret over the limit is rejected without being sent", func(t *testing.T) {
+		secretsRequested = false
+		err := client.CreateSecret(fingerprint, strings.Repeat("x", 11))
+		assert.GotError(t, err)
+		assert.Equal(t, false, secretsRequested)
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+// APIClient is the interface to the Fluidkeys Server API implemented by Client.
+// Depend on this rather than *Client so that tests can use a mock, see the mock subpackage.
+type APIClient interface {
+	GetPublicKey(email string) (string, error)
+	GetPublicKeyByFingerprint(fingerprint fpr.Fingerprint) (*pgpkey.PgpKey, error)
+	GetKeysBatch(fingerprints []fpr.Fingerprint) (map[fpr.Fingerprint]*pgpkey.PgpKey, error)
+	ListPublicKeys(since time.Time) ([]KeySummary, error)
+	GetPublicKeyHistory(fingerprint fpr.Fingerprint) ([]KeyHistoryEntry, error)
+	UpsertPublicKey(armoredPublicKey string, privateKey *pgpkey.PgpKey,
+		options ...UpsertPublicKeyOption) error
+	ReportKey(fingerprint fpr.Fingerprint, reason string) error
+	RevokeKey(fingerprint fpr.Fingerprint, revocationCert string) error
+
+	CreateSecret(recipientFingerprint fpr.Fingerprint, armoredEncryptedSecret string,
+		options ...CreateSecretOption) error
+	ListSecrets(fingerprint fpr.Fingerprint) ([]Secret, error)
+	DeleteSecret(fingerprint fpr.Fingerprint, uuid string) error
+	BatchDeleteSecrets(fingerprint fpr.Fingerprint, uuids []string) error
+	GetSecretMetadata(fingerprint fpr.Fingerprint, uuid string) (*SecretMetadata, error)
+
+	UpsertTeam(roster string, rosterSignature string, signerFingerprint fpr.Fingerprint) error
+	GetTeamName(teamUUID uuid.UUID) (string, error)
+	ListTeams(fingerprint fpr.Fingerprint) ([]TeamSummary, error)
+	GetTeamRoster(teamUUID uuid.UUID, me fpr.Fingerprint) (
+		roster string, signature string, err error)
+	DownloadRosterBundle(teamUUID uuid.UUID, me fpr.Fingerprint) (*team.RosterBundle, error)
+	RequestToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fingerprint, email string,
+		options ...RequestToJoinTeamOption) error
+	ListRequestsToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fingerprint) (
+		[]team.RequestToJoinTeam, error)
+	DeleteRequestToJoinTeam(teamUUID uuid.UUID, requestUUID uuid.UUID) error
+	UpdateTeamMemberKey(teamUUID uuid.UUID, oldFingerprint, newFingerprint fpr.Fingerprint,
+		privateKey *pgpkey.PgpKey) error
+	LeaveTeam(teamUUID uuid.UUID, privateKey *pgpkey.PgpKey) error
+
+	GetServerCapabilities() (*ServerCapabilities, error)
+	Ping() (time.Duration, error)
+	Log(event Event) error
+}
+
+var _ APIClient = &Client{}
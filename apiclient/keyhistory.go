@@ -0,0 +1,83 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+// KeyHistoryEntry records a change to a public key on the server, returned by
+// GetPublicKeyHistory.
+type KeyHistoryEntry struct {
+	UpdatedAt  time.Time
+	SHA256     string // hash of the public key after the change
+	ChangeType string // one of KeyCreated, KeyUpdated or KeyRevoked
+}
+
+const (
+	// KeyCreated means the public key was first uploaded
+	KeyCreated = "created"
+
+	// KeyUpdated means a new version of the public key was uploaded
+	KeyUpdated = "updated"
+
+	// KeyRevoked means the public key was revoked
+	KeyRevoked = "revoked"
+)
+
+// GetPublicKeyHistory returns the changes made to the public key with the given fingerprint,
+// oldest first, so admins can audit when a team member's key was last updated.
+func (c *Client) GetPublicKeyHistory(fingerprint fpr.Fingerprint) ([]KeyHistoryEntry, error) {
+	path := fmt.Sprintf("key/%s/history", fingerprint.Hex())
+	request, err := c.newRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	decodedJSON := new(getPublicKeyHistoryResponse)
+	response, err := c.do(request, &decodedJSON)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, ErrPublicKeyNotFound
+		}
+		return nil, err
+	}
+
+	history := []KeyHistoryEntry{}
+	for _, jsonEntry := range decodedJSON.History {
+		history = append(history, KeyHistoryEntry{
+			UpdatedAt:  jsonEntry.UpdatedAt.UTC(),
+			SHA256:     jsonEntry.SHA256,
+			ChangeType: jsonEntry.ChangeType,
+		})
+	}
+	return history, nil
+}
+
+// getPublicKeyHistoryResponse is the JSON structure returned by the key history API endpoint
+type getPublicKeyHistoryResponse struct {
+	History []struct {
+		UpdatedAt  time.Time `json:"updatedAt"`
+		SHA256     string    `json:"sha256"`
+		ChangeType string    `json:"changeType"`
+	} `json:"history"`
+}
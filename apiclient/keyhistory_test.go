@@ -0,0 +1,86 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestGetPublicKeyHistory(t *testing.T) {
+	path := "/key/" + exampledata.ExampleFingerprint4.Hex() + "/history"
+
+	t.Run("unmarshals history entries and parses times as UTC", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "GET", r.Method)
+
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"history": [
+				{"updatedAt": "2019-06-21T09:00:00Z", "sha256": "aaaa", "changeType": "created"},
+				{"updatedAt": "2019-07-01T10:30:00+01:00", "sha256": "bbbb",
+				 "changeType": "updated"}
+			]}`)
+		})
+
+		got, err := client.GetPublicKeyHistory(exampledata.ExampleFingerprint4)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(got))
+
+		assert.AssertEqualTimes(t, time.Date(2019, 6, 21, 9, 0, 0, 0, time.UTC), got[0].UpdatedAt)
+		assert.Equal(t, "aaaa", got[0].SHA256)
+		assert.Equal(t, KeyCreated, got[0].ChangeType)
+
+		assert.AssertEqualTimes(t, time.Date(2019, 7, 1, 9, 30, 0, 0, time.UTC), got[1].UpdatedAt)
+		assert.Equal(t, "bbbb", got[1].SHA256)
+		assert.Equal(t, KeyUpdated, got[1].ChangeType)
+	})
+
+	t.Run("returns an empty history", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"history": []}`)
+		})
+
+		got, err := client.GetPublicKeyHistory(exampledata.ExampleFingerprint4)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyHistoryEntry{}, got)
+	})
+
+	t.Run("returns ErrPublicKeyNotFound for an unknown key", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		_, err := client.GetPublicKeyHistory(exampledata.ExampleFingerprint4)
+		assert.Equal(t, ErrPublicKeyNotFound, err)
+	})
+}
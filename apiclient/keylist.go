@@ -0,0 +1,72 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"log"
+	"net/url"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+// KeySummary describes a public key returned by ListPublicKeys
+type KeySummary struct {
+	Fingerprint fpr.Fingerprint
+	UpdatedAt   time.Time
+	Emails      []string
+}
+
+// ListPublicKeys lists the public keys which were uploaded or updated after since, so that
+// callers can fetch only the keys that changed.
+func (c *Client) ListPublicKeys(since time.Time) (keys []KeySummary, err error) {
+	query := url.Values{}
+	query.Set("updated_since", since.UTC().Format(time.RFC3339))
+
+	request, err := c.newRequest("GET", "keys?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	decodedJSON := new(listPublicKeysResponse)
+	if _, err = c.do(request, &decodedJSON); err != nil {
+		return nil, err
+	}
+
+	for _, jsonKey := range decodedJSON.Keys {
+		fingerprint, err := fpr.Parse(jsonKey.Fingerprint)
+		if err != nil {
+			log.Printf("ignoring key with invalid fingerprint '%s': %v", jsonKey.Fingerprint, err)
+			continue
+		}
+		keys = append(keys, KeySummary{
+			Fingerprint: fingerprint,
+			UpdatedAt:   jsonKey.UpdatedAt,
+			Emails:      jsonKey.Emails,
+		})
+	}
+	return keys, nil
+}
+
+// listPublicKeysResponse is the JSON structure returned by the list keys API endpoint
+type listPublicKeysResponse struct {
+	Keys []struct {
+		Fingerprint string    `json:"fingerprint"`
+		UpdatedAt   time.Time `json:"updatedAt"`
+		Emails      []string  `json:"emails"`
+	} `json:"keys"`
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestListPublicKeys(t *testing.T) {
+	since := time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC)
+	updatedAt := time.Date(2019, 6, 21, 9, 0, 0, 0, time.UTC)
+
+	t.Run("sends updated_since and unmarshals changed keys", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "GET", r.Method)
+			assert.Equal(t, "2019-06-20T16:35:00Z", r.URL.Query().Get("updated_since"))
+
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"keys": [
+				{"fingerprint": "%s", "updatedAt": "2019-06-21T09:00:00Z",
+				 "emails": ["test4@example.com"]}
+			]}`, exampledata.ExampleFingerprint4.Hex())
+		})
+
+		got, err := client.ListPublicKeys(since)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeySummary{
+			{
+				Fingerprint: exampledata.ExampleFingerprint4,
+				UpdatedAt:   updatedAt,
+				Emails:      []string{"test4@example.com"},
+			},
+		}, got)
+	})
+
+	t.Run("returns no keys if nothing changed", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"keys": []}`)
+		})
+
+		got, err := client.ListPublicKeys(since)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(got))
+	})
+
+	t.Run("skips keys with an invalid fingerprint", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"keys": [
+				{"fingerprint": "not-a-fingerprint", "updatedAt": "2019-06-21T09:00:00Z"},
+				{"fingerprint": "%s", "updatedAt": "2019-06-21T09:00:00Z"}
+			]}`, exampledata.ExampleFingerprint4.Hex())
+		})
+
+		got, err := client.ListPublicKeys(since)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeySummary{
+			{Fingerprint: exampledata.ExampleFingerprint4, UpdatedAt: updatedAt},
+		}, got)
+	})
+
+	t.Run("returns an error from the server", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+
+		_, err := client.ListPublicKeys(since)
+		assert.GotError(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"strings"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+// GetKeysBatch gets the public keys for the given fingerprints. Keys the server doesn't have
+// are missing from the returned map.
+// If the server supports it, the keys are fetched in one request to `POST /keys/batch`, which
+// responds with a multipart body containing one armored key per part. Otherwise they're
+// fetched one at a time with GetPublicKeyByFingerprint.
+func (c *Client) GetKeysBatch(fingerprints []fpr.Fingerprint) (
+	map[fpr.Fingerprint]*pgpkey.PgpKey, error) {
+
+	if !c.capabilitiesOrDefault().SupportsKeysBatch {
+		return c.getKeysSerially(fingerprints)
+	}
+
+	requestData := []string{}
+	for _, fingerprint := range fingerprints {
+		requestData = append(requestData, fingerprint.Hex())
+	}
+
+	request, err := c.newRequest("POST", "keys/batch", requestData)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "multipart/mixed")
+
+	response, err := c.sendWithRetries(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if !isSuccess(response.StatusCode) {
+		return nil, makeErrorForAPIResponse(response)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Type: %v", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("expected multipart response, got %s", mediaType)
+	}
+
+	requested := map[fpr.Fingerprint]bool{}
+	for _, fingerprint := range fingerprints {
+		requested[fingerprint] = true
+	}
+
+	keys := map[fpr.Fingerprint]*pgpkey.PgpKey{}
+	reader := multipart.NewReader(response.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading multipart response: %v", err)
+		}
+
+		armoredKey, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("error reading multipart response: %v", err)
+		}
+
+		key, err := pgpkey.LoadFromArmoredPublicKey(string(armoredKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load armored key: %v", err)
+		}
+
+		if !requested[key.Fingerprint()] {
+			log.Printf("danger: requested keys %v from API but got back key %s\n",
+				fingerprints, key.Fingerprint())
+			return nil, fmt.Errorf("got back key %s which wasn't requested", key.Fingerprint())
+		}
+		keys[key.Fingerprint()] = key
+	}
+	return keys, nil
+}
+
+// getKeysSerially gets each key with GetPublicKeyByFingerprint, for servers which don't
+// support `POST /keys/batch`
+func (c *Client) getKeysSerially(fingerprints []fpr.Fingerprint) (
+	map[fpr.Fingerprint]*pgpkey.PgpKey, error) {
+
+	keys := map[fpr.Fingerprint]*pgpkey.PgpKey{}
+	for _, fingerprint := range fingerprints {
+		key, err := c.GetPublicKeyByFingerprint(fingerprint)
+		if err == ErrPublicKeyNotFound {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get key %s: %v", fingerprint, err)
+		}
+		keys[fingerprint] = key
+	}
+	return keys, nil
+}
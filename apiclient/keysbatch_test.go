@@ -0,0 +1,150 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+func TestGetKeysBatch(t *testing.T) {
+	fingerprints := []fpr.Fingerprint{
+		exampledata.ExampleFingerprint3,
+		exampledata.ExampleFingerprint4,
+	}
+
+	handleCapabilities := func(mux *http.ServeMux, supportsKeysBatch bool) {
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"supportsKeysBatch": %v}`, supportsKeysBatch)
+		})
+	}
+
+	// handleBatch responds to `POST /keys/batch` with a multipart body containing
+	// armoredKeys
+	handleBatch := func(t *testing.T, mux *http.ServeMux, armoredKeys ...string) {
+		mux.HandleFunc("/keys/batch", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "POST", r.Method)
+
+			requested := []string{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&requested))
+			assert.Equal(t, []string{
+				exampledata.ExampleFingerprint3.Hex(),
+				exampledata.ExampleFingerprint4.Hex(),
+			}, requested)
+
+			writer := multipart.NewWriter(w)
+			w.Header().Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+			for _, armoredKey := range armoredKeys {
+				part, err := writer.CreatePart(map[string][]string{
+					"Content-Type": {"application/pgp-keys"},
+				})
+				assert.NoError(t, err)
+				fmt.Fprint(part, armoredKey)
+			}
+			assert.NoError(t, writer.Close())
+		})
+	}
+
+	t.Run("server supports batch endpoint", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		handleBatch(t, mux, exampledata.ExamplePublicKey3, exampledata.ExamplePublicKey4)
+
+		keys, err := client.GetKeysBatch(fingerprints)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(keys))
+		assert.Equal(t, exampledata.ExampleFingerprint3,
+			keys[exampledata.ExampleFingerprint3].Fingerprint())
+		assert.Equal(t, exampledata.ExampleFingerprint4,
+			keys[exampledata.ExampleFingerprint4].Fingerprint())
+	})
+
+	t.Run("server leaves out a key it doesn't have", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		handleBatch(t, mux, exampledata.ExamplePublicKey4)
+
+		keys, err := client.GetKeysBatch(fingerprints)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(keys))
+		_, gotKey3 := keys[exampledata.ExampleFingerprint3]
+		assert.Equal(t, false, gotKey3)
+	})
+
+	t.Run("server returns a key that wasn't requested", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		handleBatch(t, mux, exampledata.ExamplePublicKey2)
+
+		_, err := client.GetKeysBatch(fingerprints)
+		assert.Equal(t, fmt.Errorf("got back key %s which wasn't requested",
+			exampledata.ExampleFingerprint2), err)
+	})
+
+	t.Run("server doesn't support batch endpoint", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, false)
+		mux.HandleFunc("/keys/batch", func(w http.ResponseWriter, r *http.Request) {
+			t.Fatalf("unexpected request to /keys/batch")
+		})
+		mux.HandleFunc("/key/"+exampledata.ExampleFingerprint3.Hex()+".asc",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			})
+		mux.HandleFunc("/key/"+exampledata.ExampleFingerprint4.Hex()+".asc",
+			func(w http.ResponseWriter, r *http.Request) {
+				assertClientSentVerb(t, "GET", r.Method)
+				fmt.Fprint(w, exampledata.ExamplePublicKey4)
+			})
+
+		keys, err := client.GetKeysBatch(fingerprints)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(keys))
+		assert.Equal(t, exampledata.ExampleFingerprint4,
+			keys[exampledata.ExampleFingerprint4].Fingerprint())
+	})
+
+	t.Run("passes up error codes", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		mux.HandleFunc("/keys/batch", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+
+		_, err := client.GetKeysBatch(fingerprints)
+		assert.Equal(t, &APIError{StatusCode: http.StatusBadRequest}, err)
+	})
+}
@@ -0,0 +1,325 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package mock provides MockClient, an apiclient.APIClient for tests which returns configured
+// values rather than talking to the Fluidkeys Server API.
+package mock
+
+import (
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+// MockClient implements apiclient.APIClient. Each method records a Call then returns the
+// values of the fields named after it, for example GetTeamName returns GetTeamNameName and
+// GetTeamNameError. Unset fields return zero values, so set only those a test cares about.
+type MockClient struct {
+	// Calls records every method called, in order.
+	Calls []Call
+
+	GetPublicKeyArmored string
+	GetPublicKeyError   error
+
+	GetPublicKeyByFingerprintKey   *pgpkey.PgpKey
+	GetPublicKeyByFingerprintError error
+
+	GetKeysBatchKeys  map[fpr.Fingerprint]*pgpkey.PgpKey
+	GetKeysBatchError error
+
+	ListPublicKeysKeys  []apiclient.KeySummary
+	ListPublicKeysError error
+
+	GetPublicKeyHistoryHistory []apiclient.KeyHistoryEntry
+	GetPublicKeyHistoryError   error
+
+	UpsertPublicKeyError error
+
+	ReportKeyError error
+
+	RevokeKeyError error
+
+	CreateSecretError error
+
+	ListSecretsSecrets []apiclient.Secret
+	ListSecretsError   error
+
+	DeleteSecretError error
+
+	BatchDeleteSecretsError error
+
+	GetSecretMetadataMetadata *apiclient.SecretMetadata
+	GetSecretMetadataError    error
+
+	UpsertTeamError error
+
+	GetTeamNameName  string
+	GetTeamNameError error
+
+	ListTeamsTeams []apiclient.TeamSummary
+	ListTeamsError error
+
+	// GetTeamRosterRoster, GetTeamRosterSignature and GetTeamRosterError are keyed by the
+	// requesting fingerprint. If there's no entry for a fingerprint, GetTeamRoster returns
+	// apiclient.ErrForbidden, like the real API for someone outside the team.
+	GetTeamRosterRoster    map[fpr.Fingerprint]string
+	GetTeamRosterSignature map[fpr.Fingerprint]string
+	GetTeamRosterError     map[fpr.Fingerprint]error
+
+	DownloadRosterBundleBundle *team.RosterBundle
+	DownloadRosterBundleError  error
+
+	RequestToJoinTeamError error
+
+	ListRequestsToJoinTeamRequests []team.RequestToJoinTeam
+	ListRequestsToJoinTeamError    error
+
+	DeleteRequestToJoinTeamError error
+
+	UpdateTeamMemberKeyError error
+
+	LeaveTeamError error
+
+	GetServerCapabilitiesCapabilities *apiclient.ServerCapabilities
+	GetServerCapabilitiesError        error
+
+	PingLatency time.Duration
+	PingError   error
+
+	LogError error
+}
+
+// Call is a method called on MockClient, with its arguments.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+var _ apiclient.APIClient = &MockClient{}
+
+func (m *MockClient) record(method string, args ...interface{}) {
+	m.Calls = append(m.Calls, Call{Method: method, Args: args})
+}
+
+// CallsTo returns the calls made to the given method, in order.
+func (m *MockClient) CallsTo(method string) []Call {
+	calls := []Call{}
+	for _, call := range m.Calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// GetPublicKey returns GetPublicKeyArmored and GetPublicKeyError
+func (m *MockClient) GetPublicKey(email string) (string, error) {
+	m.record("GetPublicKey", email)
+	return m.GetPublicKeyArmored, m.GetPublicKeyError
+}
+
+// GetPublicKeyByFingerprint returns GetPublicKeyByFingerprintKey and
+// GetPublicKeyByFingerprintError
+func (m *MockClient) GetPublicKeyByFingerprint(fingerprint fpr.Fingerprint) (
+	*pgpkey.PgpKey, error) {
+
+	m.record("GetPublicKeyByFingerprint", fingerprint)
+	return m.GetPublicKeyByFingerprintKey, m.GetPublicKeyByFingerprintError
+}
+
+// GetKeysBatch returns GetKeysBatchKeys and GetKeysBatchError
+func (m *MockClient) GetKeysBatch(fingerprints []fpr.Fingerprint) (
+	map[fpr.Fingerprint]*pgpkey.PgpKey, error) {
+
+	m.record("GetKeysBatch", fingerprints)
+	return m.GetKeysBatchKeys, m.GetKeysBatchError
+}
+
+// ListPublicKeys returns ListPublicKeysKeys and ListPublicKeysError
+func (m *MockClient) ListPublicKeys(since time.Time) ([]apiclient.KeySummary, error) {
+	m.record("ListPublicKeys", since)
+	return m.ListPublicKeysKeys, m.ListPublicKeysError
+}
+
+// GetPublicKeyHistory returns GetPublicKeyHistoryHistory and GetPublicKeyHistoryError
+func (m *MockClient) GetPublicKeyHistory(fingerprint fpr.Fingerprint) (
+	[]apiclient.KeyHistoryEntry, error) {
+
+	m.record("GetPublicKeyHistory", fingerprint)
+	return m.GetPublicKeyHistoryHistory, m.GetPublicKeyHistoryError
+}
+
+// UpsertPublicKey returns UpsertPublicKeyError
+func (m *MockClient) UpsertPublicKey(armoredPublicKey string, privateKey *pgpkey.PgpKey,
+	options ...apiclient.UpsertPublicKeyOption) error {
+
+	m.record("UpsertPublicKey", armoredPublicKey, privateKey, options)
+	return m.UpsertPublicKeyError
+}
+
+// ReportKey returns ReportKeyError
+func (m *MockClient) ReportKey(fingerprint fpr.Fingerprint, reason string) error {
+	m.record("ReportKey", fingerprint, reason)
+	return m.ReportKeyError
+}
+
+// RevokeKey returns RevokeKeyError
+func (m *MockClient) RevokeKey(fingerprint fpr.Fingerprint, revocationCert string) error {
+	m.record("RevokeKey", fingerprint, revocationCert)
+	return m.RevokeKeyError
+}
+
+// CreateSecret returns CreateSecretError
+func (m *MockClient) CreateSecret(recipientFingerprint fpr.Fingerprint,
+	armoredEncryptedSecret string, options ...apiclient.CreateSecretOption) error {
+
+	m.record("CreateSecret", recipientFingerprint, armoredEncryptedSecret, options)
+	return m.CreateSecretError
+}
+
+// ListSecrets returns ListSecretsSecrets and ListSecretsError
+func (m *MockClient) ListSecrets(fingerprint fpr.Fingerprint) ([]apiclient.Secret, error) {
+	m.record("ListSecrets", fingerprint)
+	return m.ListSecretsSecrets, m.ListSecretsError
+}
+
+// DeleteSecret returns DeleteSecretError
+func (m *MockClient) DeleteSecret(fingerprint fpr.Fingerprint, uuid string) error {
+	m.record("DeleteSecret", fingerprint, uuid)
+	return m.DeleteSecretError
+}
+
+// BatchDeleteSecrets returns BatchDeleteSecretsError
+func (m *MockClient) BatchDeleteSecrets(fingerprint fpr.Fingerprint, uuids []string) error {
+	m.record("BatchDeleteSecrets", fingerprint, uuids)
+	return m.BatchDeleteSecretsError
+}
+
+// GetSecretMetadata returns GetSecretMetadataMetadata and GetSecretMetadataError
+func (m *MockClient) GetSecretMetadata(fingerprint fpr.Fingerprint, uuid string) (
+	*apiclient.SecretMetadata, error) {
+
+	m.record("GetSecretMetadata", fingerprint, uuid)
+	return m.GetSecretMetadataMetadata, m.GetSecretMetadataError
+}
+
+// UpsertTeam returns UpsertTeamError
+func (m *MockClient) UpsertTeam(
+	roster string, rosterSignature string, signerFingerprint fpr.Fingerprint) error {
+
+	m.record("UpsertTeam", roster, rosterSignature, signerFingerprint)
+	return m.UpsertTeamError
+}
+
+// GetTeamName returns GetTeamNameName and GetTeamNameError
+func (m *MockClient) GetTeamName(teamUUID uuid.UUID) (string, error) {
+	m.record("GetTeamName", teamUUID)
+	return m.GetTeamNameName, m.GetTeamNameError
+}
+
+// ListTeams returns ListTeamsTeams and ListTeamsError
+func (m *MockClient) ListTeams(fingerprint fpr.Fingerprint) ([]apiclient.TeamSummary, error) {
+	m.record("ListTeams", fingerprint)
+	return m.ListTeamsTeams, m.ListTeamsError
+}
+
+// GetTeamRoster returns the roster, signature and error configured for the fingerprint `me`, or
+// apiclient.ErrForbidden if none are configured.
+func (m *MockClient) GetTeamRoster(teamUUID uuid.UUID, me fpr.Fingerprint) (
+	roster string, signature string, err error) {
+
+	m.record("GetTeamRoster", teamUUID, me)
+	if err, ok := m.GetTeamRosterError[me]; ok {
+		return "", "", err
+	}
+	roster, ok := m.GetTeamRosterRoster[me]
+	if !ok {
+		return "", "", apiclient.ErrForbidden
+	}
+	return roster, m.GetTeamRosterSignature[me], nil
+}
+
+// DownloadRosterBundle returns DownloadRosterBundleBundle and DownloadRosterBundleError
+func (m *MockClient) DownloadRosterBundle(teamUUID uuid.UUID, me fpr.Fingerprint) (
+	*team.RosterBundle, error) {
+
+	m.record("DownloadRosterBundle", teamUUID, me)
+	return m.DownloadRosterBundleBundle, m.DownloadRosterBundleError
+}
+
+// RequestToJoinTeam returns RequestToJoinTeamError
+func (m *MockClient) RequestToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fingerprint,
+	email string, options ...apiclient.RequestToJoinTeamOption) error {
+
+	m.record("RequestToJoinTeam", teamUUID, fingerprint, email, options)
+	return m.RequestToJoinTeamError
+}
+
+// ListRequestsToJoinTeam returns ListRequestsToJoinTeamRequests and ListRequestsToJoinTeamError
+func (m *MockClient) ListRequestsToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fingerprint) (
+	[]team.RequestToJoinTeam, error) {
+
+	m.record("ListRequestsToJoinTeam", teamUUID, fingerprint)
+	return m.ListRequestsToJoinTeamRequests, m.ListRequestsToJoinTeamError
+}
+
+// DeleteRequestToJoinTeam returns DeleteRequestToJoinTeamError
+func (m *MockClient) DeleteRequestToJoinTeam(teamUUID uuid.UUID, requestUUID uuid.UUID) error {
+	m.record("DeleteRequestToJoinTeam", teamUUID, requestUUID)
+	return m.DeleteRequestToJoinTeamError
+}
+
+// UpdateTeamMemberKey returns UpdateTeamMemberKeyError
+func (m *MockClient) UpdateTeamMemberKey(teamUUID uuid.UUID, oldFingerprint,
+	newFingerprint fpr.Fingerprint, privateKey *pgpkey.PgpKey) error {
+
+	m.record("UpdateTeamMemberKey", teamUUID, oldFingerprint, newFingerprint, privateKey)
+	return m.UpdateTeamMemberKeyError
+}
+
+// LeaveTeam returns LeaveTeamError
+func (m *MockClient) LeaveTeam(teamUUID uuid.UUID, privateKey *pgpkey.PgpKey) error {
+	m.record("LeaveTeam", teamUUID, privateKey)
+	return m.LeaveTeamError
+}
+
+// GetServerCapabilities returns GetServerCapabilitiesCapabilities and
+// GetServerCapabilitiesError. If neither is set it returns empty capabilities, like a server
+// which doesn't support the capabilities endpoint.
+func (m *MockClient) GetServerCapabilities() (*apiclient.ServerCapabilities, error) {
+	m.record("GetServerCapabilities")
+	if m.GetServerCapabilitiesCapabilities == nil && m.GetServerCapabilitiesError == nil {
+		return &apiclient.ServerCapabilities{}, nil
+	}
+	return m.GetServerCapabilitiesCapabilities, m.GetServerCapabilitiesError
+}
+
+// Ping returns PingLatency and PingError
+func (m *MockClient) Ping() (time.Duration, error) {
+	m.record("Ping")
+	return m.PingLatency, m.PingError
+}
+
+// Log returns LogError
+func (m *MockClient) Log(event apiclient.Event) error {
+	m.record("Log", event)
+	return m.LogError
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// NonceMaxAge is how long a single use UUID is remembered for by a NonceStore
+const NonceMaxAge = 24 * time.Hour
+
+// NonceStore persists the single use UUIDs sent in signed requests, so reuse can be detected
+// across runs. database.Database implements it.
+type NonceStore interface {
+	IsNonceUsed(nonce string, maxAge time.Duration, now time.Time) (bool, error)
+	RecordUsedNonce(nonce string, maxAge time.Duration, now time.Time) error
+}
+
+// ErrNonceAlreadyUsed means a single use UUID was found in the NonceStore, so a signed request
+// containing it could be mistaken for a replay.
+var ErrNonceAlreadyUsed = fmt.Errorf("single use UUID has already been used")
+
+// newSingleUseUUID returns a UUID for a signed request, to stop the request being replayed.
+func (c *Client) newSingleUseUUID() (uuid.UUID, error) {
+	singleUseUUID, err := uuid.NewV4()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("couldn't generate UUID: %v", err)
+	}
+	if err := c.useNonce(singleUseUUID.String(), time.Now()); err != nil {
+		return uuid.Nil, err
+	}
+	return singleUseUUID, nil
+}
+
+// useNonce records that nonce has been used. Using the same nonce twice in one session can
+// only happen because of a bug, so it panics. If the nonce is found in c.NonceStore from a
+// previous session, it returns ErrNonceAlreadyUsed.
+// The NonceStore is only a safety net, so if it fails the error is logged and the nonce is used
+// without checking previous sessions.
+func (c *Client) useNonce(nonce string, now time.Time) error {
+	c.usedNoncesMutex.Lock()
+	defer c.usedNoncesMutex.Unlock()
+
+	if c.usedNonces == nil {
+		c.usedNonces = map[string]bool{}
+	}
+	if c.usedNonces[nonce] {
+		log.Panicf("single use UUID %s was used twice", nonce)
+	}
+	c.usedNonces[nonce] = true
+
+	if c.NonceStore == nil {
+		return nil
+	}
+
+	used, err := c.NonceStore.IsNonceUsed(nonce, NonceMaxAge, now)
+	if err != nil {
+		log.Printf("failed to check single use UUID, continuing without checking: %v", err)
+		return nil
+	} else if used {
+		return ErrNonceAlreadyUsed
+	}
+
+	if err := c.NonceStore.RecordUsedNonce(nonce, NonceMaxAge, now); err != nil {
+		log.Printf("failed to record single use UUID: %v", err)
+	}
+	return nil
+}
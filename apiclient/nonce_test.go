@@ -0,0 +1,97 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestUseNonce(t *testing.T) {
+	now := time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC)
+
+	t.Run("panics if the same nonce is used twice in a session", func(t *testing.T) {
+		client := New("vtest")
+		assert.NoError(t, client.useNonce("nonce-1", now))
+
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected useNonce to panic")
+			}
+		}()
+		client.useNonce("nonce-1", now)
+	})
+
+	t.Run("with a NonceStore", func(t *testing.T) {
+		store := &mockNonceStore{used: map[string]time.Time{}}
+		client := New("vtest")
+		client.NonceStore = store
+
+		assert.NoError(t, client.useNonce("nonce-1", now))
+		assert.Equal(t, map[string]time.Time{"nonce-1": now}, store.used)
+
+		t.Run("rejects a nonce used in a previous session", func(t *testing.T) {
+			newClient := New("vtest")
+			newClient.NonceStore = store
+
+			assert.Equal(t, ErrNonceAlreadyUsed, newClient.useNonce("nonce-1", now))
+		})
+
+		t.Run("carries on if the store fails", func(t *testing.T) {
+			store.err = fmt.Errorf("disk full")
+			defer func() { store.err = nil }()
+
+			assert.NoError(t, client.useNonce("nonce-2", now))
+		})
+	})
+
+	t.Run("newSingleUseUUID returns a different UUID each time", func(t *testing.T) {
+		client := New("vtest")
+		first, err := client.newSingleUseUUID()
+		assert.NoError(t, err)
+		second, err := client.newSingleUseUUID()
+		assert.NoError(t, err)
+
+		if first == second {
+			t.Fatalf("expected different UUIDs, got %s twice", first)
+		}
+	})
+}
+
+type mockNonceStore struct {
+	used map[string]time.Time
+	err  error
+}
+
+func (s *mockNonceStore) IsNonceUsed(nonce string, maxAge time.Duration, now time.Time) (
+	bool, error) {
+
+	if s.err != nil {
+		return false, s.err
+	}
+	_, found := s.used[nonce]
+	return found, nil
+}
+
+func (s *mockNonceStore) RecordUsedNonce(nonce string, maxAge time.Duration, now time.Time) error {
+	s.used[nonce] = now
+	return s.err
+}
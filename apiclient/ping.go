@@ -0,0 +1,37 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"time"
+)
+
+// Ping sends a request to the API's ping endpoint and returns how long the round trip took, to
+// help diagnose slow or unreliable connections.
+func (c *Client) Ping() (latency time.Duration, err error) {
+	request, err := c.newRequest("GET", "ping", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err := c.do(request, nil); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestPing(t *testing.T) {
+	t.Run("returns the round trip time", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		delay := 100 * time.Millisecond
+		mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "GET", r.Method)
+			time.Sleep(delay)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		latency, err := client.Ping()
+		assert.NoError(t, err)
+
+		if latency < delay || latency > delay+time.Second {
+			t.Fatalf("expected latency between %v and %v, got %v", delay, delay+time.Second, latency)
+		}
+	})
+
+	t.Run("passes up error codes", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		latency, err := client.Ping()
+		assert.Equal(t, &APIError{StatusCode: http.StatusNotFound}, err)
+		assert.Equal(t, time.Duration(0), latency)
+	})
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryConfig controls how requests are retried after a network error or a response saying
+// the server is temporarily unavailable. Only idempotent requests (GET, HEAD, PUT) are retried:
+// retrying a POST could, for example, send a secret twice. DELETEs aren't retried either, since
+// a delete that succeeded behind an error would be retried into a "not found" error, and
+// signed deletes such as leaving a team can only be sent once.
+type RetryConfig struct {
+	// MaxRetries is how many times to retry after the first attempt, at most 10
+	MaxRetries int
+
+	// RetryBaseDelay is the delay before the first retry, at least 100ms. It doubles for each
+	// subsequent retry.
+	RetryBaseDelay time.Duration
+
+	// RetryJitterFraction randomly varies each delay by up to this fraction either way, so many
+	// clients don't retry in lockstep. Between 0 and 1.
+	RetryJitterFraction float64
+}
+
+// DefaultRetryConfig is used unless the client is made with WithRetryConfig
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries:          3,
+	RetryBaseDelay:      500 * time.Millisecond,
+	RetryJitterFraction: 0.2,
+}
+
+// Validate checks the config is within sensible limits
+func (r RetryConfig) Validate() error {
+	if r.MaxRetries < 0 || r.MaxRetries > 10 {
+		return fmt.Errorf("MaxRetries must be between 0 and 10, got %d", r.MaxRetries)
+	}
+	if r.RetryBaseDelay < 100*time.Millisecond {
+		return fmt.Errorf("RetryBaseDelay must be at least 100ms, got %s", r.RetryBaseDelay)
+	}
+	if r.RetryJitterFraction < 0 || r.RetryJitterFraction > 1 {
+		return fmt.Errorf(
+			"RetryJitterFraction must be between 0 and 1, got %v", r.RetryJitterFraction)
+	}
+	return nil
+}
+
+// ClientOption configures a Client made with New
+type ClientOption func(*Client)
+
+// WithRetryConfig makes the client retry requests according to cfg. It panics if cfg isn't
+// valid.
+func WithRetryConfig(cfg RetryConfig) ClientOption {
+	if err := cfg.Validate(); err != nil {
+		log.Panicf("invalid RetryConfig: %v", err)
+	}
+	return func(c *Client) {
+		c.retryConfig = cfg
+	}
+}
+
+// sendWithRetries sends the request, retrying idempotent requests according to
+// c.retryConfig if there's a network error or the server is temporarily unavailable.
+func (c *Client) sendWithRetries(req *http.Request) (*http.Response, error) {
+	after := c.after
+	if after == nil {
+		after = time.After
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := c.client.Do(req)
+
+		if attempt >= c.retryConfig.MaxRetries || !isIdempotent(req.Method) ||
+			!shouldRetry(response, err) {
+			return response, err
+		}
+
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return response, err // can't rewind the body to send it again
+			}
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		if response != nil {
+			response.Body.Close()
+		}
+
+		delay := c.retryDelay(attempt)
+		log.Printf("retrying %s %s in %s (attempt %d failed: %v)",
+			req.Method, req.URL, delay, attempt+1, describeFailure(response, err))
+		<-after(delay)
+	}
+}
+
+// retryDelay returns how long to wait before the retry after the given (0-based) attempt
+func (c *Client) retryDelay(attempt int) time.Duration {
+	random := c.random
+	if random == nil {
+		random = rand.Float64
+	}
+
+	delay := float64(c.retryConfig.RetryBaseDelay) * float64(int(1)<<uint(attempt))
+	jitter := c.retryConfig.RetryJitterFraction * (2*random() - 1) // between -fraction and +fraction
+	return time.Duration(delay * (1 + jitter))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "PUT":
+		return true
+	default:
+		return false
+	}
+}
+
+// shouldRetry returns true for network errors and responses saying the server is temporarily
+// unavailable.
+func shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func describeFailure(response *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return response.Status
+}
@@ -0,0 +1,210 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/gofrs/uuid"
+)
+
+func TestRetries(t *testing.T) {
+	teamUUID := uuid.Must(uuid.NewV4())
+
+	// fakeClock records how long the client asked to wait, without waiting
+	type fakeClock struct{ waits []time.Duration }
+	useFakeClock := func(client *Client) *fakeClock {
+		clock := &fakeClock{}
+		client.after = func(d time.Duration) <-chan time.Time {
+			clock.waits = append(clock.waits, d)
+			ch := make(chan time.Time, 1)
+			ch <- time.Time{}
+			return ch
+		}
+		client.random = func() float64 { return 0.5 } // no jitter
+		return clock
+	}
+
+	// failThen responds with failStatus for the first numFailures requests, then 200 OK
+	failThen := func(numFailures int, failStatus int, numRequests *int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*numRequests++
+			if *numRequests <= numFailures {
+				w.WriteHeader(failStatus)
+				return
+			}
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"name": "Kiffix"}`)
+		}
+	}
+
+	t.Run("retries GET with doubling delays", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+		clock := useFakeClock(client)
+
+		numRequests := 0
+		mux.HandleFunc("/team/"+teamUUID.String(),
+			failThen(2, http.StatusServiceUnavailable, &numRequests))
+
+		name, err := client.GetTeamName(teamUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Kiffix", name)
+		assert.Equal(t, 3, numRequests)
+		assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second}, clock.waits)
+	})
+
+	t.Run("gives up after MaxRetries", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+		clock := useFakeClock(client)
+
+		numRequests := 0
+		mux.HandleFunc("/team/"+teamUUID.String(),
+			failThen(100, http.StatusBadGateway, &numRequests))
+
+		_, err := client.GetTeamName(teamUUID)
+		assert.Equal(t, &APIError{StatusCode: http.StatusBadGateway}, err)
+		assert.Equal(t, 4, numRequests)
+		assert.Equal(t, []time.Duration{
+			500 * time.Millisecond, time.Second, 2 * time.Second,
+		}, clock.waits)
+	})
+
+	t.Run("uses the config from WithRetryConfig", func(t *testing.T) {
+		_, mux, serverURL, teardown := setup()
+		defer teardown()
+
+		client := New("vtest", WithRetryConfig(RetryConfig{
+			MaxRetries:          1,
+			RetryBaseDelay:      200 * time.Millisecond,
+			RetryJitterFraction: 0.5,
+		}))
+		client.BaseURL, _ = client.BaseURL.Parse(serverURL + "/")
+		clock := useFakeClock(client)
+		client.random = func() float64 { return 1 } // maximum jitter
+
+		numRequests := 0
+		mux.HandleFunc("/team/"+teamUUID.String(),
+			failThen(100, http.StatusTooManyRequests, &numRequests))
+
+		_, err := client.GetTeamName(teamUUID)
+		assert.GotError(t, err)
+		assert.Equal(t, 2, numRequests)
+		assert.Equal(t, []time.Duration{300 * time.Millisecond}, clock.waits)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+		clock := useFakeClock(client)
+
+		numRequests := 0
+		mux.HandleFunc("/team/"+teamUUID.String(),
+			failThen(100, http.StatusInternalServerError, &numRequests))
+
+		_, err := client.GetTeamName(teamUUID)
+		assert.GotError(t, err)
+		assert.Equal(t, 1, numRequests)
+		assert.Equal(t, 0, len(clock.waits))
+	})
+
+	t.Run("doesn't retry POST", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+		useFakeClock(client)
+
+		numRequests := 0
+		mux.HandleFunc("/reports", failThen(100, http.StatusServiceUnavailable, &numRequests))
+
+		assert.GotError(t, client.ReportKey(exampledata.ExampleFingerprint4, "spam"))
+		assert.Equal(t, 1, numRequests)
+	})
+
+	t.Run("doesn't retry DELETE", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+		useFakeClock(client)
+
+		numRequests := 0
+		mux.HandleFunc("/secrets/some-uuid",
+			failThen(100, http.StatusBadGateway, &numRequests))
+
+		assert.GotError(t, client.DeleteSecret(exampledata.ExampleFingerprint4, "some-uuid"))
+		assert.Equal(t, 1, numRequests)
+	})
+
+	t.Run("resends the body of a retried PUT", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+		useFakeClock(client)
+
+		bodies := []string{}
+		mux.HandleFunc("/things", func(w http.ResponseWriter, r *http.Request) {
+			buf := make([]byte, 100)
+			n, _ := r.Body.Read(buf)
+			bodies = append(bodies, string(buf[:n]))
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+
+		request, err := client.newRequest("PUT", "things", map[string]string{"a": "b"})
+		assert.NoError(t, err)
+		_, err = client.do(request, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"{\"a\":\"b\"}\n", "{\"a\":\"b\"}\n"}, bodies)
+	})
+}
+
+func TestRetryConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultRetryConfig.Validate())
+
+	tests := []struct {
+		name          string
+		config        RetryConfig
+		expectedError error
+	}{
+		{
+			"too many retries",
+			RetryConfig{MaxRetries: 11, RetryBaseDelay: time.Second},
+			fmt.Errorf("MaxRetries must be between 0 and 10, got 11"),
+		},
+		{
+			"base delay too short",
+			RetryConfig{MaxRetries: 3, RetryBaseDelay: 99 * time.Millisecond},
+			fmt.Errorf("RetryBaseDelay must be at least 100ms, got 99ms"),
+		},
+		{
+			"jitter fraction too big",
+			RetryConfig{MaxRetries: 3, RetryBaseDelay: time.Second, RetryJitterFraction: 1.5},
+			fmt.Errorf("RetryJitterFraction must be between 0 and 1, got 1.5"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedError, test.config.Validate())
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+// DownloadRosterBundle gets the team's roster, signature and member public keys, authorizing as
+// me. Keys the server doesn't have are missing from the Keys map. Nothing in the bundle is
+// verified: use team.LoadFromSignedBundle to check it.
+// If the server supports it, everything is fetched in one request to `GET /team/<uuid>/bundle`.
+// Otherwise the roster is fetched with GetTeamRoster and the keys with GetKeysBatch.
+// It returns ErrTeamNotFound or ErrForbidden like GetTeamRoster.
+func (c *Client) DownloadRosterBundle(teamUUID uuid.UUID, me fpr.Fingerprint) (
+	*team.RosterBundle, error) {
+
+	if !c.capabilitiesOrDefault().SupportsRosterBundle {
+		return c.downloadRosterBundleSeparately(teamUUID, me)
+	}
+
+	path := fmt.Sprintf("team/%s/bundle", teamUUID)
+	request, err := c.newRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("authorization", authorization(me))
+	decodedJSON := new(rosterBundleResponse)
+	response, err := c.do(request, &decodedJSON)
+	if err != nil {
+		if response == nil {
+			return nil, err
+		}
+		switch response.StatusCode {
+		case http.StatusNotFound:
+			return nil, ErrTeamNotFound
+
+		case http.StatusForbidden:
+			return nil, ErrForbidden
+
+		default:
+			return nil, err
+		}
+	}
+
+	bundle := team.RosterBundle{
+		Roster:    decodedJSON.TeamRoster,
+		Signature: decodedJSON.ArmoredDetachedSignature,
+		Keys:      map[fpr.Fingerprint]*pgpkey.PgpKey{},
+	}
+	for _, armoredKey := range decodedJSON.ArmoredPublicKeys {
+		key, err := pgpkey.LoadFromArmoredPublicKey(armoredKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load armored key: %v", err)
+		}
+		bundle.Keys[key.Fingerprint()] = key
+	}
+	return &bundle, nil
+}
+
+// downloadRosterBundleSeparately gets the roster then the keys of everyone in it, for servers
+// which don't support `GET /team/<uuid>/bundle`
+func (c *Client) downloadRosterBundleSeparately(teamUUID uuid.UUID, me fpr.Fingerprint) (
+	*team.RosterBundle, error) {
+
+	roster, signature, err := c.GetTeamRoster(teamUUID, me)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := team.Load(roster, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team roster: %v", err)
+	}
+
+	keys, err := c.GetKeysBatch(t.Fingerprints())
+	if err != nil {
+		return nil, err
+	}
+	return &team.RosterBundle{Roster: roster, Signature: signature, Keys: keys}, nil
+}
+
+// rosterBundleResponse is the JSON structure returned by the team bundle API endpoint
+type rosterBundleResponse struct {
+	TeamRoster               string   `json:"teamRoster"`
+	ArmoredDetachedSignature string   `json:"armoredDetachedSignature"`
+	ArmoredPublicKeys        []string `json:"armoredPublicKeys"`
+}
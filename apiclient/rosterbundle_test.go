@@ -0,0 +1,144 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+func TestDownloadRosterBundle(t *testing.T) {
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+	me := exampledata.ExampleFingerprint4
+
+	kiffix := team.Team{
+		UUID: teamUUID,
+		Name: "Kiffix",
+		People: []team.Person{
+			{Email: "test3@example.com", Fingerprint: exampledata.ExampleFingerprint3},
+			{Email: "test4@example.com", Fingerprint: me, IsAdmin: true},
+		},
+	}
+	roster, err := kiffix.PreviewRoster()
+	assert.NoError(t, err)
+
+	handleCapabilities := func(mux *http.ServeMux, supportsRosterBundle bool) {
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"supportsRosterBundle": %v}`, supportsRosterBundle)
+		})
+	}
+
+	t.Run("server supports bundle endpoint", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		mux.HandleFunc("/team/"+teamUUID.String()+"/bundle",
+			func(w http.ResponseWriter, r *http.Request) {
+				assertClientSentVerb(t, "GET", r.Method)
+				assertClientSentValidAuthHeader(t, me, r.Header)
+
+				w.Header().Add("Content-Type", "application/json")
+				assert.NoError(t, json.NewEncoder(w).Encode(rosterBundleResponse{
+					TeamRoster:               roster,
+					ArmoredDetachedSignature: "fake signature",
+					ArmoredPublicKeys: []string{
+						exampledata.ExamplePublicKey3, exampledata.ExamplePublicKey4,
+					},
+				}))
+			})
+
+		bundle, err := client.DownloadRosterBundle(teamUUID, me)
+		assert.NoError(t, err)
+		assert.Equal(t, roster, bundle.Roster)
+		assert.Equal(t, "fake signature", bundle.Signature)
+		assert.Equal(t, 2, len(bundle.Keys))
+		assert.Equal(t, exampledata.ExampleFingerprint3,
+			bundle.Keys[exampledata.ExampleFingerprint3].Fingerprint())
+		assert.Equal(t, me, bundle.Keys[me].Fingerprint())
+	})
+
+	t.Run("returns an error for an invalid key in the bundle", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		mux.HandleFunc("/team/"+teamUUID.String()+"/bundle",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Content-Type", "application/json")
+				fmt.Fprint(w, `{"teamRoster": "", "armoredPublicKeys": ["not a key"]}`)
+			})
+
+		_, err := client.DownloadRosterBundle(teamUUID, me)
+		assert.GotError(t, err)
+	})
+
+	t.Run("returns ErrForbidden if I'm not in the team", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		mux.HandleFunc("/team/"+teamUUID.String()+"/bundle",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			})
+
+		_, err := client.DownloadRosterBundle(teamUUID, me)
+		assert.Equal(t, ErrForbidden, err)
+	})
+
+	t.Run("server doesn't support bundle endpoint", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, false)
+		mux.HandleFunc("/team/"+teamUUID.String()+"/roster",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"teamRoster": %q, "armoredDetachedSignature": "fake signature"}`,
+					roster)
+			})
+		keysFetched := 0
+		for fingerprint, armoredKey := range map[string]string{
+			exampledata.ExampleFingerprint3.Hex(): exampledata.ExamplePublicKey3,
+			exampledata.ExampleFingerprint4.Hex(): exampledata.ExamplePublicKey4,
+		} {
+			armoredKey := armoredKey
+			mux.HandleFunc("/key/"+fingerprint+".asc",
+				func(w http.ResponseWriter, r *http.Request) {
+					keysFetched++
+					fmt.Fprint(w, armoredKey)
+				})
+		}
+
+		bundle, err := client.DownloadRosterBundle(teamUUID, me)
+		assert.NoError(t, err)
+		assert.Equal(t, roster, bundle.Roster)
+		assert.Equal(t, "fake signature", bundle.Signature)
+		assert.Equal(t, 2, len(bundle.Keys))
+		assert.Equal(t, 2, keysFetched)
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+// batchDeleteConcurrency is how many DeleteSecret requests BatchDeleteSecrets makes at once
+// when the server doesn't support deleting secrets in one request
+const batchDeleteConcurrency = 4
+
+// BatchDeleteError is returned by BatchDeleteSecrets when some of the secrets couldn't be
+// deleted. The others were deleted.
+type BatchDeleteError struct {
+	// Failures maps the UUID of each secret that wasn't deleted to the reason why, for example
+	// ErrSecretNotFound
+	Failures map[string]error
+}
+
+func (e *BatchDeleteError) Error() string {
+	uuids := []string{}
+	for uuid := range e.Failures {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	messages := []string{}
+	for _, uuid := range uuids {
+		messages = append(messages, fmt.Sprintf("%s: %v", uuid, e.Failures[uuid]))
+	}
+	return fmt.Sprintf("failed to delete %d secrets: %s",
+		len(e.Failures), strings.Join(messages, "; "))
+}
+
+// BatchDeleteSecrets deletes the secrets with the given UUIDs waiting for the key. If some of
+// them can't be deleted it returns a *BatchDeleteError describing which.
+// If the server supports it, the secrets are deleted in one request to `DELETE /secrets`.
+// Otherwise they're deleted with DeleteSecret, a few at a time.
+func (c *Client) BatchDeleteSecrets(fingerprint fpr.Fingerprint, uuids []string) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+	if !c.capabilitiesOrDefault().SupportsSecretsBatchDelete {
+		return c.deleteSecretsConcurrently(fingerprint, uuids)
+	}
+
+	request, err := c.newRequest("DELETE", "secrets", batchDeleteSecretsRequest{UUIDs: uuids})
+	if err != nil {
+		return err
+	}
+	request.Header.Add("authorization", authorization(fingerprint))
+
+	decodedJSON := batchDeleteSecretsResponse{}
+	if _, err := c.do(request, &decodedJSON); err != nil {
+		return err
+	}
+
+	if len(decodedJSON.NotFound) == 0 {
+		return nil
+	}
+	batchError := &BatchDeleteError{Failures: map[string]error{}}
+	for _, uuid := range decodedJSON.NotFound {
+		batchError.Failures[uuid] = ErrSecretNotFound
+	}
+	return batchError
+}
+
+// deleteSecretsConcurrently deletes each secret with DeleteSecret, for servers which don't
+// support `DELETE /secrets`. At most batchDeleteConcurrency requests are made at once.
+func (c *Client) deleteSecretsConcurrently(fingerprint fpr.Fingerprint, uuids []string) error {
+	errs := make([]error, len(uuids))
+	semaphore := make(chan struct{}, batchDeleteConcurrency)
+
+	var wg sync.WaitGroup
+	for i, uuid := range uuids {
+		wg.Add(1)
+		go func(i int, uuid string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			errs[i] = c.DeleteSecret(fingerprint, uuid)
+		}(i, uuid)
+	}
+	wg.Wait()
+
+	batchError := &BatchDeleteError{Failures: map[string]error{}}
+	for i, err := range errs {
+		if err != nil {
+			batchError.Failures[uuids[i]] = err
+		}
+	}
+	if len(batchError.Failures) > 0 {
+		return batchError
+	}
+	return nil
+}
+
+type batchDeleteSecretsRequest struct {
+	UUIDs []string `json:"uuids"`
+}
+
+// batchDeleteSecretsResponse lists the requested secrets that weren't waiting for the key.
+// The rest were deleted.
+type batchDeleteSecretsResponse struct {
+	NotFound []string `json:"notFound"`
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestBatchDeleteSecrets(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+	uuids := []string{
+		"11111111-0000-4000-8000-000000000000",
+		"22222222-0000-4000-8000-000000000000",
+		"33333333-0000-4000-8000-000000000000",
+	}
+
+	handleCapabilities := func(mux *http.ServeMux, supportsBatchDelete bool) {
+		mux.HandleFunc("/capabilities", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"supportsSecretsBatchDelete": %v}`, supportsBatchDelete)
+		})
+	}
+
+	t.Run("server supports batch delete", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+
+		var gotRequest batchDeleteSecretsRequest
+		mux.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "DELETE", r.Method)
+			assert.Equal(t, authorization(fingerprint), r.Header.Get("authorization"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&gotRequest))
+
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprint(w, `{"notFound": []}`)
+		})
+
+		err := client.BatchDeleteSecrets(fingerprint, uuids)
+		assert.NoError(t, err)
+		assert.Equal(t, uuids, gotRequest.UUIDs)
+	})
+
+	t.Run("batch delete returns BatchDeleteError for secrets not found", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		mux.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"notFound": ["%s"]}`, uuids[1])
+		})
+
+		err := client.BatchDeleteSecrets(fingerprint, uuids)
+		assert.Equal(t, &BatchDeleteError{
+			Failures: map[string]error{uuids[1]: ErrSecretNotFound},
+		}, err)
+	})
+
+	t.Run("batch delete passes up error responses", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, true)
+		mux.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+
+		err := client.BatchDeleteSecrets(fingerprint, uuids)
+		assert.Equal(t, &APIError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("falls back to deleting each secret", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, false)
+
+		var deleted []string
+		var deletedMutex sync.Mutex
+		mux.HandleFunc("/secrets/", func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "DELETE", r.Method)
+
+			deletedMutex.Lock()
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/secrets/"))
+			deletedMutex.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		err := client.BatchDeleteSecrets(fingerprint, uuids)
+		assert.NoError(t, err)
+		sort.Strings(deleted) // deleted concurrently, so in any order
+		assert.Equal(t, uuids, deleted)
+	})
+
+	t.Run("fallback collects each failure", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		handleCapabilities(mux, false)
+		mux.HandleFunc("/secrets/", func(w http.ResponseWriter, r *http.Request) {
+			switch strings.TrimPrefix(r.URL.Path, "/secrets/") {
+			case uuids[0]:
+				w.WriteHeader(http.StatusNotFound)
+			case uuids[2]:
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+
+		err := client.BatchDeleteSecrets(fingerprint, uuids)
+		assert.Equal(t, &BatchDeleteError{
+			Failures: map[string]error{
+				uuids[0]: ErrSecretNotFound,
+				uuids[2]: &APIError{StatusCode: http.StatusForbidden},
+			},
+		}, err)
+	})
+
+	t.Run("does nothing for no secrets", func(t *testing.T) {
+		client, _, _, teardown := setup()
+		defer teardown()
+
+		assert.NoError(t, client.BatchDeleteSecrets(fingerprint, []string{}))
+	})
+}
+
+func TestBatchDeleteErrorMessage(t *testing.T) {
+	err := BatchDeleteError{Failures: map[string]error{
+		"bbbb": ErrSecretNotFound,
+		"aaaa": fmt.Errorf("oops"),
+	}}
+	assert.Equal(t, "failed to delete 2 secrets: aaaa: oops; bbbb: Secret not found", err.Error())
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/gofrs/uuid"
+)
+
+// UpdateTeamMemberKey asks the server to replace oldFingerprint with newFingerprint in the
+// team's roster, for example after the member has rotated their key.
+// privateKey must be the unlocked old key: the request is signed with it to prove the member
+// controls it. The new key must already have been uploaded with UpsertPublicKey.
+func (c *Client) UpdateTeamMemberKey(teamUUID uuid.UUID, oldFingerprint,
+	newFingerprint fpr.Fingerprint, privateKey *pgpkey.PgpKey) error {
+
+	if privateKey.Fingerprint() != oldFingerprint {
+		return fmt.Errorf("request must be signed by old key %s, not %s",
+			oldFingerprint, privateKey.Fingerprint())
+	}
+
+	singleUseUUID, err := c.newSingleUseUUID()
+	if err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.Marshal(updateTeamMemberKeySignedData{
+		Timestamp:      time.Now(),
+		SingleUseUUID:  singleUseUUID.String(),
+		TeamUUID:       teamUUID.String(),
+		OldFingerprint: oldFingerprint.Uri(),
+		NewFingerprint: newFingerprint.Uri(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't marshal JSON: %v", err)
+	}
+
+	armoredSignedJSON, err := privateKey.MakeArmoredClearsignedText(jsonBytes)
+	if err != nil {
+		return fmt.Errorf("couldn't sign request: %v", err)
+	}
+
+	path := fmt.Sprintf("team/%s/members/%s", teamUUID, oldFingerprint.Hex())
+	request, err := c.newRequest("PATCH", path, updateTeamMemberKeyRequest{
+		ArmoredSignedJSON: armoredSignedJSON,
+	})
+	if err != nil {
+		return err
+	}
+	request.Header.Add("authorization", authorization(oldFingerprint))
+
+	response, err := c.do(request, nil)
+	if err != nil {
+		if response == nil {
+			return err
+		}
+		switch response.StatusCode {
+		case http.StatusNotFound:
+			return ErrTeamNotFound
+
+		case http.StatusForbidden:
+			return ErrForbidden
+
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+type updateTeamMemberKeyRequest struct {
+	// ArmoredSignedJSON is updateTeamMemberKeySignedData, signed by the old key
+	ArmoredSignedJSON string `json:"armoredSignedJson"`
+}
+
+type updateTeamMemberKeySignedData struct {
+	// Timestamp is the client's current time, which must be close to the server's time
+	Timestamp time.Time `json:"timestamp"`
+
+	// SingleUseUUID prevents the signed request being replayed
+	SingleUseUUID string `json:"singleUseUuid"`
+
+	// TeamUUID, OldFingerprint and NewFingerprint are signed so the request can't be
+	// redirected to another team or key
+	TeamUUID       string `json:"teamUuid"`
+	OldFingerprint string `json:"oldFingerprint"`
+	NewFingerprint string `json:"newFingerprint"`
+}
+
+// LeaveTeam asks the server to remove the member with privateKey from the team's roster.
+// privateKey must be unlocked: the request is signed with it to prove the member controls it.
+func (c *Client) LeaveTeam(teamUUID uuid.UUID, privateKey *pgpkey.PgpKey) error {
+	singleUseUUID, err := c.newSingleUseUUID()
+	if err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.Marshal(leaveTeamSignedData{
+		Timestamp:     time.Now(),
+		SingleUseUUID: singleUseUUID.String(),
+		TeamUUID:      teamUUID.String(),
+		Fingerprint:   privateKey.Fingerprint().Uri(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't marshal JSON: %v", err)
+	}
+
+	armoredSignedJSON, err := privateKey.MakeArmoredClearsignedText(jsonBytes)
+	if err != nil {
+		return fmt.Errorf("couldn't sign request: %v", err)
+	}
+
+	path := fmt.Sprintf("team/%s/membership", teamUUID)
+	request, err := c.newRequest("DELETE", path, leaveTeamRequest{
+		ArmoredSignedJSON: armoredSignedJSON,
+	})
+	if err != nil {
+		return err
+	}
+	request.Header.Add("authorization", authorization(privateKey.Fingerprint()))
+
+	response, err := c.do(request, nil)
+	if err != nil {
+		if response == nil {
+			return err
+		}
+		switch response.StatusCode {
+		case http.StatusNotFound:
+			return ErrTeamNotFound
+
+		case http.StatusForbidden:
+			return ErrForbidden
+
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+type leaveTeamRequest struct {
+	// ArmoredSignedJSON is leaveTeamSignedData, signed by the leaving member's key
+	ArmoredSignedJSON string `json:"armoredSignedJson"`
+}
+
+type leaveTeamSignedData struct {
+	// Timestamp is the client's current time, which must be close to the server's time
+	Timestamp time.Time `json:"timestamp"`
+
+	// SingleUseUUID prevents the signed request being replayed
+	SingleUseUUID string `json:"singleUseUuid"`
+
+	// TeamUUID and Fingerprint are signed so the request can't be used to remove the member
+	// from a different team
+	TeamUUID    string `json:"teamUuid"`
+	Fingerprint string `json:"fingerprint"`
+}
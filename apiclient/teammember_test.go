@@ -0,0 +1,181 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/clearsign"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/gofrs/uuid"
+)
+
+func TestUpdateTeamMemberKey(t *testing.T) {
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+	oldFingerprint := exampledata.ExampleFingerprint4
+	newFingerprint := exampledata.ExampleFingerprint2
+	path := "/team/74bb40b4-3510-11e9-968e-53c38df634be/members/" + oldFingerprint.Hex()
+
+	oldKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	t.Run("sends request signed by the old key", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotSignedData updateTeamMemberKeySignedData
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "PATCH", r.Method)
+			assertClientSentValidAuthHeader(t, oldFingerprint, r.Header)
+
+			requestData := updateTeamMemberKeyRequest{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&requestData))
+
+			block, _ := clearsign.Decode([]byte(requestData.ArmoredSignedJSON))
+			if block == nil {
+				t.Fatalf("request wasn't clearsigned: %s", requestData.ArmoredSignedJSON)
+			}
+			_, err := openpgp.CheckDetachedSignature(
+				openpgp.EntityList{&oldKey.Entity},
+				bytes.NewReader(block.Bytes),
+				block.ArmoredSignature.Body,
+			)
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal(block.Plaintext, &gotSignedData))
+
+			w.WriteHeader(http.StatusOK)
+		})
+
+		err := client.UpdateTeamMemberKey(teamUUID, oldFingerprint, newFingerprint, oldKey)
+		assert.NoError(t, err)
+
+		assert.Equal(t, teamUUID.String(), gotSignedData.TeamUUID)
+		assert.Equal(t, oldFingerprint.Uri(), gotSignedData.OldFingerprint)
+		assert.Equal(t, newFingerprint.Uri(), gotSignedData.NewFingerprint)
+		assert.Equal(t, 36, len(gotSignedData.SingleUseUUID))
+	})
+
+	t.Run("refuses to sign with a key other than the old key", func(t *testing.T) {
+		client, _, _, teardown := setup()
+		defer teardown()
+
+		err := client.UpdateTeamMemberKey(teamUUID, newFingerprint, oldFingerprint, oldKey)
+		assert.GotError(t, err)
+	})
+
+	errorTests := []struct {
+		statusCode  int
+		expectedErr error
+	}{
+		{http.StatusNotFound, ErrTeamNotFound},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusBadRequest, &APIError{StatusCode: http.StatusBadRequest, Detail: "new key not uploaded"}},
+	}
+
+	for _, test := range errorTests {
+		t.Run(fmt.Sprintf("with HTTP %d response", test.statusCode), func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Content-Type", "application/json")
+				w.WriteHeader(test.statusCode)
+				fmt.Fprint(w, `{"detail": "new key not uploaded"}`)
+			})
+
+			err := client.UpdateTeamMemberKey(teamUUID, oldFingerprint, newFingerprint, oldKey)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func TestLeaveTeam(t *testing.T) {
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+	fingerprint := exampledata.ExampleFingerprint4
+	path := "/team/74bb40b4-3510-11e9-968e-53c38df634be/membership"
+
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	t.Run("sends request signed by the member's key", func(t *testing.T) {
+		client, mux, _, teardown := setup()
+		defer teardown()
+
+		var gotSignedData leaveTeamSignedData
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			assertClientSentVerb(t, "DELETE", r.Method)
+			assertClientSentValidAuthHeader(t, fingerprint, r.Header)
+
+			requestData := leaveTeamRequest{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&requestData))
+
+			block, _ := clearsign.Decode([]byte(requestData.ArmoredSignedJSON))
+			if block == nil {
+				t.Fatalf("request wasn't clearsigned: %s", requestData.ArmoredSignedJSON)
+			}
+			_, err := openpgp.CheckDetachedSignature(
+				openpgp.EntityList{&key.Entity},
+				bytes.NewReader(block.Bytes),
+				block.ArmoredSignature.Body,
+			)
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal(block.Plaintext, &gotSignedData))
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		assert.NoError(t, client.LeaveTeam(teamUUID, key))
+
+		assert.Equal(t, teamUUID.String(), gotSignedData.TeamUUID)
+		assert.Equal(t, fingerprint.Uri(), gotSignedData.Fingerprint)
+		assert.Equal(t, 36, len(gotSignedData.SingleUseUUID))
+	})
+
+	errorTests := []struct {
+		statusCode  int
+		expectedErr error
+	}{
+		{http.StatusNotFound, ErrTeamNotFound},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusBadRequest, &APIError{StatusCode: http.StatusBadRequest, Detail: "bad signature"}},
+	}
+
+	for _, test := range errorTests {
+		t.Run(fmt.Sprintf("with HTTP %d response", test.statusCode), func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Content-Type", "application/json")
+				w.WriteHeader(test.statusCode)
+				fmt.Fprint(w, `{"detail": "bad signature"}`)
+			})
+
+			assert.Equal(t, test.expectedErr, client.LeaveTeam(teamUUID, key))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// newPinnedHTTPClient returns an HTTP client which, as well as the usual certificate checks,
+// rejects any server whose certificate's public key doesn't match pin.
+// pin is the base64 encoded SHA-256 hash of the certificate's DER encoded SubjectPublicKeyInfo,
+// optionally prefixed with "sha256/". Get it for the current API certificate with:
+//
+//	openssl s_client -connect api.fluidkeys.com:443 </dev/null | openssl x509 -pubkey -noout |
+//	    openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+//
+// rootCAs are the CAs to trust, or nil to use the system's CAs.
+func newPinnedHTTPClient(pin string, rootCAs *x509.CertPool) (*http.Client, error) {
+	pinnedHash, err := parseTLSPin(pin)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := newHTTPTransport()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:               rootCAs,
+		VerifyPeerCertificate: makePinnedCertificateVerifier(pinnedHash),
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// parseTLSPin decodes a base64 encoded SHA-256 hash, optionally prefixed with "sha256/"
+func parseTLSPin(pin string) ([]byte, error) {
+	pinnedHash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+	if err != nil {
+		return nil, fmt.Errorf("pin isn't valid base64: %v", err)
+	}
+	if len(pinnedHash) != sha256.Size {
+		return nil, fmt.Errorf("expected a %d byte SHA-256 hash, got %d bytes",
+			sha256.Size, len(pinnedHash))
+	}
+	return pinnedHash, nil
+}
+
+// makePinnedCertificateVerifier returns a function for tls.Config.VerifyPeerCertificate which
+// rejects the connection unless the server's certificate public key hashes to pinnedHash.
+// It's called after the normal verification against the trusted CAs, so doesn't replace it.
+func makePinnedCertificateVerifier(pinnedHash []byte) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("server didn't present a certificate")
+		}
+
+		serverCert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse server certificate: %v", err)
+		}
+
+		if gotHash := spkiHash(serverCert); !bytes.Equal(gotHash[:], pinnedHash) {
+			return fmt.Errorf("server certificate public key sha256/%s doesn't match pin sha256/%s",
+				base64.StdEncoding.EncodeToString(gotHash[:]),
+				base64.StdEncoding.EncodeToString(pinnedHash))
+		}
+		return nil
+	}
+}
+
+// spkiHash returns the SHA-256 hash of the certificate's DER encoded SubjectPublicKeyInfo
+func spkiHash(cert *x509.Certificate) [sha256.Size]byte {
+	return sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+}
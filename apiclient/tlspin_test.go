@@ -0,0 +1,103 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestPinnedHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+
+	serverHash := spkiHash(server.Certificate())
+	correctPin := base64.StdEncoding.EncodeToString(serverHash[:])
+	wrongPin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	t.Run("connects if the certificate matches the pin", func(t *testing.T) {
+		for _, pin := range []string{correctPin, "sha256/" + correctPin} {
+			client, err := newPinnedHTTPClient(pin, rootCAs)
+			assert.NoError(t, err)
+
+			response, err := client.Get(server.URL)
+			assert.NoError(t, err)
+			response.Body.Close()
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+		}
+	})
+
+	t.Run("rejects a certificate that doesn't match the pin", func(t *testing.T) {
+		client, err := newPinnedHTTPClient(wrongPin, rootCAs)
+		assert.NoError(t, err)
+
+		_, err = client.Get(server.URL)
+		assert.GotError(t, err)
+		if !strings.Contains(err.Error(), "doesn't match pin") {
+			t.Fatalf("expected pin mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("still checks the certificate is trusted", func(t *testing.T) {
+		client, err := newPinnedHTTPClient(correctPin, x509.NewCertPool())
+		assert.NoError(t, err)
+
+		_, err = client.Get(server.URL)
+		assert.GotError(t, err)
+	})
+}
+
+func TestParseTLSPin(t *testing.T) {
+	t.Run("rejects invalid base64", func(t *testing.T) {
+		_, err := parseTLSPin("not base64!")
+		assert.GotError(t, err)
+	})
+
+	t.Run("rejects a hash of the wrong length", func(t *testing.T) {
+		_, err := parseTLSPin(base64.StdEncoding.EncodeToString([]byte("too short")))
+		assert.Equal(t, fmt.Errorf("expected a 32 byte SHA-256 hash, got 9 bytes"), err)
+	})
+}
+
+func TestNewWithTLSPin(t *testing.T) {
+	pin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	os.Setenv("FLUIDKEYS_TLS_PIN", pin)
+	defer os.Unsetenv("FLUIDKEYS_TLS_PIN")
+
+	client := New("vtest")
+
+	transport, ok := client.client.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil ||
+		transport.TLSClientConfig.VerifyPeerCertificate == nil {
+
+		t.Fatalf("expected client to verify pinned certificate, got transport %v", transport)
+	}
+}
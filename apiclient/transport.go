@@ -0,0 +1,52 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxIdleConnsPerHost is how many connections to the API are kept open between requests.
+	// http.DefaultTransport only keeps 2, so concurrent batch operations (see
+	// batchDeleteConcurrency) would otherwise open a new connection for most requests.
+	maxIdleConnsPerHost = 10
+
+	// idleConnTimeout is how long an unused connection is kept open for
+	idleConnTimeout = 30 * time.Second
+)
+
+// newHTTPTransport returns a transport with the same settings as http.DefaultTransport, except
+// that it keeps enough connections to the API alive to reuse them across batches of requests.
+// It's built field by field since http.Transport.Clone needs Go 1.13.
+func newHTTPTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
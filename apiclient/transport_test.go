@@ -0,0 +1,126 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestNewHTTPTransport(t *testing.T) {
+	transport := newHTTPTransport()
+
+	assert.Equal(t, maxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, idleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, false, transport.DisableCompression)
+	assert.Equal(t, false, transport.DisableKeepAlives)
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	client, newConnections, teardown := setupConnectionCountingServer(t, newHTTPTransport())
+	defer teardown()
+
+	for i := 0; i < 50; i++ {
+		_, err := client.Ping()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), atomic.LoadInt64(newConnections))
+}
+
+// BenchmarkAPICalls makes 50 API calls per iteration, reporting how many new connections each
+// iteration needed.
+func BenchmarkAPICalls(b *testing.B) {
+	noKeepAlive := newHTTPTransport()
+	noKeepAlive.DisableKeepAlives = true
+
+	defaultIdleConns := newHTTPTransport()
+	defaultIdleConns.MaxIdleConnsPerHost = 0 // http.DefaultMaxIdleConnsPerHost, like DefaultTransport
+
+	b.Run("sequential without keep-alive", func(b *testing.B) {
+		benchmarkAPICalls(b, noKeepAlive, 1)
+	})
+	b.Run("sequential with newHTTPTransport", func(b *testing.B) {
+		benchmarkAPICalls(b, newHTTPTransport(), 1)
+	})
+	b.Run("concurrent with default idle connections", func(b *testing.B) {
+		benchmarkAPICalls(b, defaultIdleConns, 8)
+	})
+	b.Run("concurrent with newHTTPTransport", func(b *testing.B) {
+		benchmarkAPICalls(b, newHTTPTransport(), 8)
+	})
+}
+
+func benchmarkAPICalls(b *testing.B, transport *http.Transport, workers int) {
+	client, newConnections, teardown := setupConnectionCountingServer(b, transport)
+	defer teardown()
+
+	const calls = 50
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for call := w; call < calls; call += workers {
+					if _, err := client.Ping(); err != nil {
+						b.Error(err)
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+	b.Logf("%.1f new connections per iteration",
+		float64(atomic.LoadInt64(newConnections))/float64(b.N))
+}
+
+// setupConnectionCountingServer returns a client using transport to talk to a test server, and
+// a counter of how many connections have been made to the server.
+func setupConnectionCountingServer(t testing.TB, transport *http.Transport) (
+	client *Client, newConnections *int64, teardown func()) {
+
+	t.Helper()
+	newConnections = new(int64)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) },
+	))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(newConnections, 1)
+		}
+	}
+	server.Start()
+
+	client = New("vtest")
+	client.client = &http.Client{Transport: transport}
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	return client, newConnections, func() {
+		transport.CloseIdleConnections()
+		server.Close()
+	}
+}
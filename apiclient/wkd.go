@@ -0,0 +1,126 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+)
+
+// maxWKDResponseBytes limits how much we'll read from a Web Key Directory
+const maxWKDResponseBytes = 1024 * 1024
+
+// wkdTimeout limits how long each Web Key Directory request can take. Lookups go to whatever
+// domain a team member's email is at, so one that doesn't respond mustn't hang a fetch.
+const wkdTimeout = 5 * time.Second
+
+// A WKDClient looks up public keys published in a domain's Web Key Directory (WKD)
+// See https://tools.ietf.org/html/draft-koch-openpgp-webkey-service-07
+type WKDClient struct {
+	client    *http.Client // HTTP client used to query Web Key Directories
+	UserAgent string       // User agent used when querying Web Key Directories
+}
+
+// NewWKDClient returns a new Web Key Directory client.
+func NewWKDClient(fluidkeysVersion string) *WKDClient {
+	return &WKDClient{
+		client:    &http.Client{Transport: newHTTPTransport(), Timeout: wkdTimeout},
+		UserAgent: userAgent + "-" + fluidkeysVersion,
+	}
+}
+
+// Lookup queries the Web Key Directory of the email address's domain for a public key, trying
+// the advanced method then the direct method.
+// If no key is found, it returns ErrPublicKeyNotFound.
+func (w *WKDClient) Lookup(email string) (*pgpkey.PgpKey, error) {
+	urls, err := wkdURLs(email)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range urls {
+		key, err := w.fetchKey(u, email)
+		if err != nil {
+			log.Printf("failed to get key from WKD %s: %v", u, err)
+			continue
+		}
+		return key, nil
+	}
+	return nil, ErrPublicKeyNotFound
+}
+
+func (w *WKDClient) fetchKey(keyURL string, email string) (*pgpkey.PgpKey, error) {
+	request, err := http.NewRequest("GET", keyURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", w.UserAgent)
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got HTTP %d", response.StatusCode)
+	}
+
+	entities, err := openpgp.ReadKeyRing(io.LimitReader(response.Body, maxWKDResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error reading key: %v", err)
+	}
+
+	// the directory may serve multiple keys: only return one with a matching user ID
+	for _, entity := range entities {
+		key := pgpkey.PgpKey{Entity: *entity}
+		for _, keyEmail := range key.Emails(true) {
+			if strings.ToLower(keyEmail) == strings.ToLower(email) {
+				return &key, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no key with user ID matching %s", email)
+}
+
+// wkdURLs returns the advanced and direct method URLs for the given email address
+func wkdURLs(email string) ([]string, error) {
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		return nil, fmt.Errorf("invalid email address: %s", email)
+	}
+	localPart := email[:at]
+	domain := strings.ToLower(email[at+1:])
+
+	hash := team.WKDHash(localPart)
+	query := "?l=" + url.QueryEscape(localPart)
+
+	return []string{
+		"https://openpgpkey." + domain + "/.well-known/openpgpkey/" + domain + "/hu/" + hash + query,
+		"https://" + domain + "/.well-known/openpgpkey/hu/" + hash + query,
+	}, nil
+}
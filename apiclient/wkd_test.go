@@ -0,0 +1,153 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package apiclient
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+)
+
+func TestWKDLookup(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+
+	keyData := bytes.NewBuffer(nil)
+	assert.NoError(t, key.Serialize(keyData))
+
+	hash := team.WKDHash("test2")
+
+	advancedPath := "/.well-known/openpgpkey/example.com/hu/" + hash
+	directPath := "/.well-known/openpgpkey/hu/" + hash
+
+	serveKey := func(w http.ResponseWriter, r *http.Request) {
+		assertClientSentVerb(t, "GET", r.Method)
+		assert.Equal(t, "test2", r.URL.Query().Get("l"))
+		w.Write(keyData.Bytes())
+	}
+
+	t.Run("finds key using the advanced method", func(t *testing.T) {
+		wkdClient, mux, teardown := setupWKD()
+		defer teardown()
+
+		mux.HandleFunc(advancedPath, serveKey)
+
+		got, err := wkdClient.Lookup("test2@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, exampledata.ExampleFingerprint2, got.Fingerprint())
+	})
+
+	t.Run("falls back to the direct method", func(t *testing.T) {
+		wkdClient, mux, teardown := setupWKD()
+		defer teardown()
+
+		mux.HandleFunc(directPath, serveKey)
+
+		got, err := wkdClient.Lookup("test2@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, exampledata.ExampleFingerprint2, got.Fingerprint())
+	})
+
+	t.Run("returns ErrPublicKeyNotFound if neither method has the key", func(t *testing.T) {
+		wkdClient, _, teardown := setupWKD()
+		defer teardown()
+
+		_, err := wkdClient.Lookup("test2@example.com")
+		assert.Equal(t, ErrPublicKeyNotFound, err)
+	})
+
+	t.Run("ignores a key without a matching user ID", func(t *testing.T) {
+		wkdClient, mux, teardown := setupWKD()
+		defer teardown()
+
+		mux.HandleFunc(
+			"/.well-known/openpgpkey/example.com/hu/"+team.WKDHash("test3"),
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write(keyData.Bytes()) // test2's key
+			},
+		)
+
+		_, err := wkdClient.Lookup("test3@example.com")
+		assert.Equal(t, ErrPublicKeyNotFound, err)
+	})
+
+	t.Run("gives up on a server that doesn't respond", func(t *testing.T) {
+		wkdClient, mux, teardown := setupWKD()
+		defer teardown()
+
+		wkdClient.client.Timeout = 50 * time.Millisecond
+		unblock := make(chan struct{})
+		defer close(unblock) // before teardown, which waits for the handler to return
+
+		mux.HandleFunc(advancedPath, func(w http.ResponseWriter, r *http.Request) {
+			<-unblock
+		})
+
+		_, err := wkdClient.Lookup("test2@example.com")
+		assert.Equal(t, ErrPublicKeyNotFound, err)
+	})
+
+	t.Run("returns an error for an invalid email", func(t *testing.T) {
+		wkdClient, _, teardown := setupWKD()
+		defer teardown()
+
+		_, err := wkdClient.Lookup("example.com")
+		assert.GotError(t, err)
+	})
+}
+
+func TestWKDURLs(t *testing.T) {
+	got, err := wkdURLs("Joe.Doe@Example.ORG")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"https://openpgpkey.example.org/.well-known/openpgpkey/example.org/hu/" +
+			"iy9q119eutrkn8s1mk4r39qejnbu3n5q?l=Joe.Doe",
+		"https://example.org/.well-known/openpgpkey/hu/" +
+			"iy9q119eutrkn8s1mk4r39qejnbu3n5q?l=Joe.Doe",
+	}, got)
+}
+
+// setupWKD returns a WKDClient which sends every request to a test server, whatever the domain
+func setupWKD() (wkdClient *WKDClient, mux *http.ServeMux, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	serverURL, _ := url.Parse(server.URL)
+
+	wkdClient = NewWKDClient("vtest")
+	wkdClient.client.Transport = rewriteHostTransport{host: serverURL.Host}
+	return wkdClient, mux, server.Close
+}
+
+type rewriteHostTransport struct {
+	host string
+}
+
+func (r rewriteHostTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request.URL.Scheme = "http"
+	request.URL.Host = r.host
+	return http.DefaultTransport.RoundTrip(request)
+}
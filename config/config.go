@@ -71,6 +71,9 @@ type Config struct {
 
 	// keyConfigs map[fpr.Fingerprint]key
 	filename string
+
+	// lookupEnv overrides os.LookupEnv when getting settings, for testing
+	lookupEnv func(string) (string, bool)
 }
 
 // GetFilename returns the filename where the given config is stored
@@ -209,6 +212,12 @@ func parse(r io.Reader) (*Config, error) {
 		}
 	}
 
+	for key := range parsedConfig.Settings {
+		if _, err := findSetting(key); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(metadata.Undecoded()) > 0 {
 		// found config variables that we don't know how to match to
 		// the tomlConfig structure
@@ -247,8 +256,9 @@ const (
 )
 
 type tomlConfig struct {
-	RunFromCron bool           `toml:"run_from_cron"`
-	PgpKeys     map[string]key `toml:"pgpkeys"`
+	RunFromCron bool              `toml:"run_from_cron"`
+	Settings    map[string]string `toml:"settings,omitempty"`
+	PgpKeys     map[string]key    `toml:"pgpkeys"`
 }
 
 type key struct {
@@ -268,6 +278,13 @@ const defaultConfigFile string = `# Fluidkeys configuration file for 'fk' comman
 #
 # run_from_cron = true
 #
+# # settings are preferences managed with 'fk config set <key> <value>'. run
+# # 'fk config list' to see them all. environment variables take precedence.
+# [settings]
+#   api_url = "https://api.fluidkeys.com/v1/"
+#   default_team = "74bb40b4-3510-11e9-968e-53c38df634be"
+#   editor = "vim"
+#
 # [pgpkeys]
 #   [pgpkeys."AAAA1111AAAA1111AAAA1111AAAA1111AAAA1111"]
 #
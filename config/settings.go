@@ -0,0 +1,199 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Setting is a user preference which can be changed with `fk config set`. Its value comes from
+// the first of EnvVars that's set, then the config file, then Default.
+type Setting struct {
+	Key         string
+	Description string
+	EnvVars     []string
+	Default     string
+
+	validate func(value string) error
+}
+
+// Settings is the whitelist of keys accepted by Get and Set.
+var Settings = []Setting{
+	{
+		Key:         "api_url",
+		Description: "Fluidkeys server API URL",
+		EnvVars:     []string{"FLUIDKEYS_API_URL"},
+		Default:     "", // apiclient uses its built-in URL
+		validate:    validateAPIURL,
+	},
+	{
+		Key:         "default_team",
+		Description: "UUID of the team to use when a command isn't given one",
+		EnvVars:     []string{"FLUIDKEYS_DEFAULT_TEAM"},
+		Default:     "",
+		validate:    validateUUID,
+	},
+	{
+		Key:         "editor",
+		Description: "Editor for commands like `fk team edit`",
+		EnvVars:     []string{"VISUAL", "EDITOR"},
+		Default:     "vi",
+		validate:    validateNotBlank,
+	},
+	{
+		Key:         "request_expiry",
+		Description: "How long a request to join a team lasts before it expires, e.g. 168h",
+		EnvVars:     []string{"FLUIDKEYS_REQUEST_EXPIRY"},
+		Default:     "", // team uses its built-in expiry
+		validate:    validatePositiveDuration,
+	},
+}
+
+// Source describes where the value of a setting came from
+type Source string
+
+const (
+	// SourceDefault means the setting isn't set, so has its default value
+	SourceDefault Source = "default"
+
+	// SourceFile means the setting is set in the config file
+	SourceFile Source = "config file"
+)
+
+// SourceEnv returns the Source for a setting taken from the given environment variable
+func SourceEnv(envVar string) Source {
+	return Source("$" + envVar)
+}
+
+// Get returns the value of the setting with the given key and where that value came from.
+// It returns an error if key isn't one of Settings.
+func (c *Config) Get(key string) (value string, source Source, err error) {
+	setting, err := findSetting(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, envVar := range setting.EnvVars {
+		if value := strings.TrimSpace(c.getenv(envVar)); value != "" {
+			return value, SourceEnv(envVar), nil
+		}
+	}
+
+	if value, inMap := c.parsedConfig.Settings[key]; inMap {
+		return value, SourceFile, nil
+	}
+
+	return setting.Default, SourceDefault, nil
+}
+
+// Set validates the value and saves it to the config file. It returns an error if key isn't one
+// of Settings or the value is invalid for that setting.
+// Note that environment variables still take precedence over the config file.
+func (c *Config) Set(key string, value string) error {
+	if err := Validate(key, value); err != nil {
+		return err
+	}
+
+	if c.parsedConfig.Settings == nil { // initialize the map if empty
+		c.parsedConfig.Settings = make(map[string]string)
+	}
+	c.parsedConfig.Settings[key] = value
+	return c.save()
+}
+
+// Validate returns an error if key isn't one of Settings or the value is invalid for that
+// setting. Values from the environment aren't checked by Get, so use this before relying on one.
+func Validate(key string, value string) error {
+	setting, err := findSetting(key)
+	if err != nil {
+		return err
+	}
+
+	if err := setting.validate(value); err != nil {
+		return fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return nil
+}
+
+func (c *Config) getenv(envVar string) string {
+	if c.lookupEnv != nil {
+		value, _ := c.lookupEnv(envVar)
+		return value
+	}
+	return os.Getenv(envVar)
+}
+
+func findSetting(key string) (*Setting, error) {
+	for i := range Settings {
+		if Settings[i].Key == key {
+			return &Settings[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown setting '%s': use one of %s",
+		key, strings.Join(settingKeys(), ", "))
+}
+
+func settingKeys() (keys []string) {
+	for _, setting := range Settings {
+		keys = append(keys, setting.Key)
+	}
+	return keys
+}
+
+func validateAPIURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Errorf("expected http:// or https:// URL, got '%s'", value)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("missing host in URL '%s'", value)
+	}
+	return nil
+}
+
+func validateUUID(value string) error {
+	_, err := uuid.FromString(value)
+	return err
+}
+
+func validateNotBlank(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("can't be blank")
+	}
+	return nil
+}
+
+func validatePositiveDuration(value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if duration <= 0 {
+		return fmt.Errorf("must be longer than zero, got '%s'", value)
+	}
+	return nil
+}
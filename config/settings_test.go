@@ -0,0 +1,158 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+)
+
+func TestGetSetting(t *testing.T) {
+	fakeEnv := func(env map[string]string) func(string) (string, bool) {
+		return func(envVar string) (string, bool) {
+			value, got := env[envVar]
+			return value, got
+		}
+	}
+
+	config, err := parse(strings.NewReader(`
+[settings]
+editor = "nano"
+`))
+	assert.NoError(t, err)
+
+	t.Run("returns the default if not set anywhere", func(t *testing.T) {
+		config.lookupEnv = fakeEnv(nil)
+
+		value, source, err := config.Get("default_team")
+		assert.NoError(t, err)
+		assert.Equal(t, "", value)
+		assert.Equal(t, SourceDefault, source)
+	})
+
+	t.Run("returns the config file value over the default", func(t *testing.T) {
+		config.lookupEnv = fakeEnv(nil)
+
+		value, source, err := config.Get("editor")
+		assert.NoError(t, err)
+		assert.Equal(t, "nano", value)
+		assert.Equal(t, SourceFile, source)
+	})
+
+	t.Run("returns an environment variable over the config file", func(t *testing.T) {
+		config.lookupEnv = fakeEnv(map[string]string{"EDITOR": "emacs"})
+
+		value, source, err := config.Get("editor")
+		assert.NoError(t, err)
+		assert.Equal(t, "emacs", value)
+		assert.Equal(t, Source("$EDITOR"), source)
+	})
+
+	t.Run("checks environment variables in order", func(t *testing.T) {
+		config.lookupEnv = fakeEnv(map[string]string{"EDITOR": "emacs", "VISUAL": "code"})
+
+		value, source, err := config.Get("editor")
+		assert.NoError(t, err)
+		assert.Equal(t, "code", value)
+		assert.Equal(t, Source("$VISUAL"), source)
+	})
+
+	t.Run("ignores blank environment variables", func(t *testing.T) {
+		config.lookupEnv = fakeEnv(map[string]string{"VISUAL": " "})
+
+		value, source, err := config.Get("editor")
+		assert.NoError(t, err)
+		assert.Equal(t, "nano", value)
+		assert.Equal(t, SourceFile, source)
+	})
+
+	t.Run("returns an error for an unknown key", func(t *testing.T) {
+		_, _, err := config.Get("favourite_colour")
+		assert.Equal(t,
+			"unknown setting 'favourite_colour': use one of api_url, default_team, editor, request_expiry",
+			err.Error())
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("accepts a valid value", func(t *testing.T) {
+		assert.NoError(t, Validate("request_expiry", "168h"))
+	})
+
+	t.Run("returns an error for an invalid value", func(t *testing.T) {
+		assert.Equal(t, "invalid request_expiry: must be longer than zero, got '-1h'",
+			Validate("request_expiry", "-1h").Error())
+		assert.GotError(t, Validate("request_expiry", "7d"))
+	})
+
+	t.Run("returns an error for an unknown key", func(t *testing.T) {
+		assert.GotError(t, Validate("favourite_colour", "blue"))
+	})
+}
+
+func TestSetSetting(t *testing.T) {
+	dir := testhelpers.Maketemp(t)
+	config := Config{filename: filepath.Join(dir, "config.toml")}
+
+	t.Run("saves a valid value to the config file", func(t *testing.T) {
+		err := config.Set("default_team", "74bb40b4-3510-11e9-968e-53c38df634be")
+		assert.NoError(t, err)
+
+		saved, err := ioutil.ReadFile(config.filename)
+		assert.NoError(t, err)
+		reloaded, err := parse(strings.NewReader(string(saved)))
+		assert.NoError(t, err)
+		reloaded.lookupEnv = func(string) (string, bool) { return "", false }
+
+		value, source, err := reloaded.Get("default_team")
+		assert.NoError(t, err)
+		assert.Equal(t, "74bb40b4-3510-11e9-968e-53c38df634be", value)
+		assert.Equal(t, SourceFile, source)
+	})
+
+	t.Run("returns an error for an invalid value", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"api_url":        "ftp://example.com",
+			"default_team":   "not-a-uuid",
+			"editor":         " ",
+			"request_expiry": "7 days",
+		} {
+			assert.GotError(t, config.Set(key, value))
+		}
+	})
+
+	t.Run("returns an error for a request expiry that isn't positive", func(t *testing.T) {
+		for _, value := range []string{"0s", "-1h"} {
+			assert.GotError(t, config.Set("request_expiry", value))
+		}
+	})
+
+	t.Run("returns an error for an unknown key", func(t *testing.T) {
+		assert.GotError(t, config.Set("favourite_colour", "blue"))
+	})
+
+	t.Run("config file with an unknown setting fails to parse", func(t *testing.T) {
+		_, err := parse(strings.NewReader("[settings]\nfavourite_colour = \"blue\"\n"))
+		assert.GotError(t, err)
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 
 	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
@@ -44,6 +45,11 @@ type Message struct {
 	KeysImportedIntoGnuPG []KeyImportedIntoGnuPGMessage
 	RequestsToJoinTeams   []RequestToJoinTeamMessage
 	EventTimes            map[string]time.Time
+	DiscoveredTeams       map[string]DiscoveredTeamMessage `json:",omitempty"`
+	UsedNonces            map[string]time.Time             `json:",omitempty"`
+
+	PreferredEncryptionSubkeys map[string]fpr.Fingerprint `json:",omitempty"`
+	RevokedKeys                []fpr.Fingerprint          `json:",omitempty"`
 }
 
 // KeyImportedIntoGnuPGMessage represents a key the user has imported into GnuPG from Fluidkeys
@@ -59,12 +65,23 @@ type RequestToJoinTeamMessage struct {
 	RequestedAt time.Time       `json: "RequestedAt"`
 }
 
+// DiscoveredTeamMessage records a team UUID found in a domain's DNS, and when it was looked up.
+type DiscoveredTeamMessage struct {
+	TeamUUID     uuid.UUID
+	DiscoveredAt time.Time
+}
+
 // New returns a database from the given fluidkeys directory
 func New(fluidkeysDirectory string) Database {
 	jsonFilename := filepath.Join(fluidkeysDirectory, "db.json")
 	return Database{jsonFilename: jsonFilename}
 }
 
+// Filename returns the full path of the database's JSON file
+func (db *Database) Filename() string {
+	return db.jsonFilename
+}
+
 // RecordFingerprintImportedIntoGnuPG takes a given fingperprint and records that it's been
 // imported into GnuPG by writing an updated json database.
 func (db *Database) RecordFingerprintImportedIntoGnuPG(newFingerprint fpr.Fingerprint) error {
@@ -108,6 +125,130 @@ func (db *Database) RecordRequestToJoinTeam(
 	return db.saveToFile(*message)
 }
 
+// RecordDiscoveredTeam records that looking up domain in DNS gave teamUUID, so that the lookup
+// can be cached.
+func (db *Database) RecordDiscoveredTeam(domain string, teamUUID uuid.UUID, now time.Time) error {
+	message, err := db.loadFromFile()
+	if err != nil {
+		return err
+	}
+
+	if message.DiscoveredTeams == nil {
+		message.DiscoveredTeams = make(map[string]DiscoveredTeamMessage)
+	}
+
+	message.DiscoveredTeams[strings.ToLower(domain)] = DiscoveredTeamMessage{
+		TeamUUID:     teamUUID,
+		DiscoveredAt: now,
+	}
+
+	return db.saveToFile(*message)
+}
+
+// GetDiscoveredTeam returns the team UUID recorded for domain by RecordDiscoveredTeam, provided
+// it was recorded less than maxAge ago. If not, found is false.
+func (db *Database) GetDiscoveredTeam(domain string, maxAge time.Duration, now time.Time) (
+	teamUUID uuid.UUID, found bool, err error) {
+
+	message, err := db.loadFromFile()
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	discovered, ok := message.DiscoveredTeams[strings.ToLower(domain)]
+	if !ok || now.Sub(discovered.DiscoveredAt) >= maxAge {
+		return uuid.Nil, false, nil
+	}
+	return discovered.TeamUUID, true, nil
+}
+
+// RecordUsedNonce records that the single use nonce was used at now. Nonces used more than
+// maxAge ago are forgotten.
+func (db *Database) RecordUsedNonce(nonce string, maxAge time.Duration, now time.Time) error {
+	message, err := db.loadFromFile()
+	if err != nil {
+		return err
+	}
+
+	usedNonces := map[string]time.Time{nonce: now}
+	for existingNonce, usedAt := range message.UsedNonces {
+		if now.Sub(usedAt) < maxAge && existingNonce != nonce {
+			usedNonces[existingNonce] = usedAt
+		}
+	}
+	message.UsedNonces = usedNonces
+
+	return db.saveToFile(*message)
+}
+
+// IsNonceUsed returns true if the nonce was recorded by RecordUsedNonce less than maxAge ago.
+func (db *Database) IsNonceUsed(nonce string, maxAge time.Duration, now time.Time) (bool, error) {
+	message, err := db.loadFromFile()
+	if err != nil {
+		return false, err
+	}
+
+	usedAt, found := message.UsedNonces[nonce]
+	return found && now.Sub(usedAt) < maxAge, nil
+}
+
+// SetPreferredEncryptionSubkey records that secrets encrypted to the primary key should use
+// the given subkey, rather than the newest valid encryption subkey.
+func (db *Database) SetPreferredEncryptionSubkey(primary, subkey fpr.Fingerprint) error {
+	message, err := db.loadFromFile()
+	if err != nil {
+		return err
+	}
+
+	if message.PreferredEncryptionSubkeys == nil {
+		message.PreferredEncryptionSubkeys = map[string]fpr.Fingerprint{}
+	}
+	message.PreferredEncryptionSubkeys[primary.Hex()] = subkey
+
+	return db.saveToFile(*message)
+}
+
+// GetPreferredEncryptionSubkey returns the subkey recorded for the primary key by
+// SetPreferredEncryptionSubkey. If none was recorded, found is false.
+func (db *Database) GetPreferredEncryptionSubkey(primary fpr.Fingerprint) (
+	subkey fpr.Fingerprint, found bool, err error) {
+
+	message, err := db.loadFromFile()
+	if err != nil {
+		return fpr.Fingerprint{}, false, err
+	}
+
+	subkey, found = message.PreferredEncryptionSubkeys[primary.Hex()]
+	return subkey, found, nil
+}
+
+// RecordRevokedKey records that a revocation certificate for the key has been published, so
+// roster updates can refuse to change that key's entry.
+func (db *Database) RecordRevokedKey(fingerprint fpr.Fingerprint) error {
+	message, err := db.loadFromFile()
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range message.RevokedKeys {
+		if existing == fingerprint {
+			return nil
+		}
+	}
+	message.RevokedKeys = append(message.RevokedKeys, fingerprint)
+
+	return db.saveToFile(*message)
+}
+
+// GetRevokedKeys returns the fingerprints of keys recorded by RecordRevokedKey.
+func (db *Database) GetRevokedKeys() (fingerprints []fpr.Fingerprint, err error) {
+	message, err := db.loadFromFile()
+	if err != nil {
+		return nil, err
+	}
+	return message.RevokedKeys, nil
+}
+
 // RecordLast takes a verb and item and records the action in the database, e.g verb "fetched",
 // item: key.
 func (db *Database) RecordLast(verb string, item interface{}, now time.Time) error {
@@ -287,6 +428,33 @@ func (db *Database) DeleteRequestToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.
 	return db.saveToFile(*message)
 }
 
+// DeleteTeam deletes everything recorded about the given team: requests to join it (from any
+// key) and the times of any events recorded against it with RecordLast.
+func (db *Database) DeleteTeam(teamUUID uuid.UUID) error {
+	message, err := db.loadFromFile()
+	if err != nil {
+		return err
+	}
+
+	newRequests := []RequestToJoinTeamMessage{}
+	for _, req := range message.RequestsToJoinTeams {
+		if req.TeamUUID == teamUUID {
+			log.Printf("deleting request to join team: %v", req)
+			continue
+		}
+		newRequests = append(newRequests, req)
+	}
+	message.RequestsToJoinTeams = newRequests
+
+	teamSuffix := ":" + teamItem + ":" + teamUUID.String()
+	for mapKey := range message.EventTimes {
+		if strings.HasSuffix(mapKey, teamSuffix) {
+			delete(message.EventTimes, mapKey)
+		}
+	}
+	return db.saveToFile(*message)
+}
+
 func (db *Database) loadFromFile() (message *Message, err error) {
 	file, err := os.Open(db.jsonFilename)
 	if err != nil {
@@ -317,6 +485,11 @@ func (db *Database) loadFromFile() (message *Message, err error) {
 		),
 		RequestsToJoinTeams: message.RequestsToJoinTeams,
 		EventTimes:          message.EventTimes,
+		DiscoveredTeams:     message.DiscoveredTeams,
+		UsedNonces:          message.UsedNonces,
+
+		PreferredEncryptionSubkeys: message.PreferredEncryptionSubkeys,
+		RevokedKeys:                message.RevokedKeys,
 	}, nil
 }
 
@@ -290,6 +290,186 @@ func TestDeleteRequestToJoinTeam(t *testing.T) {
 	})
 }
 
+func TestDeleteTeam(t *testing.T) {
+	teamToDelete := team.Team{UUID: uuid.Must(uuid.NewV4())}
+	otherTeam := team.Team{UUID: uuid.Must(uuid.NewV4())}
+
+	database := New(testhelpers.Maketemp(t))
+
+	addRequestToJoinToDatabase(t, team.RequestToJoinTeam{
+		TeamUUID:    teamToDelete.UUID,
+		Fingerprint: exampledata.ExampleFingerprint2,
+		RequestedAt: now,
+	}, database)
+	addRequestToJoinToDatabase(t, team.RequestToJoinTeam{
+		TeamUUID:    teamToDelete.UUID,
+		Fingerprint: exampledata.ExampleFingerprint3,
+		RequestedAt: now,
+	}, database)
+	otherRequest := team.RequestToJoinTeam{
+		TeamUUID:    otherTeam.UUID,
+		Fingerprint: exampledata.ExampleFingerprint2,
+		RequestedAt: now,
+	}
+	addRequestToJoinToDatabase(t, otherRequest, database)
+
+	assert.NoError(t, database.RecordLast("fetch", teamToDelete, now))
+	assert.NoError(t, database.RecordLast("request-leave", teamToDelete, now))
+	assert.NoError(t, database.RecordLast("fetch", otherTeam, now))
+
+	assert.NoError(t, database.DeleteTeam(teamToDelete.UUID))
+
+	t.Run("deletes requests to join the team from any key", func(t *testing.T) {
+		gotRequests, err := database.GetRequestsToJoinTeams()
+		assert.NoError(t, err)
+		assert.Equal(t, []team.RequestToJoinTeam{otherRequest}, gotRequests)
+	})
+
+	t.Run("deletes event times for the team", func(t *testing.T) {
+		for _, verb := range []string{"fetch", "request-leave"} {
+			got, err := database.GetLast(verb, teamToDelete)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Time{}, got)
+		}
+	})
+
+	t.Run("keeps event times for other teams", func(t *testing.T) {
+		got, err := database.GetLast("fetch", otherTeam)
+		assert.NoError(t, err)
+		assert.Equal(t, now, got)
+	})
+}
+
+func TestDiscoveredTeams(t *testing.T) {
+	now := time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC)
+	teamUUID := uuid.Must(uuid.NewV4())
+
+	database := New(testhelpers.Maketemp(t))
+
+	t.Run("nothing found before anything is recorded", func(t *testing.T) {
+		_, found, err := database.GetDiscoveredTeam("example.com", 24*time.Hour, now)
+		assert.NoError(t, err)
+		assert.Equal(t, false, found)
+	})
+
+	assert.NoError(t, database.RecordDiscoveredTeam("Example.com", teamUUID, now))
+
+	t.Run("found within maxAge, ignoring case of domain", func(t *testing.T) {
+		got, found, err := database.GetDiscoveredTeam(
+			"example.COM", 24*time.Hour, now.Add(24*time.Hour-time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, true, found)
+		assert.Equal(t, teamUUID, got)
+	})
+
+	t.Run("not found once maxAge has passed", func(t *testing.T) {
+		_, found, err := database.GetDiscoveredTeam("example.com", 24*time.Hour, now.Add(24*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, false, found)
+	})
+
+	t.Run("not found for other domains", func(t *testing.T) {
+		_, found, err := database.GetDiscoveredTeam("example.org", 24*time.Hour, now)
+		assert.NoError(t, err)
+		assert.Equal(t, false, found)
+	})
+}
+
+func TestUsedNonces(t *testing.T) {
+	now := time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC)
+	maxAge := 24 * time.Hour
+
+	database := New(testhelpers.Maketemp(t))
+
+	assert.NoError(t, database.RecordUsedNonce("nonce-1", maxAge, now))
+
+	t.Run("a recorded nonce is used", func(t *testing.T) {
+		used, err := database.IsNonceUsed("nonce-1", maxAge, now.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, true, used)
+	})
+
+	t.Run("another nonce isn't used", func(t *testing.T) {
+		used, err := database.IsNonceUsed("nonce-2", maxAge, now)
+		assert.NoError(t, err)
+		assert.Equal(t, false, used)
+	})
+
+	t.Run("a nonce older than maxAge isn't used", func(t *testing.T) {
+		used, err := database.IsNonceUsed("nonce-1", maxAge, now.Add(maxAge))
+		assert.NoError(t, err)
+		assert.Equal(t, false, used)
+	})
+
+	t.Run("recording forgets nonces older than maxAge", func(t *testing.T) {
+		assert.NoError(t, database.RecordUsedNonce("nonce-2", maxAge, now.Add(maxAge)))
+
+		message, err := database.loadFromFile()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]time.Time{"nonce-2": now.Add(maxAge)}, message.UsedNonces)
+	})
+}
+
+func TestPreferredEncryptionSubkeys(t *testing.T) {
+	database := New(testhelpers.Maketemp(t))
+
+	t.Run("nothing found before anything is recorded", func(t *testing.T) {
+		_, found, err := database.GetPreferredEncryptionSubkey(exampleFingerprintA)
+		assert.NoError(t, err)
+		assert.Equal(t, false, found)
+	})
+
+	assert.NoError(t, database.SetPreferredEncryptionSubkey(exampleFingerprintA, exampleFingerprintB))
+
+	t.Run("found for the primary key", func(t *testing.T) {
+		got, found, err := database.GetPreferredEncryptionSubkey(exampleFingerprintA)
+		assert.NoError(t, err)
+		assert.Equal(t, true, found)
+		assert.Equal(t, exampleFingerprintB, got)
+	})
+
+	t.Run("not found for other keys", func(t *testing.T) {
+		_, found, err := database.GetPreferredEncryptionSubkey(exampleFingerprintB)
+		assert.NoError(t, err)
+		assert.Equal(t, false, found)
+	})
+
+	t.Run("setting again replaces the subkey", func(t *testing.T) {
+		assert.NoError(t, database.SetPreferredEncryptionSubkey(exampleFingerprintA, exampleFingerprintC))
+
+		got, _, err := database.GetPreferredEncryptionSubkey(exampleFingerprintA)
+		assert.NoError(t, err)
+		assert.Equal(t, exampleFingerprintC, got)
+	})
+}
+
+func TestRevokedKeys(t *testing.T) {
+	database := New(testhelpers.Maketemp(t))
+
+	t.Run("empty before anything is recorded", func(t *testing.T) {
+		got, err := database.GetRevokedKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(got))
+	})
+
+	assert.NoError(t, database.RecordRevokedKey(exampleFingerprintA))
+	assert.NoError(t, database.RecordRevokedKey(exampleFingerprintB))
+
+	t.Run("returns recorded keys", func(t *testing.T) {
+		got, err := database.GetRevokedKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, []fpr.Fingerprint{exampleFingerprintA, exampleFingerprintB}, got)
+	})
+
+	t.Run("recording a key twice doesn't duplicate it", func(t *testing.T) {
+		assert.NoError(t, database.RecordRevokedKey(exampleFingerprintA))
+
+		got, err := database.GetRevokedKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, []fpr.Fingerprint{exampleFingerprintA, exampleFingerprintB}, got)
+	})
+}
+
 func TestGetExistingRequestToJoinTeam(t *testing.T) {
 	now := time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC)
 
@@ -239,3 +239,17 @@ TBAU6yBBSeV0Z8rL
 `
 
 var ExampleFingerprint4 = fpr.MustParse("BB3C 44BF 188D 56E6 35F4  A092 F73D 2F05 33D7 F9D6")
+
+// ExampleSignedData4 is signed by ExampleDetachedSignature4
+const ExampleSignedData4 = "Hello, this file was signed.\n"
+
+// ExampleDetachedSignature4 is a detached signature over ExampleSignedData4 made by the primary
+// key of ExamplePrivateKey4 at 2026-10-15 04:00:33 UTC
+const ExampleDetachedSignature4 = `-----BEGIN PGP SIGNATURE-----
+
+wpwEAAEIABAFAmrQT+EJEPc9LwUz1/nWAABMcwQAQk7b2llAnfqWEfaYQDCNY6LS
+rcn7v/y1ZtaV9n75zBlOMREzBA3mjy4cIoOSZqhmt5r9WJTGEfEJjxl2uJGE/bKw
+towwxp1rxh+DYrjy1KCX4rdNsjoxvlxSegkCSZ5/Rks7842RoQtsSLurPhzfolxr
+O1EzmWuWHH5m9emOezo=
+=DVTC
+-----END PGP SIGNATURE-----`
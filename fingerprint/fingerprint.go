@@ -149,6 +149,14 @@ func (f Fingerprint) Uri() string {
 	return fmt.Sprintf("OPENPGP4FPR:%s", f.Hex())
 }
 
+// Short returns the last 16 characters of the hex fingerprint, which is the same as the long key
+// ID shown by GnuPG, for example:
+// `AB01AB01AB01AB01`
+// It's only for display: unlike the full fingerprint it isn't a safe way to identify a key.
+func (f Fingerprint) Short() string {
+	return f.Hex()[24:]
+}
+
 func (f Fingerprint) Bytes() [20]byte {
 	f.assertIsSet()
 	return f.fingerprintBytes
@@ -167,6 +167,16 @@ func TestFingerprint(t *testing.T) {
 		}
 	})
 
+	t.Run("Short method", func(t *testing.T) {
+		fp := MustParse("0999 B749 8D1A 8DC4 73E5  3C92 309F 635D AD1B 5517")
+		expected := "309F635DAD1B5517"
+		got := fp.Short()
+
+		if expected != got {
+			t.Errorf("expected Short='%s', got='%s'", expected, got)
+		}
+	})
+
 	t.Run("IsSet method (when set)", func(t *testing.T) {
 		fp := MustParse("A999 B749 8D1A 8DC4 73E5  3C92 309F 635D AD1B 5517")
 		expected := true
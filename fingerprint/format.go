@@ -0,0 +1,74 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fingerprint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FingerprintFormat is a way of writing out a fingerprint, chosen with Format.
+type FingerprintFormat string
+
+const (
+	// FormatHex is uppercase hex without spaces, as returned by Hex
+	FormatHex FingerprintFormat = "hex"
+
+	// FormatOpenpgp4fpr is an OPENPGP4FPR: URI, as returned by Uri
+	FormatOpenpgp4fpr FingerprintFormat = "openpgp4fpr"
+
+	// FormatShort is the last 16 hex characters, as returned by Short
+	FormatShort FingerprintFormat = "short"
+)
+
+// SupportedFormats lists every FingerprintFormat understood by Format
+var SupportedFormats = []FingerprintFormat{FormatOpenpgp4fpr, FormatHex, FormatShort}
+
+// ParseFormat returns the FingerprintFormat with the given name, ignoring case.
+func ParseFormat(name string) (FingerprintFormat, error) {
+	for _, format := range SupportedFormats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+
+	names := []string{}
+	for _, format := range SupportedFormats {
+		names = append(names, string(format))
+	}
+	return "", fmt.Errorf("unsupported fingerprint format '%s': use one of %s",
+		name, strings.Join(names, ", "))
+}
+
+// Format returns the fingerprint written in the given format. Any other format gives the
+// human-friendly format returned by String.
+func (f Fingerprint) Format(format FingerprintFormat) string {
+	switch format {
+	case FormatHex:
+		return f.Hex()
+
+	case FormatOpenpgp4fpr:
+		return f.Uri()
+
+	case FormatShort:
+		return f.Short()
+
+	default:
+		return f.String()
+	}
+}
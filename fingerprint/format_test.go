@@ -0,0 +1,67 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fingerprint
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestFormat(t *testing.T) {
+	fp := MustParse("A999 B749 8D1A 8DC4 73E5  3C92 309F 635D AD1B 5517")
+
+	var tests = []struct {
+		format   FingerprintFormat
+		expected string
+	}{
+		{FormatHex, "A999B7498D1A8DC473E53C92309F635DAD1B5517"},
+		{FormatOpenpgp4fpr, "OPENPGP4FPR:A999B7498D1A8DC473E53C92309F635DAD1B5517"},
+		{FormatShort, "309F635DAD1B5517"},
+		{FingerprintFormat(""), "A999 B749 8D1A 8DC4 73E5  3C92 309F 635D AD1B 5517"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("with format '%s'", test.format), func(t *testing.T) {
+			assert.Equal(t, test.expected, fp.Format(test.format))
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Run("parses each supported format", func(t *testing.T) {
+		for _, format := range SupportedFormats {
+			got, err := ParseFormat(string(format))
+			assert.NoError(t, err)
+			assert.Equal(t, format, got)
+		}
+	})
+
+	t.Run("ignores case", func(t *testing.T) {
+		got, err := ParseFormat("OPENPGP4FPR")
+		assert.NoError(t, err)
+		assert.Equal(t, FormatOpenpgp4fpr, got)
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		_, err := ParseFormat("base64")
+		assert.Equal(t, fmt.Errorf(
+			"unsupported fingerprint format 'base64': use one of openpgp4fpr, hex, short"), err)
+	})
+}
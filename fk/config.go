@@ -0,0 +1,157 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/docopt/docopt-go"
+	"github.com/fluidkeys/fluidkeys/config"
+	outputformat "github.com/fluidkeys/fluidkeys/fk/format"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func configSubcommand(args docopt.Opts) exitCode {
+	switch getSubcommand(args, []string{"get", "set", "list", "show-paths"}) {
+	case "get":
+		key, err := args.String("<key>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return configGet(key)
+
+	case "set":
+		key, err := args.String("<key>")
+		if err != nil {
+			log.Panic(err)
+		}
+		value, err := args.String("<value>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return configSet(key, value)
+
+	case "list":
+		return configList()
+
+	case "show-paths":
+		return configShowPaths()
+	}
+	log.Panic(fmt.Errorf("configSubcommand got unexpected arguments: %v", args))
+	panic(nil)
+}
+
+func configGet(key string) exitCode {
+	value, _, err := Config.Get(key)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to get setting", nil, err))
+		return 1
+	}
+	out.Print(value + "\n")
+	return 0
+}
+
+func configSet(key string, value string) exitCode {
+	if err := Config.Set(key, value); err != nil {
+		out.Print(ui.FormatFailure("Failed to save setting", nil, err))
+		return 1
+	}
+
+	printSuccess(fmt.Sprintf("Set %s in %s", key, Config.GetFilename()))
+
+	if _, source, _ := Config.Get(key); source != config.SourceFile {
+		printWarning(fmt.Sprintf("%s overrides the config file for %s", source, key))
+	}
+	return 0
+}
+
+func configList() exitCode {
+	records, err := makeConfigListRecords(&Config)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list settings", nil, err))
+		return 1
+	}
+	return printFormatted(outputformat.Table, configListColumns, records)
+}
+
+var configListColumns = []string{"key", "value", "source"}
+
+type settingGetter interface {
+	Get(key string) (value string, source config.Source, err error)
+}
+
+func makeConfigListRecords(getter settingGetter) (records []map[string]string, err error) {
+	for _, setting := range config.Settings {
+		value, source, err := getter.Get(setting.Key)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, map[string]string{
+			"key":    setting.Key,
+			"value":  value,
+			"source": string(source),
+		})
+	}
+	return records, nil
+}
+
+// configShowPaths prints where Fluidkeys keeps its data, and whether each path exists.
+// It doesn't use the network, so it works even if the API is unreachable.
+func configShowPaths() exitCode {
+	gnupgHome, err := gpg.HomeDir()
+	if err != nil {
+		log.Printf("failed to get GnuPG home directory: %v", err)
+	}
+
+	records := makeShowPathsRecords([]namedPath{
+		{name: "fluidkeys directory", path: fluidkeysDirectory},
+		{name: "config file", path: Config.GetFilename()},
+		{name: "database", path: db.Filename()},
+		{name: "GnuPG home", path: gnupgHome},
+	})
+	return printFormatted(outputformat.Table, showPathsColumns, records)
+}
+
+var showPathsColumns = []string{"name", "path", "exists"}
+
+type namedPath struct {
+	name string
+	path string // empty if unknown
+}
+
+func makeShowPathsRecords(paths []namedPath) (records []map[string]string) {
+	for _, p := range paths {
+		record := map[string]string{"name": p.name, "path": p.path, "exists": ""}
+
+		if p.path == "" {
+			record["path"] = "unknown"
+		} else if _, err := os.Stat(p.path); err == nil {
+			record["exists"] = "yes"
+		} else if os.IsNotExist(err) {
+			record["exists"] = "no"
+		} else {
+			log.Printf("failed to stat %s: %v", p.path, err)
+			record["exists"] = "unknown"
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/config"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+)
+
+func TestMakeConfigListRecords(t *testing.T) {
+	getter := mockSettingGetter{
+		"api_url":        {"", config.SourceDefault},
+		"default_team":   {"74bb40b4-3510-11e9-968e-53c38df634be", config.SourceFile},
+		"editor":         {"vim", config.SourceEnv("EDITOR")},
+		"request_expiry": {"", config.SourceDefault},
+	}
+
+	records, err := makeConfigListRecords(getter)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{"key": "api_url", "value": "", "source": "default"},
+		{"key": "default_team", "value": "74bb40b4-3510-11e9-968e-53c38df634be", "source": "config file"},
+		{"key": "editor", "value": "vim", "source": "$EDITOR"},
+		{"key": "request_expiry", "value": "", "source": "default"},
+	}, records)
+}
+
+type mockSettingGetter map[string]struct {
+	value  string
+	source config.Source
+}
+
+func (m mockSettingGetter) Get(key string) (string, config.Source, error) {
+	return m[key].value, m[key].source, nil
+}
+
+func TestMakeShowPathsRecords(t *testing.T) {
+	dir := testhelpers.Maketemp(t)
+	configFilename := filepath.Join(dir, "config.toml")
+	dbFilename := filepath.Join(dir, "db.json")
+
+	records := makeShowPathsRecords([]namedPath{
+		{name: "fluidkeys directory", path: dir},
+		{name: "config file", path: configFilename},
+		{name: "database", path: dbFilename},
+		{name: "GnuPG home", path: ""},
+	})
+
+	assert.Equal(t, []map[string]string{
+		{"name": "fluidkeys directory", "path": dir, "exists": "yes"},
+		{"name": "config file", "path": configFilename, "exists": "no"},
+		{"name": "database", "path": dbFilename, "exists": "no"},
+		{"name": "GnuPG home", "path": "unknown", "exists": ""},
+	}, records)
+}
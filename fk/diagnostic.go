@@ -0,0 +1,143 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
+)
+
+// diagnostic prints details of the user's environment to paste into a bug report. It
+// deliberately leaves out email addresses, team names and key contents.
+func diagnostic() exitCode {
+	fingerprints, err := db.GetFingerprintsImportedIntoGnuPG()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load keys", nil, err))
+		return 1
+	}
+
+	groupedMemberships, err := user.GroupedMemberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load team memberships", nil, err))
+		return 1
+	}
+	teams := []team.Team{}
+	for _, membership := range groupedMemberships {
+		teams = append(teams, membership.Team)
+	}
+
+	info := collectDiagnostics(api, gpg.Version, len(fingerprints), teams)
+
+	out.Print("\nPaste the following into your bug report:\n\n")
+	out.Print(formatDiagnostics(info))
+	out.Print("\n")
+	return 0
+}
+
+// diagnosticInfo is everything printed by fk diagnostic
+type diagnosticInfo struct {
+	fluidkeysVersion string
+	goVersion        string
+	osArch           string
+	apiStatus        string
+	apiLatency       string
+	gnupgVersion     string
+	numLocalKeys     int
+	teams            []diagnosticTeam
+}
+
+// diagnosticTeam describes a team without anything that identifies its members
+type diagnosticTeam struct {
+	uuid         uuid.UUID
+	fingerprints []fpr.Fingerprint
+}
+
+type diagnosticAPI interface {
+	GetServerCapabilities() (*apiclient.ServerCapabilities, error)
+	Ping() (time.Duration, error)
+}
+
+func collectDiagnostics(apiClient diagnosticAPI, gpgVersion func() (string, error),
+	numLocalKeys int, teams []team.Team) diagnosticInfo {
+
+	info := diagnosticInfo{
+		fluidkeysVersion: Version,
+		goVersion:        runtime.Version(),
+		osArch:           runtime.GOOS + "/" + runtime.GOARCH,
+		numLocalKeys:     numLocalKeys,
+	}
+
+	if capabilities, err := apiClient.GetServerCapabilities(); err != nil {
+		info.apiStatus = fmt.Sprintf("error: %v", err)
+	} else if capabilities.APIVersion != "" {
+		info.apiStatus = "ok (API version " + capabilities.APIVersion + ")"
+	} else {
+		info.apiStatus = "ok"
+	}
+
+	if latency, err := apiClient.Ping(); err != nil {
+		info.apiLatency = fmt.Sprintf("error: %v", err)
+	} else {
+		info.apiLatency = latency.Round(time.Millisecond).String()
+	}
+
+	if version, err := gpgVersion(); err != nil {
+		info.gnupgVersion = fmt.Sprintf("error: %v", err)
+	} else {
+		info.gnupgVersion = version
+	}
+
+	for _, t := range teams {
+		info.teams = append(info.teams, diagnosticTeam{uuid: t.UUID, fingerprints: t.Fingerprints()})
+	}
+	return info
+}
+
+// formatDiagnostics returns info as a Markdown code block
+func formatDiagnostics(info diagnosticInfo) string {
+	lines := []string{
+		"```",
+		"fluidkeys version: " + info.fluidkeysVersion,
+		"go version:        " + info.goVersion,
+		"os/arch:           " + info.osArch,
+		"api:               " + info.apiStatus,
+		"api latency:       " + info.apiLatency,
+		"gnupg version:     " + info.gnupgVersion,
+		fmt.Sprintf("local keys:        %d", info.numLocalKeys),
+		fmt.Sprintf("teams:             %d", len(info.teams)),
+	}
+
+	for _, t := range info.teams {
+		lines = append(lines, "  team "+t.uuid.String())
+		for _, fingerprint := range t.fingerprints {
+			lines = append(lines, "    "+formatFingerprint(fingerprint, fpr.Fingerprint.Hex))
+		}
+	}
+
+	lines = append(lines, "```")
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+func TestDiagnostics(t *testing.T) {
+	teams := []team.Team{
+		{
+			UUID: uuid.Must(uuid.FromString("6caa3730-2ca3-47b9-b671-5dc326100431")),
+			Name: "Kiffix",
+			People: []team.Person{
+				{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2},
+				{Email: "test3@example.com", Fingerprint: exampledata.ExampleFingerprint3},
+			},
+		},
+	}
+	gpgVersion := func() (string, error) { return "2.2.4", nil }
+
+	t.Run("with a working API", func(t *testing.T) {
+		mockAPI := mock.MockClient{
+			GetServerCapabilitiesCapabilities: &apiclient.ServerCapabilities{APIVersion: "1.2"},
+			PingLatency:                       123456789 * time.Nanosecond,
+		}
+
+		got := formatDiagnostics(collectDiagnostics(&mockAPI, gpgVersion, 2, teams))
+
+		assert.Equal(t, true, strings.HasPrefix(got, "```\nfluidkeys version: "+Version+"\n"))
+		assert.Equal(t, true, strings.HasSuffix(got, "```\n"))
+		assert.Equal(t, true, strings.Contains(got, "api:               ok (API version 1.2)\n"))
+		assert.Equal(t, true, strings.Contains(got, "api latency:       123ms\n"))
+		assert.Equal(t, true, strings.Contains(got, "gnupg version:     2.2.4\n"))
+		assert.Equal(t, true, strings.Contains(got, "local keys:        2\n"))
+		assert.Equal(t, true, strings.Contains(got, "  team 6caa3730-2ca3-47b9-b671-5dc326100431\n"+
+			"    "+exampledata.ExampleFingerprint2.Hex()+"\n"+
+			"    "+exampledata.ExampleFingerprint3.Hex()+"\n"))
+
+		t.Run("without email addresses or team names", func(t *testing.T) {
+			assert.Equal(t, false, strings.Contains(got, "@"))
+			assert.Equal(t, false, strings.Contains(got, "example.com"))
+			assert.Equal(t, false, strings.Contains(got, "Kiffix"))
+		})
+	})
+
+	t.Run("with errors from the API and GnuPG", func(t *testing.T) {
+		mockAPI := mock.MockClient{
+			GetServerCapabilitiesError: fmt.Errorf("connection refused"),
+			PingError:                  fmt.Errorf("connection refused"),
+		}
+		brokenGpgVersion := func() (string, error) { return "", fmt.Errorf("gpg not found") }
+
+		got := formatDiagnostics(collectDiagnostics(&mockAPI, brokenGpgVersion, 0, nil))
+
+		assert.Equal(t, true, strings.Contains(got, "api:               error: connection refused\n"))
+		assert.Equal(t, true, strings.Contains(got, "api latency:       error: connection refused\n"))
+		assert.Equal(t, true, strings.Contains(got, "gnupg version:     error: gpg not found\n"))
+		assert.Equal(t, true, strings.Contains(got, "teams:             0\n"))
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"strings"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/team"
+)
+
+// fingerprintFormat is set with the global --fingerprint-format flag. If it's empty, each command
+// shows fingerprints in its usual format.
+var fingerprintFormat fpr.FingerprintFormat
+
+// formatFingerprint returns the fingerprint in the format chosen with --fingerprint-format, or
+// formatted with defaultFormat if no format was chosen.
+func formatFingerprint(fingerprint fpr.Fingerprint,
+	defaultFormat func(fpr.Fingerprint) string) string {
+
+	if fingerprintFormat == "" {
+		return defaultFormat(fingerprint)
+	}
+	return fingerprint.Format(fingerprintFormat)
+}
+
+// displayFingerprint returns the fingerprint in the format chosen with --fingerprint-format, or
+// in the usual spaced-out format if no format was chosen.
+func displayFingerprint(fingerprint fpr.Fingerprint) string {
+	return formatFingerprint(fingerprint, fpr.Fingerprint.String)
+}
+
+// formatPerson returns the person's email and fingerprint like team.Person.LongString, but
+// using the format chosen with --fingerprint-format.
+func formatPerson(person team.Person) string {
+	if fingerprintFormat == "" || !person.Fingerprint.IsSet() {
+		return person.LongString()
+	}
+
+	formatted := "[" + person.Fingerprint.Format(fingerprintFormat) + "]"
+	if person.Email == "" {
+		return formatted
+	}
+	return person.Email + " " + formatted
+}
+
+// extractGlobalFlag removes a flag given as `--name=value` or `--name value` from argv, returning
+// the remaining arguments and the flag's value. It lets global flags appear anywhere on the
+// command line, which docopt can't express without adding them to every usage pattern.
+func extractGlobalFlag(argv []string, name string) (remaining []string, value string, found bool) {
+	remaining = []string{}
+	for i := 0; i < len(argv); i++ {
+		switch {
+		case strings.HasPrefix(argv[i], name+"="):
+			value, found = strings.TrimPrefix(argv[i], name+"="), true
+
+		case argv[i] == name && i+1 < len(argv):
+			value, found = argv[i+1], true
+			i++
+
+		default:
+			remaining = append(remaining, argv[i])
+		}
+	}
+	return remaining, value, found
+}
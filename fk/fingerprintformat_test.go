@@ -0,0 +1,108 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/team"
+)
+
+func TestFormatFingerprint(t *testing.T) {
+	fingerprint := fpr.MustParse("AAAA1111AAAA1111AAAA1111AAAA1111AD1B5517")
+
+	defer func(previous fpr.FingerprintFormat) { fingerprintFormat = previous }(fingerprintFormat)
+
+	t.Run("with no format set, uses the default format", func(t *testing.T) {
+		fingerprintFormat = ""
+		assert.Equal(t, fingerprint.Uri(), formatFingerprint(fingerprint, fpr.Fingerprint.Uri))
+	})
+
+	t.Run("with a format set, ignores the default format", func(t *testing.T) {
+		fingerprintFormat = fpr.FormatShort
+		assert.Equal(t, "AAAA1111AD1B5517", formatFingerprint(fingerprint, fpr.Fingerprint.Uri))
+	})
+}
+
+func TestFormatPerson(t *testing.T) {
+	fingerprint := fpr.MustParse("AAAA1111AAAA1111AAAA1111AAAA1111AD1B5517")
+
+	defer func(previous fpr.FingerprintFormat) { fingerprintFormat = previous }(fingerprintFormat)
+	fingerprintFormat = fpr.FormatShort
+
+	t.Run("with email and fingerprint", func(t *testing.T) {
+		person := team.Person{Email: "jane@example.com", Fingerprint: fingerprint}
+		assert.Equal(t, "jane@example.com [AAAA1111AD1B5517]", formatPerson(person))
+	})
+
+	t.Run("with no email", func(t *testing.T) {
+		person := team.Person{Fingerprint: fingerprint}
+		assert.Equal(t, "[AAAA1111AD1B5517]", formatPerson(person))
+	})
+
+	t.Run("with no fingerprint", func(t *testing.T) {
+		person := team.Person{Email: "jane@example.com"}
+		assert.Equal(t, "jane@example.com", formatPerson(person))
+	})
+}
+
+func TestExtractGlobalFlag(t *testing.T) {
+	var tests = []struct {
+		argv              []string
+		expectedRemaining []string
+		expectedValue     string
+		expectedFound     bool
+	}{
+		{
+			[]string{"key", "list"},
+			[]string{"key", "list"},
+			"",
+			false,
+		},
+		{
+			[]string{"key", "list", "--fingerprint-format=short"},
+			[]string{"key", "list"},
+			"short",
+			true,
+		},
+		{
+			[]string{"--fingerprint-format", "hex", "team", "show"},
+			[]string{"team", "show"},
+			"hex",
+			true,
+		},
+		{
+			[]string{"key", "list", "--fingerprint-format"},
+			[]string{"key", "list", "--fingerprint-format"},
+			"",
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v", test.argv), func(t *testing.T) {
+			remaining, value, found := extractGlobalFlag(test.argv, "--fingerprint-format")
+			assert.Equal(t, test.expectedRemaining, remaining)
+			assert.Equal(t, test.expectedValue, value)
+			assert.Equal(t, test.expectedFound, found)
+		})
+	}
+}
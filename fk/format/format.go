@@ -0,0 +1,159 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package format renders a list of records, such as the output of `fk key list`, as a table,
+// JSON or CSV.
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Table renders records as aligned, human-readable columns
+	Table = "table"
+
+	// JSON renders records as a JSON array of objects
+	JSON = "json"
+
+	// CSV renders records as comma-separated values with a header row
+	CSV = "csv"
+)
+
+// SupportedFormats lists every format understood by OutputFormatter
+var SupportedFormats = []string{Table, JSON, CSV}
+
+// OutputFormatter renders records in a given format. Columns sets which fields of each record
+// are output, and in what order.
+type OutputFormatter struct {
+	format  string
+	columns []string
+}
+
+// NewOutputFormatter returns an OutputFormatter for the given format, or an error if the format
+// isn't supported.
+func NewOutputFormatter(format string, columns []string) (*OutputFormatter, error) {
+	for _, supported := range SupportedFormats {
+		if format == supported {
+			return &OutputFormatter{format: format, columns: columns}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported format '%s': use one of %s",
+		format, strings.Join(SupportedFormats, ", "))
+}
+
+// Render returns the records formatted as a string. Fields missing from a record are output as
+// an empty string, and fields not in the formatter's columns are ignored.
+func (f *OutputFormatter) Render(records []map[string]string) (string, error) {
+	switch f.format {
+	case Table:
+		return f.renderTable(records), nil
+
+	case JSON:
+		return f.renderJSON(records)
+
+	case CSV:
+		return f.renderCSV(records)
+
+	default:
+		return "", fmt.Errorf("unsupported format '%s'", f.format)
+	}
+}
+
+func (f *OutputFormatter) renderTable(records []map[string]string) string {
+	widths := make([]int, len(f.columns))
+	for i, column := range f.columns {
+		widths[i] = len(column)
+		for _, record := range records {
+			if len(record[column]) > widths[i] {
+				widths[i] = len(record[column])
+			}
+		}
+	}
+
+	var output strings.Builder
+	writeRow := func(values []string) {
+		for i, value := range values {
+			if i == len(values)-1 {
+				output.WriteString(value)
+			} else {
+				output.WriteString(fmt.Sprintf("%-*s  ", widths[i], value))
+			}
+		}
+		output.WriteString("\n")
+	}
+
+	header := make([]string, len(f.columns))
+	for i, column := range f.columns {
+		header[i] = strings.ToUpper(column)
+	}
+	writeRow(header)
+
+	for _, record := range records {
+		writeRow(f.values(record))
+	}
+	return output.String()
+}
+
+func (f *OutputFormatter) renderJSON(records []map[string]string) (string, error) {
+	filtered := make([]map[string]string, 0, len(records))
+	for _, record := range records {
+		filteredRecord := map[string]string{}
+		for _, column := range f.columns {
+			filteredRecord[column] = record[column]
+		}
+		filtered = append(filtered, filteredRecord)
+	}
+
+	encoded, err := json.MarshalIndent(filtered, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded) + "\n", nil
+}
+
+func (f *OutputFormatter) renderCSV(records []map[string]string) (string, error) {
+	buf := bytes.NewBuffer(nil)
+	writer := csv.NewWriter(buf)
+
+	if err := writer.Write(f.columns); err != nil {
+		return "", err
+	}
+	for _, record := range records {
+		if err := writer.Write(f.values(record)); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// values returns the record's values in column order
+func (f *OutputFormatter) values(record map[string]string) []string {
+	values := make([]string, len(f.columns))
+	for i, column := range f.columns {
+		values[i] = record[column]
+	}
+	return values
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package format
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+var exampleColumns = []string{"email", "fingerprint"}
+
+var exampleRecords = []map[string]string{
+	{"email": "jane@example.com", "fingerprint": "AAAA BBBB", "ignored": "x"},
+	{"email": "bob, \"the builder\"@example.com", "fingerprint": "CCCC DDDD"},
+	{"email": "missing-fingerprint@example.com"},
+}
+
+func TestNewOutputFormatter(t *testing.T) {
+	for _, format := range SupportedFormats {
+		t.Run("accepts "+format, func(t *testing.T) {
+			_, err := NewOutputFormatter(format, exampleColumns)
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("rejects unknown format", func(t *testing.T) {
+		_, err := NewOutputFormatter("xml", exampleColumns)
+		assert.GotError(t, err)
+		assert.Equal(t, "unsupported format 'xml': use one of table, json, csv", err.Error())
+	})
+}
+
+func TestRender(t *testing.T) {
+	t.Run("json output parses", func(t *testing.T) {
+		f, err := NewOutputFormatter(JSON, exampleColumns)
+		assert.NoError(t, err)
+
+		output, err := f.Render(exampleRecords)
+		assert.NoError(t, err)
+
+		var parsed []map[string]string
+		assert.NoError(t, json.Unmarshal([]byte(output), &parsed))
+
+		assert.Equal(t, 3, len(parsed))
+		assert.Equal(t, "jane@example.com", parsed[0]["email"])
+		assert.Equal(t, "AAAA BBBB", parsed[0]["fingerprint"])
+		assert.Equal(t, "", parsed[2]["fingerprint"])
+
+		_, gotIgnored := parsed[0]["ignored"]
+		assert.Equal(t, false, gotIgnored)
+	})
+
+	t.Run("json output for no records is an empty array", func(t *testing.T) {
+		f, err := NewOutputFormatter(JSON, exampleColumns)
+		assert.NoError(t, err)
+
+		output, err := f.Render(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "[]\n", output)
+	})
+
+	t.Run("csv output has the right number of columns", func(t *testing.T) {
+		f, err := NewOutputFormatter(CSV, exampleColumns)
+		assert.NoError(t, err)
+
+		output, err := f.Render(exampleRecords)
+		assert.NoError(t, err)
+
+		lines, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+		assert.NoError(t, err)
+
+		assert.Equal(t, 4, len(lines)) // header + 3 records
+		for _, line := range lines {
+			assert.Equal(t, len(exampleColumns), len(line))
+		}
+		assert.Equal(t, exampleColumns, lines[0])
+		assert.Equal(t, "bob, \"the builder\"@example.com", lines[2][0])
+	})
+
+	t.Run("table output aligns columns", func(t *testing.T) {
+		f, err := NewOutputFormatter(Table, exampleColumns)
+		assert.NoError(t, err)
+
+		output, err := f.Render(exampleRecords[0:1])
+		assert.NoError(t, err)
+
+		expected := "" +
+			"EMAIL             FINGERPRINT\n" +
+			"jane@example.com  AAAA BBBB\n"
+		assert.Equal(t, expected, output)
+	})
+}
@@ -20,9 +20,11 @@ package fk
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 
 	"path/filepath"
+	"time"
 
 	"github.com/fluidkeys/fluidkeys/apiclient"
 	"github.com/fluidkeys/fluidkeys/config"
@@ -30,6 +32,7 @@ import (
 	"github.com/fluidkeys/fluidkeys/gpgwrapper"
 	"github.com/fluidkeys/fluidkeys/keyring"
 	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
 	userpackage "github.com/fluidkeys/fluidkeys/user"
 	"github.com/mitchellh/go-homedir"
 )
@@ -43,6 +46,7 @@ func init() {
 	initGpgWrapper()
 	initAPIClient()
 	initUser()
+	initRequestExpiry()
 }
 
 func initFluidkeysDirectory() {
@@ -95,13 +99,44 @@ func initOutput() {
 }
 
 func initAPIClient() {
-	api = apiclient.New(Version)
+	client := apiclient.New(Version)
+
+	// $FLUIDKEYS_API_URL is already handled by apiclient.New
+	if apiURL, source, _ := Config.Get("api_url"); source == config.SourceFile {
+		parsedURL, err := url.Parse(apiURL)
+		if err != nil {
+			log.Panic(fmt.Errorf("error parsing api_url '%s': %v", apiURL, err))
+		}
+		client.BaseURL = parsedURL
+	}
+	client.NonceStore = &db
+	api = client
+	apiBaseURL = client.BaseURL.String()
+	wkd = apiclient.NewWKDClient(Version)
 }
 
 func initUser() {
 	user = userpackage.New(fluidkeysDirectory, &db)
 }
 
+// initRequestExpiry sets how long requests to join a team last from the request_expiry setting.
+// An invalid value is logged and the default used, so it doesn't stop every command running.
+func initRequestExpiry() {
+	value, source, _ := Config.Get("request_expiry")
+	if value == "" {
+		return
+	}
+
+	if err := config.Validate("request_expiry", value); err != nil {
+		log.Printf("ignoring request_expiry from %s, using default of %s: %v",
+			source, team.DefaultRequestExpiryDuration, err)
+		return
+	}
+
+	duration, _ := time.ParseDuration(value) // already validated
+	team.RequestExpiryDuration = duration
+}
+
 func getFluidkeysDirectory() (string, error) {
 	dirFromEnv := os.Getenv("FLUIDKEYS_DIR")
 
@@ -0,0 +1,104 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/emailutils"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// keyAddUid adds a user ID for email to the user's key, stores the updated key in GnuPG and
+// republishes it so that secrets can be sent to the new email address.
+func keyAddUid(email string) exitCode {
+	if !emailutils.RoughlyValidateEmail(email) {
+		out.Print(ui.FormatFailure("Not a valid email address: "+email, nil, nil))
+		return 1
+	}
+
+	keys, err := loadPgpKeys()
+	if err != nil {
+		out.Print(ui.FormatFailure("Error loading pgp keys", nil, err))
+		return 1
+	}
+	if existing := findKeyWithEmail(keys, email); existing != nil {
+		out.Print(ui.FormatFailure("Email is already on one of your keys", []string{
+			email + " is a user ID on key " +
+				formatFingerprint(existing.Fingerprint(), fpr.Fingerprint.String),
+		}, nil))
+		return 1
+	}
+
+	key, code := chooseOwnKey()
+	if code != 0 {
+		return code
+	}
+
+	unlockedKey, password, err := getDecryptedPrivateKeyAndPassword(
+		key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	if err := unlockedKey.AddUserId(email, time.Now()); err != nil {
+		out.Print(ui.FormatFailure("Failed to add user ID", nil, err))
+		return 1
+	}
+
+	if err := pushPrivateKeyBackToGpg(unlockedKey, password, &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to store updated key in GnuPG", nil, err))
+		return 1
+	}
+
+	if !Config.ShouldPublishToAPI(unlockedKey.Fingerprint()) {
+		out.Print(ui.FormatSuccess("Added "+email+" to your key", []string{
+			"Your key isn't published to Fluidkeys, so people can't find it by " + email + " yet.",
+			"To publish it, run:",
+			"",
+			"  " + colour.Cmd("fk key upload"),
+		}))
+		return 0
+	}
+
+	if err := publishKeyToAPI(unlockedKey); err != nil {
+		out.Print(ui.FormatFailure("Failed to upload updated key", nil, err))
+		return 1
+	}
+
+	out.Print(ui.FormatSuccess("Added "+email+" to your key", []string{
+		fmt.Sprintf("People can now send secrets to %s.", email),
+	}))
+	return 0
+}
+
+// findKeyWithEmail returns the first of keys with a user ID for email, or nil if none has one.
+func findKeyWithEmail(keys []pgpkey.PgpKey, email string) *pgpkey.PgpKey {
+	for i := range keys {
+		if keyHasEmail(&keys[i], email) {
+			return &keys[i]
+		}
+	}
+	return nil
+}
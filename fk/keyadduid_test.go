@@ -0,0 +1,57 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestFindKeyWithEmail(t *testing.T) {
+	key2, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+	key3, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+	assert.NoError(t, err)
+
+	keys := []pgpkey.PgpKey{*key2, *key3}
+
+	t.Run("finds the key with the email", func(t *testing.T) {
+		got := findKeyWithEmail(keys, "test3@example.com")
+		if got == nil {
+			t.Fatalf("expected to find key, got nil")
+		}
+		assert.Equal(t, key3.Fingerprint(), got.Fingerprint())
+	})
+
+	t.Run("ignores case", func(t *testing.T) {
+		got := findKeyWithEmail(keys, "TEST2@example.com")
+		if got == nil {
+			t.Fatalf("expected to find key, got nil")
+		}
+		assert.Equal(t, key2.Fingerprint(), got.Fingerprint())
+	})
+
+	t.Run("returns nil if no key has the email", func(t *testing.T) {
+		if got := findKeyWithEmail(keys, "new@example.com"); got != nil {
+			t.Fatalf("expected nil, got %v", got.Fingerprint())
+		}
+	})
+}
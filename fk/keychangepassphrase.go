@@ -0,0 +1,126 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/gpgwrapper"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+func keyChangePassphrase(fingerprintFlag string) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	key, err := loadPgpKey(fingerprint)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load key "+displayFingerprint(fingerprint), nil, err))
+		return 1
+	}
+
+	prompter := &interactivePasswordPrompter{}
+
+	_, oldPassword, err := getDecryptedPrivateKeyAndPassword(key, prompter)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	newPassword, err := prompter.promptForNewPassword()
+	if err != nil {
+		out.Print(ui.FormatFailure("Password not changed", nil, err))
+		return 1
+	}
+
+	if err := changePassphrase(fingerprint, oldPassword, newPassword, &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to change password in GnuPG", nil, err))
+		return 1
+	}
+
+	if Config.ShouldStorePassword(fingerprint) {
+		if err := Keyring.SavePassword(fingerprint, newPassword); err != nil {
+			log.Printf("changed password but failed to save it: %v", err)
+			out.Print(ui.FormatWarning("Failed to save the new password to "+Keyring.Name(),
+				[]string{"You'll be asked for it next time it's needed."}, err))
+		}
+	}
+
+	out.Print(ui.FormatSuccess("Password changed", []string{
+		"The private key for " + displayFingerprint(fingerprint) +
+			" is now protected by the new password.",
+	}))
+	return 0
+}
+
+type passphraseChanger interface {
+	gpgwrapper.GnuPGInterface
+	ChangePassphrase(fingerprint fpr.Fingerprint, oldPassword string, newPassword string) error
+}
+
+// changePassphrase changes the password of the private key in GnuPG, then checks that the key
+// can be loaded with the new password.
+func changePassphrase(fingerprint fpr.Fingerprint, oldPassword string, newPassword string,
+	gpg passphraseChanger) error {
+
+	if err := gpg.ChangePassphrase(fingerprint, oldPassword, newPassword); err != nil {
+		return err
+	}
+
+	if _, err := loadPrivateKey(fingerprint, newPassword, gpg, &pgpkey.Loader{}); err != nil {
+		return fmt.Errorf("failed to load key with the new password: %v", err)
+	}
+	return nil
+}
+
+// promptForNewPassword asks the user for a new password twice and returns it if both match.
+func (p *interactivePasswordPrompter) promptForNewPassword() (string, error) {
+	out.Print("Enter new password: ")
+	password, err := terminal.ReadPassword(0)
+	if err != nil {
+		return "", fmt.Errorf("error reading password: %v", err)
+	}
+	out.Print("\n")
+
+	out.Print("Repeat new password: ")
+	repeated, err := terminal.ReadPassword(0)
+	if err != nil {
+		return "", fmt.Errorf("error reading password: %v", err)
+	}
+	out.Print("\n\n")
+
+	return string(password), checkNewPassword(string(password), string(repeated))
+}
+
+// checkNewPassword returns an error if password is empty or doesn't match repeated
+func checkNewPassword(password string, repeated string) error {
+	if password == "" {
+		return fmt.Errorf("the new password can't be empty")
+	} else if password != repeated {
+		return fmt.Errorf("the passwords didn't match")
+	}
+	return nil
+}
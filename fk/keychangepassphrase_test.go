@@ -0,0 +1,89 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/gpgwrapper"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestChangePassphrase(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+
+	t.Run("key loads with the new password and not the old one", func(t *testing.T) {
+		gpg := &passphraseGpg{armoredPrivateKey: exampledata.ExamplePrivateKey4}
+
+		assert.NoError(t, changePassphrase(fingerprint, "test4", "new password", gpg))
+
+		key, err := loadPrivateKey(fingerprint, "new password", gpg, &pgpkey.Loader{})
+		assert.NoError(t, err)
+		assert.Equal(t, fingerprint, key.Fingerprint())
+
+		_, err = loadPrivateKey(fingerprint, "test4", gpg, &pgpkey.Loader{})
+		if _, ok := err.(*IncorrectPassword); !ok {
+			t.Fatalf("expected IncorrectPassword loading with the old password, got %v", err)
+		}
+	})
+
+	t.Run("passes through an error from GnuPG", func(t *testing.T) {
+		gpg := &passphraseGpg{armoredPrivateKey: exampledata.ExamplePrivateKey4}
+
+		err := changePassphrase(fingerprint, "wrong password", "new password", gpg)
+		assert.Equal(t, &gpgwrapper.BadPasswordError{}, err)
+	})
+}
+
+func TestCheckNewPassword(t *testing.T) {
+	assert.NoError(t, checkNewPassword("correct horse", "correct horse"))
+	assert.Equal(t, fmt.Errorf("the passwords didn't match"),
+		checkNewPassword("correct horse", "battery staple"))
+	assert.Equal(t, fmt.Errorf("the new password can't be empty"), checkNewPassword("", ""))
+}
+
+// passphraseGpg stores a single armored private key, re-encrypting it when its passphrase is
+// changed.
+type passphraseGpg struct {
+	armoredPrivateKey string
+}
+
+func (g *passphraseGpg) ChangePassphrase(
+	fingerprint fpr.Fingerprint, oldPassword string, newPassword string) error {
+
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(g.armoredPrivateKey, oldPassword)
+	if err != nil {
+		return &gpgwrapper.BadPasswordError{}
+	}
+	g.armoredPrivateKey, err = key.ArmorPrivate(newPassword)
+	return err
+}
+
+func (g *passphraseGpg) ExportPrivateKey(fingerprint fpr.Fingerprint, password string) (
+	string, error) {
+
+	return g.armoredPrivateKey, nil
+}
+
+func (g *passphraseGpg) ImportArmoredKey(armoredKey string) error { return nil }
+
+func (g *passphraseGpg) TrustUltimately(fingerprint fpr.Fingerprint) error { return nil }
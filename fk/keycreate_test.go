@@ -4,22 +4,14 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
 	"github.com/fluidkeys/fluidkeys/assert"
 	"github.com/fluidkeys/fluidkeys/exampledata"
 )
 
-type mockGetPublicKey struct {
-	mockArmoredKey string
-	mockError      error
-}
-
-func (m *mockGetPublicKey) GetPublicKey(email string) (string, error) {
-	return m.mockArmoredKey, m.mockError
-}
-
 func TestVerifyEmailMatchesKeyInAPI(t *testing.T) {
 	t.Run("with a valid armored key", func(t *testing.T) {
-		mockGetter := mockGetPublicKey{exampledata.ExamplePublicKey2, nil}
+		mockGetter := mock.MockClient{GetPublicKeyArmored: exampledata.ExamplePublicKey2}
 
 		verified, err := verifyEmailMatchesKeyInAPI(
 			"test2@example.com",
@@ -32,7 +24,7 @@ func TestVerifyEmailMatchesKeyInAPI(t *testing.T) {
 	})
 
 	t.Run("with an invalid armored key", func(t *testing.T) {
-		mockGetter := mockGetPublicKey{"foobar", nil}
+		mockGetter := mock.MockClient{GetPublicKeyArmored: "foobar"}
 
 		verified, err := verifyEmailMatchesKeyInAPI(
 			"test2@example.com",
@@ -44,7 +36,7 @@ func TestVerifyEmailMatchesKeyInAPI(t *testing.T) {
 	})
 
 	t.Run("when the API returns an error", func(t *testing.T) {
-		mockGetter := mockGetPublicKey{"", fmt.Errorf("Error")}
+		mockGetter := mock.MockClient{GetPublicKeyError: fmt.Errorf("Error")}
 
 		verified, err := verifyEmailMatchesKeyInAPI(
 			"test2@example.com",
@@ -59,7 +51,7 @@ func TestVerifyEmailMatchesKeyInAPI(t *testing.T) {
 	})
 
 	t.Run("when the API returns a mismatching fingerprint", func(t *testing.T) {
-		mockGetter := mockGetPublicKey{exampledata.ExamplePublicKey3, nil}
+		mockGetter := mock.MockClient{GetPublicKeyArmored: exampledata.ExamplePublicKey3}
 
 		verified, err := verifyEmailMatchesKeyInAPI(
 			"test2@example.com",
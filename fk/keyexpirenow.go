@@ -0,0 +1,95 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// keyExpireNow makes the key and its subkeys expire immediately, which is the quickest way to
+// stop a compromised key being used short of revoking it.
+func keyExpireNow(fingerprintString string, confirm bool) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintString)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	key, err := loadPgpKey(fingerprint)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load key "+displayFingerprint(fingerprint), nil, err))
+		return 1
+	}
+
+	if !confirm {
+		out.Print(ui.FormatWarning("This will make the key stop working immediately", []string{
+			"Nobody will be able to send you secrets or verify your signatures with " +
+				displayFingerprint(fingerprint) + ".",
+		}, nil))
+		out.Print("Run again with " + colour.Cmd("--confirm") + " to expire the key now.\n\n")
+		return 1
+	}
+
+	unlockedKey, password, err := getDecryptedPrivateKeyAndPassword(
+		key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	if err := expireKeyNow(unlockedKey, time.Now()); err != nil {
+		out.Print(ui.FormatFailure("Failed to expire key", nil, err))
+		return 1
+	}
+
+	if err := pushPrivateKeyBackToGpg(unlockedKey, password, &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to store expired key in GnuPG", nil, err))
+		return 1
+	}
+
+	if Config.ShouldPublishToAPI(fingerprint) {
+		if err := publishKeyToAPI(unlockedKey); err != nil {
+			out.Print(ui.FormatFailure("Failed to upload expired key", nil, err))
+			return 1
+		}
+	}
+
+	out.Print(ui.FormatSuccess("Key has expired", []string{
+		"The primary key and its subkeys have been updated.",
+		"Automatic maintenance is off for this key, so it won't be extended.",
+		"If the key has been compromised, revoke it too with " +
+			colour.Cmd("fk key revoke --reason=compromised --publish"),
+	}))
+	return 0
+}
+
+// expireKeyNow turns off automatic maintenance for the key, then makes it expire. Otherwise
+// automatic maintenance would see that the key has expired and extend it again.
+func expireKeyNow(unlockedKey *pgpkey.PgpKey, now time.Time) error {
+	if err := Config.SetMaintainAutomatically(unlockedKey.Fingerprint(), false); err != nil {
+		return fmt.Errorf("failed to turn off automatic maintenance: %v", err)
+	}
+	return unlockedKey.ExpireNow(now)
+}
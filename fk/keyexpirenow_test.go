@@ -0,0 +1,65 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/config"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+)
+
+func TestExpireKeyNow(t *testing.T) {
+	originalConfig := Config
+	defer func() { Config = originalConfig }()
+
+	loadedConfig, err := config.Load(testhelpers.Maketemp(t))
+	assert.NoError(t, err)
+	Config = *loadedConfig
+
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	assert.NoError(t, Config.SetMaintainAutomatically(key.Fingerprint(), true))
+	assert.NoError(t, Config.SetStorePassword(key.Fingerprint(), true))
+
+	now := time.Now()
+	assert.NoError(t, expireKeyNow(key, now))
+
+	t.Run("turns off automatic maintenance", func(t *testing.T) {
+		assert.Equal(t, false, Config.ShouldMaintainAutomatically(key.Fingerprint()))
+	})
+
+	t.Run("automatic maintenance doesn't run actions for the key", func(t *testing.T) {
+		responder := &automaticResponder{}
+		assert.Equal(t, false, responder.promptYesNo(promptBackupAndRunActions, "y", key))
+	})
+
+	t.Run("automatic maintenance leaves the key expired", func(t *testing.T) {
+		keys := []pgpkey.PgpKey{*key}
+		runKeyMaintain(keys, &automaticResponder{}, &alwaysFailPasswordPrompter{})
+
+		hasExpiry, expiry := keys[0].PrimaryKeyExpiry()
+		assert.Equal(t, true, hasExpiry)
+		assert.Equal(t, true, !expiry.After(now))
+	})
+}
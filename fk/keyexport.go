@@ -0,0 +1,108 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"os"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func keyExport(fingerprintString string, private bool, outputFilename string) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintString)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	key, err := loadPgpKey(fingerprint)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load key "+displayFingerprint(fingerprint), nil, err))
+		return 1
+	}
+
+	var password string
+	if private {
+		out.Print(ui.FormatWarning("You're exporting your private key", []string{
+			"The exported key contains sensitive material. Anyone with a copy of it who",
+			"knows your password can read secrets sent to you and sign as you.",
+		}, nil))
+
+		// always prompt, even if the password is stored, to confirm the user knows it
+		password, err = (&interactivePasswordPrompter{}).promptForPassword(key)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to read password", nil, err))
+			return 1
+		}
+
+		key, err = loadPrivateKey(fingerprint, password, &gpg, &pgpkey.Loader{})
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+			return 1
+		}
+	}
+
+	armoredKey, err := armorKeyForExport(key, private, password)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to export key", nil, err))
+		return 1
+	}
+
+	if outputFilename == "" {
+		out.PrintDontLog(armoredKey)
+		return 0
+	}
+
+	if err := writeExportedKey(armoredKey, outputFilename, private); err != nil {
+		out.Print(ui.FormatFailure("Failed to write key to "+outputFilename, nil, err))
+		return 1
+	}
+	out.Print(ui.FormatSuccess("Exported key to "+outputFilename, nil))
+	return 0
+}
+
+// armorKeyForExport returns the ASCII armored public key or, if private is true, the armored
+// private key encrypted with password. To export the private key, key must be decrypted.
+func armorKeyForExport(key *pgpkey.PgpKey, private bool, password string) (string, error) {
+	if private {
+		return key.ArmorPrivate(password)
+	}
+	return key.Armor()
+}
+
+// writeExportedKey writes armoredKey to a new file, refusing to overwrite an existing one.
+// Private keys are only readable by the user.
+func writeExportedKey(armoredKey string, filename string, private bool) error {
+	var mode os.FileMode = 0644
+	if private {
+		mode = 0600
+	}
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(armoredKey); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
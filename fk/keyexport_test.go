@@ -0,0 +1,99 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+)
+
+func TestArmorKeyForExport(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	t.Run("public key round-trips through import", func(t *testing.T) {
+		armoredKey, err := armorKeyForExport(key, false, "")
+		assert.NoError(t, err)
+
+		reimported, err := pgpkey.LoadFromArmoredPublicKey(armoredKey)
+		assert.NoError(t, err)
+		assert.Equal(t, key.Fingerprint(), reimported.Fingerprint())
+
+		_, err = parseArmoredPrivateKey(armoredKey)
+		assert.GotError(t, err) // no private key in the export
+	})
+
+	t.Run("private key round-trips through import with its password", func(t *testing.T) {
+		armoredKey, err := armorKeyForExport(key, true, "test4")
+		assert.NoError(t, err)
+
+		lockedKey, err := parseArmoredPrivateKey(armoredKey)
+		assert.NoError(t, err)
+		assert.Equal(t, true, lockedKey.PrivateKey.Encrypted)
+
+		reimported, password, err := unlockKeyForImport(
+			armoredKey, lockedKey, &mockPasswordPrompter{passwords: []string{"test4"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "test4", password)
+		assert.Equal(t, key.Fingerprint(), reimported.Fingerprint())
+		assert.Equal(t, len(key.Subkeys), len(reimported.Subkeys))
+	})
+
+	t.Run("private export fails for a locked key", func(t *testing.T) {
+		publicKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+
+		_, err = armorKeyForExport(publicKey, true, "test4")
+		assert.GotError(t, err)
+	})
+}
+
+func TestWriteExportedKey(t *testing.T) {
+	dir := testhelpers.Maketemp(t)
+
+	t.Run("writes a private key readable only by the user", func(t *testing.T) {
+		filename := filepath.Join(dir, "private.asc")
+		assert.NoError(t, writeExportedKey("private key", filename, true))
+
+		info, err := os.Stat(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		got, err := ioutil.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, "private key", string(got))
+	})
+
+	t.Run("refuses to overwrite an existing file", func(t *testing.T) {
+		filename := filepath.Join(dir, "existing.asc")
+		assert.NoError(t, ioutil.WriteFile(filename, []byte("existing"), 0644))
+
+		assert.GotError(t, writeExportedKey("public key", filename, false))
+
+		got, err := ioutil.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, "existing", string(got))
+	})
+}
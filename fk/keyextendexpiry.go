@@ -0,0 +1,106 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func keyExtendExpiry(fingerprintString string, durationString string, force bool) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintString)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	duration, err := parseDuration(durationString)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid --duration", nil, err))
+		return 1
+	}
+
+	key, err := loadPgpKey(fingerprint)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load key "+displayFingerprint(fingerprint), nil, err))
+		return 1
+	}
+
+	now := time.Now()
+	validUntil := now.Add(duration)
+
+	if shorten, currentExpiry := wouldShortenExpiry(key, validUntil); shorten {
+		out.Print(ui.FormatWarning("This would bring the key's expiry forward", []string{
+			"The key currently expires " + currentExpiry + ".",
+			"Setting it to expire in " + durationString + " makes it expire sooner.",
+		}, nil))
+
+		if !force {
+			out.Print("Run again with " + colour.Cmd("--force") + " to do it anyway.\n\n")
+			return 1
+		}
+	}
+
+	unlockedKey, password, err := getDecryptedPrivateKeyAndPassword(
+		key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	if err := unlockedKey.UpdateExpiry(validUntil, now); err != nil {
+		out.Print(ui.FormatFailure("Failed to update expiry", nil, err))
+		return 1
+	}
+
+	if err := pushPrivateKeyBackToGpg(unlockedKey, password, &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to store updated key in GnuPG", nil, err))
+		return 1
+	}
+
+	if Config.ShouldPublishToAPI(fingerprint) {
+		if err := publishKeyToAPI(unlockedKey); err != nil {
+			out.Print(ui.FormatFailure("Failed to upload updated key", nil, err))
+			return 1
+		}
+	}
+
+	out.Print(ui.FormatSuccess(
+		"Key now expires "+validUntil.Format("2 January 2006"),
+		[]string{"The primary key and its subkeys have been updated."},
+	))
+	return 0
+}
+
+// wouldShortenExpiry returns true if making key expire at validUntil would make it expire sooner
+// than it does now, including if it currently doesn't expire at all. currentExpiry describes
+// the key's current expiry.
+func wouldShortenExpiry(key *pgpkey.PgpKey, validUntil time.Time) (
+	shorten bool, currentExpiry string) {
+
+	hasExpiry, expiry := key.PrimaryKeyExpiry()
+	if !hasExpiry {
+		return true, "never"
+	}
+	return expiry.After(validUntil), "on " + expiry.Format("2 January 2006")
+}
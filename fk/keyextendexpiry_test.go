@@ -0,0 +1,55 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestWouldShortenExpiry(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+	assert.NoError(t, key.UpdateExpiry(now.Add(30*24*time.Hour), now))
+
+	t.Run("not if the new expiry is later", func(t *testing.T) {
+		shorten, current := wouldShortenExpiry(key, now.Add(60*24*time.Hour))
+		assert.Equal(t, false, shorten)
+		assert.Equal(t, "on 1 July 2019", current)
+	})
+
+	t.Run("if the new expiry is sooner", func(t *testing.T) {
+		shorten, _ := wouldShortenExpiry(key, now.Add(7*24*time.Hour))
+		assert.Equal(t, true, shorten)
+	})
+
+	t.Run("if the key doesn't currently expire", func(t *testing.T) {
+		for _, identity := range key.Identities {
+			identity.SelfSignature.KeyLifetimeSecs = nil
+		}
+		shorten, current := wouldShortenExpiry(key, now.Add(60*24*time.Hour))
+		assert.Equal(t, true, shorten)
+		assert.Equal(t, "never", current)
+	})
+}
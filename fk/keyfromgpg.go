@@ -117,7 +117,7 @@ func formatListedKeysForImportingFromGpg(keyListings []gpgwrapper.KeyListing) st
 
 func printSecretKeyListing(listNumber int, key gpgwrapper.KeyListing) string {
 	formattedListNumber := colour.Info(fmt.Sprintf("%-4s", (strconv.Itoa(listNumber) + ".")))
-	output := fmt.Sprintf("%s%s\n", formattedListNumber, key.Fingerprint)
+	output := formattedListNumber + formatFingerprint(key.Fingerprint, fpr.Fingerprint.String) + "\n"
 	output += fmt.Sprintf("    Created on %s\n", key.Created.Format("2 January 2006"))
 	for _, uid := range key.Uids {
 		output += fmt.Sprintf("      %v\n", uid)
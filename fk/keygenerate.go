@@ -0,0 +1,84 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/emailutils"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// keyGenerate makes a new key for email using the given algorithm (or the default if empty),
+// valid for 2 years, and stores it in GnuPG. Unlike `fk key create` it doesn't verify the email
+// address or set up automatic maintenance.
+func keyGenerate(email string, algorithm string) exitCode {
+	if algorithm == "" {
+		algorithm = pgpkey.DefaultAlgorithm
+	}
+	if err := pgpkey.ValidateAlgorithm(algorithm); err != nil {
+		out.Print(ui.FormatFailure("Unsupported algorithm", nil, err))
+		return 1
+	}
+	if !emailutils.RoughlyValidateEmail(email) {
+		out.Print(ui.FormatFailure("Not a valid email address: "+email, nil, nil))
+		return 1
+	}
+
+	now := time.Now()
+	out.Print("Generating " + algorithm + " key for " + email + "...\n\n")
+	key, err := pgpkey.GenerateWithAlgorithm(email, algorithm, now.AddDate(2, 0, 0), now, nil)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to generate key", nil, err))
+		return 1
+	}
+	fingerprint := key.Fingerprint()
+
+	printHeader("Store your password")
+	password := generatePassword(DicewareNumberOfWords, DicewareSeparator)
+	out.Print("We've made you a strong password to protect your key:\n\n")
+	displayPassword(password)
+
+	if err := pushPrivateKeyBackToGpg(key, password.AsString(), &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to store key in GnuPG", nil, err))
+		return 1
+	}
+	if err := db.RecordFingerprintImportedIntoGnuPG(fingerprint); err != nil {
+		out.Print(ui.FormatFailure("Failed to record key in database", nil, err))
+		return 1
+	}
+	Config.SetMaintainAutomatically(fingerprint, false)
+
+	if err := tryStorePassword(fingerprint, password.AsString()); err != nil {
+		out.Print(ui.FormatWarning("Failed to store password in "+Keyring.Name(), nil, err))
+	}
+
+	printSuccess("Generated key " + displayFingerprint(fingerprint))
+	out.Print("\n")
+
+	if shouldPublishToAPI(key) {
+		if err := publishKeyToAPI(key); err != nil {
+			out.Print(ui.FormatFailure("Failed to upload public key", nil, err))
+			return 1
+		}
+		printSuccess("Uploaded public key to Fluidkeys\n")
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/table"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// keyHistory prints the changes the Fluidkeys server has recorded for a public key, so admins can
+// check when a team member's key was last updated.
+func keyHistory(fingerprintFlag string) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	history, err := api.GetPublicKeyHistory(fingerprint)
+	if err != nil {
+		out.Print(ui.FormatFailure(
+			"Failed to get history for "+displayFingerprint(fingerprint), nil, err))
+		return 1
+	}
+
+	if len(history) == 0 {
+		out.Print(ui.FormatInfo("No history for "+displayFingerprint(fingerprint), nil))
+		return 0
+	}
+
+	out.Print(table.FormatKeyHistoryTable(makeKeyHistoryRows(history)))
+	return 0
+}
+
+func makeKeyHistoryRows(history []apiclient.KeyHistoryEntry) (rows []table.KeyHistoryRow) {
+	for _, entry := range history {
+		rows = append(rows, table.KeyHistoryRow{
+			UpdatedAt:  entry.UpdatedAt.Format("2 Jan 2006 15:04 MST"),
+			ChangeType: entry.ChangeType,
+			SHA256:     entry.SHA256,
+		})
+	}
+	return rows
+}
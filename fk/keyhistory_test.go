@@ -0,0 +1,47 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/table"
+)
+
+func TestMakeKeyHistoryRows(t *testing.T) {
+	history := []apiclient.KeyHistoryEntry{
+		{
+			UpdatedAt:  time.Date(2019, 6, 21, 9, 0, 0, 0, time.UTC),
+			SHA256:     "aaaa",
+			ChangeType: apiclient.KeyCreated,
+		},
+		{
+			UpdatedAt:  time.Date(2019, 7, 1, 9, 30, 0, 0, time.UTC),
+			SHA256:     "bbbb",
+			ChangeType: apiclient.KeyRevoked,
+		},
+	}
+
+	assert.Equal(t, []table.KeyHistoryRow{
+		{UpdatedAt: "21 Jun 2019 09:00 UTC", ChangeType: "created", SHA256: "aaaa"},
+		{UpdatedAt: "1 Jul 2019 09:30 UTC", ChangeType: "revoked", SHA256: "bbbb"},
+	}, makeKeyHistoryRows(history))
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func keyImport(filename string) exitCode {
+	armoredKey, err := readArmoredKeyFile(filename, os.Stdin)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to read key", nil, err))
+		return 1
+	}
+
+	lockedKey, err := parseArmoredPrivateKey(armoredKey)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to read key", nil, err))
+		return 1
+	}
+
+	unlockedKey, password, err := unlockKeyForImport(
+		armoredKey, lockedKey, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+	fingerprint := unlockedKey.Fingerprint()
+
+	if existingKey, err := loadPgpKey(fingerprint); err == nil {
+		newSubkeys := newSubkeyIDs(existingKey, unlockedKey)
+		if len(newSubkeys) == 0 {
+			out.Print(ui.FormatInfo("You already have this key", []string{
+				"GnuPG already has key " + displayFingerprint(fingerprint) + " and all its subkeys.",
+			}))
+			return 0
+		}
+
+		prompter := interactiveYesNoPrompter{}
+		question := fmt.Sprintf("Merge %s into your existing key?",
+			humanize.Pluralize(len(newSubkeys), "new subkey", "new subkeys"))
+		if !prompter.promptYesNo(question, "y", existingKey) {
+			out.Print("Not importing key\n")
+			return 0
+		}
+	}
+
+	if err := pushPrivateKeyBackToGpg(unlockedKey, password, &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to import key into GnuPG", nil, err))
+		return 1
+	}
+
+	importedFingerprints, err := db.GetFingerprintsImportedIntoGnuPG()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to get fingerprints from database", nil, err))
+		return 1
+	}
+	if !fpr.Contains(importedFingerprints, fingerprint) {
+		if err := db.RecordFingerprintImportedIntoGnuPG(fingerprint); err != nil {
+			out.Print(ui.FormatFailure("Failed to record key in database", nil, err))
+			return 1
+		}
+		Config.SetStorePassword(fingerprint, false)
+		Config.SetMaintainAutomatically(fingerprint, false)
+	}
+	printSuccess("Imported key " + displayFingerprint(fingerprint) + " into GnuPG")
+	out.Print("\n")
+
+	if shouldPublishToAPI(unlockedKey) {
+		if err := publishKeyToAPI(unlockedKey); err != nil {
+			out.Print(ui.FormatFailure("Failed to upload public key", nil, err))
+			return 1
+		}
+		printSuccess("Uploaded public key to Fluidkeys\n")
+	}
+	return 0
+}
+
+// readArmoredKeyFile returns the contents of filename, or of stdin if filename is "-"
+func readArmoredKeyFile(filename string, stdin io.Reader) (string, error) {
+	if filename == "-" {
+		keyBytes, err := ioutil.ReadAll(stdin)
+		return string(keyBytes), err
+	}
+
+	keyBytes, err := ioutil.ReadFile(filename)
+	return string(keyBytes), err
+}
+
+// parseArmoredPrivateKey checks that armoredKey is a single ASCII armored private key and returns
+// it, still locked if it's protected by a password.
+func parseArmoredPrivateKey(armoredKey string) (*pgpkey.PgpKey, error) {
+	return pgpkey.LoadFromArmoredPrivateKey(armoredKey, nil)
+}
+
+// unlockKeyForImport decrypts armoredKey, prompting for its password if lockedKey is protected
+// by one. It returns the decrypted key and the password, which is empty for an unprotected key.
+func unlockKeyForImport(armoredKey string, lockedKey *pgpkey.PgpKey,
+	prompter promptForPasswordInterface) (*pgpkey.PgpKey, string, error) {
+
+	if !lockedKey.PrivateKey.Encrypted {
+		unlockedKey, err := pgpkey.LoadFromArmoredPrivateKey(armoredKey, []byte{})
+		return unlockedKey, "", err
+	}
+
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		var password string
+		password, err = prompter.promptForPassword(lockedKey)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var unlockedKey *pgpkey.PgpKey
+		unlockedKey, err = pgpkey.LoadFromArmoredPrivateKey(armoredKey, []byte(password))
+		if err == nil {
+			return unlockedKey, password, nil
+		}
+		if _, ok := err.(*pgpkey.IncorrectPassword); !ok {
+			return nil, "", err
+		}
+		out.Print(ui.FormatWarning("Incorrect password", nil, nil))
+	}
+	return nil, "", err
+}
+
+// newSubkeyIDs returns the IDs of subkeys in importedKey that aren't in existingKey
+func newSubkeyIDs(existingKey *pgpkey.PgpKey, importedKey *pgpkey.PgpKey) []uint64 {
+	existing := map[uint64]bool{}
+	for _, subkey := range existingKey.Subkeys {
+		existing[subkey.PublicKey.KeyId] = true
+	}
+
+	var newIDs []uint64
+	for _, subkey := range importedKey.Subkeys {
+		if !existing[subkey.PublicKey.KeyId] {
+			newIDs = append(newIDs, subkey.PublicKey.KeyId)
+		}
+	}
+	return newIDs
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+)
+
+func TestReadArmoredKeyFile(t *testing.T) {
+	t.Run("reads from a file", func(t *testing.T) {
+		filename := filepath.Join(testhelpers.Maketemp(t), "key.asc")
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(exampledata.ExamplePrivateKey4), 0600))
+
+		got, err := readArmoredKeyFile(filename, strings.NewReader("not this"))
+		assert.NoError(t, err)
+		assert.Equal(t, exampledata.ExamplePrivateKey4, got)
+	})
+
+	t.Run("reads from stdin given -", func(t *testing.T) {
+		got, err := readArmoredKeyFile("-", strings.NewReader(exampledata.ExamplePrivateKey4))
+		assert.NoError(t, err)
+		assert.Equal(t, exampledata.ExamplePrivateKey4, got)
+	})
+}
+
+func TestParseArmoredPrivateKey(t *testing.T) {
+	t.Run("returns a locked key for a password protected key", func(t *testing.T) {
+		key, err := parseArmoredPrivateKey(exampledata.ExamplePrivateKey4)
+		assert.NoError(t, err)
+		assert.Equal(t, exampledata.ExampleFingerprint4, key.Fingerprint())
+		assert.Equal(t, true, key.PrivateKey.Encrypted)
+	})
+
+	t.Run("rejects a public key", func(t *testing.T) {
+		_, err := parseArmoredPrivateKey(exampledata.ExamplePublicKey4)
+		assert.GotError(t, err)
+	})
+
+	t.Run("rejects something that isn't a key", func(t *testing.T) {
+		_, err := parseArmoredPrivateKey("hello")
+		assert.GotError(t, err)
+	})
+}
+
+func TestUnlockKeyForImport(t *testing.T) {
+	t.Run("with a password protected key file", func(t *testing.T) {
+		lockedKey, err := parseArmoredPrivateKey(exampledata.ExamplePrivateKey4)
+		assert.NoError(t, err)
+
+		prompter := &mockPasswordPrompter{passwords: []string{"wrong", "test4"}}
+		unlockedKey, password, err := unlockKeyForImport(
+			exampledata.ExamplePrivateKey4, lockedKey, prompter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test4", password)
+		assert.Equal(t, 2, prompter.timesPrompted)
+		assert.Equal(t, false, unlockedKey.PrivateKey.Encrypted)
+	})
+
+	t.Run("gives up after 3 wrong passwords", func(t *testing.T) {
+		lockedKey, err := parseArmoredPrivateKey(exampledata.ExamplePrivateKey4)
+		assert.NoError(t, err)
+
+		prompter := &mockPasswordPrompter{passwords: []string{"wrong1", "wrong2", "wrong3"}}
+		_, _, err = unlockKeyForImport(exampledata.ExamplePrivateKey4, lockedKey, prompter)
+
+		assert.GotError(t, err)
+		assert.Equal(t, 3, prompter.timesPrompted)
+	})
+
+	t.Run("with an unprotected key file", func(t *testing.T) {
+		unprotectedKey := makeUnprotectedArmoredKey(t)
+		lockedKey, err := parseArmoredPrivateKey(unprotectedKey)
+		assert.NoError(t, err)
+		assert.Equal(t, false, lockedKey.PrivateKey.Encrypted)
+
+		prompter := &mockPasswordPrompter{}
+		unlockedKey, password, err := unlockKeyForImport(unprotectedKey, lockedKey, prompter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "", password)
+		assert.Equal(t, 0, prompter.timesPrompted)
+		assert.Equal(t, exampledata.ExampleFingerprint4, unlockedKey.Fingerprint())
+	})
+}
+
+func TestNewSubkeyIDs(t *testing.T) {
+	existingKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+
+	t.Run("returns nothing for the same key", func(t *testing.T) {
+		importedKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, len(newSubkeyIDs(existingKey, importedKey)))
+	})
+
+	t.Run("returns subkeys missing from the existing key", func(t *testing.T) {
+		importedKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+
+		existingWithoutSubkeys := *existingKey
+		existingWithoutSubkeys.Subkeys = nil
+
+		var expected []uint64
+		for _, subkey := range importedKey.Subkeys {
+			expected = append(expected, subkey.PublicKey.KeyId)
+		}
+		assert.Equal(t, expected, newSubkeyIDs(&existingWithoutSubkeys, importedKey))
+	})
+}
+
+// makeUnprotectedArmoredKey returns example key 4 as an armored private key without a password
+func makeUnprotectedArmoredKey(t *testing.T) string {
+	t.Helper()
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	armoredKey, err := key.ArmorPrivate("")
+	assert.NoError(t, err)
+	return armoredKey
+}
+
+type mockPasswordPrompter struct {
+	passwords     []string
+	timesPrompted int
+}
+
+func (m *mockPasswordPrompter) promptForPassword(key *pgpkey.PgpKey) (string, error) {
+	password := m.passwords[m.timesPrompted]
+	m.timesPrompted++
+	return password, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"strings"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+// keyListFingerprintsFormat is the --format for `fk key list` that prints just the fingerprint
+// of each key, for use in scripts
+const keyListFingerprintsFormat = "fingerprints"
+
+// filterKeyList returns the keys matching the `fk key list` filters. If secretFingerprints isn't
+// nil, only keys with a fingerprint in it are returned. If withEmail isn't empty, only keys with
+// that email address (ignoring case) are returned.
+func filterKeyList(keys []pgpkey.PgpKey, secretFingerprints []fpr.Fingerprint,
+	withEmail string) []pgpkey.PgpKey {
+
+	filtered := []pgpkey.PgpKey{}
+	for _, key := range keys {
+		if secretFingerprints != nil && !fingerprintInList(key.Fingerprint(), secretFingerprints) {
+			continue
+		}
+		if withEmail != "" && !keyHasEmail(&key, withEmail) {
+			continue
+		}
+		filtered = append(filtered, key)
+	}
+	return filtered
+}
+
+// formatFingerprintList returns the fingerprint of each key as an OPENPGP4FPR: URI, one per line
+func formatFingerprintList(keys []pgpkey.PgpKey) string {
+	var output strings.Builder
+	for _, key := range keys {
+		output.WriteString(formatFingerprint(key.Fingerprint(), fpr.Fingerprint.Uri) + "\n")
+	}
+	return output.String()
+}
+
+func fingerprintInList(fingerprint fpr.Fingerprint, fingerprints []fpr.Fingerprint) bool {
+	for _, f := range fingerprints {
+		if f == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+func keyHasEmail(key *pgpkey.PgpKey, email string) bool {
+	for _, keyEmail := range key.Emails(true) {
+		if strings.EqualFold(keyEmail, email) {
+			return true
+		}
+	}
+	return false
+}
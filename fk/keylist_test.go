@@ -0,0 +1,91 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestFilterKeyList(t *testing.T) {
+	keys := []pgpkey.PgpKey{}
+	for _, armored := range []string{
+		exampledata.ExamplePublicKey2, exampledata.ExamplePublicKey3, exampledata.ExamplePublicKey4,
+	} {
+		key, err := pgpkey.LoadFromArmoredPublicKey(armored)
+		assert.NoError(t, err)
+		keys = append(keys, *key)
+	}
+
+	fingerprints := func(keys []pgpkey.PgpKey) []fpr.Fingerprint {
+		result := []fpr.Fingerprint{}
+		for _, key := range keys {
+			result = append(result, key.Fingerprint())
+		}
+		return result
+	}
+
+	t.Run("with no filters returns all keys", func(t *testing.T) {
+		got := filterKeyList(keys, nil, "")
+		assert.Equal(t, fingerprints(keys), fingerprints(got))
+	})
+
+	t.Run("with secret fingerprints only returns those keys", func(t *testing.T) {
+		got := filterKeyList(keys, []fpr.Fingerprint{exampledata.ExampleFingerprint4}, "")
+		assert.Equal(t, []fpr.Fingerprint{exampledata.ExampleFingerprint4}, fingerprints(got))
+	})
+
+	t.Run("with empty secret fingerprints returns no keys", func(t *testing.T) {
+		got := filterKeyList(keys, []fpr.Fingerprint{}, "")
+		assert.Equal(t, 0, len(got))
+	})
+
+	t.Run("with email matches any of a key's emails, ignoring case", func(t *testing.T) {
+		got := filterKeyList(keys, nil, "Another@Example.com")
+		assert.Equal(t, []fpr.Fingerprint{exampledata.ExampleFingerprint3}, fingerprints(got))
+	})
+
+	t.Run("combines filters", func(t *testing.T) {
+		got := filterKeyList(keys, []fpr.Fingerprint{exampledata.ExampleFingerprint4},
+			"test3@example.com")
+		assert.Equal(t, 0, len(got))
+	})
+}
+
+func TestFormatFingerprintList(t *testing.T) {
+	key2, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+	key4, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+
+	t.Run("prints one OPENPGP4FPR URI per line", func(t *testing.T) {
+		assert.Equal(t,
+			"OPENPGP4FPR:5C78E71F6FEFB55829654CC5343CC240D350C30C\n"+
+				"OPENPGP4FPR:BB3C44BF188D56E635F4A092F73D2F0533D7F9D6\n",
+			formatFingerprintList([]pgpkey.PgpKey{*key2, *key4}),
+		)
+	})
+
+	t.Run("prints nothing for no keys", func(t *testing.T) {
+		assert.Equal(t, "", formatFingerprintList([]pgpkey.PgpKey{}))
+	})
+}
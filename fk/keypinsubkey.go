@@ -0,0 +1,91 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func keyPinSubkey(fingerprintFlag string, subkeyFlag string) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	subkeyFingerprint, err := fpr.Parse(subkeyFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid subkey fingerprint", nil, err))
+		return 1
+	}
+
+	key, err := loadPgpKey(fingerprint)
+	if err != nil {
+		log.Printf("failed to find key %s in GnuPG: %v", fingerprint, err)
+
+		if key, err = api.GetPublicKeyByFingerprint(fingerprint); err != nil {
+			out.Print(ui.FormatFailure("Couldn't find key "+displayFingerprint(fingerprint), nil, err))
+			return 1
+		}
+	}
+
+	if _, err := key.WithPinnedEncryptionSubkey(subkeyFingerprint, time.Now()); err != nil {
+		out.Print(ui.FormatFailure("Can't pin that subkey", nil, err))
+		return 1
+	}
+
+	if err := db.SetPreferredEncryptionSubkey(fingerprint, subkeyFingerprint); err != nil {
+		out.Print(ui.FormatFailure("Failed to save the pinned subkey", nil, err))
+		return 1
+	}
+
+	printSuccess(fmt.Sprintf("Secrets sent to %s will be encrypted to subkey %s",
+		displayFingerprint(fingerprint),
+		displayFingerprint(subkeyFingerprint)))
+	return 0
+}
+
+type preferredSubkeyGetter interface {
+	GetPreferredEncryptionSubkey(primary fpr.Fingerprint) (fpr.Fingerprint, bool, error)
+}
+
+// applyPinnedEncryptionSubkey returns the key restricted to the subkey pinned with
+// fk key pin-subkey, or the key unchanged if no subkey is pinned.
+func applyPinnedEncryptionSubkey(key *pgpkey.PgpKey, database preferredSubkeyGetter,
+	now time.Time) (*pgpkey.PgpKey, error) {
+
+	subkeyFingerprint, found, err := database.GetPreferredEncryptionSubkey(key.Fingerprint())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pinned subkey: %v", err)
+	} else if !found {
+		return key, nil
+	}
+
+	pinned, err := key.WithPinnedEncryptionSubkey(subkeyFingerprint, now)
+	if err != nil {
+		return nil, fmt.Errorf("pinned subkey is no longer usable: %v", err)
+	}
+	return pinned, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestApplyPinnedEncryptionSubkey(t *testing.T) {
+	now := time.Now()
+
+	pgpKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	originalSubkey := pgpKey.Subkeys[0].PublicKey
+	// the new subkey is the newest, so it's the one that's used unless another is pinned
+	assert.NoError(t, pgpKey.CreateNewEncryptionSubkey(now.Add(24*time.Hour), now, nil))
+	newSubkey := pgpKey.Subkeys[1].PublicKey
+
+	t.Run("with no pinned subkey, encrypts to the newest subkey", func(t *testing.T) {
+		got, err := applyPinnedEncryptionSubkey(pgpKey, &mockPreferredSubkeyGetter{}, now)
+		assert.NoError(t, err)
+
+		armoredEncryptedSecret, err := encryptSecret("secret", "", got)
+		assert.NoError(t, err)
+
+		messageDetails := decryptMessageDetails(armoredEncryptedSecret, pgpKey, t)
+		assert.Equal(t, []uint64{newSubkey.KeyId}, messageDetails.EncryptedToKeyIds)
+	})
+
+	t.Run("with a pinned subkey, encrypts to the pinned subkey", func(t *testing.T) {
+		database := &mockPreferredSubkeyGetter{
+			pinned: map[fpr.Fingerprint]fpr.Fingerprint{
+				pgpKey.Fingerprint(): fpr.FromBytes(originalSubkey.Fingerprint),
+			},
+		}
+		got, err := applyPinnedEncryptionSubkey(pgpKey, database, now)
+		assert.NoError(t, err)
+
+		armoredEncryptedSecret, err := encryptSecret("secret", "", got)
+		assert.NoError(t, err)
+
+		messageDetails := decryptMessageDetails(armoredEncryptedSecret, pgpKey, t)
+		assert.Equal(t, []uint64{originalSubkey.KeyId}, messageDetails.EncryptedToKeyIds)
+	})
+
+	t.Run("errors if the pinned subkey has expired", func(t *testing.T) {
+		database := &mockPreferredSubkeyGetter{
+			pinned: map[fpr.Fingerprint]fpr.Fingerprint{
+				pgpKey.Fingerprint(): fpr.FromBytes(newSubkey.Fingerprint),
+			},
+		}
+		_, err := applyPinnedEncryptionSubkey(pgpKey, database, now.Add(48*time.Hour))
+		assert.GotError(t, err)
+	})
+}
+
+type mockPreferredSubkeyGetter struct {
+	pinned map[fpr.Fingerprint]fpr.Fingerprint
+}
+
+func (m *mockPreferredSubkeyGetter) GetPreferredEncryptionSubkey(primary fpr.Fingerprint) (
+	fpr.Fingerprint, bool, error) {
+
+	subkey, found := m.pinned[primary]
+	return subkey, found, nil
+}
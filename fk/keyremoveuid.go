@@ -0,0 +1,80 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// keyRemoveUid revokes the user ID for email on the user's key which has it, stores the updated
+// key in GnuPG and republishes it.
+func keyRemoveUid(email string) exitCode {
+	keys, err := loadPgpKeys()
+	if err != nil {
+		out.Print(ui.FormatFailure("Error loading pgp keys", nil, err))
+		return 1
+	}
+
+	key := findKeyWithEmail(keys, email)
+	if key == nil {
+		out.Print(ui.FormatFailure("None of your keys have the email "+email, nil, nil))
+		return 1
+	}
+
+	unlockedKey, password, err := getDecryptedPrivateKeyAndPassword(
+		key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	if err := unlockedKey.RevokeUserId(email, time.Now()); err != nil {
+		out.Print(ui.FormatFailure("Failed to remove user ID", nil, err))
+		return 1
+	}
+
+	if err := pushPrivateKeyBackToGpg(unlockedKey, password, &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to store updated key in GnuPG", nil, err))
+		return 1
+	}
+
+	publish := Config.ShouldPublishToAPI(unlockedKey.Fingerprint())
+	if publish {
+		if err := publishKeyToAPI(unlockedKey); err != nil {
+			out.Print(ui.FormatFailure("Failed to upload updated key", nil, err))
+			return 1
+		}
+	}
+
+	out.Print(ui.FormatSuccess("Removed "+email+" from your key", nil))
+
+	out.Print(ui.FormatWarning("Old secrets can still be read", []string{
+		"Anything already encrypted to " + email + " can still be decrypted with your key.",
+		"Removing the email only stops people finding your key by it in future.",
+	}, nil))
+
+	if !publish {
+		out.Print("Publish your key so that other people see the email has been removed:\n\n")
+		out.Print("    " + colour.Cmd("fk key upload") + "\n\n")
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// keyReport reports a key to the Fluidkeys directory maintainers as compromised or abusive.
+func keyReport(fingerprintFlag string, reason string) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	if err := api.ReportKey(fingerprint, reason); err != nil {
+		out.Print(ui.FormatFailure("Failed to report key", nil, err))
+		return 1
+	}
+
+	out.Print(ui.FormatSuccess("Reported key "+displayFingerprint(fingerprint), []string{
+		"Thanks. The Fluidkeys directory maintainers will look into it.",
+	}))
+	return 0
+}
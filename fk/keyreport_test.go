@@ -0,0 +1,59 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestKeyReport(t *testing.T) {
+	originalAPI := api
+	defer func() { api = originalAPI }()
+
+	t.Run("reports the key with the reason", func(t *testing.T) {
+		mockAPI := &mock.MockClient{}
+		api = mockAPI
+
+		code := keyReport(exampledata.ExampleFingerprint4.Hex(), "key is compromised")
+		assert.Equal(t, 0, code)
+
+		calls := mockAPI.CallsTo("ReportKey")
+		assert.Equal(t, 1, len(calls))
+		assert.Equal(t, exampledata.ExampleFingerprint4, calls[0].Args[0])
+		assert.Equal(t, "key is compromised", calls[0].Args[1])
+	})
+
+	t.Run("fails for an invalid fingerprint", func(t *testing.T) {
+		mockAPI := &mock.MockClient{}
+		api = mockAPI
+
+		assert.Equal(t, 1, keyReport("not a fingerprint", "spam"))
+		assert.Equal(t, 0, len(mockAPI.CallsTo("ReportKey")))
+	})
+
+	t.Run("fails if the API returns an error", func(t *testing.T) {
+		api = &mock.MockClient{ReportKeyError: fmt.Errorf("reason can't be empty")}
+
+		assert.Equal(t, 1, keyReport(exampledata.ExampleFingerprint4.Hex(), ""))
+	})
+}
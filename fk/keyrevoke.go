@@ -0,0 +1,128 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func keyRevoke(reason string, publish bool) exitCode {
+	if reason == "" {
+		out.Print(ui.FormatFailure("Please give a reason for revoking the key", nil, nil))
+		return 1
+	}
+
+	key, code := chooseOwnKey()
+	if code != 0 {
+		return code
+	}
+
+	unlockedKey, _, err := getDecryptedPrivateKeyAndPassword(key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	revocationCert, err := unlockedKey.Revoke(reason)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to make revocation certificate", nil, err))
+		return 1
+	}
+
+	filename, err := saveRevocationCertificate(
+		revocationCert, key, filepath.Join(fluidkeysDirectory, "revocations"), time.Now())
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to save revocation certificate", nil, err))
+		return 1
+	}
+
+	if publish {
+		if err := publishRevocation(key.Fingerprint(), revocationCert, api, &db); err != nil {
+			out.Print(ui.FormatFailure("Failed to publish revocation certificate", []string{
+				"Saved to " + filename,
+			}, err))
+			return 1
+		}
+
+		out.Print(ui.FormatSuccess("Published revocation certificate", []string{
+			"Saved to " + filename,
+			"",
+			"To revoke the key in GnuPG too, import the certificate:",
+			"",
+			"  gpg --import " + filename,
+		}))
+		return 0
+	}
+
+	out.Print(ui.FormatSuccess("Made revocation certificate", []string{
+		"Saved to " + filename,
+		"",
+		"Your key hasn't been revoked yet. To revoke it, import the certificate into GnuPG:",
+		"",
+		"  gpg --import " + filename,
+		"",
+		"then publish your key so that other people see it's revoked.",
+	}))
+	return 0
+}
+
+type keyRevoker interface {
+	RevokeKey(fingerprint fpr.Fingerprint, revocationCert string) error
+}
+
+type revokedKeyRecorder interface {
+	RecordRevokedKey(fingerprint fpr.Fingerprint) error
+}
+
+// publishRevocation sends the revocation certificate to the API and, once it's been accepted,
+// records the key as revoked so that roster updates can refuse to change its entry.
+func publishRevocation(fingerprint fpr.Fingerprint, revocationCert string,
+	revoker keyRevoker, recorder revokedKeyRecorder) error {
+
+	if err := revoker.RevokeKey(fingerprint, revocationCert); err != nil {
+		return err
+	}
+	return recorder.RecordRevokedKey(fingerprint)
+}
+
+// saveRevocationCertificate writes the certificate to a new file in directory, named after the
+// key's fingerprint and the time, and returns the filename.
+func saveRevocationCertificate(
+	revocationCert string, key *pgpkey.PgpKey, directory string, now time.Time) (string, error) {
+
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return "", fmt.Errorf("failed to make directory %s: %v", directory, err)
+	}
+
+	filename := filepath.Join(directory, fmt.Sprintf(
+		"%s-%s.rev.asc", key.Fingerprint().Hex(), now.UTC().Format("20060102T150405Z")))
+
+	if err := ioutil.WriteFile(filename, []byte(revocationCert), 0600); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/database"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestSaveRevocationCertificate(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "fk.keyrevoke.")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2019, 3, 20, 14, 0, 0, 0, time.UTC)
+	revocationsDir := filepath.Join(dir, "revocations")
+
+	filename, err := saveRevocationCertificate("fake certificate", key, revocationsDir, now)
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		filepath.Join(revocationsDir, "BB3C44BF188D56E635F4A092F73D2F0533D7F9D6-20190320T140000Z.rev.asc"),
+		filename,
+	)
+
+	contents, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "fake certificate", string(contents))
+
+	info, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestPublishRevocation(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+
+	t.Run("records the key as revoked once it's published", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fk.keyrevoke.")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		recorder := database.New(dir)
+
+		err = publishRevocation(fingerprint, "fake certificate", &mock.MockClient{}, &recorder)
+		assert.NoError(t, err)
+
+		revoked, err := recorder.GetRevokedKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, []fpr.Fingerprint{fingerprint}, revoked)
+	})
+
+	t.Run("doesn't record the key if the API rejects the certificate", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fk.keyrevoke.")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		recorder := database.New(dir)
+
+		revoker := mock.MockClient{RevokeKeyError: fmt.Errorf("bad certificate")}
+		err = publishRevocation(fingerprint, "fake certificate", &revoker, &recorder)
+		assert.Equal(t, fmt.Errorf("bad certificate"), err)
+
+		revoked, err := recorder.GetRevokedKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(revoked))
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func keySign(filename string, cleartext bool) exitCode {
+	key, code := chooseOwnKey()
+	if code != 0 {
+		return code
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to read "+filename, nil, err))
+		return 1
+	}
+
+	unlockedKey, _, err := getDecryptedPrivateKeyAndPassword(key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	signature, err := makeFileSignature(unlockedKey, data, cleartext)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to sign "+filename, nil, err))
+		return 1
+	}
+
+	outputFilename := signatureFilename(filename)
+	if err := ioutil.WriteFile(outputFilename, []byte(signature), 0644); err != nil {
+		out.Print(ui.FormatFailure("Failed to write signature", nil, err))
+		return 1
+	}
+
+	lines := []string{"Wrote signature to " + outputFilename}
+	if !cleartext {
+		email, err := key.Email()
+		if err == nil {
+			lines = append(lines,
+				"",
+				"Anyone with the file and signature can check it with:",
+				"",
+				fmt.Sprintf("  fk key verify --signer=%s --file=%s", email, filename),
+			)
+		}
+	}
+	out.Print(ui.FormatSuccess("Signed "+filename, lines))
+	return 0
+}
+
+// makeFileSignature signs data with a detached signature, or if cleartext is true, wraps data
+// in a cleartext signature.
+func makeFileSignature(key *pgpkey.PgpKey, data []byte, cleartext bool) (string, error) {
+	if !key.HasSigningCapability() {
+		return "", pgpkey.ErrNoSigningCapability
+	}
+
+	if cleartext {
+		return key.MakeArmoredClearsignedText(data)
+	}
+	return key.MakeArmoredDetachedSignature(data)
+}
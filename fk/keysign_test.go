@@ -0,0 +1,116 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestMakeFileSignature(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	data := []byte("Hello, this file was signed.\n")
+
+	t.Run("detached signature can be verified by fk key verify", func(t *testing.T) {
+		signature, err := makeFileSignature(key, data, false)
+		assert.NoError(t, err)
+
+		_, err = key.VerifyArmoredDetachedSignature(data, signature)
+		assert.NoError(t, err)
+	})
+
+	t.Run("detached signature can be verified by gpg --verify", func(t *testing.T) {
+		signature, err := makeFileSignature(key, data, false)
+		assert.NoError(t, err)
+
+		dir, filename := writeSignedFile(t, data, signature)
+		defer os.RemoveAll(dir)
+
+		assertGpgVerifies(t, dir, filename+".asc", filename)
+	})
+
+	t.Run("cleartext signature can be verified by gpg --verify", func(t *testing.T) {
+		signature, err := makeFileSignature(key, data, true)
+		assert.NoError(t, err)
+
+		dir, filename := writeSignedFile(t, data, signature)
+		defer os.RemoveAll(dir)
+
+		assertGpgVerifies(t, dir, filename+".asc")
+	})
+
+	t.Run("fails with a locked private key", func(t *testing.T) {
+		lockedKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+
+		_, err = makeFileSignature(lockedKey, data, false)
+		assert.GotError(t, err)
+	})
+}
+
+// writeSignedFile writes data to file.txt and signature to file.txt.asc in a new temporary
+// directory
+func writeSignedFile(t *testing.T, data []byte, signature string) (dir string, filename string) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "fk.keysign.")
+	assert.NoError(t, err)
+
+	filename = filepath.Join(dir, "file.txt")
+	assert.NoError(t, ioutil.WriteFile(filename, data, 0600))
+	assert.NoError(t, ioutil.WriteFile(signatureFilename(filename), []byte(signature), 0600))
+	return dir, filename
+}
+
+// assertGpgVerifies runs `gpg --verify` in a throwaway GnuPG home directory containing only the
+// public key of ExamplePrivateKey4
+func assertGpgVerifies(t *testing.T, dir string, verifyArgs ...string) {
+	t.Helper()
+
+	gpgPath, err := exec.LookPath("gpg")
+	if err != nil {
+		t.Skip("gpg not installed")
+	}
+
+	gpgHome := filepath.Join(dir, "gnupg")
+	assert.NoError(t, os.Mkdir(gpgHome, 0700))
+
+	publicKeyFilename := filepath.Join(dir, "public.asc")
+	assert.NoError(t, ioutil.WriteFile(
+		publicKeyFilename, []byte(exampledata.ExamplePublicKey4), 0600))
+
+	runGpg := func(args ...string) {
+		args = append([]string{"--homedir", gpgHome, "--batch"}, args...)
+		output, err := exec.Command(gpgPath, args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("gpg %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	runGpg("--import", publicKeyFilename)
+	runGpg(append([]string{"--verify"}, verifyArgs...)...)
+}
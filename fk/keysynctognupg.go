@@ -0,0 +1,217 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+)
+
+// keySyncToGnupg fetches the latest version of team members' keys from Fluidkeys and imports
+// them into GnuPG, which might have older versions from before Fluidkeys was installed.
+// If fingerprintFlag isn't empty, only that key is synced.
+// Keys seen for the first time must be verified by the user, unless trustOnFirstUse is true.
+func keySyncToGnupg(fingerprintFlag string, trustOnFirstUse bool) exitCode {
+	groupedMemberships, err := user.GroupedMemberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+	ownFingerprints, err := db.GetFingerprintsImportedIntoGnuPG()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list your keys", nil, err))
+		return 1
+	}
+	people := teamMembersToSync(groupedMemberships, ownFingerprints)
+
+	if fingerprintFlag != "" {
+		fingerprint, err := fpr.Parse(fingerprintFlag)
+		if err != nil {
+			out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+			return 1
+		}
+		person, found := findPersonByFingerprint(people, fingerprint)
+		if !found {
+			out.Print(ui.FormatFailure(
+				displayFingerprint(fingerprint)+" isn't the key of anyone in your teams", nil, nil))
+			return 1
+		}
+		people = []team.Person{person}
+	}
+
+	if len(people) == 0 {
+		out.Print(ui.FormatInfo("No keys to sync", []string{
+			"Fluidkeys syncs the keys of people in your teams. To join a team, run",
+			colour.Cmd("fk team apply <uuid-or-domain>"),
+		}))
+		return 0
+	}
+
+	prompter := &interactiveYesNoPrompter{}
+	verify := func(person team.Person) bool {
+		return verifyKey(person, false, trustOnFirstUse, prompter)
+	}
+
+	summary := syncKeysToGnupg(people, verify, api, &gpg)
+	out.Print(formatKeySyncSummary(summary))
+
+	if len(summary.failed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// teamMembersToSync returns each person in the teams, once for each key, except for the user's
+// own keys which are already in GnuPG.
+func teamMembersToSync(memberships []userpackage.GroupedMembership,
+	ownFingerprints []fpr.Fingerprint) (people []team.Person) {
+
+	seen := map[fpr.Fingerprint]bool{}
+	for _, fingerprint := range ownFingerprints {
+		seen[fingerprint] = true
+	}
+
+	for _, membership := range memberships {
+		for _, person := range membership.Team.People {
+			if !seen[person.Fingerprint] {
+				seen[person.Fingerprint] = true
+				people = append(people, person)
+			}
+		}
+	}
+	return people
+}
+
+func findPersonByFingerprint(people []team.Person, fingerprint fpr.Fingerprint) (
+	person team.Person, found bool) {
+
+	for _, person := range people {
+		if person.Fingerprint == fingerprint {
+			return person, true
+		}
+	}
+	return team.Person{}, false
+}
+
+type gnupgKeyImporter interface {
+	ExportPublicKey(fingerprint fpr.Fingerprint) (string, error)
+	ImportArmoredKey(armoredKey string) error
+}
+
+// keySyncSummary counts what happened to each key synced by syncKeysToGnupg
+type keySyncSummary struct {
+	updated int
+	current int
+	skipped int
+	failed  []keySyncFailure
+}
+
+type keySyncFailure struct {
+	fingerprint fpr.Fingerprint
+	err         error
+}
+
+// syncKeysToGnupg fetches each person's key and imports it into GnuPG, carrying on if a key
+// fails. Keys which verify returns false for are skipped, so an unverified key never gets into
+// GnuPG (where it would be treated as verified).
+func syncKeysToGnupg(people []team.Person, verify func(team.Person) bool,
+	fetcher team.PublicKeyFetcher, gnupg gnupgKeyImporter) keySyncSummary {
+
+	summary := keySyncSummary{}
+
+	for _, person := range people {
+		if !verify(person) {
+			log.Printf("not syncing unverified key %s to GnuPG", person.Fingerprint)
+			summary.skipped++
+			continue
+		}
+
+		updated, err := syncKeyToGnupg(person.Fingerprint, fetcher, gnupg)
+		switch {
+		case err != nil:
+			log.Printf("failed to sync %s to GnuPG: %v", person.Fingerprint, err)
+			summary.failed = append(summary.failed, keySyncFailure{person.Fingerprint, err})
+
+		case updated:
+			summary.updated++
+
+		default:
+			summary.current++
+		}
+	}
+	return summary
+}
+
+// syncKeyToGnupg imports the latest version of the key into GnuPG. It returns updated=false if
+// GnuPG's copy of the key was already the same as the latest version.
+func syncKeyToGnupg(fingerprint fpr.Fingerprint, fetcher team.PublicKeyFetcher,
+	gnupg gnupgKeyImporter) (updated bool, err error) {
+
+	key, err := fetcher.GetPublicKeyByFingerprint(fingerprint)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch key: %v", err)
+	}
+	armoredKey, err := key.Armor()
+	if err != nil {
+		return false, fmt.Errorf("failed to ASCII armor key: %v", err)
+	}
+
+	// importing merges the key with GnuPG's copy, so compare GnuPG's copy before and after.
+	// an error here means GnuPG doesn't have the key yet.
+	before, _ := gnupg.ExportPublicKey(fingerprint)
+
+	if err := gnupg.ImportArmoredKey(armoredKey); err != nil {
+		return false, fmt.Errorf("failed to import key into GnuPG: %v", err)
+	}
+
+	after, err := gnupg.ExportPublicKey(fingerprint)
+	if err != nil {
+		return false, fmt.Errorf("failed to check key in GnuPG: %v", err)
+	}
+	return after != before, nil
+}
+
+func formatKeySyncSummary(summary keySyncSummary) string {
+	lines := []string{
+		humanize.Pluralize(summary.updated, "key", "keys") + " updated",
+		humanize.Pluralize(summary.current, "key", "keys") + " already up to date",
+	}
+	if summary.skipped > 0 {
+		lines = append(lines,
+			humanize.Pluralize(summary.skipped, "key", "keys")+" skipped: not verified")
+	}
+
+	if len(summary.failed) == 0 {
+		return ui.FormatSuccess("Synced keys to GnuPG", lines)
+	}
+
+	lines = append(lines, humanize.Pluralize(len(summary.failed), "key", "keys")+" failed:")
+	for _, failure := range summary.failed {
+		lines = append(lines, fmt.Sprintf("%s: %v", displayFingerprint(failure.fingerprint),
+			failure.err))
+	}
+	return ui.FormatWarning("Some keys failed to sync to GnuPG", lines, nil)
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+)
+
+func TestTeamMembersToSync(t *testing.T) {
+	memberships := []userpackage.GroupedMembership{
+		{Team: team.Team{People: []team.Person{
+			{Email: "me@example.com", Fingerprint: exampledata.ExampleFingerprint2},
+			{Email: "other@example.com", Fingerprint: exampledata.ExampleFingerprint3},
+		}}},
+		{Team: team.Team{People: []team.Person{
+			{Email: "other@example.com", Fingerprint: exampledata.ExampleFingerprint3},
+			{Email: "another@example.com", Fingerprint: exampledata.ExampleFingerprint4},
+		}}},
+	}
+
+	got := teamMembersToSync(memberships, []fpr.Fingerprint{exampledata.ExampleFingerprint2})
+
+	assert.Equal(t, []team.Person{
+		{Email: "other@example.com", Fingerprint: exampledata.ExampleFingerprint3},
+		{Email: "another@example.com", Fingerprint: exampledata.ExampleFingerprint4},
+	}, got)
+}
+
+func TestSyncKeysToGnupg(t *testing.T) {
+	key3, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+	assert.NoError(t, err)
+	key4, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+
+	fetcher := mockKeyFetcher{
+		exampledata.ExampleFingerprint3: key3,
+		exampledata.ExampleFingerprint4: key4,
+	}
+
+	person2 := team.Person{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2}
+	person3 := team.Person{Email: "test3@example.com", Fingerprint: exampledata.ExampleFingerprint3}
+	person4 := team.Person{Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4}
+	verifyAll := func(team.Person) bool { return true }
+
+	t.Run("counts updated, current and failed keys", func(t *testing.T) {
+		gnupg := &mockGnupgKeyring{keys: map[fpr.Fingerprint]string{
+			exampledata.ExampleFingerprint3: "current key 3",
+		}}
+
+		summary := syncKeysToGnupg([]team.Person{
+			person2, // not on the server
+			person3,
+			person4,
+		}, verifyAll, fetcher, gnupg)
+
+		assert.Equal(t, 1, summary.updated)
+		assert.Equal(t, 1, summary.current)
+		assert.Equal(t, 1, len(summary.failed))
+		assert.Equal(t, exampledata.ExampleFingerprint2, summary.failed[0].fingerprint)
+		assert.Equal(t, 2, len(gnupg.imported))
+	})
+
+	t.Run("replaces a stale copy in GnuPG", func(t *testing.T) {
+		gnupg := &mockGnupgKeyring{
+			keys:  map[fpr.Fingerprint]string{exampledata.ExampleFingerprint3: "stale key 3"},
+			stale: map[fpr.Fingerprint]bool{exampledata.ExampleFingerprint3: true},
+		}
+
+		summary := syncKeysToGnupg([]team.Person{person3}, verifyAll, fetcher, gnupg)
+
+		assert.Equal(t, 1, summary.updated)
+		assert.Equal(t, 0, summary.current)
+		assert.Equal(t, 0, len(summary.failed))
+		if gnupg.keys[exampledata.ExampleFingerprint3] == "stale key 3" {
+			t.Fatalf("expected stale key to be replaced in GnuPG")
+		}
+	})
+
+	t.Run("records a failed GnuPG import", func(t *testing.T) {
+		gnupg := &mockGnupgKeyring{
+			keys:        map[fpr.Fingerprint]string{},
+			importError: fmt.Errorf("gpg exploded"),
+		}
+
+		summary := syncKeysToGnupg([]team.Person{person4}, verifyAll, fetcher, gnupg)
+
+		assert.Equal(t, 0, summary.updated)
+		assert.Equal(t, 1, len(summary.failed))
+		assert.Equal(t,
+			"failed to import key into GnuPG: gpg exploded", summary.failed[0].err.Error())
+	})
+
+	t.Run("skips keys that aren't verified", func(t *testing.T) {
+		gnupg := &mockGnupgKeyring{keys: map[fpr.Fingerprint]string{}}
+		verified := []team.Person{}
+		verify := func(person team.Person) bool {
+			verified = append(verified, person)
+			return person == person3
+		}
+
+		summary := syncKeysToGnupg([]team.Person{person3, person4}, verify, fetcher, gnupg)
+
+		assert.Equal(t, []team.Person{person3, person4}, verified)
+		assert.Equal(t, 1, summary.updated)
+		assert.Equal(t, 1, summary.skipped)
+		assert.Equal(t, 1, len(gnupg.imported))
+		if _, found := gnupg.keys[exampledata.ExampleFingerprint4]; found {
+			t.Fatalf("expected unverified key not to be imported into GnuPG")
+		}
+	})
+}
+
+type mockKeyFetcher map[fpr.Fingerprint]*pgpkey.PgpKey
+
+func (m mockKeyFetcher) GetPublicKeyByFingerprint(fingerprint fpr.Fingerprint) (
+	*pgpkey.PgpKey, error) {
+
+	if key, found := m[fingerprint]; found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+// mockGnupgKeyring stores imported keys by fingerprint. Like GnuPG merging in a key it already
+// has, importing a key it already has doesn't change it, unless its copy is marked as stale.
+type mockGnupgKeyring struct {
+	keys        map[fpr.Fingerprint]string
+	stale       map[fpr.Fingerprint]bool
+	imported    []string
+	importError error
+}
+
+func (m *mockGnupgKeyring) ExportPublicKey(fingerprint fpr.Fingerprint) (string, error) {
+	if armoredKey, found := m.keys[fingerprint]; found {
+		return armoredKey, nil
+	}
+	return "", fmt.Errorf("nothing exported")
+}
+
+func (m *mockGnupgKeyring) ImportArmoredKey(armoredKey string) error {
+	if m.importError != nil {
+		return m.importError
+	}
+	key, err := pgpkey.LoadFromArmoredPublicKey(armoredKey)
+	if err != nil {
+		return err
+	}
+	if _, found := m.keys[key.Fingerprint()]; !found || m.stale[key.Fingerprint()] {
+		m.keys[key.Fingerprint()] = armoredKey
+		delete(m.stale, key.Fingerprint())
+	}
+	m.imported = append(m.imported, armoredKey)
+	return nil
+}
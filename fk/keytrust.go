@@ -0,0 +1,79 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/gpgwrapper"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func keyTrust(fingerprintFlag string, levelFlag string) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	level := gpgwrapper.TrustFull
+	if levelFlag != "" {
+		if level, err = gpgwrapper.ParseTrustLevel(levelFlag); err != nil {
+			out.Print(ui.FormatFailure("Invalid trust level", nil, err))
+			return 1
+		}
+	}
+
+	if err := trustKey(fingerprint, level, &gpg); err != nil {
+		out.Print(ui.FormatFailure("Failed to set trust in GnuPG", nil, err))
+		return 1
+	}
+
+	printSuccess(fmt.Sprintf(
+		"Set trust for %s to %s in GnuPG", displayFingerprint(fingerprint), level))
+	return 0
+}
+
+type ownerTrustSetter interface {
+	ListPublicKeys(searchString string) ([]gpgwrapper.KeyListing, error)
+	SetOwnerTrust(fpr.Fingerprint, gpgwrapper.TrustLevel) error
+}
+
+// trustKey sets the ownertrust of the given key in GnuPG, after checking GnuPG has the key.
+func trustKey(fingerprint fpr.Fingerprint, level gpgwrapper.TrustLevel,
+	gpg ownerTrustSetter) error {
+
+	listings, err := gpg.ListPublicKeys(fingerprint.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to list keys in GnuPG: %v", err)
+	}
+
+	gotKey := false
+	for _, listing := range listings {
+		if listing.Fingerprint == fingerprint {
+			gotKey = true
+		}
+	}
+	if !gotKey {
+		return fmt.Errorf("key %s isn't in GnuPG", fingerprint)
+	}
+
+	return gpg.SetOwnerTrust(fingerprint, level)
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/gpgwrapper"
+)
+
+func TestTrustKey(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint2
+
+	t.Run("sets ownertrust for a key in GnuPG", func(t *testing.T) {
+		gpg := mockOwnerTrustSetter{
+			listings: []gpgwrapper.KeyListing{{Fingerprint: fingerprint}},
+		}
+
+		err := trustKey(fingerprint, gpgwrapper.TrustMarginal, &gpg)
+		assert.NoError(t, err)
+		assert.Equal(t, fingerprint.Hex(), gpg.searchedFor)
+		assert.Equal(t, map[fpr.Fingerprint]gpgwrapper.TrustLevel{
+			fingerprint: gpgwrapper.TrustMarginal,
+		}, gpg.setTrust)
+	})
+
+	t.Run("returns an error if the key isn't in GnuPG", func(t *testing.T) {
+		gpg := mockOwnerTrustSetter{}
+
+		err := trustKey(fingerprint, gpgwrapper.TrustFull, &gpg)
+		assert.Equal(t, fmt.Errorf("key %s isn't in GnuPG", fingerprint), err)
+		assert.Equal(t, 0, len(gpg.setTrust))
+	})
+
+	t.Run("passes through an error setting ownertrust", func(t *testing.T) {
+		gpg := mockOwnerTrustSetter{
+			listings:         []gpgwrapper.KeyListing{{Fingerprint: fingerprint}},
+			setOwnerTrustErr: fmt.Errorf("gpg broke"),
+		}
+
+		err := trustKey(fingerprint, gpgwrapper.TrustFull, &gpg)
+		assert.Equal(t, fmt.Errorf("gpg broke"), err)
+	})
+}
+
+type mockOwnerTrustSetter struct {
+	listings         []gpgwrapper.KeyListing
+	setOwnerTrustErr error
+
+	searchedFor string
+	setTrust    map[fpr.Fingerprint]gpgwrapper.TrustLevel
+}
+
+func (m *mockOwnerTrustSetter) ListPublicKeys(searchString string) ([]gpgwrapper.KeyListing, error) {
+	m.searchedFor = searchString
+	return m.listings, nil
+}
+
+func (m *mockOwnerTrustSetter) SetOwnerTrust(
+	fingerprint fpr.Fingerprint, level gpgwrapper.TrustLevel) error {
+
+	if m.setOwnerTrustErr != nil {
+		return m.setOwnerTrustErr
+	}
+	if m.setTrust == nil {
+		m.setTrust = map[fpr.Fingerprint]gpgwrapper.TrustLevel{}
+	}
+	m.setTrust[fingerprint] = level
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+const (
+	exitSignatureValid   exitCode = 0
+	exitSignatureInvalid exitCode = 1
+	exitKeyNotFound      exitCode = 2
+)
+
+func keyVerify(signerEmail string, filename string) exitCode {
+	key, err := findPublicKeyForEmail(signerEmail)
+	if err != nil {
+		out.Print(ui.FormatFailure("Couldn't find a key for "+signerEmail, nil, err))
+		return exitKeyNotFound
+	}
+
+	details, err := verifySignedFile(key, filename)
+	if err != nil {
+		out.Print(ui.FormatFailure("Signature is NOT valid", []string{
+			"File:      " + filename,
+			"Signature: " + signatureFilename(filename),
+		}, err))
+		return exitSignatureInvalid
+	}
+
+	lines := []string{
+		"Signed by: " + signerEmail,
+		"Key:       " + formatFingerprint(key.Fingerprint(), fpr.Fingerprint.String),
+		"Signed at: " + details.CreationTime.Format("2 January 2006 15:04:05 MST"),
+	}
+	if details.MadeBySubkey {
+		lines = append(lines, fmt.Sprintf("Subkey:    0x%016X", details.SignerKeyId))
+	}
+	out.Print(ui.FormatSuccess("Signature is valid", lines))
+	return exitSignatureValid
+}
+
+// verifySignedFile checks the file against the detached signature in <filename>.asc
+func verifySignedFile(key *pgpkey.PgpKey, filename string) (*pgpkey.SignatureDetails, error) {
+	signedData, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	armoredSignature, err := ioutil.ReadFile(signatureFilename(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %v", err)
+	}
+
+	return key.VerifyArmoredDetachedSignature(signedData, string(armoredSignature))
+}
+
+func signatureFilename(filename string) string {
+	return filename + ".asc"
+}
+
+// findPublicKeyForEmail looks for a public key with the given email in GnuPG, then the
+// Fluidkeys directory, then the email domain's Web Key Directory.
+func findPublicKeyForEmail(email string) (*pgpkey.PgpKey, error) {
+	if key, err := findPublicKeyInGnuPG(email); err != nil {
+		log.Printf("failed to find key for %s in GnuPG: %v", email, err)
+	} else {
+		return key, nil
+	}
+
+	if armoredKey, err := api.GetPublicKey(email); err != nil {
+		log.Printf("failed to find key for %s in API: %v", email, err)
+	} else if key, err := pgpkey.LoadFromArmoredPublicKey(armoredKey); err != nil {
+		log.Printf("failed to load key for %s from API: %v", email, err)
+	} else {
+		return key, nil
+	}
+
+	if key, err := wkd.Lookup(email); err != nil {
+		log.Printf("failed to find key for %s in WKD: %v", email, err)
+	} else {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("no key found in GnuPG, Fluidkeys or Web Key Directory")
+}
+
+func findPublicKeyInGnuPG(email string) (*pgpkey.PgpKey, error) {
+	listings, err := gpg.ListPublicKeys("<" + email + ">")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, listing := range listings {
+		armoredKey, err := gpg.ExportPublicKey(listing.Fingerprint)
+		if err != nil {
+			log.Printf("failed to export key %s from GnuPG: %v", listing.Fingerprint, err)
+			continue
+		}
+		key, err := pgpkey.LoadFromArmoredPublicKey(armoredKey)
+		if err != nil {
+			log.Printf("failed to load key %s from GnuPG: %v", listing.Fingerprint, err)
+			continue
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("no key with email %s", email)
+}
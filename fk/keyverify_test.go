@@ -0,0 +1,68 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestVerifySignedFile(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "fk.keyverify.")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile := func(filename string, content string) string {
+		fullPath := filepath.Join(dir, filename)
+		assert.NoError(t, ioutil.WriteFile(fullPath, []byte(content), 0600))
+		return fullPath
+	}
+
+	t.Run("with a valid signature", func(t *testing.T) {
+		filename := writeFile("valid.txt", exampledata.ExampleSignedData4)
+		writeFile("valid.txt.asc", exampledata.ExampleDetachedSignature4)
+
+		details, err := verifySignedFile(key, filename)
+		assert.NoError(t, err)
+		assert.Equal(t, key.PrimaryKey.KeyId, details.SignerKeyId)
+	})
+
+	t.Run("with a modified file", func(t *testing.T) {
+		filename := writeFile("modified.txt", exampledata.ExampleSignedData4+"extra")
+		writeFile("modified.txt.asc", exampledata.ExampleDetachedSignature4)
+
+		_, err := verifySignedFile(key, filename)
+		assert.GotError(t, err)
+	})
+
+	t.Run("with a missing signature file", func(t *testing.T) {
+		filename := writeFile("unsigned.txt", exampledata.ExampleSignedData4)
+
+		_, err := verifySignedFile(key, filename)
+		assert.GotError(t, err)
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// keyVerifySelfSig checks the self-signatures binding each user ID to a key in GnuPG, which
+// can cause confusing GnuPG failures if they're missing or corrupt.
+func keyVerifySelfSig(fingerprintFlag string) exitCode {
+	fingerprint, err := fpr.Parse(fingerprintFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid fingerprint", nil, err))
+		return 1
+	}
+
+	key, err := loadPgpKey(fingerprint)
+	if err != nil {
+		out.Print(ui.FormatFailure("Couldn't load key "+displayFingerprint(fingerprint)+" from GnuPG",
+			nil, err))
+		return 1
+	}
+
+	selfSigErrors := key.VerifySelfSignatures()
+	out.Print(formatSelfSigResult(key, selfSigErrors))
+
+	if len(selfSigErrors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// formatSelfSigResult describes the outcome of checking the key's self-signatures
+func formatSelfSigResult(key *pgpkey.PgpKey, selfSigErrors []pgpkey.SelfSigError) string {
+	if len(selfSigErrors) == 0 {
+		return ui.FormatSuccess("Self-signatures are valid", []string{
+			humanize.Pluralize(len(key.Identities), "user ID is", "user IDs are") +
+				" correctly bound to " + displayFingerprint(key.Fingerprint()),
+		})
+	}
+
+	lines := []string{}
+	for _, selfSigError := range selfSigErrors {
+		lines = append(lines, selfSigError.Error())
+	}
+	return ui.FormatFailure(
+		humanize.Pluralize(len(selfSigErrors), "user ID has", "user IDs have")+
+			" a missing or invalid self-signature",
+		lines, nil)
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+func TestFormatSelfSigResult(t *testing.T) {
+	t.Run("for a key with valid self-signatures", func(t *testing.T) {
+		key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+		assert.NoError(t, err)
+
+		got := colour.StripAllColourCodes(formatSelfSigResult(key, key.VerifySelfSignatures()))
+		assert.Equal(t, true, strings.Contains(got, "Self-signatures are valid"))
+		assert.Equal(t, true, strings.Contains(got, "3 user IDs are correctly bound"))
+	})
+
+	t.Run("for a key with a stripped self-signature", func(t *testing.T) {
+		key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+		assert.NoError(t, err)
+		key.Identities["<test3@example.com>"].SelfSignature = nil
+
+		got := colour.StripAllColourCodes(formatSelfSigResult(key, key.VerifySelfSignatures()))
+		assert.Equal(t, true,
+			strings.Contains(got, "1 user ID has a missing or invalid self-signature"))
+		assert.Equal(t, true, strings.Contains(got, "<test3@example.com>: missing self-signature"))
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/fluidkeys/fluidkeys/emailutils"
+	outputformat "github.com/fluidkeys/fluidkeys/fk/format"
 	"github.com/fluidkeys/fluidkeys/status"
 	"github.com/fluidkeys/fluidkeys/table"
 
@@ -53,7 +54,9 @@ var (
 	db                 database.Database
 	Config             config.Config
 	Keyring            keyring.Keyring
-	api                *apiclient.Client
+	api                apiclient.APIClient
+	apiBaseURL         string // e.g. https://api.fluidkeys.com/v1/
+	wkd                *apiclient.WKDClient
 	user               *userpackage.User
 )
 
@@ -70,32 +73,119 @@ Usage:
 	fk setup
 	fk setup <email>
 	fk team create
-	fk team apply <uuid>
+	fk team apply <uuid-or-domain> [--invite=<token>]
+	fk team show [<uuid>]
+	fk team list [--format=<format>]
+	fk team leave [<uuid>]
 	fk team authorize
-	fk team fetch [--cron-output]
+	fk team list-pending-requests [--team=<uuid>] [--expired]
+	fk team invite <email>
+	fk team fetch [--cron-output] [--trust-on-first-use] [--no-gpg-import] [--team=<uuid>]
+	fk team fetch --watch [--interval=<duration>] [--trust-on-first-use] [--no-gpg-import] [--team=<uuid>]
+	fk team sync [--cron-output] [--trust-on-first-use] [--no-gpg-import]
+	fk team edit [--dry-run]
+	fk team add-admin <email>
+	fk team remove-admin <email>
+	fk team audit
+	fk team check-roster <file>
+	fk team export --format=<format> [--team=<uuid>] [--output=<file>]
+	fk team export-wkd --output=<dir>
 	fk status
-	fk secret send <recipient-email>
-	fk secret send [<filename>] --to=<email>
+	fk diagnostic
+	fk config get <key>
+	fk config set <key> <value>
+	fk config list
+	fk config show-paths
+	fk secret send <recipient-email> [--expires-in=<duration>] [--team=<uuid>]
+	fk secret send [<filename>] --to=<email> [--expires-in=<duration>] [--team=<uuid>]
 	fk secret receive
+	fk secret list [--count] [--format=<format>]
+	fk secret re-encrypt-all
+	fk secret delete <uuid>
+	fk secret delete --all
+	fk secret forward <uuid> <recipient-email> [--delete-original]
+	fk secret copy-clipboard <uuid>
 	fk key create
 	fk key from-gpg
-	fk key list
+	fk key generate --email=<email> [--algorithm=<algorithm>]
+	fk key import <file>
+	fk key export <fingerprint> [--public | --private] [--output=<file>]
+	fk key list [--format=<format>] [--private-only] [--with-email=<email>]
 	fk key maintain [--dry-run]
 	fk key maintain automatic [--cron-output]
 	fk key upload
+	fk key revoke --reason=<reason> [--publish]
+	fk key sign --file=<path> [--cleartext]
+	fk key verify --signer=<email> --file=<path>
+	fk key trust <fingerprint> [--level=<level>]
+	fk key extend-expiry <fingerprint> --duration=<duration> [--force]
+	fk key expire-now <fingerprint> [--confirm]
+	fk key report <fingerprint> --reason=<reason>
+	fk key pin-subkey <fingerprint> --subkey=<fingerprint>
+	fk key change-passphrase <fingerprint>
+	fk key verify-self-sig <fingerprint>
+	fk key history <fingerprint>
+	fk key add-uid <email>
+	fk key remove-uid <email>
+	fk key sync-to-gnupg [--all | --fingerprint=<fingerprint>] [--trust-on-first-use]
 	fk sync [--cron-output]
 
 Options:
-	-h --help         Show this screen
-	   --dry-run      Don't change anything: only output what would happen
-	   --cron-output  Only print output on errors`, // TODO: Document `automatic`
+	-h --help                 Show this screen
+	   --dry-run              Don't change anything: only output what would happen
+	   --cron-output          Only print output on errors
+	   --output=<dir>         Directory or file to write to
+	   --public               Export the public key (the default)
+	   --private              Export the private key, encrypted with its password
+	   --format=<format>      Output format: table (the default), json or csv
+	                          (fk key list also takes fingerprints: one per line;
+	                          fk team export takes json, vcard or gpg-keyring)
+	   --private-only         Only list keys with a private key in GnuPG
+	   --with-email=<email>   Only list keys with this email address
+	   --trust-on-first-use   Import new team keys without asking to verify them
+	   --no-gpg-import        Only save team keys to the team directory, not GnuPG
+	   --invite=<token>       Invitation from a team admin, made with fk team invite
+	   --count                Only print the number of secrets
+	   --expired              Also list requests to join that have expired
+	   --all                  Delete all secrets waiting for you, without reading them
+	                          (fk key sync-to-gnupg: sync every team member's key, the default)
+	   --fingerprint=<fingerprint>  Only sync the key with this fingerprint
+	   --delete-original      Delete your copy of the secret once it's been forwarded
+	   --expires-in=<duration>  Delete the secret if it isn't received in time, e.g. 7d
+	   --team=<uuid>          Only use this team, e.g. only send to a member of it
+	   --signer=<email>       Email of the person who signed the file
+	   --file=<path>          File to sign or verify, with its signature in <path>.asc
+	   --cleartext            Make a cleartext signature instead of a detached one
+	   --reason=<reason>      Why the key is being revoked or reported
+	   --publish              Also publish the revocation certificate to Fluidkeys
+	   --email=<email>        Email address for the new key
+	   --algorithm=<algorithm>  Key algorithm: rsa4096 (the default)
+	   --level=<level>        Trust level: full (the default) or marginal
+	   --duration=<duration>  How long from now until the key expires, e.g. 365d
+	   --force                Do it even if the key would expire sooner than before
+	   --confirm              Confirm that the key should stop working immediately
+	   --subkey=<fingerprint>  Subkey to encrypt secrets to, instead of the newest
+	   --watch                Keep fetching until stopped with Ctrl-C
+	   --interval=<duration>  How often to fetch with --watch, e.g. 10m (default 5m)
+	   --fingerprint-format=<format>  Show fingerprints as openpgp4fpr, hex or short. This
+	                          can be given with any command.`, // TODO: Document `automatic`
 		Version,
 		Config.GetFilename(),
 		out.GetLogFilename(),
 	)
 
 	log.Print("$ " + strings.Join(os.Args, " "))
-	args, _ := docopt.ParseDoc(usage)
+
+	argv, formatFlag, gotFormatFlag := extractGlobalFlag(os.Args[1:], "--fingerprint-format")
+	args, _ := docopt.ParseArgs(usage, argv, "")
+
+	if gotFormatFlag {
+		var err error
+		if fingerprintFormat, err = fpr.ParseFormat(formatFlag); err != nil {
+			out.Print(ui.FormatFailure("Invalid --fingerprint-format", nil, err))
+			return 1
+		}
+	}
 
 	ensureSchedulerStateMatchesConfig()
 
@@ -109,7 +199,9 @@ Options:
 	}
 	var code exitCode
 
-	switch getSubcommand(args, []string{"key", "secret", "team", "setup", "sync", "status"}) {
+	switch getSubcommand(args, []string{
+		"key", "secret", "team", "setup", "sync", "status", "config", "diagnostic",
+	}) {
 	case "key":
 		code = keySubcommand(args)
 
@@ -128,6 +220,12 @@ Options:
 	case "status":
 		code = statusSubcommand(args)
 
+	case "config":
+		code = configSubcommand(args)
+
+	case "diagnostic":
+		code = diagnostic()
+
 	default:
 		out.Print("unhandled subcommand")
 		code = 1
@@ -269,17 +367,53 @@ func getSubcommand(args docopt.Opts, subcommands []string) string {
 
 func keySubcommand(args docopt.Opts) exitCode {
 	switch getSubcommand(args, []string{
-		"create", "from-gpg", "list", "maintain", "upload",
+		"create", "export", "from-gpg", "generate", "import", "list", "maintain", "revoke",
+		"sign", "upload", "verify", "trust", "extend-expiry", "report",
+		"pin-subkey", "change-passphrase", "verify-self-sig", "history",
+		"add-uid", "remove-uid", "sync-to-gnupg", "expire-now",
 	}) {
 	case "create":
 		exitCode, _ := keyCreate("")
 		return exitCode
 
+	case "export":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		private, err := args.Bool("--private")
+		if err != nil {
+			log.Panic(err)
+		}
+		outputFilename, _ := args.String("--output") // optional: print to stdout if not given
+		return keyExport(fingerprint, private, outputFilename)
+
 	case "from-gpg":
 		return keyFromGpg()
 
+	case "generate":
+		email, err := args.String("--email")
+		if err != nil {
+			log.Panic(err)
+		}
+		algorithm, _ := args.String("--algorithm") // optional: use the default if not given
+		return keyGenerate(email, algorithm)
+
+	case "import":
+		filename, err := args.String("<file>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyImport(filename)
+
 	case "list":
-		return keyList()
+		format, _ := args.String("--format")        // optional: default to a table
+		withEmail, _ := args.String("--with-email") // optional: default to all emails
+		privateOnly, err := args.Bool("--private-only")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyList(format, privateOnly, withEmail)
 
 	case "maintain":
 		dryRun, err := args.Bool("--dry-run")
@@ -292,8 +426,140 @@ func keySubcommand(args docopt.Opts) exitCode {
 		}
 		return keyMaintain(dryRun, automatic)
 
+	case "revoke":
+		reason, err := args.String("--reason")
+		if err != nil {
+			log.Panic(err)
+		}
+		publish, err := args.Bool("--publish")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyRevoke(reason, publish)
+
+	case "sign":
+		filename, err := args.String("--file")
+		if err != nil {
+			log.Panic(err)
+		}
+		cleartext, err := args.Bool("--cleartext")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keySign(filename, cleartext)
+
 	case "upload":
 		return keyUpload()
+
+	case "verify":
+		signerEmail, err := args.String("--signer")
+		if err != nil {
+			log.Panic(err)
+		}
+		filename, err := args.String("--file")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyVerify(signerEmail, filename)
+
+	case "trust":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		level, _ := args.String("--level") // optional: default to full
+		return keyTrust(fingerprint, level)
+
+	case "extend-expiry":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		duration, err := args.String("--duration")
+		if err != nil {
+			log.Panic(err)
+		}
+		force, err := args.Bool("--force")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyExtendExpiry(fingerprint, duration, force)
+
+	case "expire-now":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		confirm, err := args.Bool("--confirm")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyExpireNow(fingerprint, confirm)
+
+	case "report":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		reason, err := args.String("--reason")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyReport(fingerprint, reason)
+
+	case "pin-subkey":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		subkey, err := args.String("--subkey")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyPinSubkey(fingerprint, subkey)
+
+	case "change-passphrase":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyChangePassphrase(fingerprint)
+
+	case "verify-self-sig":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyVerifySelfSig(fingerprint)
+
+	case "history":
+		fingerprint, err := args.String("<fingerprint>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyHistory(fingerprint)
+
+	case "add-uid":
+		email, err := args.String("<email>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyAddUid(email)
+
+	case "remove-uid":
+		email, err := args.String("<email>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keyRemoveUid(email)
+
+	case "sync-to-gnupg":
+		fingerprint, _ := args.String("--fingerprint") // optional: default to --all
+		trustOnFirstUse, err := args.Bool("--trust-on-first-use")
+		if err != nil {
+			log.Panic(err)
+		}
+		return keySyncToGnupg(fingerprint, trustOnFirstUse)
 	}
 	log.Panicf("keySubcommand got unexpected arguments: %v", args)
 	panic(nil)
@@ -332,13 +598,57 @@ func loadPgpKey(fingerprint fpr.Fingerprint) (*pgpkey.PgpKey, error) {
 	return pgpKey, nil
 }
 
-func keyList() exitCode {
+// chooseOwnKey returns the user's key, or prints a failure and returns a non-zero exit code if
+// they don't have exactly one key.
+func chooseOwnKey() (*pgpkey.PgpKey, exitCode) {
+	keys, err := loadPgpKeys()
+	if err != nil {
+		out.Print(ui.FormatFailure("Error loading pgp keys", nil, err))
+		return nil, 1
+	}
+
+	switch len(keys) {
+	case 0:
+		out.Print(ui.FormatFailure("You don't have a key yet", []string{
+			"Create one with:",
+			"",
+			"  fk key create",
+		}, nil))
+		return nil, 1
+
+	case 1:
+		return &keys[0], 0
+
+	default:
+		out.Print(ui.FormatFailure("Choosing from multiple keys not implemented", nil, nil))
+		return nil, 1
+	}
+}
+
+func keyList(format string, privateOnly bool, withEmail string) exitCode {
 	keys, err := loadPgpKeys()
 	if err != nil {
 		log.Panic(err)
 	}
 
-	out.Print("\n")
+	var secretFingerprints []fpr.Fingerprint
+	if privateOnly {
+		secretKeys, err := gpg.ListSecretKeys()
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to list private keys in GnuPG", nil, err))
+			return 1
+		}
+		secretFingerprints = []fpr.Fingerprint{}
+		for _, secretKey := range secretKeys {
+			secretFingerprints = append(secretFingerprints, secretKey.Fingerprint)
+		}
+	}
+	keys = filterKeyList(keys, secretFingerprints, withEmail)
+
+	if format == keyListFingerprintsFormat {
+		out.Print(formatFingerprintList(keys))
+		return 0
+	}
 
 	keysWithWarnings := []table.KeyWithWarnings{}
 
@@ -352,15 +662,58 @@ func keyList() exitCode {
 		keysWithWarnings = append(keysWithWarnings, keyWithWarnings)
 	}
 
+	if format != "" && format != outputformat.Table {
+		return printFormatted(format, keyListColumns, makeKeyListRecords(keysWithWarnings))
+	}
+
+	out.Print("\n")
 	out.Print(table.FormatKeyTable(keysWithWarnings))
 	out.Print(table.FormatKeyTablePrimaryInstruction(keysWithWarnings))
 	return 0
 }
 
+var keyListColumns = []string{"fingerprint", "emails", "created", "warnings"}
+
+// makeKeyListRecords returns a record for each key, for output with --format
+func makeKeyListRecords(keysWithWarnings []table.KeyWithWarnings) []map[string]string {
+	records := []map[string]string{}
+	for _, keyWithWarnings := range keysWithWarnings {
+		warnings := []string{}
+		for _, warning := range keyWithWarnings.Warnings {
+			warnings = append(warnings, colour.StripAllColourCodes(warning.String()))
+		}
+
+		records = append(records, map[string]string{
+			"fingerprint": formatFingerprint(keyWithWarnings.Key.Fingerprint(), fpr.Fingerprint.Hex),
+			"emails":      strings.Join(keyWithWarnings.Key.Emails(true), ", "),
+			"created":     keyWithWarnings.Key.PrimaryKey.CreationTime.Format("2006-01-02"),
+			"warnings":    strings.Join(warnings, "; "),
+		})
+	}
+	return records
+}
+
+// printFormatted renders records in the given format, for list commands that accept --format
+func printFormatted(format string, columns []string, records []map[string]string) exitCode {
+	formatter, err := outputformat.NewOutputFormatter(format, columns)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid --format", nil, err))
+		return 1
+	}
+
+	output, err := formatter.Render(records)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to format output", nil, err))
+		return 1
+	}
+	out.Print(output)
+	return 0
+}
+
 func displayName(key *pgpkey.PgpKey) string {
 	displayName, err := key.Email()
 	if err != nil {
-		displayName = fmt.Sprintf("%s", key.Fingerprint())
+		displayName = displayFingerprint(key.Fingerprint())
 	}
 	return colour.Info(displayName)
 }
@@ -381,7 +734,7 @@ func promptForInput(prompt string) string {
 
 func secretSubcommand(args docopt.Opts) exitCode {
 	switch getSubcommand(args, []string{
-		"send", "receive",
+		"send", "receive", "list", "re-encrypt-all", "delete", "forward", "copy-clipboard",
 	}) {
 	case "send":
 		emailAddress, err := args.String("<recipient-email>")
@@ -413,21 +766,71 @@ func secretSubcommand(args docopt.Opts) exitCode {
 			log.Panic(err)
 		}
 
+		expiresIn, _ := args.String("--expires-in") // optional: secrets don't expire by default
+		teamUUID, _ := args.String("--team")        // optional: don't check team membership
+
 		filename, err := args.String("<filename>")
 		if err != nil {
 			// Case 1: `fk secret send --to=someone@example.com`
 			// ... read from stdin
 
-			return secretSend(emailAddress, "")
+			return secretSend(emailAddress, "", expiresIn, teamUUID)
 		} else {
 			// Case 2: `fk secret send secret.txt --to=someone@example.com`
 			// ... read from secret.txt
 
-			return secretSend(emailAddress, filename)
+			return secretSend(emailAddress, filename, expiresIn, teamUUID)
 		}
 
 	case "receive":
 		return secretReceive()
+
+	case "list":
+		countOnly, err := args.Bool("--count")
+		if err != nil {
+			log.Panic(err)
+		}
+		format, _ := args.String("--format") // optional: default to a table
+		return secretList(countOnly, format)
+
+	case "re-encrypt-all":
+		return secretReencryptAll()
+
+	case "delete":
+		all, err := args.Bool("--all")
+		if err != nil {
+			log.Panic(err)
+		}
+		if all {
+			return secretDeleteAll()
+		}
+		secretUUID, err := args.String("<uuid>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return secretDelete(secretUUID)
+
+	case "forward":
+		secretUUID, err := args.String("<uuid>")
+		if err != nil {
+			log.Panic(err)
+		}
+		recipientEmail, err := args.String("<recipient-email>")
+		if err != nil {
+			log.Panic(err)
+		}
+		deleteOriginal, err := args.Bool("--delete-original")
+		if err != nil {
+			log.Panic(err)
+		}
+		return secretForward(secretUUID, recipientEmail, deleteOriginal)
+
+	case "copy-clipboard":
+		secretUUID, err := args.String("<uuid>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return secretCopyClipboard(secretUUID)
 	}
 	log.Panicf("secretSubcommand got unexpected arguments: %v", args)
 	panic(nil)
@@ -0,0 +1,112 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
+)
+
+// clipboardClearDelay is how long a secret copied with `fk secret copy-clipboard` stays on the
+// clipboard before it's cleared.
+const clipboardClearDelay = 30 * time.Second
+
+// secretCopyClipboard decrypts a secret waiting for the user's key and copies it to the
+// clipboard, so it doesn't end up in the terminal's scrollback or shell history. The clipboard
+// is cleared after clipboardClearDelay.
+func secretCopyClipboard(secretUUIDString string) exitCode {
+	secretUUID, err := uuid.FromString(secretUUIDString)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid UUID", nil, err))
+		return 1
+	}
+
+	key, code := chooseOwnKey()
+	if code != 0 {
+		return code
+	}
+
+	encryptedSecrets, err := downloadEncryptedSecrets(key.Fingerprint(), api)
+	if _, noSecrets := err.(errNoSecretsFound); noSecrets {
+		out.Print("\n📭 No secrets waiting\n\n")
+		return 1
+	} else if err != nil {
+		out.Print(ui.FormatFailure("Failed to list secrets", nil, err))
+		return 1
+	}
+
+	privateKey, _, err := getDecryptedPrivateKeyAndPassword(key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	found, err := findDecryptedSecret(secretUUID, encryptedSecrets, privateKey)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to decrypt secret", nil, err))
+		return 1
+	}
+
+	if err := systemClipboard.WriteAll(found.decryptedContent); err != nil {
+		out.Print(ui.FormatFailure("Failed to copy secret to clipboard", nil, err))
+		return 1
+	}
+	printSuccess(fmt.Sprintf("Secret copied to clipboard, expires in %d seconds.",
+		int(clipboardClearDelay.Seconds())))
+
+	if err := clearClipboardAfter(
+		found.decryptedContent, systemClipboard, time.After(clipboardClearDelay)); err != nil {
+
+		out.Print(ui.FormatFailure("Failed to clear clipboard", []string{
+			"The secret may still be on your clipboard.",
+		}, err))
+		return 1
+	}
+	return 0
+}
+
+type clipboardInterface interface {
+	ReadAll() (string, error)
+	WriteAll(text string) error
+}
+
+// atottoClipboard uses the system clipboard: pbcopy on macOS, xclip or xsel on Linux and the
+// clipboard API on Windows.
+type atottoClipboard struct{}
+
+func (atottoClipboard) ReadAll() (string, error)   { return clipboard.ReadAll() }
+func (atottoClipboard) WriteAll(text string) error { return clipboard.WriteAll(text) }
+
+var systemClipboard clipboardInterface = atottoClipboard{}
+
+// clearClipboardAfter waits until timer fires then overwrites the clipboard with an empty string,
+// unless the clipboard no longer contains content because something else has been copied since.
+func clearClipboardAfter(content string, clip clipboardInterface, timer <-chan time.Time) error {
+	<-timer
+
+	current, err := clip.ReadAll()
+	if err == nil && current != content {
+		return nil // something else has been copied: leave it alone
+	}
+	return clip.WriteAll("")
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+// fakeClipboard records what's written to it instead of running pbcopy, xclip etc.
+type fakeClipboard struct {
+	content   string
+	writes    []string
+	readError error
+}
+
+func (c *fakeClipboard) ReadAll() (string, error) {
+	return c.content, c.readError
+}
+
+func (c *fakeClipboard) WriteAll(text string) error {
+	c.content = text
+	c.writes = append(c.writes, text)
+	return nil
+}
+
+func TestClearClipboardAfter(t *testing.T) {
+	fired := func() <-chan time.Time {
+		timer := make(chan time.Time, 1)
+		timer <- time.Now()
+		return timer
+	}
+
+	t.Run("clears the clipboard once the timer fires", func(t *testing.T) {
+		clip := &fakeClipboard{}
+		assert.NoError(t, clip.WriteAll("secret"))
+
+		assert.NoError(t, clearClipboardAfter("secret", clip, fired()))
+		assert.Equal(t, "", clip.content)
+		assert.Equal(t, []string{"secret", ""}, clip.writes)
+	})
+
+	t.Run("doesn't clear before the timer fires", func(t *testing.T) {
+		clip := &fakeClipboard{content: "secret"}
+		timer := make(chan time.Time)
+		done := make(chan error)
+
+		go func() { done <- clearClipboardAfter("secret", clip, timer) }()
+
+		select {
+		case <-done:
+			t.Fatalf("cleared clipboard before the timer fired")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		timer <- time.Now()
+		assert.NoError(t, <-done)
+		assert.Equal(t, "", clip.content)
+	})
+
+	t.Run("leaves something else that's been copied since", func(t *testing.T) {
+		clip := &fakeClipboard{content: "something else"}
+
+		assert.NoError(t, clearClipboardAfter("secret", clip, fired()))
+		assert.Equal(t, "something else", clip.content)
+		assert.Equal(t, 0, len(clip.writes))
+	})
+
+	t.Run("clears anyway if the clipboard can't be read", func(t *testing.T) {
+		clip := &fakeClipboard{content: "secret", readError: fmt.Errorf("no xclip")}
+
+		assert.NoError(t, clearClipboardAfter("secret", clip, fired()))
+		assert.Equal(t, []string{""}, clip.writes)
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	fp "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
+)
+
+// secretDelete deletes a single secret waiting for the user's key, without receiving it.
+func secretDelete(secretUUIDString string) exitCode {
+	secretUUID, err := uuid.FromString(secretUUIDString)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid UUID", nil, err))
+		return 1
+	}
+
+	key, code := chooseOwnKey()
+	if code != 0 {
+		return code
+	}
+
+	if err := deleteSecret(key.Fingerprint(), secretUUID, api); err != nil {
+		out.Print(ui.FormatFailure("Failed to delete secret", nil, err))
+		return 1
+	}
+
+	out.Print(ui.FormatSuccess("Deleted secret "+secretUUID.String(), nil))
+	return 0
+}
+
+// secretDeleteAll deletes every secret waiting for the user's key, after asking to confirm.
+// The secrets' UUIDs are in their encrypted metadata, so the key has to be unlocked.
+func secretDeleteAll() exitCode {
+	key, code := chooseOwnKey()
+	if code != 0 {
+		return code
+	}
+
+	encryptedSecrets, err := downloadEncryptedSecrets(key.Fingerprint(), api)
+	if _, noSecrets := err.(errNoSecretsFound); noSecrets {
+		out.Print("\n📭 No secrets waiting\n\n")
+		return 0
+	} else if err != nil {
+		out.Print(ui.FormatFailure("Failed to list secrets", nil, err))
+		return 1
+	}
+
+	out.Print("\n")
+	prompter := interactiveYesNoPrompter{}
+	question := "Delete " + humanize.Pluralize(len(encryptedSecrets), "secret", "secrets") +
+		" without reading them?"
+	if !prompter.promptYesNo(question, "n", nil) {
+		out.Print("Not deleting anything.\n\n")
+		return 0
+	}
+
+	privateKey, _, err := getDecryptedPrivateKeyAndPassword(key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	decryptedSecrets, secretErrors := decryptSecrets(encryptedSecrets, privateKey)
+	for _, secretError := range secretErrors {
+		log.Printf("failed to decrypt secret %d: %v", secretError.Index, secretError)
+	}
+
+	secretUUIDs := []uuid.UUID{}
+	for _, secret := range decryptedSecrets {
+		secretUUIDs = append(secretUUIDs, secret.UUID)
+	}
+
+	numDeleted, err := deleteSecrets(key.Fingerprint(), secretUUIDs, api)
+	if batchError, ok := err.(*apiclient.BatchDeleteError); ok {
+		out.Print(ui.FormatFailure(
+			"Failed to delete "+humanize.Pluralize(len(batchError.Failures), "secret", "secrets"),
+			append([]string{
+				humanize.Pluralize(numDeleted, "secret was", "secrets were") + " deleted.",
+				"",
+			}, formatDeleteFailures(batchError)...),
+			nil,
+		))
+		return 1
+	} else if err != nil {
+		out.Print(ui.FormatFailure("Failed to delete secrets", nil, err))
+		return 1
+	}
+
+	if len(secretErrors) > 0 {
+		out.Print(ui.FormatWarning(
+			"Deleted "+humanize.Pluralize(numDeleted, "secret", "secrets"),
+			[]string{humanize.Pluralize(len(secretErrors), "secret", "secrets") +
+				" couldn't be decrypted, so weren't deleted."},
+			nil,
+		))
+		return 1
+	}
+
+	out.Print(ui.FormatSuccess("Deleted "+humanize.Pluralize(numDeleted, "secret", "secrets"), nil))
+	return 0
+}
+
+type secretDeleter interface {
+	DeleteSecret(fingerprint fp.Fingerprint, uuid string) error
+}
+
+// deleteSecret deletes the secret, explaining if there's no such secret waiting for fingerprint.
+func deleteSecret(fingerprint fp.Fingerprint, secretUUID uuid.UUID, deleter secretDeleter) error {
+	err := deleter.DeleteSecret(fingerprint, secretUUID.String())
+	if err == apiclient.ErrSecretNotFound {
+		return fmt.Errorf("no secret with UUID %s is waiting for %s", secretUUID, fingerprint)
+	}
+	return err
+}
+
+type secretsBatchDeleter interface {
+	BatchDeleteSecrets(fingerprint fp.Fingerprint, uuids []string) error
+}
+
+// deleteSecrets deletes the secrets together. If some of them can't be deleted the others still
+// are, and it returns the *apiclient.BatchDeleteError saying which weren't.
+func deleteSecrets(fingerprint fp.Fingerprint, secretUUIDs []uuid.UUID,
+	deleter secretsBatchDeleter) (numDeleted int, err error) {
+
+	uuids := []string{}
+	for _, secretUUID := range secretUUIDs {
+		uuids = append(uuids, secretUUID.String())
+	}
+
+	err = deleter.BatchDeleteSecrets(fingerprint, uuids)
+	if batchError, ok := err.(*apiclient.BatchDeleteError); ok {
+		return len(uuids) - len(batchError.Failures), err
+	} else if err != nil {
+		return 0, err
+	}
+	return len(uuids), nil
+}
+
+// formatDeleteFailures returns a line for each secret in batchError saying why it wasn't deleted
+func formatDeleteFailures(batchError *apiclient.BatchDeleteError) (lines []string) {
+	uuids := []string{}
+	for secretUUID := range batchError.Failures {
+		uuids = append(uuids, secretUUID)
+	}
+	sort.Strings(uuids)
+
+	for _, secretUUID := range uuids {
+		reason := batchError.Failures[secretUUID]
+		if reason == apiclient.ErrSecretNotFound {
+			lines = append(lines, secretUUID+": no longer waiting for your key")
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %v", secretUUID, reason))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/gofrs/uuid"
+)
+
+func TestDeleteSecret(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+	secretUUID := uuid.Must(uuid.FromString("d8a4a3a6-e5a8-4f35-8b4f-8b3c6a1f2e01"))
+
+	t.Run("passes the fingerprint and UUID to DeleteSecret", func(t *testing.T) {
+		mockAPI := &mock.MockClient{}
+
+		assert.NoError(t, deleteSecret(fingerprint, secretUUID, mockAPI))
+
+		calls := mockAPI.CallsTo("DeleteSecret")
+		assert.Equal(t, 1, len(calls))
+		assert.Equal(t, []interface{}{fingerprint, secretUUID.String()}, calls[0].Args)
+	})
+
+	t.Run("explains if the secret isn't found", func(t *testing.T) {
+		mockAPI := &mock.MockClient{DeleteSecretError: apiclient.ErrSecretNotFound}
+
+		err := deleteSecret(fingerprint, secretUUID, mockAPI)
+		assert.Equal(t, fmt.Errorf(
+			"no secret with UUID d8a4a3a6-e5a8-4f35-8b4f-8b3c6a1f2e01 is waiting for %s",
+			fingerprint), err)
+	})
+
+	t.Run("passes up other errors", func(t *testing.T) {
+		mockAPI := &mock.MockClient{DeleteSecretError: fmt.Errorf("API error: 500")}
+
+		assert.Equal(t, fmt.Errorf("API error: 500"), deleteSecret(fingerprint, secretUUID, mockAPI))
+	})
+}
+
+func TestDeleteSecrets(t *testing.T) {
+	fingerprint := exampledata.ExampleFingerprint4
+	secretUUIDs := []uuid.UUID{uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())}
+
+	t.Run("deletes the secrets in one batch", func(t *testing.T) {
+		mockAPI := &mock.MockClient{}
+
+		numDeleted, err := deleteSecrets(fingerprint, secretUUIDs, mockAPI)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, numDeleted)
+
+		calls := mockAPI.CallsTo("BatchDeleteSecrets")
+		assert.Equal(t, 1, len(calls))
+		assert.Equal(t, []interface{}{
+			fingerprint, []string{secretUUIDs[0].String(), secretUUIDs[1].String()},
+		}, calls[0].Args)
+	})
+
+	t.Run("counts the secrets deleted when some fail", func(t *testing.T) {
+		batchError := &apiclient.BatchDeleteError{Failures: map[string]error{
+			secretUUIDs[1].String(): apiclient.ErrSecretNotFound,
+		}}
+		mockAPI := &mock.MockClient{BatchDeleteSecretsError: batchError}
+
+		numDeleted, err := deleteSecrets(fingerprint, secretUUIDs, mockAPI)
+		assert.Equal(t, batchError, err)
+		assert.Equal(t, 1, numDeleted)
+	})
+
+	t.Run("counts none deleted for other errors", func(t *testing.T) {
+		mockAPI := &mock.MockClient{BatchDeleteSecretsError: fmt.Errorf("API error: 500")}
+
+		numDeleted, err := deleteSecrets(fingerprint, secretUUIDs, mockAPI)
+		assert.GotError(t, err)
+		assert.Equal(t, 0, numDeleted)
+	})
+}
+
+func TestFormatDeleteFailures(t *testing.T) {
+	batchError := &apiclient.BatchDeleteError{Failures: map[string]error{
+		"e9b3d0f2-0000-4000-8000-000000000002": fmt.Errorf("API error: 500"),
+		"a1c2e3f4-0000-4000-8000-000000000001": apiclient.ErrSecretNotFound,
+	}}
+
+	assert.Equal(t, []string{
+		"a1c2e3f4-0000-4000-8000-000000000001: no longer waiting for your key",
+		"e9b3d0f2-0000-4000-8000-000000000002: API error: 500",
+	}, formatDeleteFailures(batchError))
+}
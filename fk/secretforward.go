@@ -0,0 +1,159 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fluidkeys/api/v1structs"
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
+)
+
+// secretForward decrypts a secret waiting for the user's key and sends it on to someone else,
+// encrypted to their key. If deleteOriginal is true, the user's copy is deleted once it's sent.
+func secretForward(secretUUIDString string, recipientEmail string, deleteOriginal bool) exitCode {
+	secretUUID, err := uuid.FromString(secretUUIDString)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid UUID", nil, err))
+		return 1
+	}
+
+	key, code := chooseOwnKey()
+	if code != 0 {
+		return code
+	}
+
+	armoredPublicKey, err := api.GetPublicKey(recipientEmail)
+	if err == apiclient.ErrPublicKeyNotFound {
+		out.Print(ui.FormatFailure("Couldn't find "+recipientEmail+" on Fluidkeys", nil, nil))
+		return 1
+	} else if err != nil {
+		out.Print(ui.FormatFailure("Failed to get the public key for "+recipientEmail, nil, err))
+		return 1
+	}
+
+	recipientKey, err := pgpkey.LoadFromArmoredPublicKey(armoredPublicKey)
+	if err != nil {
+		out.Print(ui.FormatFailure("Couldn't load the public key", nil, err))
+		return 1
+	}
+
+	recipientKey, err = applyPinnedEncryptionSubkey(recipientKey, &db, time.Now())
+	if err != nil {
+		out.Print(ui.FormatFailure("Couldn't use the pinned subkey", []string{
+			"Pin another subkey with " + colour.Cmd("fk key pin-subkey"),
+		}, err))
+		return 1
+	}
+
+	encryptedSecrets, err := downloadEncryptedSecrets(key.Fingerprint(), api)
+	if _, noSecrets := err.(errNoSecretsFound); noSecrets {
+		out.Print("\n📭 No secrets waiting\n\n")
+		return 1
+	} else if err != nil {
+		out.Print(ui.FormatFailure("Failed to list secrets", nil, err))
+		return 1
+	}
+
+	out.Print(ui.FormatWarning("Forwarding a secret reveals its contents to "+recipientEmail,
+		[]string{
+			"They'll be able to read everything in it, and keep a copy.",
+		}, nil))
+
+	prompter := interactiveYesNoPrompter{}
+	if !prompter.promptYesNo("Forward secret to "+recipientEmail+"?", "n", nil) {
+		out.Print("Not forwarding anything.\n\n")
+		return 1
+	}
+
+	privateKey, _, err := getDecryptedPrivateKeyAndPassword(key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	err = forwardSecret(secretUUID, encryptedSecrets, privateKey, recipientKey, deleteOriginal, api)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to forward secret", nil, err))
+		return 1
+	}
+
+	if deleteOriginal {
+		printSuccess("Forwarded to " + recipientEmail + " and deleted your copy.")
+	} else {
+		printSuccess("Forwarded to " + recipientEmail + ". You should tell them to check Fluidkeys.")
+	}
+	return 0
+}
+
+// forwardSecret finds the secret with the given UUID among encryptedSecrets, decrypts it with
+// unlockedKey and sends it encrypted to recipientKey. The original is only deleted if
+// deleteOriginal is true, and only after the forwarded copy has been sent.
+func forwardSecret(secretUUID uuid.UUID, encryptedSecrets []v1structs.Secret,
+	unlockedKey *pgpkey.PgpKey, recipientKey *pgpkey.PgpKey, deleteOriginal bool,
+	client reencryptSecretsInterface) error {
+
+	found, err := findDecryptedSecret(secretUUID, encryptedSecrets, unlockedKey)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptSecret(found.decryptedContent, found.originalFilename, recipientKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %v", err)
+	}
+
+	if err := client.CreateSecret(recipientKey.Fingerprint(), encrypted); err != nil {
+		return fmt.Errorf("failed to send secret: %v", err)
+	}
+
+	if !deleteOriginal {
+		return nil
+	}
+
+	if err := client.DeleteSecret(unlockedKey.Fingerprint(), secretUUID.String()); err != nil {
+		return fmt.Errorf("forwarded the secret but failed to delete the original: %v", err)
+	}
+	return nil
+}
+
+// findDecryptedSecret decrypts encryptedSecrets with unlockedKey and returns the one with the
+// given UUID.
+func findDecryptedSecret(secretUUID uuid.UUID, encryptedSecrets []v1structs.Secret,
+	unlockedKey *pgpkey.PgpKey) (*secret, error) {
+
+	decryptedSecrets, secretErrors := decryptSecrets(encryptedSecrets, unlockedKey)
+	for _, secretError := range secretErrors {
+		log.Printf("failed to decrypt secret %d: %v", secretError.Index, secretError)
+	}
+
+	for i := range decryptedSecrets {
+		if decryptedSecrets[i].UUID == secretUUID {
+			return &decryptedSecrets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no secret with UUID %s is waiting for %s",
+		secretUUID, unlockedKey.Fingerprint())
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/api/v1structs"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/gofrs/uuid"
+)
+
+func TestForwardSecret(t *testing.T) {
+	publicKey, privateKey := loadExampleKeyPair4(t)
+
+	// example keys 2 and 3 prefer hashes which aren't compiled in, so they can't be encrypted
+	// to: forward to key 4 as well, and check the secret was freshly encrypted
+	recipientKey, recipientPrivateKey := loadExampleKeyPair4(t)
+
+	encryptedSecrets := makeEncryptedSecrets(t, publicKey, 3)
+	originals, _ := decryptSecrets(encryptedSecrets, privateKey)
+	secretUUID := originals[1].UUID
+
+	t.Run("re-encrypts the secret to the recipient", func(t *testing.T) {
+		mockAPI := &mock.MockClient{}
+
+		err := forwardSecret(secretUUID, encryptedSecrets, privateKey, recipientKey, false, mockAPI)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"CreateSecret"}, callNames(mockAPI.Calls))
+
+		call := mockAPI.CallsTo("CreateSecret")[0]
+		assert.Equal(t, recipientKey.Fingerprint(), call.Args[0])
+		assert.Equal(t, false, call.Args[1].(string) == encryptedSecrets[1].EncryptedContent)
+
+		forwarded, err := decryptAPISecret(v1structs.Secret{
+			EncryptedContent:  call.Args[1].(string),
+			EncryptedMetadata: encryptedSecrets[1].EncryptedMetadata,
+		}, recipientPrivateKey)
+		assert.NoError(t, err)
+		assert.Equal(t, originals[1].decryptedContent, forwarded.decryptedContent)
+	})
+
+	t.Run("deletes the original with deleteOriginal", func(t *testing.T) {
+		mockAPI := &mock.MockClient{}
+
+		err := forwardSecret(secretUUID, encryptedSecrets, privateKey, recipientKey, true, mockAPI)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"CreateSecret", "DeleteSecret"}, callNames(mockAPI.Calls))
+
+		call := mockAPI.CallsTo("DeleteSecret")[0]
+		assert.Equal(t, privateKey.Fingerprint(), call.Args[0])
+		assert.Equal(t, secretUUID.String(), call.Args[1])
+	})
+
+	t.Run("doesn't delete the original if sending fails", func(t *testing.T) {
+		mockAPI := &mock.MockClient{CreateSecretError: fmt.Errorf("server error")}
+
+		err := forwardSecret(secretUUID, encryptedSecrets, privateKey, recipientKey, true, mockAPI)
+		assert.Equal(t, fmt.Errorf("failed to send secret: server error"), err)
+		assert.Equal(t, []string{"CreateSecret"}, callNames(mockAPI.Calls))
+	})
+
+	t.Run("returns an error for an unknown UUID", func(t *testing.T) {
+		mockAPI := &mock.MockClient{}
+		unknownUUID := uuid.Must(uuid.FromString("c4f0d4a8-ef9d-11e9-8e1b-93f9f3b48b10"))
+
+		err := forwardSecret(unknownUUID, encryptedSecrets, privateKey, recipientKey, true, mockAPI)
+		assert.Equal(t, fmt.Errorf("no secret with UUID c4f0d4a8-ef9d-11e9-8e1b-93f9f3b48b10 "+
+			"is waiting for %s", privateKey.Fingerprint()), err)
+		assert.Equal(t, 0, len(mockAPI.Calls))
+	})
+}
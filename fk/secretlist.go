@@ -0,0 +1,164 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/colour"
+	fp "github.com/fluidkeys/fluidkeys/fingerprint"
+	outputformat "github.com/fluidkeys/fluidkeys/fk/format"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/table"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func secretList(countOnly bool, format string) exitCode {
+	keys, err := loadPgpKeys()
+	if err != nil {
+		out.Print(ui.FormatFailure("Couldn't load PGP keys", nil, err))
+		return 1
+	}
+
+	publishedKeys := []pgpkey.PgpKey{}
+	for _, key := range keys {
+		if Config.ShouldPublishToAPI(key.Fingerprint()) {
+			publishedKeys = append(publishedKeys, key)
+		}
+	}
+
+	rows, err := listPendingSecrets(publishedKeys, api)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list secrets", nil, err))
+		return 1
+	}
+
+	if countOnly {
+		out.Print(strconv.Itoa(len(rows)) + "\n")
+		return 0
+	}
+
+	if format != "" && format != outputformat.Table {
+		return printFormatted(format, secretListColumns, makeSecretListRecords(rows))
+	}
+
+	out.Print("\n")
+	if len(rows) == 0 {
+		out.Print("📭 No secrets waiting\n\n")
+		return 0
+	}
+
+	out.Print("📬 " + humanize.Pluralize(len(rows), "secret", "secrets") + " waiting:\n\n")
+	out.Print(table.FormatSecretTable(rows))
+	out.Print("Run " + colour.Cmd("fk secret receive") + " to decrypt and view them.\n\n")
+	return 0
+}
+
+var secretListColumns = []string{"recipient", "approximate_size"}
+
+// makeSecretListRecords returns a record for each secret, for output with --format
+func makeSecretListRecords(rows []table.SecretRow) []map[string]string {
+	records := []map[string]string{}
+	for _, row := range rows {
+		records = append(records, map[string]string{
+			"recipient":        row.Recipient,
+			"approximate_size": row.ApproximateSize,
+		})
+	}
+	return records
+}
+
+// listPendingSecrets lists the secrets waiting for each key, without decrypting them.
+func listPendingSecrets(keys []pgpkey.PgpKey, secretLister listSecretsWithMetadataInterface) (
+	rows []table.SecretRow, err error) {
+
+	for i := range keys {
+		key := &keys[i]
+
+		encryptedSecrets, err := secretLister.ListSecrets(key.Fingerprint())
+		if err != nil {
+			return nil, err
+		}
+
+		recipient, err := key.Email()
+		if err != nil {
+			recipient = formatFingerprint(key.Fingerprint(), fp.Fingerprint.String)
+		}
+
+		for _, encryptedSecret := range encryptedSecrets {
+			rows = append(rows, table.SecretRow{
+				Recipient:       recipient,
+				ApproximateSize: secretSize(key.Fingerprint(), encryptedSecret, secretLister),
+			})
+		}
+	}
+	return rows, nil
+}
+
+// secretSize returns the approximate size of the secret. If the server left out the encrypted
+// content but gave the secret's UUID, the size is fetched with GetSecretMetadata instead.
+func secretSize(fingerprint fp.Fingerprint, secret apiclient.Secret,
+	metadataGetter listSecretsWithMetadataInterface) string {
+
+	if secret.EncryptedContent != "" || secret.UUID == "" {
+		return approximateSize(secret.EncryptedContent)
+	}
+
+	metadata, err := metadataGetter.GetSecretMetadata(fingerprint, secret.UUID)
+	if err != nil {
+		log.Printf("failed to get metadata for secret %s: %v", secret.UUID, err)
+		return "unknown"
+	}
+	return formatSize(metadata.Size)
+}
+
+type listSecretsWithMetadataInterface interface {
+	listSecretsInterface
+	GetSecretMetadata(fingerprint fp.Fingerprint, uuid string) (*apiclient.SecretMetadata, error)
+}
+
+// approximateSize returns the size of the encrypted message, which is slightly larger than the
+// content it contains.
+func approximateSize(armoredEncrypted string) string {
+	block, err := armor.Decode(strings.NewReader(armoredEncrypted))
+	if err != nil {
+		return "unknown"
+	}
+
+	data, err := ioutil.ReadAll(block.Body)
+	if err != nil {
+		return "unknown"
+	}
+
+	return formatSize(len(data))
+}
+
+func formatSize(numBytes int) string {
+	if numBytes < 1024 {
+		return humanize.Pluralize(numBytes, "byte", "bytes")
+	}
+	return fmt.Sprintf("%.1f KB", float64(numBytes)/1024)
+}
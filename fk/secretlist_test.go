@@ -0,0 +1,126 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/api/v1structs"
+	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/table"
+)
+
+func TestListPendingSecrets(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+
+	t.Run("makes a row for each secret without decrypting", func(t *testing.T) {
+		secretLister := mock.MockClient{
+			ListSecretsSecrets: []apiclient.Secret{
+				{Secret: v1structs.Secret{EncryptedContent: makeArmoredMessage(t, 100)}},
+				{Secret: v1structs.Secret{EncryptedContent: makeArmoredMessage(t, 2048)}},
+			},
+		}
+
+		rows, err := listPendingSecrets([]pgpkey.PgpKey{*key}, &secretLister)
+		assert.NoError(t, err)
+
+		expected := []table.SecretRow{
+			{Recipient: "test2@example.com", ApproximateSize: "100 bytes"},
+			{Recipient: "test2@example.com", ApproximateSize: "2.0 KB"},
+		}
+		assert.Equal(t, expected, rows)
+
+		expectedTable := "" +
+			"#    Sent To            Size     \n" +
+			"───  ─────────────────  ─────────\n" +
+			"1    test2@example.com  100 bytes\n" +
+			"2    test2@example.com  2.0 KB   \n\n"
+		assert.Equal(t, expectedTable, colour.StripAllColourCodes(table.FormatSecretTable(rows)))
+	})
+
+	t.Run("passes up errors from ListSecrets", func(t *testing.T) {
+		secretLister := mock.MockClient{ListSecretsError: fmt.Errorf("can't connect to api")}
+
+		_, err := listPendingSecrets([]pgpkey.PgpKey{*key}, &secretLister)
+		assert.Equal(t, fmt.Errorf("can't connect to api"), err)
+	})
+
+	t.Run("returns no rows if there are no secrets", func(t *testing.T) {
+		secretLister := mock.MockClient{}
+
+		rows, err := listPendingSecrets([]pgpkey.PgpKey{*key}, &secretLister)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(rows))
+	})
+
+	t.Run("gets the size from metadata if the content is left out", func(t *testing.T) {
+		secretLister := mock.MockClient{
+			ListSecretsSecrets: []apiclient.Secret{
+				{UUID: "d8a4a3a6-e5a8-4f35-8b4f-8b3c6a1f2e01"},
+			},
+			GetSecretMetadataMetadata: &apiclient.SecretMetadata{Size: 3072},
+		}
+
+		rows, err := listPendingSecrets([]pgpkey.PgpKey{*key}, &secretLister)
+		assert.NoError(t, err)
+		assert.Equal(t, []table.SecretRow{
+			{Recipient: "test2@example.com", ApproximateSize: "3.0 KB"},
+		}, rows)
+
+		calls := secretLister.CallsTo("GetSecretMetadata")
+		assert.Equal(t, 1, len(calls))
+		assert.Equal(t, "d8a4a3a6-e5a8-4f35-8b4f-8b3c6a1f2e01", calls[0].Args[1])
+	})
+
+	t.Run("size is unknown if getting metadata fails", func(t *testing.T) {
+		secretLister := mock.MockClient{
+			ListSecretsSecrets:     []apiclient.Secret{{UUID: "d8a4a3a6-e5a8-4f35-8b4f-8b3c6a1f2e01"}},
+			GetSecretMetadataError: fmt.Errorf("not found"),
+		}
+
+		rows, err := listPendingSecrets([]pgpkey.PgpKey{*key}, &secretLister)
+		assert.NoError(t, err)
+		assert.Equal(t, "unknown", rows[0].ApproximateSize)
+	})
+}
+
+func TestApproximateSize(t *testing.T) {
+	t.Run("returns unknown for a message that isn't armored", func(t *testing.T) {
+		assert.Equal(t, "unknown", approximateSize("not armored"))
+	})
+}
+
+func makeArmoredMessage(t *testing.T, numBytes int) string {
+	t.Helper()
+	buf := bytes.NewBuffer(nil)
+	writer, err := armor.Encode(buf, "PGP MESSAGE", nil)
+	assert.NoError(t, err)
+	_, err = writer.Write(bytes.Repeat([]byte{0}, numBytes))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buf.String()
+}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -35,6 +36,7 @@ import (
 	"github.com/atotto/clipboard"
 	"github.com/fluidkeys/api/v1structs"
 	"github.com/fluidkeys/crypto/openpgp/packet"
+	"github.com/fluidkeys/fluidkeys/apiclient"
 	"github.com/fluidkeys/fluidkeys/colour"
 	"github.com/fluidkeys/fluidkeys/fingerprint"
 	fp "github.com/fluidkeys/fluidkeys/fingerprint"
@@ -128,7 +130,7 @@ func secretReceive() exitCode {
 			output := humanize.Pluralize(len(secretErrors), "secret", "secrets") + " failed to download for " + displayName(&key) + ":\n"
 			out.Print(colour.Failure(colour.StripAllColourCodes(output)))
 			for _, error := range secretErrors {
-				printFailed(error.Error())
+				printFailed(fmt.Sprintf("Secret %d: %s", error.Index+1, error.Error()))
 			}
 			sawError = true
 		}
@@ -178,17 +180,67 @@ func downloadEncryptedSecrets(fingerprint fp.Fingerprint, secretLister listSecre
 	if len(encryptedSecrets) == 0 {
 		return nil, errNoSecretsFound{}
 	}
-	return encryptedSecrets, nil
+	for _, encryptedSecret := range encryptedSecrets {
+		secrets = append(secrets, encryptedSecret.Secret)
+	}
+	return secrets, nil
 }
 
+// decryptSecrets decrypts the secrets concurrently, using up to runtime.NumCPU() workers.
+// Decrypted secrets are returned in the same order as encryptedSecrets.
 func decryptSecrets(encryptedSecrets []v1structs.Secret, privateKey *pgpkey.PgpKey) (
-	secrets []secret, secretErrors []error) {
-	for _, encryptedSecret := range encryptedSecrets {
-		secret, err := decryptAPISecret(encryptedSecret, privateKey)
-		if err != nil {
-			secretErrors = append(secretErrors, err)
+	secrets []secret, secretErrors []decryptError) {
+
+	return decryptSecretsWithWorkers(encryptedSecrets, privateKey, runtime.NumCPU())
+}
+
+// decryptSecretsWithWorkers decrypts the secrets using the given number of goroutines.
+// privateKey is shared between the goroutines: this is safe since decrypting doesn't modify
+// an already-unlocked key.
+func decryptSecretsWithWorkers(
+	encryptedSecrets []v1structs.Secret, privateKey *pgpkey.PgpKey, numWorkers int) (
+	secrets []secret, secretErrors []decryptError) {
+
+	type result struct {
+		index  int
+		secret *secret
+		err    error
+	}
+
+	if numWorkers > len(encryptedSecrets) {
+		numWorkers = len(encryptedSecrets)
+	}
+
+	indexes := make(chan int)
+	resultsChannel := make(chan result)
+
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			for index := range indexes {
+				secret, err := decryptAPISecret(encryptedSecrets[index], privateKey)
+				resultsChannel <- result{index: index, secret: secret, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for index := range encryptedSecrets {
+			indexes <- index
+		}
+		close(indexes)
+	}()
+
+	results := make([]result, len(encryptedSecrets))
+	for range encryptedSecrets {
+		result := <-resultsChannel
+		results[result.index] = result
+	}
+
+	for index, result := range results {
+		if result.err != nil {
+			secretErrors = append(secretErrors, decryptError{Index: index, Err: result.err})
 		} else {
-			secrets = append(secrets, *secret)
+			secrets = append(secrets, *result.secret)
 		}
 	}
 	return secrets, secretErrors
@@ -379,6 +431,15 @@ type secret struct {
 	UUID             uuid.UUID
 }
 
+// decryptError is a secret which couldn't be decrypted. Index is the position of the secret in
+// the list given to decryptSecrets, so the caller can tell which secret failed.
+type decryptError struct {
+	Index int
+	Err   error
+}
+
+func (e decryptError) Error() string { return e.Err.Error() }
+
 type errNoSecretsFound struct{}
 
 func (e errNoSecretsFound) Error() string { return "" }
@@ -394,7 +455,7 @@ type fileSafeToWriteInterface interface {
 }
 
 type listSecretsInterface interface {
-	ListSecrets(fingerprint fingerprint.Fingerprint) ([]v1structs.Secret, error)
+	ListSecrets(fingerprint fingerprint.Fingerprint) ([]apiclient.Secret, error)
 }
 
 type decryptorInterface interface {
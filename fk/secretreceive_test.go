@@ -24,28 +24,21 @@ import (
 
 	"github.com/fluidkeys/api/v1structs"
 	"github.com/fluidkeys/crypto/openpgp/packet"
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
 	"github.com/fluidkeys/fluidkeys/assert"
 	"github.com/fluidkeys/fluidkeys/colour"
 	"github.com/fluidkeys/fluidkeys/exampledata"
-	"github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
 	"github.com/gofrs/uuid"
 )
 
-type mockListSecrets struct {
-	mockSecrets []v1structs.Secret
-	mockError   error
-}
-
-func (m *mockListSecrets) ListSecrets(fingerprint fingerprint.Fingerprint) ([]v1structs.Secret, error) {
-	return m.mockSecrets, m.mockError
-}
-
 func TestDownloadEncryptedSecrets(t *testing.T) {
 	fingerprint := exampledata.ExampleFingerprint4
 
 	t.Run("passes up errors from ListSecrets", func(t *testing.T) {
-		secretLister := mockListSecrets{
-			mockError: fmt.Errorf("can't connect to api"),
+		secretLister := mock.MockClient{
+			ListSecretsError: fmt.Errorf("can't connect to api"),
 		}
 
 		_, err := downloadEncryptedSecrets(fingerprint, &secretLister)
@@ -54,7 +47,7 @@ func TestDownloadEncryptedSecrets(t *testing.T) {
 	})
 
 	t.Run("returns a particular error (errNoSecretsFound) if no secrets are found", func(t *testing.T) {
-		secretLister := mockListSecrets{}
+		secretLister := mock.MockClient{}
 
 		_, err := downloadEncryptedSecrets(fingerprint, &secretLister)
 		assert.Equal(t, errNoSecretsFound{}, err)
@@ -72,10 +65,10 @@ func TestDownloadEncryptedSecrets(t *testing.T) {
 			},
 		}
 
-		secretLister := mockListSecrets{
-			mockSecrets: mockSecrets,
-			mockError:   nil,
-		}
+		secretLister := mock.MockClient{ListSecretsSecrets: []apiclient.Secret{
+			{Secret: mockSecrets[0]},
+			{Secret: mockSecrets[1]},
+		}}
 
 		gotSecrets, err := downloadEncryptedSecrets(fingerprint, &secretLister)
 		assert.NoError(t, err)
@@ -362,3 +355,101 @@ func TestGenerateIncrementedFilenames(t *testing.T) {
 	}
 	assert.Equal(t, expected, gotFilenames)
 }
+
+func TestDecryptSecrets(t *testing.T) {
+	publicKey, privateKey := loadExampleKeyPair4(t)
+
+	validSecrets := makeEncryptedSecrets(t, publicKey, 10)
+	invalidSecret := v1structs.Secret{
+		EncryptedContent:  "not encrypted",
+		EncryptedMetadata: "not encrypted",
+	}
+
+	encryptedSecrets := []v1structs.Secret{}
+	encryptedSecrets = append(encryptedSecrets, validSecrets[:3]...)
+	encryptedSecrets = append(encryptedSecrets, invalidSecret)
+	encryptedSecrets = append(encryptedSecrets, validSecrets[3:]...)
+	encryptedSecrets = append(encryptedSecrets, invalidSecret)
+
+	for _, numWorkers := range []int{1, 4, 100} {
+		t.Run(fmt.Sprintf("with %d workers", numWorkers), func(t *testing.T) {
+			secrets, secretErrors := decryptSecretsWithWorkers(
+				encryptedSecrets, privateKey, numWorkers)
+
+			t.Run("returns decrypted secrets in order", func(t *testing.T) {
+				assert.Equal(t, 10, len(secrets))
+				for i, secret := range secrets {
+					assert.Equal(t, fmt.Sprintf("secret %d", i), secret.decryptedContent)
+				}
+			})
+
+			t.Run("returns errors with the index of the failed secret", func(t *testing.T) {
+				assert.Equal(t, 2, len(secretErrors))
+				assert.Equal(t, 3, secretErrors[0].Index)
+				assert.Equal(t, 11, secretErrors[1].Index)
+			})
+		})
+	}
+
+	t.Run("with no secrets", func(t *testing.T) {
+		secrets, secretErrors := decryptSecrets([]v1structs.Secret{}, privateKey)
+		assert.Equal(t, 0, len(secrets))
+		assert.Equal(t, 0, len(secretErrors))
+	})
+}
+
+func BenchmarkDecryptSecrets(b *testing.B) {
+	publicKey, privateKey := loadExampleKeyPair4(b)
+	encryptedSecrets := makeEncryptedSecrets(b, publicKey, 60)
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			decryptSecretsWithWorkers(encryptedSecrets, privateKey, 1)
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			decryptSecrets(encryptedSecrets, privateKey)
+		}
+	})
+}
+
+func loadExampleKeyPair4(t testing.TB) (publicKey *pgpkey.PgpKey, privateKey *pgpkey.PgpKey) {
+	t.Helper()
+
+	publicKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	if err != nil {
+		t.Fatalf("failed to load public key: %v", err)
+	}
+	privateKey, err = pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	if err != nil {
+		t.Fatalf("failed to load private key: %v", err)
+	}
+	return publicKey, privateKey
+}
+
+// makeEncryptedSecrets returns secrets as they'd be returned by the API, with the content of the
+// n'th secret being "secret n"
+func makeEncryptedSecrets(t testing.TB, publicKey *pgpkey.PgpKey, count int) []v1structs.Secret {
+	t.Helper()
+
+	secrets := []v1structs.Secret{}
+	for i := 0; i < count; i++ {
+		content, err := encryptSecret(fmt.Sprintf("secret %d", i), "", publicKey)
+		if err != nil {
+			t.Fatalf("failed to encrypt secret: %v", err)
+		}
+		metadata, err := encryptSecret(
+			fmt.Sprintf(`{"secretUuid": "%s"}`, uuid.Must(uuid.NewV4())), "", publicKey)
+		if err != nil {
+			t.Fatalf("failed to encrypt metadata: %v", err)
+		}
+		secrets = append(secrets, v1structs.Secret{
+			EncryptedContent:  content,
+			EncryptedMetadata: metadata,
+		})
+	}
+	return secrets
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/fluidkeys/api/v1structs"
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// secretReencryptAll re-encrypts every secret waiting for each of the user's keys to the key's
+// current encryption subkey, so secrets sent before a key rotation stay readable once the old
+// subkey is gone.
+func secretReencryptAll() exitCode {
+	keys, err := loadPgpKeys()
+	if err != nil {
+		out.Print(ui.FormatFailure("Couldn't load PGP keys", nil, err))
+		return 1
+	}
+
+	sawError := false
+
+	for i := range keys {
+		key := &keys[i]
+
+		if !Config.ShouldPublishToAPI(key.Fingerprint()) {
+			continue
+		}
+
+		encryptedSecrets, err := downloadEncryptedSecrets(key.Fingerprint(), api)
+		if err != nil {
+			switch err.(type) {
+			case errNoSecretsFound:
+				out.Print("📭 " + displayName(key) + ": No secrets found\n")
+			default:
+				out.Print(ui.FormatFailure("Failed to list secrets for "+displayName(key), nil, err))
+				sawError = true
+			}
+			continue
+		}
+
+		privateKey, _, err := getDecryptedPrivateKeyAndPassword(key, &interactivePasswordPrompter{})
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to unlock "+displayName(key), nil, err))
+			sawError = true
+			continue
+		}
+
+		numReencrypted, err := reencryptSecrets(encryptedSecrets, privateKey, api)
+		if numReencrypted > 0 {
+			printSuccess(displayName(key) + ": re-encrypted " +
+				humanize.Pluralize(numReencrypted, "secret", "secrets"))
+		}
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to re-encrypt secrets for "+displayName(key), []string{
+				"Re-encrypting requires the private keys for every encryption subkey the",
+				"secrets were sent to, including ones which have since been rotated.",
+			}, err))
+			sawError = true
+		}
+	}
+
+	if sawError {
+		return 1
+	}
+	return 0
+}
+
+// reencryptSecrets decrypts every secret with unlockedKey, then re-encrypts each one to
+// unlockedKey's current encryption subkey, uploads it and deletes the original.
+// All the secrets are decrypted before any are changed: if any can't be decrypted, nothing is
+// uploaded or deleted. An original is only deleted once its replacement has been uploaded.
+func reencryptSecrets(encryptedSecrets []v1structs.Secret, unlockedKey *pgpkey.PgpKey,
+	client reencryptSecretsInterface) (numReencrypted int, err error) {
+
+	decryptedSecrets, secretErrors := decryptSecrets(encryptedSecrets, unlockedKey)
+	if len(secretErrors) > 0 {
+		return 0, fmt.Errorf("%s couldn't be decrypted, so no secrets were changed: "+
+			"secret %d: %v", humanize.Pluralize(len(secretErrors), "secret", "secrets"),
+			secretErrors[0].Index+1, secretErrors[0].Err)
+	}
+
+	for _, secret := range decryptedSecrets {
+		reencrypted, err := encryptSecret(
+			secret.decryptedContent, secret.originalFilename, unlockedKey)
+		if err != nil {
+			return numReencrypted, fmt.Errorf("failed to encrypt secret: %v", err)
+		}
+
+		if err := client.CreateSecret(unlockedKey.Fingerprint(), reencrypted); err != nil {
+			return numReencrypted, fmt.Errorf("failed to upload re-encrypted secret: %v", err)
+		}
+
+		if err := client.DeleteSecret(unlockedKey.Fingerprint(), secret.UUID.String()); err != nil {
+			// the re-encrypted copy is uploaded, so the secret isn't lost, but the original
+			// will be received as a duplicate if it can still be decrypted.
+			log.Printf("failed to delete original secret '%s': %v", secret.UUID, err)
+			return numReencrypted + 1, fmt.Errorf(
+				"uploaded re-encrypted secret but failed to delete the original: %v", err)
+		}
+		numReencrypted++
+	}
+	return numReencrypted, nil
+}
+
+type reencryptSecretsInterface interface {
+	CreateSecret(recipientFingerprint fpr.Fingerprint, armoredEncryptedSecret string,
+		options ...apiclient.CreateSecretOption) error
+	DeleteSecret(fingerprint fpr.Fingerprint, uuid string) error
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/api/v1structs"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestReencryptSecrets(t *testing.T) {
+	publicKey, privateKey := loadExampleKeyPair4(t)
+
+	t.Run("uploads each re-encrypted secret then deletes the original", func(t *testing.T) {
+		encryptedSecrets := makeEncryptedSecrets(t, publicKey, 3)
+		originals, _ := decryptSecrets(encryptedSecrets, privateKey)
+		mockAPI := &mock.MockClient{}
+
+		numReencrypted, err := reencryptSecrets(encryptedSecrets, privateKey, mockAPI)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, numReencrypted)
+
+		assert.Equal(t, []string{
+			"CreateSecret", "DeleteSecret",
+			"CreateSecret", "DeleteSecret",
+			"CreateSecret", "DeleteSecret",
+		}, callNames(mockAPI.Calls))
+
+		for i, call := range mockAPI.CallsTo("CreateSecret") {
+			assert.Equal(t, privateKey.Fingerprint(), call.Args[0])
+
+			reencrypted, err := decryptAPISecret(v1structs.Secret{
+				EncryptedContent:  call.Args[1].(string),
+				EncryptedMetadata: encryptedSecrets[i].EncryptedMetadata,
+			}, privateKey)
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("secret %d", i), reencrypted.decryptedContent)
+		}
+
+		for i, call := range mockAPI.CallsTo("DeleteSecret") {
+			assert.Equal(t, originals[i].UUID.String(), call.Args[1])
+		}
+	})
+
+	t.Run("changes nothing if any secret can't be decrypted", func(t *testing.T) {
+		encryptedSecrets := append(makeEncryptedSecrets(t, publicKey, 2), v1structs.Secret{
+			EncryptedContent:  "not encrypted",
+			EncryptedMetadata: "not encrypted",
+		})
+		mockAPI := &mock.MockClient{}
+
+		numReencrypted, err := reencryptSecrets(encryptedSecrets, privateKey, mockAPI)
+		assert.GotError(t, err)
+		assert.Equal(t, 0, numReencrypted)
+		assert.Equal(t, 0, len(mockAPI.Calls))
+	})
+
+	t.Run("doesn't delete the original if uploading fails", func(t *testing.T) {
+		encryptedSecrets := makeEncryptedSecrets(t, publicKey, 2)
+		mockAPI := &mock.MockClient{CreateSecretError: fmt.Errorf("server error")}
+
+		numReencrypted, err := reencryptSecrets(encryptedSecrets, privateKey, mockAPI)
+		assert.Equal(t, fmt.Errorf("failed to upload re-encrypted secret: server error"), err)
+		assert.Equal(t, 0, numReencrypted)
+		assert.Equal(t, []string{"CreateSecret"}, callNames(mockAPI.Calls))
+	})
+}
+
+func callNames(calls []mock.Call) (names []string) {
+	for _, call := range calls {
+		names = append(names, call.Method)
+	}
+	return names
+}
@@ -22,9 +22,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/fluidkeys/crypto/openpgp"
@@ -35,9 +38,51 @@ import (
 	"github.com/fluidkeys/fluidkeys/pgpkey"
 	"github.com/fluidkeys/fluidkeys/policy"
 	"github.com/fluidkeys/fluidkeys/stringutils"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
 )
 
-func secretSend(recipientEmail string, filename string) exitCode {
+func secretSend(recipientEmail string, filename string, expiresIn string,
+	teamFlag string) exitCode {
+
+	var expiryDuration time.Duration
+	if expiresIn != "" {
+		var err error
+		if expiryDuration, err = parseDuration(expiresIn); err != nil {
+			printFailed("Invalid --expires-in: " + err.Error())
+			return 1
+		}
+	}
+
+	var teamMember *team.Person
+	if teamFlag != "" {
+		teamUUID, err := uuid.FromString(teamFlag)
+		if err != nil {
+			out.Print(ui.FormatFailure("Invalid --team", nil, err))
+			return 1
+		}
+
+		memberships, err := user.Memberships()
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+			return 1
+		}
+
+		teamMember, err = findRecipientInTeam(recipientEmail, teamUUID, memberships)
+		if notInTeam, ok := err.(notInTeamError); ok {
+			out.Print(ui.FormatFailure(notInTeam.Error(), append(
+				[]string{"The team's current members are:", ""}, notInTeam.memberEmails...,
+			), nil))
+			return 1
+		} else if err != nil {
+			out.Print(ui.FormatFailure("Couldn't check the team roster", []string{
+				"Run " + colour.Cmd("fk team fetch") + " to get the latest roster.",
+			}, err))
+			return 1
+		}
+	}
+
 	armoredPublicKey, err := api.GetPublicKey(recipientEmail)
 	if err != nil {
 		if err == apiclient.ErrPublicKeyNotFound {
@@ -66,6 +111,23 @@ https://download.fluidkeys.com#` + recipientEmail + `
 		return 1
 	}
 
+	if teamMember != nil && pgpKey.Fingerprint() != teamMember.Fingerprint {
+		out.Print(ui.FormatFailure("The key for "+recipientEmail+" doesn't match the team roster",
+			[]string{
+				"Fluidkeys has key " + displayFingerprint(pgpKey.Fingerprint()),
+				"but the team roster has key " + displayFingerprint(teamMember.Fingerprint),
+			}, nil))
+		return 1
+	}
+
+	pgpKey, err = applyPinnedEncryptionSubkey(pgpKey, &db, time.Now())
+	if err != nil {
+		printFailed("Couldn't use the pinned subkey:")
+		out.Print("Error: " + err.Error() + "\n")
+		out.Print("Pin another subkey with fk key pin-subkey\n")
+		return 1
+	}
+
 	_, err = encryptSecret("dummy data to test encryption", "", pgpKey)
 	if err != nil {
 		printFailed("Couldn't encrypt to the key:")
@@ -123,7 +185,18 @@ https://download.fluidkeys.com#` + recipientEmail + `
 		return 1
 	}
 
-	err = api.CreateSecret(pgpKey.Fingerprint(), encryptedSecret)
+	var options []apiclient.CreateSecretOption
+	if expiryDuration != 0 {
+		var warning string
+		options, warning = secretExpiryOptions(expiryDuration, api, time.Now())
+		if warning != "" {
+			out.Print(ui.FormatWarning(warning, []string{
+				"The secret will be sent without an expiry time.",
+			}, nil))
+		}
+	}
+
+	err = api.CreateSecret(pgpKey.Fingerprint(), encryptedSecret, options...)
 	if err != nil {
 		printFailed("Couldn't send the secret to " + recipientEmail)
 		out.Print("Error: " + err.Error() + "\n")
@@ -134,6 +207,49 @@ https://download.fluidkeys.com#` + recipientEmail + `
 	return 0
 }
 
+// parseDuration parses a duration given on the command line, such as "30m", "12h" or "7d"
+func parseDuration(durationString string) (time.Duration, error) {
+	var duration time.Duration
+
+	if strings.HasSuffix(durationString, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(durationString, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days '%s'", durationString)
+		}
+		duration = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		if duration, err = time.ParseDuration(durationString); err != nil {
+			return 0, fmt.Errorf("invalid duration '%s': use e.g. 30m, 12h or 7d", durationString)
+		}
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return duration, nil
+}
+
+// secretExpiryOptions returns the option to make a secret expire after expiresIn. If the
+// server doesn't support secret expiry it returns no options and a warning explaining why.
+func secretExpiryOptions(expiresIn time.Duration, capabilitiesGetter getServerCapabilitiesInterface,
+	now time.Time) (options []apiclient.CreateSecretOption, warning string) {
+
+	capabilities, err := capabilitiesGetter.GetServerCapabilities()
+	if err != nil {
+		log.Printf("failed to get server capabilities: %v", err)
+		return nil, "Couldn't check whether the server supports secrets that expire"
+	}
+	if !capabilities.SupportsSecretExpiry {
+		return nil, "The server doesn't support secrets that expire"
+	}
+	return []apiclient.CreateSecretOption{apiclient.WithExpiresAt(now.Add(expiresIn))}, ""
+}
+
+type getServerCapabilitiesInterface interface {
+	GetServerCapabilities() (*apiclient.ServerCapabilities, error)
+}
+
 func getSecretFromFile(filename string, fileReader ioutilReadFileInterface) (string, error) {
 	if fileReader == nil {
 		fileReader = &ioutilReadFilePassthrough{}
@@ -194,15 +310,25 @@ func (s *stdinReader) scanUntilEOF() (message string, err error) {
 }
 
 func encryptSecret(secret string, filename string, pgpKey *pgpkey.PgpKey) (string, error) {
+	if !pgpKey.HasEncryptionCapability() {
+		return "", pgpkey.ErrNoEncryptionCapability
+	}
+
 	buffer := bytes.NewBuffer(nil)
 	message, err := armor.Encode(buffer, "PGP MESSAGE", nil)
 	if err != nil {
 		return "", err
 	}
 
+	// encrypt to the subkey picked by SubkeyForEncryption, rather than leaving openpgp to pick
+	recipient := pgpKey.Entity
+	if subkey, err := pgpKey.SubkeyForEncryption(); err == nil {
+		recipient.Subkeys = []openpgp.Subkey{*subkey}
+	}
+
 	pgpWriteCloser, err := openpgp.Encrypt(
 		message,
-		[]*openpgp.Entity{&pgpKey.Entity},
+		[]*openpgp.Entity{&recipient},
 		nil,
 		makeFileHintsForFilename(filename),
 		nil,
@@ -6,15 +6,82 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fluidkeys/crypto/openpgp"
 	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
 	"github.com/fluidkeys/fluidkeys/assert"
 	"github.com/fluidkeys/fluidkeys/colour"
 	"github.com/fluidkeys/fluidkeys/exampledata"
 	"github.com/fluidkeys/fluidkeys/pgpkey"
 )
 
+func TestParseDuration(t *testing.T) {
+	goodTests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"30m", 30 * time.Minute},
+		{"12h", 12 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+	}
+	for _, test := range goodTests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := parseDuration(test.input)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+
+	badTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"", "invalid duration '': use e.g. 30m, 12h or 7d"},
+		{"soon", "invalid duration 'soon': use e.g. 30m, 12h or 7d"},
+		{"xd", "invalid number of days 'xd'"},
+		{"0d", "duration must be positive"},
+		{"-1h", "duration must be positive"},
+	}
+	for _, test := range badTests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := parseDuration(test.input)
+			assert.GotError(t, err)
+			assert.Equal(t, test.expectedError, err.Error())
+		})
+	}
+}
+
+func TestSecretExpiryOptions(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("server supports expiry", func(t *testing.T) {
+		mockAPI := &mock.MockClient{
+			GetServerCapabilitiesCapabilities: &apiclient.ServerCapabilities{
+				SupportsSecretExpiry: true,
+			},
+		}
+		options, warning := secretExpiryOptions(time.Hour, mockAPI, now)
+		assert.Equal(t, "", warning)
+		assert.Equal(t, 1, len(options))
+	})
+
+	t.Run("server doesn't support expiry", func(t *testing.T) {
+		options, warning := secretExpiryOptions(time.Hour, &mock.MockClient{}, now)
+		assert.Equal(t, "The server doesn't support secrets that expire", warning)
+		assert.Equal(t, 0, len(options))
+	})
+
+	t.Run("capabilities request fails", func(t *testing.T) {
+		mockAPI := &mock.MockClient{GetServerCapabilitiesError: fmt.Errorf("timeout")}
+		options, warning := secretExpiryOptions(time.Hour, mockAPI, now)
+		assert.Equal(t, "Couldn't check whether the server supports secrets that expire", warning)
+		assert.Equal(t, 0, len(options))
+	})
+}
+
 func TestEncryptSecret(t *testing.T) {
 	secret := "Secret message!"
 
@@ -47,6 +114,14 @@ func TestEncryptSecret(t *testing.T) {
 		}
 	})
 
+	t.Run("with a key that has no encryption subkey", func(t *testing.T) {
+		signingOnlyKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+		signingOnlyKey.Subkeys = nil
+
+		_, err = encryptSecret(secret, "", signingOnlyKey)
+		assert.Equal(t, pgpkey.ErrNoEncryptionCapability, err)
+	})
 }
 
 type mockReadFile struct {
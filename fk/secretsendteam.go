@@ -0,0 +1,66 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluidkeys/fluidkeys/team"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
+)
+
+// notInTeamError is returned by findRecipientInTeam if the recipient isn't a member of the team.
+type notInTeamError struct {
+	email        string
+	teamName     string
+	memberEmails []string
+}
+
+func (e notInTeamError) Error() string {
+	return fmt.Sprintf("%s isn't a member of %s", e.email, e.teamName)
+}
+
+// findRecipientInTeam returns the person with the given email in the roster of the team with
+// the given UUID, which must be one of memberships.
+// If there's no such person it returns a notInTeamError listing the team's members, to help
+// pick the right address.
+func findRecipientInTeam(email string, teamUUID uuid.UUID,
+	memberships []userpackage.TeamMembership) (*team.Person, error) {
+
+	for _, membership := range memberships {
+		if membership.Team.UUID != teamUUID {
+			continue
+		}
+
+		memberEmails := []string{}
+		for i, person := range membership.Team.People {
+			if strings.EqualFold(person.Email, email) {
+				return &membership.Team.People[i], nil
+			}
+			memberEmails = append(memberEmails, person.Email)
+		}
+		return nil, notInTeamError{
+			email:        email,
+			teamName:     membership.Team.Name,
+			memberEmails: memberEmails,
+		}
+	}
+	return nil, fmt.Errorf("you aren't a member of team %s", teamUUID)
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/team"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
+)
+
+func TestFindRecipientInTeam(t *testing.T) {
+	me := team.Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	other := team.Person{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2}
+	kiffix := team.Team{
+		UUID:   uuid.Must(uuid.NewV4()),
+		Name:   "Kiffix",
+		People: []team.Person{me, other},
+	}
+	memberships := []userpackage.TeamMembership{{Team: kiffix, Me: me}}
+
+	t.Run("returns a member of the team, ignoring case", func(t *testing.T) {
+		got, err := findRecipientInTeam("Test2@Example.com", kiffix.UUID, memberships)
+		assert.NoError(t, err)
+		assert.Equal(t, other, *got)
+	})
+
+	t.Run("rejects someone who isn't in the team, listing the members", func(t *testing.T) {
+		_, err := findRecipientInTeam("test3@example.com", kiffix.UUID, memberships)
+		assert.Equal(t, notInTeamError{
+			email:        "test3@example.com",
+			teamName:     "Kiffix",
+			memberEmails: []string{"test4@example.com", "test2@example.com"},
+		}, err)
+		assert.Equal(t, "test3@example.com isn't a member of Kiffix", err.Error())
+	})
+
+	t.Run("errors for a team the user isn't in", func(t *testing.T) {
+		otherUUID := uuid.Must(uuid.NewV4())
+		_, err := findRecipientInTeam("test2@example.com", otherUUID, memberships)
+		assert.Equal(t, fmt.Errorf("you aren't a member of team %s", otherUUID), err)
+	})
+}
@@ -19,14 +19,21 @@ package fk
 
 import (
 	"fmt"
+	"log"
 	"math/rand"
 	"strings"
 	"time"
 
 	"github.com/fluidkeys/fluidkeys/colour"
 	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/fluidkeys/fluidkeys/wizard"
+	"github.com/gofrs/uuid"
 )
 
+// setup walks a new user through getting started with Fluidkeys. Each step is skipped if it's
+// already been done, so if setup stops part way, running it again carries on from there.
 func setup(email string) exitCode {
 	out.Print("\n")
 
@@ -35,38 +42,265 @@ func setup(email string) exitCode {
 
 	out.Print("Fluidkeys makes it easy to send end-to-end encrypted secrets using PGP.\n")
 
-	exitCode, pgpKey := keyCreate(email)
-	if exitCode != 0 {
-		return exitCode
+	prompter := &interactiveYesNoPrompter{}
+	state := &setupState{email: email}
+
+	steps := []wizard.Step{
+		&setupKeyStep{state: state, prompter: prompter, promptForKey: promptForKeyByNumber},
+		&setupUploadStep{state: state, prompter: prompter},
+		&setupTeamStep{
+			prompter:    prompter,
+			isInTeam:    isInOrJoiningTeam,
+			promptInput: promptForInput,
+			join:        func(uuidOrDomain string) exitCode { return teamApplyTo(uuidOrDomain, "") },
+			create:      teamCreate,
+		},
+		&setupFetchStep{
+			isInTeam: isInOrJoiningTeam,
+			fetch:    func() exitCode { return teamFetch(false, false, false, uuid.Nil) },
+		},
 	}
 
-	encryptedSecret, err := encryptSecret(secretSquirrelMessage(), "", pgpKey)
-	if err != nil {
-		printFailed("Couldn't encrypt a test secret message:")
-		out.Print("Error: " + err.Error() + "\n")
+	if err := wizard.Run(steps, announceSetupStep); err != nil {
+		out.Print(ui.FormatFailure("Setup didn't finish", []string{
+			"Run " + colour.Cmd("fk setup") + " again to carry on from where it stopped.",
+		}, err))
 		return 1
 	}
 
-	email, err = pgpKey.Email()
+	out.Print(ui.FormatSuccess("You're all set up", nil))
+	return 0
+}
+
+func announceSetupStep(step wizard.Step, skipping bool) {
+	if skipping {
+		out.Print(colour.Disabled(" ▸   "+step.Name()+": already done") + "\n\n")
+		return
+	}
+	printHeader(step.Name())
+}
+
+// setupState is shared between the steps of fk setup
+type setupState struct {
+	email string         // email to make a key for, or empty to prompt for one
+	key   *pgpkey.PgpKey // the user's key, once found or created
+}
+
+// setupKeyStep connects a key the user already has in GnuPG, or creates a new one
+type setupKeyStep struct {
+	state        *setupState
+	prompter     promptYesNoInterface
+	promptForKey func(keys []pgpkey.PgpKey) *pgpkey.PgpKey
+}
+
+func (s *setupKeyStep) Name() string { return "Find or create your key" }
+
+// Completed returns true if the user already has a key in Fluidkeys, choosing which one to use
+// for the rest of setup.
+func (s *setupKeyStep) Completed() bool {
+	if s.state.key != nil {
+		return true
+	}
+
+	keys, err := loadPgpKeys()
+	if err != nil || len(keys) == 0 {
+		return false
+	}
+	s.state.key = s.chooseKey(keys)
+	return true
+}
+
+// chooseKey returns the only key, or the key for the email given to fk setup. Otherwise it asks
+// the user which key to use.
+func (s *setupKeyStep) chooseKey(keys []pgpkey.PgpKey) *pgpkey.PgpKey {
+	if len(keys) == 1 {
+		return &keys[0]
+	}
+
+	if s.state.email != "" {
+		for i := range keys {
+			for _, email := range keys[i].Emails(true) {
+				if strings.EqualFold(email, s.state.email) {
+					return &keys[i]
+				}
+			}
+		}
+	}
+
+	printHeader("Which is your team email address?")
+	if err := printEmailsWithNumbers(keys); err != nil {
+		log.Printf("failed to list emails: %v", err)
+	}
+	return s.promptForKey(keys)
+}
+
+func (s *setupKeyStep) Run() error {
+	availableKeys, err := keysAvailableToGetFromGpg()
 	if err != nil {
-		printFailed("Couldn't get email address for key:")
-		out.Print("Error: " + err.Error() + "\n")
-		return 1
+		log.Printf("failed to list keys available in GnuPG: %v", err)
+	}
+
+	if len(availableKeys) > 0 &&
+		s.prompter.promptYesNo("Use a key you already have in GnuPG?", "y", nil) {
+
+		if code := keyFromGpg(); code != 0 {
+			return fmt.Errorf("failed to connect key from GnuPG")
+		}
+		if !s.Completed() {
+			return fmt.Errorf("no key was connected")
+		}
+		return nil
+	}
+
+	code, key := keyCreate(s.state.email)
+	if code != 0 {
+		return fmt.Errorf("failed to create key")
+	}
+	s.state.key = key
+
+	return sendTestSecret(key)
+}
+
+// setupUploadStep publishes the user's key to the Fluidkeys directory so that other people can
+// send them secrets
+type setupUploadStep struct {
+	state    *setupState
+	prompter promptYesNoInterface
+}
+
+func (s *setupUploadStep) Name() string { return "Upload your key" }
+
+func (s *setupUploadStep) Completed() bool {
+	if s.state.key == nil || !Config.ShouldPublishToAPI(s.state.key.Fingerprint()) {
+		return false
+	}
+	_, err := api.GetPublicKeyByFingerprint(s.state.key.Fingerprint())
+	return err == nil
+}
+
+func (s *setupUploadStep) Run() error {
+	if s.state.key == nil {
+		return fmt.Errorf("no key to upload")
+	}
+
+	out.Print("Uploading your public key lets other people find it and send you secrets.\n\n")
+	if !s.prompter.promptYesNo("Upload your key to Fluidkeys?", "y", nil) {
+		out.Print("You can upload it later by running " + colour.Cmd("fk key upload") + "\n\n")
+		return nil
 	}
 
-	err = api.CreateSecret(pgpKey.Fingerprint(), encryptedSecret)
+	unlockedKey, _, err := getDecryptedPrivateKeyAndPassword(
+		s.state.key, &interactivePasswordPrompter{})
 	if err != nil {
-		printFailed("Couldn't send a test secret to " + email)
-		out.Print("Error: " + err.Error() + "\n")
-		return 1
+		return fmt.Errorf("failed to unlock private key: %v", err)
+	}
+
+	if err := Config.SetPublishToAPI(unlockedKey.Fingerprint(), true); err != nil {
+		return fmt.Errorf("failed to enable uploading key: %v", err)
+	}
+	if err := publishKeyToAPI(unlockedKey); err != nil {
+		return err
+	}
+	printSuccess("Uploaded your key")
+	return nil
+}
+
+// setupTeamStep offers to join an existing team or create a new one. Both are optional.
+type setupTeamStep struct {
+	prompter    promptYesNoInterface
+	isInTeam    func() (bool, error)
+	promptInput func(prompt string) string
+	join        func(uuidOrDomain string) exitCode
+	create      func() exitCode
+}
+
+func (s *setupTeamStep) Name() string { return "Join or create a team" }
+
+func (s *setupTeamStep) Completed() bool {
+	inTeam, err := s.isInTeam()
+	if err != nil {
+		log.Printf("failed to check for teams: %v", err)
+		return false
+	}
+	return inTeam
+}
+
+func (s *setupTeamStep) Run() error {
+	if s.prompter.promptYesNo("Join an existing team?", "y", nil) {
+		uuidOrDomain := s.promptInput("[team UUID or domain] : ")
+		if code := s.join(uuidOrDomain); code != 0 {
+			return fmt.Errorf("failed to request to join team")
+		}
+		return nil
+	}
+
+	if s.prompter.promptYesNo("Create a new team?", "n", nil) {
+		if code := s.create(); code != 0 {
+			return fmt.Errorf("failed to create team")
+		}
+		return nil
+	}
+
+	out.Print("You can join a team later by running " +
+		colour.Cmd("fk team apply <uuid-or-domain>") + "\n\n")
+	return nil
+}
+
+// setupFetchStep fetches the roster and keys for the user's teams
+type setupFetchStep struct {
+	isInTeam func() (bool, error)
+	fetch    func() exitCode
+}
+
+func (s *setupFetchStep) Name() string { return "Fetch your team" }
+
+// Completed returns true if there's no team to fetch. Otherwise the team is always fetched, since
+// it's how a request to join is found to have been approved.
+func (s *setupFetchStep) Completed() bool {
+	inTeam, err := s.isInTeam()
+	if err != nil {
+		log.Printf("failed to check for teams: %v", err)
+		return false
+	}
+	return !inTeam
+}
+
+func (s *setupFetchStep) Run() error {
+	if code := s.fetch(); code != 0 {
+		return fmt.Errorf("failed to fetch team")
+	}
+	return nil
+}
+
+// isInOrJoiningTeam returns true if the user is in a team or has asked to join one
+func isInOrJoiningTeam() (bool, error) {
+	memberships, err := user.Memberships()
+	if err != nil {
+		return false, err
+	}
+	requests, err := user.RequestsToJoinTeams()
+	if err != nil {
+		return false, err
+	}
+	return len(memberships) > 0 || len(requests) > 0, nil
+}
+
+// sendTestSecret sends a secret to the new key, so the user can try receiving it
+func sendTestSecret(key *pgpkey.PgpKey) error {
+	encryptedSecret, err := encryptSecret(secretSquirrelMessage(), "", key)
+	if err != nil {
+		return fmt.Errorf("couldn't encrypt a test secret message: %v", err)
+	}
+
+	if err := api.CreateSecret(key.Fingerprint(), encryptedSecret); err != nil {
+		return fmt.Errorf("couldn't send a test secret: %v", err)
 	}
 
 	time.Sleep(3 * time.Second)
 
 	out.Print("🛎️  You've got a new secret. Read it by running:\n\n")
 	out.Print(colour.Cmd("fk secret receive") + "\n\n")
-
-	return 0
+	return nil
 }
 
 func secretSquirrelMessage() (message string) {
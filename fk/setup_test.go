@@ -0,0 +1,195 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/wizard"
+)
+
+func TestSetupTeamAndFetchSteps(t *testing.T) {
+	// makeSteps returns the team and fetch steps, recording what they do in calls
+	makeSteps := func(prompter *mockYesNoPrompter, inTeam *bool, calls *[]string) []wizard.Step {
+		isInTeam := func() (bool, error) { return *inTeam, nil }
+
+		return []wizard.Step{
+			&setupTeamStep{
+				prompter:    prompter,
+				isInTeam:    isInTeam,
+				promptInput: func(string) string { return "example.com" },
+				join: func(uuidOrDomain string) exitCode {
+					*calls = append(*calls, "join "+uuidOrDomain)
+					*inTeam = true
+					return 0
+				},
+				create: func() exitCode {
+					*calls = append(*calls, "create")
+					*inTeam = true
+					return 0
+				},
+			},
+			&setupFetchStep{
+				isInTeam: isInTeam,
+				fetch: func() exitCode {
+					*calls = append(*calls, "fetch")
+					return 0
+				},
+			},
+		}
+	}
+
+	t.Run("joins a team then fetches it", func(t *testing.T) {
+		prompter := &mockYesNoPrompter{answers: []bool{true}}
+		inTeam := false
+		calls := []string{}
+
+		err := wizard.Run(makeSteps(prompter, &inTeam, &calls), func(wizard.Step, bool) {})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Join an existing team?"}, prompter.asked)
+		assert.Equal(t, []string{"join example.com", "fetch"}, calls)
+	})
+
+	t.Run("creates a team if not joining one", func(t *testing.T) {
+		prompter := &mockYesNoPrompter{answers: []bool{false, true}}
+		inTeam := false
+		calls := []string{}
+
+		err := wizard.Run(makeSteps(prompter, &inTeam, &calls), func(wizard.Step, bool) {})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Join an existing team?", "Create a new team?"}, prompter.asked)
+		assert.Equal(t, []string{"create", "fetch"}, calls)
+	})
+
+	t.Run("skips fetching if the user doesn't want a team", func(t *testing.T) {
+		prompter := &mockYesNoPrompter{answers: []bool{false, false}}
+		inTeam := false
+		calls := []string{}
+
+		err := wizard.Run(makeSteps(prompter, &inTeam, &calls), func(wizard.Step, bool) {})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, calls)
+	})
+
+	t.Run("only fetches if already in a team", func(t *testing.T) {
+		prompter := &mockYesNoPrompter{}
+		inTeam := true
+		calls := []string{}
+		skipped := []string{}
+
+		err := wizard.Run(makeSteps(prompter, &inTeam, &calls), func(step wizard.Step, skip bool) {
+			if skip {
+				skipped = append(skipped, step.Name())
+			}
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(prompter.asked))
+		assert.Equal(t, []string{"Join or create a team"}, skipped)
+		assert.Equal(t, []string{"fetch"}, calls)
+	})
+
+	t.Run("stops if joining the team fails", func(t *testing.T) {
+		prompter := &mockYesNoPrompter{answers: []bool{true}}
+		inTeam := false
+		calls := []string{}
+
+		steps := makeSteps(prompter, &inTeam, &calls)
+		steps[0].(*setupTeamStep).join = func(string) exitCode { return 1 }
+
+		err := wizard.Run(steps, func(wizard.Step, bool) {})
+		assert.Equal(t, fmt.Errorf("failed to request to join team"), err.(*wizard.StepError).Err)
+		assert.Equal(t, []string{}, calls)
+	})
+}
+
+func TestSetupKeyStepChooseKey(t *testing.T) {
+	keys := []pgpkey.PgpKey{}
+	for _, armored := range []string{exampledata.ExamplePublicKey2, exampledata.ExamplePublicKey3} {
+		key, err := pgpkey.LoadFromArmoredPublicKey(armored)
+		assert.NoError(t, err)
+		keys = append(keys, *key)
+	}
+
+	// makeStep returns a setupKeyStep whose prompt records that it was shown and picks the
+	// second key
+	makeStep := func(email string, prompted *bool) *setupKeyStep {
+		return &setupKeyStep{
+			state: &setupState{email: email},
+			promptForKey: func(keys []pgpkey.PgpKey) *pgpkey.PgpKey {
+				*prompted = true
+				return &keys[1]
+			},
+		}
+	}
+
+	t.Run("uses the only key without asking", func(t *testing.T) {
+		prompted := false
+		got := makeStep("", &prompted).chooseKey(keys[:1])
+		assert.Equal(t, keys[0].Fingerprint(), got.Fingerprint())
+		assert.Equal(t, false, prompted)
+	})
+
+	t.Run("uses the key for the email given to setup", func(t *testing.T) {
+		prompted := false
+		got := makeStep("test3@example.com", &prompted).chooseKey(keys)
+		assert.Equal(t, keys[1].Fingerprint(), got.Fingerprint())
+		assert.Equal(t, false, prompted)
+	})
+
+	t.Run("asks which key to use if there's more than one", func(t *testing.T) {
+		prompted := false
+		got := makeStep("", &prompted).chooseKey(keys)
+		assert.Equal(t, keys[1].Fingerprint(), got.Fingerprint())
+		assert.Equal(t, true, prompted)
+	})
+}
+
+func TestSetupUploadStep(t *testing.T) {
+	t.Run("doesn't upload the key if the user says no", func(t *testing.T) {
+		key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+		assert.NoError(t, err)
+		prompter := &mockYesNoPrompter{answers: []bool{false}}
+
+		step := setupUploadStep{state: &setupState{key: key}, prompter: prompter}
+		assert.NoError(t, step.Run())
+		assert.Equal(t, []string{"Upload your key to Fluidkeys?"}, prompter.asked)
+	})
+}
+
+// mockYesNoPrompter gives each of answers in turn, recording the questions asked
+type mockYesNoPrompter struct {
+	answers []bool
+	asked   []string
+}
+
+func (m *mockYesNoPrompter) promptYesNo(message string, defaultResponse string,
+	key *pgpkey.PgpKey) bool {
+
+	m.asked = append(m.asked, message)
+	if len(m.answers) == 0 {
+		log.Panicf("mockYesNoPrompter ran out of answers for: %s", message)
+	}
+	answer := m.answers[0]
+	m.answers = m.answers[1:]
+	return answer
+}
@@ -242,7 +242,7 @@ func formatFailedToLoadKey(fingerprint fpr.Fingerprint, teamName string, err err
 	return ui.FormatFailure(
 		headline,
 		[]string{
-			"Tried to load key from GnuPG: " + fingerprint.Hex(),
+			"Tried to load key from GnuPG: " + formatFingerprint(fingerprint, fpr.Fingerprint.Hex),
 			"",
 			"If this key no longer exists, you can remove it from the `db.json` file",
 			"in your Fluidkeys directory " + fluidkeysDirectory,
@@ -23,6 +23,7 @@ import (
 	"github.com/fluidkeys/fluidkeys/out"
 	"github.com/fluidkeys/fluidkeys/scheduler"
 	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
 )
 
 func syncSubcommand(args docopt.Opts) exitCode {
@@ -47,7 +48,7 @@ func sync() (code exitCode) {
 
 	out.Print("\n")
 	out.Print("-> " + colour.Cmd("fk team fetch") + "\n\n")
-	if exitCode := teamFetch(true); exitCode != 0 {
+	if exitCode := teamFetch(true, false, false, uuid.Nil); exitCode != 0 {
 		code = exitCode
 	}
 
@@ -21,6 +21,7 @@ import (
 	"log"
 
 	"github.com/docopt/docopt-go"
+	"github.com/fluidkeys/fluidkeys/colour"
 	"github.com/fluidkeys/fluidkeys/out"
 	"github.com/fluidkeys/fluidkeys/ui"
 	"github.com/gofrs/uuid"
@@ -28,14 +29,53 @@ import (
 
 func teamSubcommand(args docopt.Opts) exitCode {
 	switch getSubcommand(args, []string{
-		"authorize", "create", "apply", "fetch",
+		"authorize", "create", "apply", "fetch", "sync", "edit", "audit", "export", "export-wkd",
+		"show", "leave", "list", "invite", "check-roster", "add-admin",
+		"remove-admin", "list-pending-requests",
 	}) {
 
 	case "apply":
-		id, err := args.String("<uuid>")
+		uuidOrDomain, err := args.String("<uuid-or-domain>")
+		if err != nil {
+			log.Panic(err)
+		}
+
+		invitationToken, _ := args.String("--invite") // optional: needs admin approval if not given
+		return teamApplyTo(uuidOrDomain, invitationToken)
+
+	case "add-admin":
+		email, err := args.String("<email>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return teamAddAdmin(email)
+
+	case "remove-admin":
+		email, err := args.String("<email>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return teamRemoveAdmin(email)
+
+	case "invite":
+		email, err := args.String("<email>")
 		if err != nil {
 			log.Panic(err)
 		}
+		return teamInvite(email)
+
+	case "show":
+		id, err := args.String("<uuid>")
+		if err != nil {
+			// no UUID given: use `fk config set default_team <uuid>` if set
+			if id, _, _ = Config.Get("default_team"); id == "" {
+				out.Print(ui.FormatFailure("No team given", []string{
+					"Run " + colour.Cmd("fk team show <uuid>") + " or set a default team with",
+					colour.Cmd("fk config set default_team <uuid>"),
+				}, nil))
+				return 1
+			}
+		}
 
 		teamUUID, err := uuid.FromString(id)
 		if err != nil {
@@ -43,16 +83,117 @@ func teamSubcommand(args docopt.Opts) exitCode {
 			return 1
 		}
 
-		return teamApply(teamUUID)
+		return teamShow(teamUUID)
 
-	case "fetch":
-		return teamFetch(false)
+	case "leave":
+		id, err := args.String("<uuid>")
+		if err != nil {
+			// no UUID given: choose from the teams Fluidkeys knows we're in
+			teams, err := listOwnTeams()
+			if err != nil {
+				out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+				return 1
+			}
+			if len(teams) == 0 {
+				out.Print(ui.FormatFailure("You aren't a member of any teams", nil, nil))
+				return 1
+			}
+			return teamLeave(promptForTeamByNumber(teams).UUID)
+		}
+
+		teamUUID, err := uuid.FromString(id)
+		if err != nil {
+			out.Print(ui.FormatFailure("Invalid UUID", nil, err))
+			return 1
+		}
+
+		return teamLeave(teamUUID)
+
+	case "list":
+		format, _ := args.String("--format") // optional: default to a table
+		return teamList(format)
+
+	case "fetch", "sync":
+		// `fk team sync` is an alias for `fk team fetch`
+		trustOnFirstUse, err := args.Bool("--trust-on-first-use")
+		if err != nil {
+			log.Panic(err)
+		}
+		noGpgImport, err := args.Bool("--no-gpg-import")
+		if err != nil {
+			log.Panic(err)
+		}
+		onlyTeam := uuid.Nil
+		if id, _ := args.String("--team"); id != "" { // optional: fetch every team if not given
+			if onlyTeam, err = uuid.FromString(id); err != nil {
+				out.Print(ui.FormatFailure("Invalid --team", nil, err))
+				return 1
+			}
+		}
+		if watch, _ := args.Bool("--watch"); watch { // optional: only for `fk team fetch`
+			interval, _ := args.String("--interval") // optional: default to 5 minutes
+			return teamFetchWatch(interval, trustOnFirstUse, noGpgImport, onlyTeam)
+		}
+		return teamFetch(false, trustOnFirstUse, noGpgImport, onlyTeam)
+
+	case "edit":
+		dryRun, err := args.Bool("--dry-run")
+		if err != nil {
+			log.Panic(err)
+		}
+		return teamEdit(dryRun)
 
 	case "create":
 		return teamCreate()
 
 	case "authorize":
 		return teamAuthorize()
+
+	case "list-pending-requests":
+		includeExpired, err := args.Bool("--expired")
+		if err != nil {
+			log.Panic(err)
+		}
+		onlyTeam := uuid.Nil
+		if id, _ := args.String("--team"); id != "" { // optional: list every admin team's requests
+			if onlyTeam, err = uuid.FromString(id); err != nil {
+				out.Print(ui.FormatFailure("Invalid --team", nil, err))
+				return 1
+			}
+		}
+		return teamListPendingRequests(onlyTeam, includeExpired)
+
+	case "audit":
+		return teamAudit()
+
+	case "check-roster":
+		filename, err := args.String("<file>")
+		if err != nil {
+			log.Panic(err)
+		}
+		return teamCheckRoster(filename)
+
+	case "export":
+		format, err := args.String("--format")
+		if err != nil {
+			log.Panic(err)
+		}
+		onlyTeam := uuid.Nil
+		if id, _ := args.String("--team"); id != "" { // optional: if in only one team
+			if onlyTeam, err = uuid.FromString(id); err != nil {
+				out.Print(ui.FormatFailure("Invalid --team", nil, err))
+				return 1
+			}
+		}
+		outputFilename, _ := args.String("--output") // optional: print to stdout if not given
+		return teamExport(format, onlyTeam, outputFilename)
+
+	case "export-wkd":
+		outputDir, err := args.String("--output")
+		if err != nil {
+			log.Panic(err)
+		}
+		return teamExportWKD(outputDir)
 	}
 	log.Panicf("secretSubcommand got unexpected arguments: %v", args)
 	panic(nil)
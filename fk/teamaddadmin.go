@@ -0,0 +1,117 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func teamAddAdmin(email string) exitCode {
+	allMemberships, err := user.Memberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	adminMemberships := filterByAdmin(allMemberships)
+
+	switch len(adminMemberships) {
+	case 0:
+		out.Print(ui.FormatFailure("You aren't an admin of any teams", nil, nil))
+		return 1
+
+	case 1:
+		myTeam := adminMemberships[0].Team
+		me := adminMemberships[0].Me
+
+		printHeader("Make " + email + " an admin of " + myTeam.Name)
+
+		updatedTeam, err := promoteToAdmin(myTeam, email, api, time.Now())
+		if err != nil {
+			out.Print(ui.FormatFailure("Can't make "+email+" an admin", nil, err))
+			return 1
+		}
+
+		revokedFingerprints, err := db.GetRevokedKeys()
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to list revoked keys", nil, err))
+			return 1
+		}
+
+		err = doEditTeam(myTeam, *updatedTeam, me, revokedFingerprints, false, api)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to update team", nil, err))
+			return 1
+		}
+		return 0
+
+	default:
+		out.Print(ui.FormatFailure("Choosing from multiple teams not implemented", nil, nil))
+		return 1
+	}
+}
+
+// promoteToAdmin returns a copy of the team with the member with the given email made an admin.
+// It fetches the member's public key with keyFetcher and returns an error if the key has been
+// revoked or has expired, since an admin needs a working key to sign the roster.
+func promoteToAdmin(t team.Team, email string, keyFetcher team.PublicKeyFetcher,
+	now time.Time) (*team.Team, error) {
+
+	person, err := t.GetPersonForEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("%s isn't in the team", email)
+	}
+	if person.IsAdmin {
+		return nil, fmt.Errorf("%s is already an admin", email)
+	}
+
+	key, err := keyFetcher.GetPublicKeyByFingerprint(person.Fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key for %s: %v", email, err)
+	}
+	if len(key.Revocations) > 0 {
+		return nil, fmt.Errorf("key %s has been revoked", person.Fingerprint)
+	}
+	if hasExpiry, expiry := key.PrimaryKeyExpiry(); hasExpiry && !expiry.After(now) {
+		return nil, fmt.Errorf("key %s expired on %s", person.Fingerprint,
+			expiry.Format("2 January 2006"))
+	}
+
+	return withAdmin(t, person.Fingerprint, true), nil
+}
+
+// withAdmin returns a copy of the team with IsAdmin set for the person with the given
+// fingerprint. It copies People so the original team isn't changed.
+func withAdmin(t team.Team, fingerprint fpr.Fingerprint, isAdmin bool) *team.Team {
+	updated := t
+	updated.People = make([]team.Person, len(t.People))
+	copy(updated.People, t.People)
+
+	for i := range updated.People {
+		if updated.People[i].Fingerprint == fingerprint {
+			updated.People[i].IsAdmin = isAdmin
+		}
+	}
+	return &updated
+}
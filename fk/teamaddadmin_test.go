@@ -0,0 +1,111 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp/packet"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+func TestPromoteToAdmin(t *testing.T) {
+	me := team.Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+		IsAdmin:     true,
+	}
+	other := team.Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     false,
+	}
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+	before := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, other}}
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	loadOtherKey := func(t *testing.T) *pgpkey.PgpKey {
+		t.Helper()
+		key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+		return key
+	}
+
+	t.Run("makes the member an admin", func(t *testing.T) {
+		keyFetcher := mock.MockClient{GetPublicKeyByFingerprintKey: loadOtherKey(t)}
+
+		after, err := promoteToAdmin(before, "TEST4@example.com", &keyFetcher, now)
+		assert.NoError(t, err)
+		assert.Equal(t, true, after.IsAdmin(other.Fingerprint))
+
+		t.Run("without changing the original team", func(t *testing.T) {
+			assert.Equal(t, false, before.IsAdmin(other.Fingerprint))
+		})
+
+		t.Run("and the update is valid", func(t *testing.T) {
+			uploader := mock.MockClient{}
+			err := doEditTeam(before, *after, me, nil, true, &uploader)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, len(uploader.CallsTo("UpsertTeam")))
+		})
+	})
+
+	t.Run("returns an error for someone not in the team", func(t *testing.T) {
+		keyFetcher := mock.MockClient{GetPublicKeyByFingerprintKey: loadOtherKey(t)}
+
+		_, err := promoteToAdmin(before, "nobody@example.com", &keyFetcher, now)
+		assert.Equal(t, fmt.Errorf("nobody@example.com isn't in the team"), err)
+	})
+
+	t.Run("returns an error for someone who's already an admin", func(t *testing.T) {
+		keyFetcher := mock.MockClient{}
+
+		_, err := promoteToAdmin(before, "test2@example.com", &keyFetcher, now)
+		assert.Equal(t, fmt.Errorf("test2@example.com is already an admin"), err)
+		assert.Equal(t, 0, len(keyFetcher.CallsTo("GetPublicKeyByFingerprint")))
+	})
+
+	t.Run("returns an error if the member's key has been revoked", func(t *testing.T) {
+		key := loadOtherKey(t)
+		key.Revocations = []*packet.Signature{{SigType: packet.SigTypeKeyRevocation}}
+		keyFetcher := mock.MockClient{GetPublicKeyByFingerprintKey: key}
+
+		_, err := promoteToAdmin(before, "test4@example.com", &keyFetcher, now)
+		assert.Equal(t,
+			fmt.Errorf("key %s has been revoked", exampledata.ExampleFingerprint4), err)
+	})
+
+	t.Run("returns an error if the member's key has expired", func(t *testing.T) {
+		key := loadOtherKey(t)
+		oneDay := uint32(24 * 60 * 60)
+		for _, identity := range key.Identities {
+			identity.SelfSignature.KeyLifetimeSecs = &oneDay
+		}
+		keyFetcher := mock.MockClient{GetPublicKeyByFingerprintKey: key}
+
+		_, err := promoteToAdmin(before, "test4@example.com", &keyFetcher, now)
+		assert.GotError(t, err)
+	})
+}
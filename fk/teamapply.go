@@ -31,12 +31,59 @@ import (
 	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
 	"github.com/fluidkeys/fluidkeys/out"
 	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
 	"github.com/fluidkeys/fluidkeys/ui"
 	"github.com/gofrs/uuid"
 	spin "github.com/tj/go-spin"
 )
 
-func teamApply(teamUUID uuid.UUID) exitCode {
+// getTeamUUIDToApply returns the team UUID given by uuidOrDomain. If it isn't a UUID, it's
+// treated as a domain and the team is looked up in that domain's DNS using discover. Results of
+// lookups are cached in the database for team.DomainhintCacheDuration.
+func getTeamUUIDToApply(
+	uuidOrDomain string, discover func(domain string) (uuid.UUID, error), now time.Time) (
+	uuid.UUID, error) {
+
+	if teamUUID, err := uuid.FromString(uuidOrDomain); err == nil {
+		return teamUUID, nil
+	}
+
+	if !strings.Contains(uuidOrDomain, ".") {
+		return uuid.Nil, fmt.Errorf("not a valid team UUID or domain")
+	}
+
+	teamUUID, found, err := db.GetDiscoveredTeam(uuidOrDomain, team.DomainhintCacheDuration, now)
+	if err != nil {
+		log.Printf("error getting discovered team for %s from db: %v", uuidOrDomain, err)
+	} else if found {
+		log.Printf("using cached team UUID %s for %s", teamUUID, uuidOrDomain)
+		return teamUUID, nil
+	}
+
+	teamUUID, err = discover(uuidOrDomain)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := db.RecordDiscoveredTeam(uuidOrDomain, teamUUID, now); err != nil {
+		log.Printf("failed to record discovered team for %s: %v", uuidOrDomain, err)
+	}
+	return teamUUID, nil
+}
+
+// teamApplyTo requests to join the team given by its UUID or a domain with a team domainhint.
+func teamApplyTo(uuidOrDomain string, invitationToken string) exitCode {
+	teamUUID, err := getTeamUUIDToApply(uuidOrDomain, team.DiscoverViaDomainhint, time.Now())
+	if err != nil {
+		out.Print(ui.FormatFailure("Couldn't find team "+uuidOrDomain, nil, err))
+		return 1
+	}
+	return teamApply(teamUUID, invitationToken)
+}
+
+// teamApply requests to join the team. If invitationToken is set, it's sent with the request so
+// a team admin only needs to check the key's fingerprint to authorize it.
+func teamApply(teamUUID uuid.UUID, invitationToken string) exitCode {
 	if code := ensureUserCanJoinTeam(teamUUID); code != 0 {
 		return code
 	}
@@ -76,10 +123,16 @@ func teamApply(teamUUID uuid.UUID) exitCode {
 			return 1
 		}
 
-		return teamFetch(false)
+		return teamFetch(false, false, false, uuid.Nil)
+	}
+
+	var options []apiclient.RequestToJoinTeamOption
+	if invitationToken != "" {
+		options = append(options, apiclient.WithInvitationToken(invitationToken))
 	}
 
-	if err := api.RequestToJoinTeam(teamUUID, pgpKey.Fingerprint(), email); err != nil {
+	if err := api.RequestToJoinTeam(
+		teamUUID, pgpKey.Fingerprint(), email, options...); err != nil {
 		out.Print(ui.FormatFailure("Failed to apply to join "+teamName, nil, err))
 		return 1
 	}
@@ -170,12 +223,12 @@ func pollThenRunTeamFetch(teamUUID uuid.UUID, fingerprint fp.Fingerprint) exitCo
 		}
 	}
 	out.Print("Running " + colour.Cmd("fk team fetch") + "\n\n")
-	return teamFetch(false)
+	return teamFetch(false, false, false, uuid.Nil)
 }
 
 func formatVerificationLines(fingerprint fpr.Fingerprint, email string) []string {
 	return []string{
-		"» key:   " + fingerprint.String(),
+		"» key:   " + displayFingerprint(fingerprint),
 		"  email: " + email,
 	}
 }
@@ -259,7 +312,7 @@ func printEmailsWithNumbers(keys []pgpkey.PgpKey) error {
 		if err != nil {
 			out.Print(ui.FormatFailure(
 				"Failed to get email for key", []string{
-					fmt.Sprintf("%s has no identities with email addresses", key.Fingerprint()),
+					displayFingerprint(key.Fingerprint()) + " has no identities with email addresses",
 				},
 				err,
 			))
@@ -0,0 +1,80 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/database"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+	"github.com/gofrs/uuid"
+)
+
+func TestGetTeamUUIDToApply(t *testing.T) {
+	originalDB := db
+	defer func() { db = originalDB }()
+	db = database.New(testhelpers.Maketemp(t))
+
+	now := time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC)
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+
+	lookups := 0
+	discover := func(domain string) (uuid.UUID, error) {
+		lookups++
+		if domain == "example.com" {
+			return teamUUID, nil
+		}
+		return uuid.Nil, fmt.Errorf("no such host")
+	}
+
+	t.Run("with a UUID, doesn't look anything up", func(t *testing.T) {
+		got, err := getTeamUUIDToApply(teamUUID.String(), discover, now)
+		assert.NoError(t, err)
+		assert.Equal(t, teamUUID, got)
+		assert.Equal(t, 0, lookups)
+	})
+
+	t.Run("with something that's neither a UUID or domain", func(t *testing.T) {
+		_, err := getTeamUUIDToApply("not-a-uuid", discover, now)
+		assert.Equal(t, fmt.Errorf("not a valid team UUID or domain"), err)
+		assert.Equal(t, 0, lookups)
+	})
+
+	t.Run("with a domain, looks it up then uses the cache", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			got, err := getTeamUUIDToApply("example.com", discover, now.Add(time.Hour))
+			assert.NoError(t, err)
+			assert.Equal(t, teamUUID, got)
+		}
+		assert.Equal(t, 1, lookups)
+	})
+
+	t.Run("looks up again once the cache expires", func(t *testing.T) {
+		_, err := getTeamUUIDToApply("example.com", discover, now.Add(48*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, lookups)
+	})
+
+	t.Run("returns error if the lookup fails", func(t *testing.T) {
+		_, err := getTeamUUIDToApply("example.org", discover, now)
+		assert.Equal(t, fmt.Errorf("no such host"), err)
+	})
+}
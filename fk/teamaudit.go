@@ -0,0 +1,88 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func teamAudit() exitCode {
+	groupedMemberships, err := user.GroupedMemberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	if len(groupedMemberships) == 0 {
+		out.Print(ui.FormatFailure("You aren't a member of any teams", nil, nil))
+		return 1
+	}
+
+	sawError := false
+
+	for _, membership := range groupedMemberships {
+		t := membership.Team
+
+		printHeader("Roster changes for " + t.Name)
+
+		teamSubdirectory, err := team.Directory(t, fluidkeysDirectory)
+		if err != nil {
+			out.Print(ui.FormatWarning("Failed to find team directory", nil, err))
+			sawError = true
+			continue
+		}
+
+		entries, err := team.AuditLog{Directory: teamSubdirectory}.Entries()
+		if err != nil {
+			out.Print(ui.FormatWarning("Failed to read audit log", nil, err))
+			sawError = true
+			continue
+		}
+
+		if len(entries) == 0 {
+			out.Print("No roster changes recorded yet.\n\n")
+			continue
+		}
+
+		for _, entry := range entries {
+			out.Print(formatAuditEntry(entry))
+		}
+	}
+
+	if sawError {
+		return 1
+	}
+	return 0
+}
+
+func formatAuditEntry(entry team.AuditEntry) string {
+	signedBy := "unknown key"
+	if entry.AdminFingerprint != nil {
+		signedBy = displayFingerprint(*entry.AdminFingerprint)
+	}
+
+	return colour.Info(fmt.Sprintf("Version %d", entry.RosterVersion)) + " " +
+		entry.Timestamp.Local().Format("2 January 2006 15:04") + "\n" +
+		"Signed by " + signedBy + "\n\n" +
+		formatTeamDiff(entry.Diff)
+}
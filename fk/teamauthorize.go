@@ -18,11 +18,14 @@
 package fk
 
 import (
+	"log"
 	"strconv"
+	"time"
 
 	"github.com/fluidkeys/fluidkeys/colour"
 	"github.com/fluidkeys/fluidkeys/humanize"
 	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/progress"
 	"github.com/fluidkeys/fluidkeys/team"
 	"github.com/fluidkeys/fluidkeys/ui"
 	userpackage "github.com/fluidkeys/fluidkeys/user"
@@ -58,19 +61,38 @@ func teamAuthorize() exitCode {
 			return 0
 		}
 
-		out.Print(ui.FormatInfo(
-			"Authorizing a key adds it to the team roster",
-			[]string{
-				"By authorizing a key, everyone in your team will fetch and trust that key.",
-				"",
-				"Your team should have sent you verification details.",
-				"Check the key and email below match the verification details you've received.",
-			},
-		))
+		adminKeys, err := fetchAdminPublicKeys(myTeam)
+		if err != nil {
+			log.Printf("failed to get admin keys, so can't check invitations: %v", err)
+		}
 
-		approvedRequests, deleteRequests := reviewRequests(requests, myTeam)
+		// requests made with an invitation from `fk team invite` only need their key checking
+		invitedRequests, requests := splitInvitedRequests(
+			requests, adminKeys, time.Now(), isInvitationUsed)
+		confirmedInvitedRequests, conflictingRequests := confirmInvitedRequests(
+			invitedRequests, myTeam, &interactiveYesNoPrompter{})
+		requests = append(requests, conflictingRequests...)
+		approvedRequests := append([]team.RequestToJoinTeam{}, confirmedInvitedRequests...)
+		deleteRequests := append([]team.RequestToJoinTeam{}, confirmedInvitedRequests...)
+
+		if len(requests) > 0 {
+			out.Print(ui.FormatInfo(
+				"Authorizing a key adds it to the team roster",
+				[]string{
+					"By authorizing a key, everyone in your team will fetch and trust that key.",
+					"",
+					"Your team should have sent you verification details.",
+					"Check the key and email below match the verification details you've received.",
+				},
+			))
+
+			reviewedApproved, reviewedDelete := reviewRequests(requests, myTeam)
+			approvedRequests = append(approvedRequests, reviewedApproved...)
+			deleteRequests = append(deleteRequests, reviewedDelete...)
+		}
 
 		if len(approvedRequests) > 0 {
+			before := myTeam
 			for _, request := range approvedRequests {
 				myTeam.UpsertPerson(
 					team.Person{
@@ -80,16 +102,37 @@ func teamAuthorize() exitCode {
 					})
 			}
 
+			revokedFingerprints, err := db.GetRevokedKeys()
+			if err != nil {
+				out.Print(ui.FormatFailure("Failed to list revoked keys", nil, err))
+				return 1
+			}
+			err = team.ValidateUpdate(&before, &myTeam, me.Fingerprint, revokedFingerprints)
+			if err != nil {
+				out.Print(ui.FormatFailure("Can't add the approved requests to the team", nil, err))
+				return 1
+			}
+
+			for _, request := range approvedRequests {
+				// the admin has just checked the fingerprint, so don't ask again
+				recordKeyVerified(request.Fingerprint)
+			}
+
 			printHeader("Sign and upload team roster")
 
 			out.Print("The team roster is a signed file that defines who is in the team.\n\n")
 
-			if err := promptAndSignAndUploadRoster(myTeam, me.Fingerprint); err != nil {
+			if err := promptAndSignAndUploadRoster(myTeam, me.Fingerprint, api); err != nil {
 				out.Print(ui.FormatFailure("Failed to sign and upload roster", nil, err))
 				return 1
 			}
+			for _, request := range confirmedInvitedRequests {
+				recordInvitationUsed(request.InvitationToken)
+			}
 
-			if err := fetchAndCertifyTeamKeys(myTeam, me, false); err != nil {
+			if err := fetchAndCertifyTeamKeys(
+				myTeam, me, false, true, false, false,
+				&progress.TerminalReporter{Spinner: true}); err != nil {
 				out.Print(ui.FormatWarning("Error fetching team keys", nil, err))
 				return 1
 			}
@@ -131,7 +174,7 @@ func reviewRequests(requests []team.RequestToJoinTeam, myTeam team.Team) (
 
 	prompter := interactiveYesNoPrompter{}
 	for _, request := range requests {
-		out.Print("» key:   " + colour.Info(request.Fingerprint.String()) + "\n")
+		out.Print("» key:   " + colour.Info(displayFingerprint(request.Fingerprint)) + "\n")
 		out.Print("  email: " + colour.Info(request.Email) + "\n")
 
 		err, existingPerson := myTeam.GetUpsertPersonWarnings(team.Person{
@@ -153,15 +196,16 @@ func reviewRequests(requests []team.RequestToJoinTeam, myTeam team.Team) (
 			case team.ErrEmailWouldBeUpdated:
 				out.Print(ui.FormatWarning(
 					"A key with this fingerprint is already in the team", []string{
-						"Existing key belonging to " + existingPerson.Email,
-						"will be replaced.",
+						"It belongs to " + existingPerson.Email + ", and the email for a key",
+						"can't be changed. Skipping.",
 					},
 					nil,
 				))
+				continue
 			case team.ErrKeyWouldBeUpdated:
 				out.Print(ui.FormatWarning(
 					existingPerson.Email+" is already in the team", []string{
-						"Existing key " + existingPerson.Fingerprint.String(),
+						"Existing key " + displayFingerprint(existingPerson.Fingerprint),
 						"will be replaced.",
 					},
 					nil,
@@ -0,0 +1,188 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+// teamCheckRoster validates a roster file, and its signature in <filename>.asc if there is one,
+// without uploading it.
+func teamCheckRoster(filename string) exitCode {
+	roster, err := ioutil.ReadFile(filename)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to read roster", nil, err))
+		return 1
+	}
+
+	signature, err := ioutil.ReadFile(filename + ".asc")
+	if err != nil && !os.IsNotExist(err) {
+		out.Print(ui.FormatFailure("Failed to read signature", nil, err))
+		return 1
+	}
+
+	savedTeams, err := team.LoadTeams(fluidkeysDirectory)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load saved teams", nil, err))
+		return 1
+	}
+
+	myFingerprints, err := db.GetFingerprintsImportedIntoGnuPG()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list your keys", nil, err))
+		return 1
+	}
+
+	revokedFingerprints, err := db.GetRevokedKeys()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list revoked keys", nil, err))
+		return 1
+	}
+
+	result := checkRosterFile(string(roster), string(signature), savedTeams, myFingerprints,
+		revokedFingerprints, fetchAdminPublicKeys, time.Now())
+
+	out.Print(formatRosterFileCheck(result))
+
+	if len(result.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// rosterFileCheck is the outcome of checking a roster with checkRosterFile
+type rosterFileCheck struct {
+	// Team is the team loaded from the roster, or nil if it couldn't be loaded
+	Team *team.Team
+
+	// Before is the saved roster for the same team, or nil if there isn't one
+	Before *team.Team
+
+	Signed bool
+	Errors []error
+}
+
+// checkRosterFile loads the roster and, if there's a saved roster for the same team, checks that
+// updating from the saved roster is allowed. If signature isn't empty, it's verified against
+// the keys of the saved roster's admins, or the new roster's admins for a team that hasn't
+// been saved.
+func checkRosterFile(roster string, signature string, savedTeams []team.Team,
+	myFingerprints []fpr.Fingerprint, revokedFingerprints []fpr.Fingerprint,
+	fetchAdminKeys func(team.Team) ([]*pgpkey.PgpKey, error), now time.Time) (result rosterFileCheck) {
+
+	after, err := team.Load(roster, signature)
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result
+	}
+	result.Team = after
+
+	for i := range savedTeams {
+		if savedTeams[i].UUID == after.UUID {
+			result.Before = &savedTeams[i]
+			break
+		}
+	}
+
+	if result.Before != nil {
+		signer := findAdminFingerprint(after, myFingerprints)
+		if err := team.ValidateUpdate(result.Before, after, signer, revokedFingerprints); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("invalid update: %v", err))
+		}
+	}
+
+	if strings.TrimSpace(signature) == "" {
+		return result
+	}
+	result.Signed = true
+
+	signers := after
+	if result.Before != nil {
+		signers = result.Before
+	}
+
+	adminKeys, err := fetchAdminKeys(*signers)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("error getting admin keys: %v", err))
+		return result
+	}
+
+	if err := team.VerifyRoster(roster, []string{signature}, adminKeys, 0, now); err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("bad signature: %v", err))
+	}
+	return result
+}
+
+// findAdminFingerprint returns the first of myFingerprints that's an admin of the team, or an
+// empty fingerprint if none are
+func findAdminFingerprint(t *team.Team, myFingerprints []fpr.Fingerprint) fpr.Fingerprint {
+	for _, fingerprint := range myFingerprints {
+		if t.IsAdmin(fingerprint) {
+			return fingerprint
+		}
+	}
+	return fpr.Fingerprint{}
+}
+
+func formatRosterFileCheck(result rosterFileCheck) (output string) {
+	if result.Team != nil {
+		admins := []string{}
+		for _, admin := range result.Team.Admins() {
+			admins = append(admins, admin.Email)
+		}
+
+		output += "\n"
+		output += "Team:        " + result.Team.Name + " (" + result.Team.UUID.String() + ")\n"
+		output += "Members:     " + humanize.Pluralize(result.Team.MemberCount(), "person", "people") + "\n"
+		output += "Admins:      " + strings.Join(admins, ", ") + "\n"
+
+		if result.Before != nil {
+			output += "Compared to: saved roster\n"
+		} else {
+			output += "Compared to: nothing (no saved roster for this team)\n"
+		}
+
+		if result.Signed {
+			output += "Signature:   checked against the admins' keys\n"
+		} else {
+			output += "Signature:   none (roster is unsigned)\n"
+		}
+	}
+
+	if len(result.Errors) == 0 {
+		return output + ui.FormatSuccess("Roster is valid", nil)
+	}
+
+	errorLines := []string{}
+	for _, err := range result.Errors {
+		errorLines = append(errorLines, err.Error())
+	}
+	return output + ui.FormatFailure(
+		"Roster has "+humanize.Pluralize(len(result.Errors), "error", "errors"), errorLines, nil)
+}
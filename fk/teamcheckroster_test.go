@@ -0,0 +1,140 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+func TestCheckRosterFile(t *testing.T) {
+	me := team.Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	other := team.Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+		IsAdmin:     false,
+	}
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+	myFingerprints := []fpr.Fingerprint{exampledata.ExampleFingerprint4}
+
+	signingKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	publicKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+
+	fetchAdminKeys := func(team.Team) ([]*pgpkey.PgpKey, error) {
+		return []*pgpkey.PgpKey{publicKey}, nil
+	}
+	now := time.Now()
+
+	saved := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{me}}
+	savedTeams := []team.Team{saved}
+
+	t.Run("valid and signed", func(t *testing.T) {
+		updated := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, other}}
+		assert.NoError(t, updated.UpdateRoster(signingKey))
+		roster, signature, err := updated.Roster()
+		assert.NoError(t, err)
+
+		result := checkRosterFile(roster, signature, savedTeams, myFingerprints, nil, fetchAdminKeys, now)
+		assert.Equal(t, 0, len(result.Errors))
+		assert.Equal(t, true, result.Signed)
+		assert.Equal(t, &savedTeams[0], result.Before)
+		assert.Equal(t, 2, result.Team.MemberCount())
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		updated := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, other}}
+		roster, err := updated.PreviewRoster()
+		assert.NoError(t, err)
+
+		result := checkRosterFile(roster, "", savedTeams, myFingerprints, nil, fetchAdminKeys, now)
+		assert.Equal(t, 0, len(result.Errors))
+		assert.Equal(t, false, result.Signed)
+
+		output := formatRosterFileCheck(result)
+		assert.Equal(t, true, strings.Contains(output, "none (roster is unsigned)"))
+		assert.Equal(t, true, strings.Contains(output, "Admins:      test4@example.com\n"))
+	})
+
+	t.Run("signed by someone who isn't an admin", func(t *testing.T) {
+		updated := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, other}}
+		assert.NoError(t, updated.UpdateRoster(signingKey))
+		roster, signature, err := updated.Roster()
+		assert.NoError(t, err)
+
+		noAdminKeys := func(team.Team) ([]*pgpkey.PgpKey, error) {
+			return []*pgpkey.PgpKey{}, nil
+		}
+
+		result := checkRosterFile(roster, signature, savedTeams, myFingerprints, nil, noAdminKeys, now)
+		assert.Equal(t, []error{fmt.Errorf("bad signature: %v", team.ErrSignatureInvalid)},
+			result.Errors)
+	})
+
+	t.Run("invalid update from the saved roster", func(t *testing.T) {
+		changedEmail := me
+		changedEmail.Email = "attacker@example.com"
+
+		updated := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{changedEmail}}
+		roster, err := updated.PreviewRoster()
+		assert.NoError(t, err)
+
+		result := checkRosterFile(roster, "", savedTeams, myFingerprints, nil, fetchAdminKeys, now)
+		assert.Equal(t, 1, len(result.Errors))
+		assert.Equal(t, true, strings.HasPrefix(result.Errors[0].Error(), "invalid update: "))
+
+		output := formatRosterFileCheck(result)
+		assert.Equal(t, true, strings.Contains(output, "Roster has 1 error"))
+	})
+
+	t.Run("roster that doesn't load", func(t *testing.T) {
+		result := checkRosterFile("name = ", "", savedTeams, myFingerprints, nil, fetchAdminKeys, now)
+		assert.Equal(t, 1, len(result.Errors))
+		assert.Equal(t, (*team.Team)(nil), result.Team)
+	})
+
+	t.Run("new team isn't compared to a saved roster", func(t *testing.T) {
+		newTeam := team.Team{
+			UUID:   uuid.Must(uuid.NewV4()),
+			Name:   "New team",
+			People: []team.Person{me},
+		}
+		roster, err := newTeam.PreviewRoster()
+		assert.NoError(t, err)
+
+		result := checkRosterFile(roster, "", savedTeams, myFingerprints, nil, fetchAdminKeys, now)
+		assert.Equal(t, 0, len(result.Errors))
+		assert.Equal(t, (*team.Team)(nil), result.Before)
+	})
+}
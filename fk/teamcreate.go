@@ -109,7 +109,7 @@ func teamCreate() exitCode {
 
 	out.Print("Create team roster with you in it:\n\n")
 
-	if err := promptAndSignAndUploadRoster(t, key.Fingerprint()); err != nil {
+	if err := promptAndSignAndUploadRoster(t, key.Fingerprint(), api); err != nil {
 		if err != errUserDeclinedToSign {
 			out.Print(ui.FormatFailure("Failed to sign and upload roster", nil, err))
 		}
@@ -153,7 +153,9 @@ Join now:
 	return 0
 }
 
-func promptAndSignAndUploadRoster(t team.Team, adminFingerprint fp.Fingerprint) (err error) {
+func promptAndSignAndUploadRoster(t team.Team, adminFingerprint fp.Fingerprint,
+	uploader upsertTeamInterface) (err error) {
+
 	unsignedRoster, err := t.PreviewRoster()
 	if err != nil {
 		return err
@@ -190,7 +192,10 @@ func promptAndSignAndUploadRoster(t team.Team, adminFingerprint fp.Fingerprint)
 	if err = t.UpdateRoster(privateKey); err != nil {
 		return failSign(err)
 	}
-	signedRoster, signature := t.Roster()
+	signedRoster, signature, err := t.Roster()
+	if err != nil {
+		return failSign(err)
+	}
 	teamSubdirectory, err := team.Directory(t, fluidkeysDirectory)
 	if err != nil {
 		return failSign(err)
@@ -203,7 +208,7 @@ func promptAndSignAndUploadRoster(t team.Team, adminFingerprint fp.Fingerprint)
 
 	ui.PrintCheckboxPending(checkboxSign)
 
-	if err := api.UpsertTeam(signedRoster, signature, privateKey.Fingerprint()); err != nil {
+	if err := uploader.UpsertTeam(signedRoster, signature, privateKey.Fingerprint()); err != nil {
 		rosterSaver.DiscardDraft()
 		return failUpload(err)
 	}
@@ -220,6 +225,10 @@ func promptAndSignAndUploadRoster(t team.Team, adminFingerprint fp.Fingerprint)
 	return nil
 }
 
+type upsertTeamInterface interface {
+	UpsertTeam(roster string, rosterSignature string, signerFingerprint fp.Fingerprint) error
+}
+
 func formatRosterPreview(roster string) string {
 	return formatFileDivider("Preview of roster.toml", 80) + "\n" +
 		roster +
@@ -0,0 +1,174 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func teamEdit(dryRun bool) exitCode {
+	allMemberships, err := user.Memberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	adminMemberships := filterByAdmin(allMemberships)
+
+	switch len(adminMemberships) {
+	case 0:
+		out.Print(ui.FormatFailure("You aren't an admin of any teams", nil, nil))
+		return 1
+
+	case 1:
+		myTeam := adminMemberships[0].Team
+		me := adminMemberships[0].Me
+
+		printHeader("Edit " + myTeam.Name)
+
+		updatedTeam, err := editTeamInEditor(myTeam)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to edit team roster", nil, err))
+			return 1
+		}
+
+		revokedFingerprints, err := db.GetRevokedKeys()
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to list revoked keys", nil, err))
+			return 1
+		}
+
+		err = doEditTeam(myTeam, *updatedTeam, me, revokedFingerprints, dryRun, api)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to update team", nil, err))
+			return 1
+		}
+		return 0
+
+	default:
+		out.Print(ui.FormatFailure("Choosing from multiple teams not implemented", nil, nil))
+		return 1
+	}
+}
+
+// doEditTeam shows the changes from `before` to `after`, validates them, then signs and uploads
+// the new roster. If dryRun is true, it stops before signing and uploading.
+func doEditTeam(before team.Team, after team.Team, me team.Person,
+	revokedFingerprints []fpr.Fingerprint, dryRun bool, uploader upsertTeamInterface) error {
+
+	diff := team.DiffTeams(&before, &after)
+	if diff.IsEmpty() {
+		out.Print(ui.FormatInfo("No changes were made to the team", nil))
+		return nil
+	}
+
+	out.Print(formatTeamDiff(diff))
+
+	if err := team.ValidateUpdate(&before, &after, me.Fingerprint, revokedFingerprints); err != nil {
+		return fmt.Errorf("invalid update: %v", err)
+	}
+
+	if dryRun {
+		out.Print(ui.FormatInfo("Dry run – no changes were made", nil))
+		return nil
+	}
+
+	return promptAndSignAndUploadRoster(after, me.Fingerprint, uploader)
+}
+
+// editTeamInEditor opens the team's roster in the user's text editor and returns the team
+// loaded from the edited roster.
+func editTeamInEditor(t team.Team) (*team.Team, error) {
+	roster, err := t.PreviewRoster()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpfile, err := ioutil.TempFile("", "roster.*.toml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to make temporary file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.WriteString(roster); err != nil {
+		tmpfile.Close()
+		return nil, fmt.Errorf("failed to write temporary file: %v", err)
+	}
+	if err := tmpfile.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := strings.Fields(getEditor())
+	cmd := exec.Command(editor[0], append(editor[1:], tmpfile.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run editor %s: %v", editor[0], err)
+	}
+
+	editedRoster, err := ioutil.ReadFile(tmpfile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited roster: %v", err)
+	}
+
+	return team.Load(string(editedRoster), "")
+}
+
+// getEditor returns the user's preferred editor from $VISUAL, $EDITOR or `fk config set editor`,
+// falling back to vi
+func getEditor() string {
+	editor, _, err := Config.Get("editor")
+	if err != nil {
+		log.Panic(err)
+	}
+	return editor
+}
+
+func formatTeamDiff(diff team.Diff) (output string) {
+	output += "Changes to the team:\n\n"
+
+	if diff.NameBefore != diff.NameAfter {
+		output += fmt.Sprintf("   name: %s → %s\n", diff.NameBefore, diff.NameAfter)
+	}
+	for _, person := range diff.Added {
+		output += colour.Success(" + add "+formatPerson(person)) + "\n"
+	}
+	for _, person := range diff.Removed {
+		output += colour.Failure(" - remove "+formatPerson(person)) + "\n"
+	}
+	for _, person := range diff.Promoted {
+		output += colour.Warning(fmt.Sprintf(" ↑ promote %s to admin", formatPerson(person))) + "\n"
+	}
+	for _, person := range diff.Demoted {
+		output += colour.Warning(fmt.Sprintf(" ↓ demote %s from admin", formatPerson(person))) + "\n"
+	}
+	return output + "\n"
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+func TestDoEditTeam(t *testing.T) {
+	me := team.Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	other := team.Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+		IsAdmin:     false,
+	}
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+
+	before := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{me}}
+
+	t.Run("with dry run, doesn't upload the roster", func(t *testing.T) {
+		after := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, other}}
+		uploader := mock.MockClient{}
+
+		err := doEditTeam(before, after, me, nil, true, &uploader)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(uploader.CallsTo("UpsertTeam")))
+	})
+
+	t.Run("with no changes, doesn't upload the roster", func(t *testing.T) {
+		uploader := mock.MockClient{}
+
+		err := doEditTeam(before, before, me, nil, false, &uploader)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(uploader.CallsTo("UpsertTeam")))
+	})
+
+	t.Run("with an invalid update, returns an error and doesn't upload", func(t *testing.T) {
+		after := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{other}}
+		uploader := mock.MockClient{}
+
+		err := doEditTeam(before, after, me, nil, true, &uploader)
+		assert.Equal(t, fmt.Errorf("invalid update: team has no administrators"), err)
+		assert.Equal(t, 0, len(uploader.CallsTo("UpsertTeam")))
+	})
+}
+
+func TestFormatTeamDiff(t *testing.T) {
+	person := team.Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+	}
+	formatted := "test2@example.com [" + exampledata.ExampleFingerprint2.String() + "]"
+
+	t.Run("shows the key being promoted", func(t *testing.T) {
+		got := colour.StripAllColourCodes(formatTeamDiff(team.Diff{Promoted: []team.Person{person}}))
+		assert.Equal(t, "Changes to the team:\n\n ↑ promote "+formatted+" to admin\n\n", got)
+	})
+
+	t.Run("shows the key being demoted", func(t *testing.T) {
+		got := colour.StripAllColourCodes(formatTeamDiff(team.Diff{Demoted: []team.Person{person}}))
+		assert.Equal(t, "Changes to the team:\n\n ↓ demote "+formatted+" from admin\n\n", got)
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
+)
+
+func teamExport(format string, onlyTeam uuid.UUID, outputFilename string) exitCode {
+	groupedMemberships, err := user.GroupedMemberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	if onlyTeam != uuid.Nil {
+		groupedMemberships = filterGroupedMembershipsByTeam(groupedMemberships, onlyTeam)
+	}
+
+	switch len(groupedMemberships) {
+	case 0:
+		if onlyTeam != uuid.Nil {
+			out.Print(ui.FormatFailure("You aren't a member of team "+onlyTeam.String(), nil, nil))
+			return 1
+		}
+		out.Print(ui.FormatFailure("You aren't a member of any teams", nil, nil))
+		return 1
+
+	case 1:
+		return exportTeam(groupedMemberships[0].Team, format, outputFilename)
+
+	default:
+		out.Print(ui.FormatFailure("Choosing from multiple teams not implemented", []string{
+			"Choose a team with --team=<uuid>",
+		}, nil))
+		return 1
+	}
+}
+
+func exportTeam(t team.Team, format string, outputFilename string) exitCode {
+	switch format {
+	case "json":
+		exported, err := team.ToJSON(&t)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to export "+t.Name, nil, err))
+			return 1
+		}
+		out.Print(string(exported) + "\n")
+		return 0
+
+	case "vcard":
+		exported, err := t.ToVCard()
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to export "+t.Name, nil, err))
+			return 1
+		}
+		out.Print(exported)
+		return 0
+
+	case "gpg-keyring":
+		if outputFilename == "" {
+			out.Print(ui.FormatFailure("A GnuPG keyring is binary and can't be printed", []string{
+				"Give a file to write it to with --output=<file>",
+			}, nil))
+			return 1
+		}
+		if err := writeTeamKeyring(&t, outputFilename, api); err != nil {
+			out.Print(ui.FormatFailure("Failed to export "+t.Name, nil, err))
+			return 1
+		}
+		out.Print(ui.FormatSuccess(
+			fmt.Sprintf("Exported keys for %d people to %s", t.MemberCount(), outputFilename),
+			[]string{
+				"Use the keyring with GnuPG like this:",
+				"",
+				"  gpg --no-default-keyring --keyring " + outputFilename + " --list-keys",
+			},
+		))
+		return 0
+
+	default:
+		out.Print(ui.FormatFailure(
+			fmt.Sprintf("Unsupported format '%s'", format),
+			[]string{"Supported formats: json, vcard, gpg-keyring"}, nil,
+		))
+		return 1
+	}
+}
+
+// filterGroupedMembershipsByTeam returns only the memberships of the team with the given UUID.
+func filterGroupedMembershipsByTeam(memberships []userpackage.GroupedMembership,
+	teamUUID uuid.UUID) (filtered []userpackage.GroupedMembership) {
+
+	for _, membership := range memberships {
+		if membership.Team.UUID == teamUUID {
+			filtered = append(filtered, membership)
+		}
+	}
+	return filtered
+}
+
+// writeTeamKeyring writes the keys of everyone in the team to a new GnuPG keyring file,
+// refusing to overwrite an existing file.
+func writeTeamKeyring(t *team.Team, filename string, keyFetcher team.PublicKeyFetcher) error {
+	keyring := bytes.NewBuffer(nil)
+	if err := team.ExportGPGKeyring(t, keyring, keyFetcher); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(keyring.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
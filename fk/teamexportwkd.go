@@ -0,0 +1,69 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func teamExportWKD(outputDir string) exitCode {
+	groupedMemberships, err := user.GroupedMemberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	if len(groupedMemberships) == 0 {
+		out.Print(ui.FormatFailure("You aren't a member of any teams", nil, nil))
+		return 1
+	}
+
+	sawError := false
+
+	for _, membership := range groupedMemberships {
+		t := membership.Team
+
+		printHeader("Export " + t.Name + " as a Web Key Directory")
+
+		if err := team.ExportWKD(&t, outputDir, api); err != nil {
+			out.Print(ui.FormatWarning("Failed to export "+t.Name, nil, err))
+			sawError = true
+			continue
+		}
+		printSuccess(fmt.Sprintf("Exported keys for %d people", t.MemberCount()))
+	}
+
+	if sawError {
+		return 1
+	}
+
+	out.Print(ui.FormatSuccess(
+		"Exported Web Key Directory",
+		[]string{
+			"Publish the following directory at https://openpgpkey.<your domain>/.well-known",
+			"",
+			"  " + filepath.Join(outputDir, ".well-known"),
+		},
+	))
+	return 0
+}
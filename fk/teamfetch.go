@@ -19,7 +19,10 @@ package fk
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/fluidkeys/fluidkeys/apiclient"
@@ -28,11 +31,28 @@ import (
 	"github.com/fluidkeys/fluidkeys/humanize"
 	"github.com/fluidkeys/fluidkeys/out"
 	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/progress"
 	"github.com/fluidkeys/fluidkeys/team"
 	"github.com/fluidkeys/fluidkeys/ui"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
 )
 
-func teamFetch(unattended bool) exitCode {
+// teamFetch updates the roster for each team and fetches everyone's keys.
+// If trustOnFirstUse is true, new keys are imported without prompting to verify them.
+// If noGpgImport is true, keys are saved in the team directory but not imported into GnuPG.
+// If onlyTeam isn't uuid.Nil, only the team with that UUID is fetched.
+func teamFetch(unattended bool, trustOnFirstUse bool, noGpgImport bool,
+	onlyTeam uuid.UUID) exitCode {
+
+	return fetchTeams(unattended, !unattended, trustOnFirstUse, noGpgImport, onlyTeam)
+}
+
+// fetchTeams does the work of teamFetch. If alwaysDownload is false, rosters and keys are only
+// checked if they were last checked more than 24 hours ago.
+func fetchTeams(unattended bool, alwaysDownload bool, trustOnFirstUse bool, noGpgImport bool,
+	onlyTeam uuid.UUID) exitCode {
+
 	sawError := false
 
 	if err := processRequestsToJoinTeam(unattended); err != nil {
@@ -46,11 +66,24 @@ func teamFetch(unattended bool) exitCode {
 		return 1
 	}
 
+	if onlyTeam != uuid.Nil {
+		if memberships, err = filterMembershipsByTeam(memberships, onlyTeam); err != nil {
+			out.Print(ui.FormatFailure("Can't fetch team "+onlyTeam.String(), []string{
+				"Run " + colour.Cmd("fk team list") + " to see which teams you're in.",
+			}, err))
+			return 1
+		}
+	}
+
+	// only animate progress when someone is watching
+	reporter := &progress.TerminalReporter{Spinner: !unattended}
+
 	for i := range memberships {
 		me := &memberships[i].Me
 		t := &memberships[i].Team
 
-		if err := doUpdateTeam(t, me, unattended); err != nil {
+		if err := doUpdateTeam(t, me, unattended, alwaysDownload, trustOnFirstUse, noGpgImport,
+			reporter); err != nil {
 			sawError = true
 
 			if unattended {
@@ -78,21 +111,76 @@ func teamFetch(unattended bool) exitCode {
 	return 0
 }
 
-func doUpdateTeam(myTeam *team.Team, me *team.Person, unattended bool) (err error) {
+// filterMembershipsByTeam returns only the memberships of the team with the given UUID, or an
+// error if there are none.
+func filterMembershipsByTeam(memberships []userpackage.TeamMembership, teamUUID uuid.UUID) (
+	[]userpackage.TeamMembership, error) {
+
+	filtered := []userpackage.TeamMembership{}
+	for _, membership := range memberships {
+		if membership.Team.UUID == teamUUID {
+			filtered = append(filtered, membership)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("you aren't a member of team %s", teamUUID)
+	}
+	return filtered, nil
+}
+
+func doUpdateTeam(myTeam *team.Team, me *team.Person, unattended bool, alwaysDownload bool,
+	trustOnFirstUse bool, noGpgImport bool, reporter progress.Reporter) (err error) {
+
 	printHeader(myTeam.Name)
 
 	var updatedTeam *team.Team
-	if updatedTeam, err = fetchAndUpdateRoster(*myTeam, *me, unattended); err != nil {
+	if updatedTeam, err = fetchAndUpdateRoster(
+		*myTeam, *me, alwaysDownload, reporter); err == team.ErrNoRoster {
+		out.Print(ui.FormatWarning("Failed to check team for updates", []string{
+			"There's no saved roster for " + myTeam.Name + ", so updates to it can't be",
+			"verified.",
+		}, err))
+		return err
+	} else if err == team.ErrRosterHashMismatch || err == team.ErrRosterHashMissing {
+		out.Print(ui.FormatFailure("Can't trust the saved roster for "+myTeam.Name, []string{
+			"The roster saved on this computer has been changed outside of Fluidkeys,",
+			"so it can't be used to verify updates.",
+			"",
+			"To start again with a fresh copy of the roster, leave the team and",
+			"ask to join it again:",
+			"",
+			"    " + colour.Cmd("fk team leave "+myTeam.UUID.String()),
+			"    " + colour.Cmd("fk team apply "+myTeam.UUID.String()),
+		}, err))
+		return err
+	} else if err != nil {
 		out.Print(ui.FormatWarning("Failed to check team for updates", []string{}, err))
 		return err
 	}
 	myTeam = updatedTeam // move myTeam pointer to updatedTeam
 
-	if err := fetchAndCertifyTeamKeys(*myTeam, *me, unattended); err != nil {
+	if err := fetchAndCertifyTeamKeys(
+		*myTeam, *me, unattended, alwaysDownload, trustOnFirstUse, noGpgImport,
+		reporter); err != nil {
 		out.Print(ui.FormatWarning("Error fetching team keys", nil, err))
 		return err
 	}
 
+	if myTeam.IsAdmin(me.Fingerprint) {
+		warnAboutExpiringMembers(*myTeam, time.Now())
+	}
+
+	if noGpgImport {
+		out.Print(ui.FormatSuccess(
+			successfullyFetchedKeysNoGpgHeadline,
+			[]string{
+				"You have successfully fetched everyone's key in " + myTeam.Name + ".",
+				"They weren't imported into GnuPG: you'll find them in the team directory.",
+			},
+		))
+		return nil
+	}
+
 	out.Print(ui.FormatSuccess(
 		successfullyFetchedKeysHeadline,
 		[]string{
@@ -111,10 +199,25 @@ func formatYouRequestedToJoin(request team.RequestToJoinTeam) string {
 
 // fetchAndUpdateRoster fetches any update to the team roster and saves it back to disk.
 // if alwaysDownload is false, only check the roster if we last checked it more than 24 hours ago
-func fetchAndUpdateRoster(t team.Team, me team.Person, unattended bool) (
-	updatedTeam *team.Team, err error) {
+// Downloading and verifying the roster is reported to reporter as "checking roster…".
+func fetchAndUpdateRoster(t team.Team, me team.Person, alwaysDownload bool,
+	reporter progress.Reporter) (updatedTeam *team.Team, err error) {
+
+	// the saved roster is what we trust to verify any update, so without it we can't update.
+	originalRoster, originalSignature, err := t.Roster()
+	if err != nil {
+		return nil, err
+	}
+
+	teamSubdir, err := team.Directory(t, fluidkeysDirectory)
+	if err != nil {
+		return nil, err
+	}
 
-	alwaysDownload := !unattended
+	saver := team.RosterSaver{Directory: teamSubdir}
+	if err := verifySavedRoster(t, saver); err != nil {
+		return nil, err
+	}
 
 	// TODO: download the updated roster and handle the case where we're forbidden, as it
 	// means we're no longer in the team.
@@ -131,56 +234,153 @@ func fetchAndUpdateRoster(t team.Team, me team.Person, unattended bool) (
 		}
 	}
 
-	roster, signature, err := api.GetTeamRoster(t.UUID, me.Fingerprint)
+	reporter.Start("checking roster…")
+	roster, signature, err := checkRoster(t, me, originalRoster, originalSignature)
 	if err != nil {
-		return nil, fmt.Errorf("error downloading team roster: %v", err)
+		reporter.Failure(err)
+		return nil, err
 	}
+	reporter.Success()
 
-	if originalRoster, _ := t.Roster(); originalRoster == roster {
+	if roster == originalRoster {
 		log.Printf("no change to roster, nothing to do.")
 		db.RecordLast("fetch", t, time.Now())
 		return &t, nil // no change to roster. nothing to do.
 	}
 
-	adminKeys, err := fetchAdminPublicKeys(t)
+	updatedTeam, err = team.Load(roster, signature)
 	if err != nil {
-		return nil, fmt.Errorf("error getting team admin public keys: %v", err)
+		return nil, err
 	}
 
-	if err := team.VerifyRoster(roster, signature, adminKeys); err != nil {
-		return nil, fmt.Errorf("couldn't validate signature on updated roster: %v", err)
+	if team.TeamsEqual(&t, updatedTeam) {
+		log.Printf("roster only changed formatting, not saving it.")
+		db.RecordLast("fetch", t, time.Now())
+		return &t, nil
 	}
-	log.Printf("new roster verified OK")
 
-	teamSubdir, err := team.Directory(t, fluidkeysDirectory)
-	if err != nil {
-		return nil, err
+	if err := validateRosterUpdate(&t, updatedTeam, signature); err != nil {
+		return nil, fmt.Errorf("refusing to save updated roster: %v", err)
 	}
 
-	saver := team.RosterSaver{Directory: teamSubdir}
 	if err := saver.Save(roster, signature); err != nil {
 		return nil, err
 	}
 
 	db.RecordLast("fetch", t, time.Now())
+	db.RecordLast("hash", t, time.Now())
+	return updatedTeam, nil
+}
 
-	updatedTeam, err = team.Load(roster, signature)
+// validateRosterUpdate returns an error if the update from before to after, signed with the
+// given signature, isn't allowed. The signer is the admin of the saved roster who issued the
+// signature, and must still be an admin in the update. Keys we know have been revoked (either
+// ours, revoked with `fk key revoke --publish`, or ones whose revocation was seen when fetching
+// team keys) can't be added or changed.
+func validateRosterUpdate(before *team.Team, after *team.Team, signature string) error {
+	signer := team.FindSigningAdmin(signature, before)
+	if signer == nil {
+		return fmt.Errorf("roster isn't signed by an admin of the team you saved")
+	}
+
+	revokedFingerprints, err := db.GetRevokedKeys()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to get revoked keys: %v", err)
 	}
-	return updatedTeam, nil
+	return team.ValidateUpdate(before, after, *signer, revokedFingerprints)
+}
+
+// verifySavedRoster checks the saved roster hasn't been changed on disk since it was saved.
+// Rosters saved before hashes were written don't have one, so a missing hash is only an error
+// once we've seen one for the team.
+func verifySavedRoster(t team.Team, saver team.RosterSaver) error {
+	err := saver.Verify()
+	switch err {
+	case nil:
+		db.RecordLast("hash", t, time.Now())
+		return nil
+
+	case team.ErrRosterHashMissing:
+		if hashed, dbErr := db.GetLast("hash", t); dbErr != nil {
+			return fmt.Errorf("failed to check whether roster has been hashed: %v", dbErr)
+		} else if hashed.IsZero() {
+			log.Printf("roster for %s saved before hashes were written, can't verify it", t.Name)
+			return nil
+		}
+		return err
+
+	default:
+		return err
+	}
+}
+
+// checkRoster downloads the team roster and, if it's changed from originalRoster, verifies that
+// it's signed by one of the team's admins, no earlier than originalSignature.
+func checkRoster(t team.Team, me team.Person, originalRoster string,
+	originalSignature string) (roster string, signature string, err error) {
+
+	roster, signature, err = api.GetTeamRoster(t.UUID, me.Fingerprint)
+	if err != nil {
+		return "", "", fmt.Errorf("error downloading team roster: %v", err)
+	}
+
+	if originalRoster == roster {
+		return roster, signature, nil
+	}
+
+	adminKeys, err := fetchAdminPublicKeys(t)
+	if err != nil {
+		return "", "", fmt.Errorf("error getting team admin public keys: %v", err)
+	}
+
+	switch err := team.VerifyRoster(roster, []string{signature}, adminKeys, 0, time.Now(),
+		team.RequireNewerThan(originalSignature)); err {
+	case nil:
+
+	case team.ErrSignatureNotFound:
+		return "", "", fmt.Errorf(
+			"updated roster from Fluidkeys isn't signed, so it can't be trusted")
+
+	case team.ErrSignatureInvalid:
+		return "", "", fmt.Errorf("updated roster isn't signed by an admin of the team you " +
+			"saved: it may have been tampered with")
+
+	case team.ErrSignatureTooOld:
+		return "", "", fmt.Errorf("updated roster was signed before the roster you saved: it " +
+			"may be an old copy being replayed")
+
+	default:
+		return "", "", fmt.Errorf("couldn't validate signature on updated roster: %v", err)
+	}
+	log.Printf("new roster verified OK")
+	return roster, signature, nil
 }
 
 // fetchAndCertifyTeamKeys fetches each key listed in the team and locally signs them in GnuPG
 // if `alwaysDownload` is false, it will only try to fetch keys every 24 hours, otherwise it'll
 // check every time.
-func fetchAndCertifyTeamKeys(
-	t team.Team, me team.Person, unattended bool) (err error) {
-
-	alwaysDownload := !unattended
+// Keys seen for the first time must be verified by the user, unless trustOnFirstUse is true.
+// Each key is saved in the team directory. Unless noGpgImport is true, it's also certified and
+// imported into GnuPG.
+// Fetching and importing each key is reported to reporter.
+func fetchAndCertifyTeamKeys(t team.Team, me team.Person, unattended bool, alwaysDownload bool,
+	trustOnFirstUse bool, noGpgImport bool, reporter progress.Reporter) (err error) {
+
+	teamDirectory, err := team.Directory(t, fluidkeysDirectory)
+	if err != nil {
+		return err
+	}
 
 	out.Print("Fetching and signing keys for other members of " + t.Name + ":\n\n")
 
+	unchanged := listUnchangedKeys(t.People, api)
+	keyDownloader := &teamKeyDownloader{
+		teamUUID:       t.UUID,
+		me:             me.Fingerprint,
+		client:         api,
+		fetchAdminKeys: func() ([]*pgpkey.PgpKey, error) { return fetchAdminPublicKeys(t) },
+	}
+
 	for _, person := range t.People {
 		if person == me {
 			continue
@@ -198,19 +398,48 @@ func fetchAndCertifyTeamKeys(
 
 		var theirKey *pgpkey.PgpKey
 
-		err = ui.RunWithCheckboxes(person.Email+": fetch key", func() error {
-			theirKey, err = api.GetPublicKeyByFingerprint(person.Fingerprint)
+		if unchanged[person.Fingerprint] {
+			if theirKey, err = loadTeamKey(person.Fingerprint, teamDirectory); err != nil {
+				log.Printf("failed to load saved key for %s, downloading it: %v", person, err)
+				theirKey = nil
+			}
+		}
+
+		if theirKey == nil {
+			err = runWithProgress(reporter, person.Email+": fetching key…", func() error {
+				theirKey, err = keyDownloader.GetPublicKeyByFingerprint(person.Fingerprint)
 
-			if err != nil && err == apiclient.ErrPublicKeyNotFound {
-				log.Print(err)
-				return fmt.Errorf("Couldn't find key")
-			} else if err != nil {
-				log.Print(err)
-				return fmt.Errorf("Got error from Fluidkeys server")
+				if err != nil && err == apiclient.ErrPublicKeyNotFound {
+					log.Print(err)
+					return fmt.Errorf("Couldn't find key at %s", person.KeyURL(apiBaseURL))
+				} else if err != nil {
+					log.Print(err)
+					return fmt.Errorf("Got error from Fluidkeys server")
+				}
+				return nil
+			})
+			if err != nil {
+				continue
 			}
-			return nil
-		})
-		if err != nil {
+		}
+
+		if len(theirKey.Revocations) > 0 {
+			// remember the revocation so later roster updates can't change their entry
+			log.Printf("key for %s has been revoked", person.Email)
+			if err := db.RecordRevokedKey(person.Fingerprint); err != nil {
+				log.Printf("failed to record revoked key %s: %v", person.Fingerprint, err)
+			}
+		}
+
+		if !verifyKey(person, unattended, trustOnFirstUse, &interactiveYesNoPrompter{}) {
+			continue
+		}
+
+		if noGpgImport {
+			// certifying only affects the copy of the key imported into GnuPG
+			err = runWithProgress(reporter, person.Email+": saving…", func() error {
+				return storeTeamKey(theirKey, teamDirectory, nil)
+			})
 			continue
 		}
 
@@ -240,21 +469,8 @@ func fetchAndCertifyTeamKeys(
 			return nil
 		})
 
-		err = ui.RunWithCheckboxes(person.Email+": import into gpg", func() error {
-			armoredKey, err := theirKey.Armor()
-			if err != nil {
-				log.Print(err)
-				return fmt.Errorf("failed to ASCII armor key")
-			}
-
-			err = gpg.ImportArmoredKey(armoredKey)
-			if err != nil {
-				log.Print(err)
-				return fmt.Errorf("Failed to import key into gpg")
-			}
-			db.RecordLast("fetch", theirKey.Fingerprint(), time.Now())
-
-			return nil
+		err = runWithProgress(reporter, person.Email+": importing…", func() error {
+			return storeTeamKey(theirKey, teamDirectory, &gpg)
 		})
 		// keep trying subsequent keys even if we hit an error.
 	}
@@ -262,6 +478,270 @@ func fetchAndCertifyTeamKeys(
 	return err
 }
 
+// warnAboutExpiringMembers prints a warning listing the members of the team whose keys, as saved
+// in the team directory, will expire within expiringMemberWarningWindow.
+func warnAboutExpiringMembers(t team.Team, now time.Time) {
+	teamDirectory, err := team.Directory(t, fluidkeysDirectory)
+	if err != nil {
+		log.Printf("failed to get team directory: %v", err)
+		return
+	}
+
+	expiring := t.ExpiringMembers(expiringMemberWarningWindow, teamKeyCache{teamDirectory}, now)
+	if len(expiring) > 0 {
+		out.Print(formatExpiringMembersWarning(expiring, now))
+	}
+}
+
+func formatExpiringMembersWarning(expiring []team.ExpiringMember, now time.Time) string {
+	lines := []string{}
+	for _, member := range expiring {
+		lines = append(lines, fmt.Sprintf("%s: expires %s (in %s)", member.Person.Email,
+			member.ExpiresAt.Format("2 Jan 2006"), humanize.RoughDuration(member.ExpiresAt.Sub(now))))
+	}
+	lines = append(lines, "", "Ask them to run "+colour.Cmd("fk key maintain")+
+		" so people can keep sending them secrets.")
+
+	return ui.FormatWarning(humanize.Pluralize(len(expiring), "team member's key expires soon",
+		"team members' keys expire soon"), lines, nil)
+}
+
+// teamKeyCache gets the keys saved in a team directory by storeTeamKey
+type teamKeyCache struct {
+	teamDirectory string
+}
+
+func (c teamKeyCache) GetPublicKeyByFingerprint(fingerprint fp.Fingerprint) (
+	*pgpkey.PgpKey, error) {
+
+	return loadTeamKey(fingerprint, c.teamDirectory)
+}
+
+type rosterBundleDownloader interface {
+	DownloadRosterBundle(teamUUID uuid.UUID, me fp.Fingerprint) (*team.RosterBundle, error)
+	GetPublicKeyByFingerprint(fingerprint fp.Fingerprint) (*pgpkey.PgpKey, error)
+}
+
+// teamKeyDownloader gets team members' keys from the team's roster bundle, which is downloaded
+// in one request the first time a key is needed. The bundle is only used if
+// team.LoadFromSignedBundle verifies it with the keys from fetchAdminKeys. Keys that aren't in
+// the bundle, or all keys if the bundle couldn't be downloaded or verified, are fetched one at
+// a time.
+type teamKeyDownloader struct {
+	teamUUID       uuid.UUID
+	me             fp.Fingerprint
+	client         rosterBundleDownloader
+	fetchAdminKeys func() ([]*pgpkey.PgpKey, error)
+
+	bundledKeys map[fp.Fingerprint]*pgpkey.PgpKey
+	triedBundle bool
+}
+
+func (d *teamKeyDownloader) GetPublicKeyByFingerprint(fingerprint fp.Fingerprint) (
+	*pgpkey.PgpKey, error) {
+
+	if !d.triedBundle {
+		d.triedBundle = true
+		if bundledKeys, err := d.downloadAndVerifyBundle(); err != nil {
+			log.Printf("not using roster bundle, fetching keys one at a time: %v", err)
+		} else {
+			d.bundledKeys = bundledKeys
+		}
+	}
+
+	if key, found := d.bundledKeys[fingerprint]; found {
+		return key, nil
+	}
+	return d.client.GetPublicKeyByFingerprint(fingerprint)
+}
+
+func (d *teamKeyDownloader) downloadAndVerifyBundle() (map[fp.Fingerprint]*pgpkey.PgpKey, error) {
+	bundle, err := d.client.DownloadRosterBundle(d.teamUUID, d.me)
+	if err != nil {
+		return nil, err
+	} else if bundle == nil {
+		return nil, fmt.Errorf("no bundle")
+	}
+
+	adminKeys, err := d.fetchAdminKeys()
+	if err != nil {
+		return nil, fmt.Errorf("error getting team admin public keys: %v", err)
+	}
+
+	_, keys, err := team.LoadFromSignedBundle(bundle, adminKeys)
+	if err != nil {
+		return nil, fmt.Errorf("bundle didn't verify: %v", err)
+	}
+	return keys, nil
+}
+
+type publicKeyLister interface {
+	ListPublicKeys(since time.Time) ([]apiclient.KeySummary, error)
+}
+
+// listUnchangedKeys returns the fingerprints of people whose keys have been fetched before and
+// haven't changed on the server since, so the copies saved by storeTeamKey can be used instead
+// of downloading them again.
+// If the server can't list changed keys, it returns an empty map so every key is downloaded.
+func listUnchangedKeys(people []team.Person, lister publicKeyLister) map[fp.Fingerprint]bool {
+	unchanged := map[fp.Fingerprint]bool{}
+
+	var since time.Time
+	for _, person := range people {
+		lastFetched, err := db.GetLast("fetch", person.Fingerprint)
+		if err != nil {
+			log.Printf("error calling db.GetLast(\"fetch\", %v): %v", person.Fingerprint, err)
+			continue
+		} else if lastFetched.IsZero() {
+			continue // never fetched, so there's no saved copy
+		}
+
+		unchanged[person.Fingerprint] = true
+		if since.IsZero() || lastFetched.Before(since) {
+			since = lastFetched
+		}
+	}
+	if len(unchanged) == 0 {
+		return unchanged
+	}
+
+	changedKeys, err := lister.ListPublicKeys(since)
+	if err != nil {
+		log.Printf("failed to list keys changed since %s, downloading all keys: %v", since, err)
+		return map[fp.Fingerprint]bool{}
+	}
+	for _, key := range changedKeys {
+		delete(unchanged, key.Fingerprint)
+	}
+	return unchanged
+}
+
+// loadTeamKey loads the key saved by storeTeamKey in the team directory.
+func loadTeamKey(fingerprint fp.Fingerprint, teamDirectory string) (*pgpkey.PgpKey, error) {
+	filename := filepath.Join(teamDirectory, "keys", fingerprint.Hex()+".asc")
+	armoredKey, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := pgpkey.LoadFromArmoredPublicKey(string(armoredKey))
+	if err != nil {
+		return nil, err
+	} else if key.Fingerprint() != fingerprint {
+		return nil, fmt.Errorf("%s contains key %s", filename, key.Fingerprint())
+	}
+	return key, nil
+}
+
+type armoredKeyImporter interface {
+	ImportArmoredKey(armoredKey string) error
+}
+
+// storeTeamKey saves the ASCII armored key to <teamDirectory>/keys/<fingerprint>.asc and, if
+// importer isn't nil, imports it (e.g. into GnuPG).
+func storeTeamKey(key *pgpkey.PgpKey, teamDirectory string, importer armoredKeyImporter) error {
+	armoredKey, err := key.Armor()
+	if err != nil {
+		log.Print(err)
+		return fmt.Errorf("failed to ASCII armor key")
+	}
+
+	keysDirectory := filepath.Join(teamDirectory, "keys")
+	if err := os.MkdirAll(keysDirectory, 0700); err != nil {
+		return fmt.Errorf("failed to make directory %s: %v", keysDirectory, err)
+	}
+
+	filename := filepath.Join(keysDirectory, key.Fingerprint().Hex()+".asc")
+	if err := ioutil.WriteFile(filename, []byte(armoredKey), 0600); err != nil {
+		return fmt.Errorf("failed to save key: %v", err)
+	}
+
+	if importer != nil {
+		if err := importer.ImportArmoredKey(armoredKey); err != nil {
+			log.Print(err)
+			return fmt.Errorf("Failed to import key into gpg")
+		}
+	}
+	db.RecordLast("fetch", key.Fingerprint(), time.Now())
+	return nil
+}
+
+// runWithProgress reports the start of the step called label to reporter, runs f, then reports
+// whether f succeeded.
+func runWithProgress(reporter progress.Reporter, label string, f func() error) error {
+	reporter.Start(label)
+	if err := f(); err != nil {
+		reporter.Failure(err)
+		return err
+	}
+	reporter.Success()
+	return nil
+}
+
+// verifyKey returns true if person's key can be used. A key seen for the first time is trusted
+// if trustOnFirstUse is true, otherwise the user is asked to verify its fingerprint. Unattended
+// runs can't ask, so they skip the key until the user runs fk team fetch themselves.
+func verifyKey(person team.Person, unattended bool, trustOnFirstUse bool,
+	prompter promptYesNoInterface) bool {
+
+	if isKeyVerified(person.Fingerprint) {
+		return true
+	}
+
+	if trustOnFirstUse {
+		recordKeyVerified(person.Fingerprint)
+		return true
+	} else if unattended {
+		ui.PrintCheckboxSkipped(person.Email + ": new key, run " +
+			colour.Cmd("fk team fetch") + " to verify it")
+		return false
+	} else if !promptToVerifyKey(person, prompter) {
+		ui.PrintCheckboxSkipped(person.Email + ": key not verified")
+		return false
+	}
+	return true
+}
+
+// isKeyVerified returns true if the key is already in GnuPG, or the user has previously verified
+// its fingerprint.
+func isKeyVerified(fingerprint fp.Fingerprint) bool {
+	if _, err := loadPgpKey(fingerprint); err == nil {
+		return true
+	}
+
+	timeVerified, err := db.GetLast("verify", fingerprint)
+	if err != nil {
+		log.Printf("error calling db.GetLast(\"verify\", %v): %v", fingerprint, err)
+		return false
+	}
+	return !timeVerified.IsZero()
+}
+
+func recordKeyVerified(fingerprint fp.Fingerprint) {
+	if err := db.RecordLast("verify", fingerprint, time.Now()); err != nil {
+		log.Printf("error calling db.RecordLast(\"verify\", %v, now): %v", fingerprint, err)
+	}
+}
+
+// promptToVerifyKey shows the fingerprint of a team member's key and asks the user to confirm it
+// matches. If they do, the fingerprint is recorded so they aren't asked again.
+func promptToVerifyKey(person team.Person, prompter promptYesNoInterface) bool {
+	out.Print(ui.FormatInfo(
+		"New key for "+person.Email, []string{
+			"Check this fingerprint matches the one " + person.Email + " gave you:",
+			"",
+			"  " + colour.Info(displayFingerprint(person.Fingerprint)),
+		},
+	))
+
+	if !prompter.promptYesNo("Does the fingerprint match?", "", nil) {
+		return false
+	}
+
+	recordKeyVerified(person.Fingerprint)
+	return true
+}
+
 // emailKeyAndCertifier represents a combination of email (from UID), key, and certifier key.
 // This is used to record in the database that we've already certified a UID.
 // Caution: renaming this struct will invalidate any log entries.
@@ -316,7 +796,7 @@ func processRequestsToJoinTeam(unattended bool) (returnError error) {
 	for _, request := range requestsToJoinTeams {
 		// TODO: check if I'm already in the team
 
-		if time.Now().Sub(request.RequestedAt) > time.Duration(7*24)*time.Hour {
+		if request.IsExpired(time.Now()) {
 			out.Print(ui.FormatWarning(
 				"Your request to join "+request.TeamName+" has expired",
 				[]string{
@@ -354,19 +834,60 @@ func processRequestsToJoinTeam(unattended bool) (returnError error) {
 		}
 
 		if err = verifyBrandNewRoster(*t, roster, signature); err != nil {
+			var details []string
+			switch err {
+			case team.ErrSignatureNotFound:
+				details = []string{"The roster for " + t.Name + " isn't signed."}
+
+			case team.ErrSignatureInvalid:
+				details = []string{
+					"The roster for " + t.Name + " isn't signed by one of its admins, so",
+					"it may have been tampered with. Ask a team admin to check the roster.",
+				}
+
+			}
 			out.Print(ui.FormatFailure(
-				"Failed to verify team roster's cryptographic signature", nil, err,
+				"Failed to verify team roster's cryptographic signature", details, err,
 			))
 			returnError = err
 			continue
 		}
 
+		// a team that fails to load shouldn't stop us joining another one
+		knownTeams, err := team.LoadTeams(fluidkeysDirectory)
+		if loadErrors, ok := err.(team.LoadErrors); ok {
+			log.Printf("some saved teams failed to load: %v", loadErrors)
+		} else if err != nil {
+			out.Print(ui.FormatFailure("Failed to load saved teams", nil, err))
+			returnError = err
+			continue
+		}
+		if err := team.ValidateNewTeam(knownTeams, t); err != nil {
+			out.Print(ui.FormatFailure("Refusing to save roster for "+t.Name, []string{
+				"You already have a saved roster for a team with the same UUID, so this",
+				"roster may be an attempt to replace it. Ask the team admin to check the",
+				"roster.",
+			}, err))
+			returnError = err
+			continue
+		}
+
 		teamSubdirectory, err := team.Directory(*t, fluidkeysDirectory)
 		if err != nil {
 			out.Print(ui.FormatFailure("Failed to get team subdirectory", nil, err))
 			returnError = err
 			continue
 		}
+		// a saved roster for this team that failed to load isn't in knownTeams, so check there
+		// isn't one before saving over it
+		if _, err := team.LoadSavedRoster(teamSubdirectory); err != team.ErrNoRoster {
+			out.Print(ui.FormatFailure("Refusing to save roster for "+t.Name, []string{
+				"You already have a saved roster for this team, but it couldn't be read.",
+			}, err))
+			returnError = fmt.Errorf("already have a saved roster for %s", t.Name)
+			continue
+		}
+
 		rosterWriter := team.RosterSaver{Directory: teamSubdirectory}
 		err = rosterWriter.Save(roster, signature)
 
@@ -375,6 +896,7 @@ func processRequestsToJoinTeam(unattended bool) (returnError error) {
 			returnError = err
 			continue
 		}
+		db.RecordLast("hash", t, time.Now())
 
 		out.Print(ui.FormatSuccess(
 			"Your request to join "+t.Name+" has been approved",
@@ -438,7 +960,7 @@ func getUnlockedKey(fingerprint fp.Fingerprint, unattended bool) (*pgpkey.PgpKey
 
 func fetchAdminPublicKeys(t team.Team) (adminKeys []*pgpkey.PgpKey, err error) {
 	for _, p := range t.Admins() {
-		key, err := discoverPublicKey(p.Fingerprint)
+		key, err := discoverPublicKey(p.Fingerprint, p.Email)
 		if err != nil {
 			return nil, err
 		}
@@ -447,7 +969,7 @@ func fetchAdminPublicKeys(t team.Team) (adminKeys []*pgpkey.PgpKey, err error) {
 	return adminKeys, nil
 }
 
-func discoverPublicKey(fingerprint fp.Fingerprint) (key *pgpkey.PgpKey, err error) {
+func discoverPublicKey(fingerprint fp.Fingerprint, email string) (key *pgpkey.PgpKey, err error) {
 	if key, err := loadPgpKey(fingerprint); err != nil { // no error
 		log.Printf("failed to find key %s in GnuPG: %v", fingerprint, err)
 	} else {
@@ -460,6 +982,15 @@ func discoverPublicKey(fingerprint fp.Fingerprint) (key *pgpkey.PgpKey, err erro
 		return key, nil
 	}
 
+	if key, err = wkd.Lookup(email); err != nil {
+		log.Printf("failed to find key for %s in WKD: %v", email, err)
+	} else if key.Fingerprint() != fingerprint {
+		log.Printf("found key %s for %s in WKD, but expected %s",
+			key.Fingerprint(), email, fingerprint)
+	} else {
+		return key, nil
+	}
+
 	return nil, fmt.Errorf("failed multiple attempts to find get public key for %s", fingerprint)
 }
 
@@ -471,11 +1002,22 @@ func verifyBrandNewRoster(t team.Team, roster string, signature string) error {
 		return err
 	}
 
-	return team.VerifyRoster(roster, signature, adminKeys)
+	err = team.VerifyRoster(roster, []string{signature}, adminKeys, 0, time.Now())
+	if err == team.ErrSignatureInvalid {
+		for _, key := range adminKeys {
+			log.Printf("roster for %s not signed by admin key %s", t.UUID, key.Fingerprint())
+		}
+	}
+	return err
 }
 
+// expiringMemberWarningWindow is how long before a team member's key expires that admins are
+// warned about it by fk team fetch
+const expiringMemberWarningWindow = 14 * 24 * time.Hour
+
 const (
-	successfullyFetchedKeysHeadline = "Successfully fetched keys and imported them into GnuPG"
+	successfullyFetchedKeysHeadline      = "Successfully fetched keys and imported them into GnuPG"
+	successfullyFetchedKeysNoGpgHeadline = "Successfully fetched keys"
 )
 
 const (
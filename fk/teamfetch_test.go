@@ -0,0 +1,604 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/database"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/gpgwrapper"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
+)
+
+func TestFetchAndUpdateRoster(t *testing.T) {
+	t.Run("returns ErrNoRoster for a team without a saved roster", func(t *testing.T) {
+		me := team.Person{
+			Email:       "test4@example.com",
+			Fingerprint: exampledata.ExampleFingerprint4,
+			IsAdmin:     true,
+		}
+		unsavedTeam := team.Team{
+			UUID:   uuid.Must(uuid.NewV4()),
+			Name:   "Kiffix",
+			People: []team.Person{me},
+		}
+
+		reporter := &recordingReporter{}
+		_, err := fetchAndUpdateRoster(unsavedTeam, me, true, reporter)
+		assert.Equal(t, team.ErrNoRoster, err)
+		assert.Equal(t, []string(nil), reporter.events)
+	})
+
+	me := team.Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	roster := `# Fluidkeys team roster
+
+uuid = "38be2a70-23d8-11e9-bafd-7f97f2e239a3"
+name = "Kiffix"
+
+[[person]]
+email = "test4@example.com"
+fingerprint = "` + exampledata.ExampleFingerprint4.String() + `"
+is_admin = true
+`
+	savedTeam, err := team.Load(roster, "signature")
+	assert.NoError(t, err)
+
+	originalAPI, originalDB := api, db
+	defer func() { api, db = originalAPI, originalDB }()
+	db = database.New(testhelpers.Maketemp(t))
+
+	t.Run("reports checking an unchanged roster", func(t *testing.T) {
+		api = &mock.MockClient{
+			GetTeamRosterRoster:    map[fpr.Fingerprint]string{me.Fingerprint: roster},
+			GetTeamRosterSignature: map[fpr.Fingerprint]string{me.Fingerprint: "signature"},
+		}
+		reporter := &recordingReporter{}
+
+		gotTeam, err := fetchAndUpdateRoster(*savedTeam, me, true, reporter)
+		assert.NoError(t, err)
+		assert.Equal(t, savedTeam.UUID, gotTeam.UUID)
+		assert.Equal(t, []string{"start: checking roster…", "success"}, reporter.events)
+	})
+
+	t.Run("reports failing to download the roster", func(t *testing.T) {
+		api = &mock.MockClient{} // no roster, so the mock returns ErrForbidden
+		reporter := &recordingReporter{}
+
+		_, err := fetchAndUpdateRoster(*savedTeam, me, true, reporter)
+		assert.GotError(t, err)
+		assert.Equal(t, []string{
+			"start: checking roster…",
+			"failure: " + err.Error(),
+		}, reporter.events)
+	})
+
+	t.Run("refuses to update a roster that's been changed on disk", func(t *testing.T) {
+		originalDirectory := fluidkeysDirectory
+		defer func() { fluidkeysDirectory = originalDirectory }()
+		fluidkeysDirectory = testhelpers.Maketemp(t)
+
+		teamSubdir, err := team.Directory(*savedTeam, fluidkeysDirectory)
+		assert.NoError(t, err)
+		saver := team.RosterSaver{Directory: teamSubdir}
+		assert.NoError(t, saver.Save(roster, "signature"))
+
+		assert.NoError(t, ioutil.WriteFile(
+			filepath.Join(teamSubdir, "roster.toml"), []byte(roster+"# tampered\n"), 0600))
+
+		mockAPI := &mock.MockClient{}
+		api = mockAPI
+		reporter := &recordingReporter{}
+
+		_, err = fetchAndUpdateRoster(*savedTeam, me, true, reporter)
+		assert.Equal(t, team.ErrRosterHashMismatch, err)
+		assert.Equal(t, 0, len(mockAPI.CallsTo("GetTeamRoster")))
+	})
+}
+
+func TestValidateRosterUpdate(t *testing.T) {
+	originalDB := db
+	defer func() { db = originalDB }()
+	db = database.New(testhelpers.Maketemp(t))
+
+	adminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+	otherKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey2, "test2")
+	assert.NoError(t, err)
+
+	admin := team.Person{
+		Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4, IsAdmin: true,
+	}
+	alice := team.Person{Email: "alice@example.com", Fingerprint: exampledata.ExampleFingerprint2}
+	bob := team.Person{Email: "bob@example.com", Fingerprint: exampledata.ExampleFingerprint3}
+
+	before := team.Team{
+		UUID:   uuid.Must(uuid.FromString("38be2a70-23d8-11e9-bafd-7f97f2e239a3")),
+		Name:   "Kiffix",
+		People: []team.Person{admin, alice, bob},
+	}
+
+	// makeUpdate returns `before` with the given people, and a signature of its roster made
+	// by signingKey
+	makeUpdate := func(t *testing.T, signingKey *pgpkey.PgpKey, people ...team.Person) (
+		*team.Team, string) {
+
+		t.Helper()
+		after := before
+		after.People = people
+		roster, err := after.PreviewRoster()
+		assert.NoError(t, err)
+		signature, err := signingKey.MakeArmoredDetachedSignature([]byte(roster))
+		assert.NoError(t, err)
+		return &after, signature
+	}
+
+	t.Run("allows an update signed by an admin", func(t *testing.T) {
+		after, signature := makeUpdate(t, adminKey, admin, alice)
+		assert.NoError(t, validateRosterUpdate(&before, after, signature))
+	})
+
+	t.Run("rejects an update not signed by an admin of the saved roster", func(t *testing.T) {
+		after, signature := makeUpdate(t, otherKey, admin, alice)
+		assert.GotError(t, validateRosterUpdate(&before, after, signature))
+	})
+
+	t.Run("rejects an update that demotes the admin who signed it", func(t *testing.T) {
+		demoted := team.Person{Email: admin.Email, Fingerprint: admin.Fingerprint}
+		promoted := team.Person{Email: alice.Email, Fingerprint: alice.Fingerprint, IsAdmin: true}
+		after, signature := makeUpdate(t, adminKey, demoted, promoted)
+		assert.GotError(t, validateRosterUpdate(&before, after, signature))
+	})
+
+	t.Run("rejects an update that changes the email for a key", func(t *testing.T) {
+		renamed := team.Person{Email: "mallory@example.com", Fingerprint: alice.Fingerprint}
+		after, signature := makeUpdate(t, adminKey, admin, renamed, bob)
+		assert.GotError(t, validateRosterUpdate(&before, after, signature))
+	})
+
+	t.Run("rejects changes to a member whose key has been recorded as revoked", func(t *testing.T) {
+		promoted := team.Person{Email: bob.Email, Fingerprint: bob.Fingerprint, IsAdmin: true}
+		after, signature := makeUpdate(t, adminKey, admin, alice, promoted)
+		assert.NoError(t, validateRosterUpdate(&before, after, signature))
+
+		assert.NoError(t, db.RecordRevokedKey(bob.Fingerprint))
+		assert.GotError(t, validateRosterUpdate(&before, after, signature))
+	})
+}
+
+func TestVerifySavedRoster(t *testing.T) {
+	roster := `# Fluidkeys team roster
+
+uuid = "38be2a70-23d8-11e9-bafd-7f97f2e239a3"
+name = "Kiffix"
+
+[[person]]
+email = "test4@example.com"
+fingerprint = "` + exampledata.ExampleFingerprint4.String() + `"
+is_admin = true
+`
+	savedTeam, err := team.Load(roster, "signature")
+	assert.NoError(t, err)
+
+	originalDB := db
+	defer func() { db = originalDB }()
+
+	setup := func(t *testing.T) (saver team.RosterSaver) {
+		db = database.New(testhelpers.Maketemp(t))
+		saver = team.RosterSaver{Directory: testhelpers.Maketemp(t)}
+		assert.NoError(t, saver.Save(roster, "signature"))
+		return saver
+	}
+
+	t.Run("passes for an unchanged roster", func(t *testing.T) {
+		saver := setup(t)
+		assert.NoError(t, verifySavedRoster(*savedTeam, saver))
+	})
+
+	t.Run("passes for a roster saved before hashes were written", func(t *testing.T) {
+		saver := setup(t)
+		assert.NoError(t, os.Remove(filepath.Join(saver.Directory, "roster.toml.sha256")))
+
+		assert.NoError(t, verifySavedRoster(*savedTeam, saver))
+	})
+
+	t.Run("fails if the hash is removed after it's been seen", func(t *testing.T) {
+		saver := setup(t)
+		assert.NoError(t, verifySavedRoster(*savedTeam, saver))
+		assert.NoError(t, os.Remove(filepath.Join(saver.Directory, "roster.toml.sha256")))
+
+		assert.Equal(t, team.ErrRosterHashMissing, verifySavedRoster(*savedTeam, saver))
+	})
+}
+
+func TestFetchAndCertifyTeamKeysWithNoGpgImport(t *testing.T) {
+	me := team.Person{Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4}
+	other := team.Person{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2}
+	myTeam := team.Team{
+		UUID:   uuid.Must(uuid.NewV4()),
+		Name:   "Kiffix",
+		People: []team.Person{me, other},
+	}
+
+	otherKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+
+	originalAPI, originalDB, originalGpg, originalDir := api, db, gpg, fluidkeysDirectory
+	defer func() {
+		api, db, gpg, fluidkeysDirectory = originalAPI, originalDB, originalGpg, originalDir
+	}()
+	fluidkeysDirectory = testhelpers.Maketemp(t)
+	db = database.New(fluidkeysDirectory)
+	gpg = gpgwrapper.GnuPG{} // not a working GnuPG: any import would fail
+	api = &mock.MockClient{GetPublicKeyByFingerprintKey: otherKey}
+
+	reporter := &recordingReporter{}
+	err = fetchAndCertifyTeamKeys(myTeam, me, false, true, true, true, reporter)
+	assert.NoError(t, err)
+
+	t.Run("saves the key without importing it", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"start: test2@example.com: fetching key…", "success",
+			"start: test2@example.com: saving…", "success",
+		}, reporter.events)
+	})
+
+	t.Run("writes the armored key to the team directory", func(t *testing.T) {
+		teamDirectory, err := team.Directory(myTeam, fluidkeysDirectory)
+		assert.NoError(t, err)
+
+		saved, err := ioutil.ReadFile(
+			filepath.Join(teamDirectory, "keys", other.Fingerprint.Hex()+".asc"))
+		assert.NoError(t, err)
+
+		savedKey, err := pgpkey.LoadFromArmoredPublicKey(string(saved))
+		assert.NoError(t, err)
+		assert.Equal(t, other.Fingerprint, savedKey.Fingerprint())
+	})
+
+	t.Run("uses the saved key if it hasn't changed since it was fetched", func(t *testing.T) {
+		mockAPI := &mock.MockClient{GetPublicKeyByFingerprintKey: otherKey}
+		api = mockAPI
+
+		reporter := &recordingReporter{}
+		assert.NoError(t, fetchAndCertifyTeamKeys(myTeam, me, false, true, true, true, reporter))
+
+		assert.Equal(t, 1, len(mockAPI.CallsTo("ListPublicKeys")))
+		assert.Equal(t, 0, len(mockAPI.CallsTo("GetPublicKeyByFingerprint")))
+		assert.Equal(t, []string{"start: test2@example.com: saving…", "success"}, reporter.events)
+	})
+
+	t.Run("downloads the key if it has changed", func(t *testing.T) {
+		mockAPI := &mock.MockClient{
+			GetPublicKeyByFingerprintKey: otherKey,
+			ListPublicKeysKeys: []apiclient.KeySummary{
+				{Fingerprint: other.Fingerprint, UpdatedAt: time.Now()},
+			},
+		}
+		api = mockAPI
+
+		assert.NoError(t, fetchAndCertifyTeamKeys(myTeam, me, false, true, true, true,
+			&recordingReporter{}))
+		assert.Equal(t, 1, len(mockAPI.CallsTo("GetPublicKeyByFingerprint")))
+	})
+
+	t.Run("fetches the key by itself if the roster bundle doesn't verify", func(t *testing.T) {
+		mockAPI := &mock.MockClient{
+			DownloadRosterBundleBundle: &team.RosterBundle{
+				Keys: map[fpr.Fingerprint]*pgpkey.PgpKey{other.Fingerprint: otherKey},
+			},
+			GetPublicKeyByFingerprintKey: otherKey,
+			ListPublicKeysKeys: []apiclient.KeySummary{
+				{Fingerprint: other.Fingerprint, UpdatedAt: time.Now()},
+			},
+		}
+		api = mockAPI
+
+		assert.NoError(t, fetchAndCertifyTeamKeys(myTeam, me, false, true, true, true,
+			&recordingReporter{}))
+		assert.Equal(t, 1, len(mockAPI.CallsTo("DownloadRosterBundle")))
+		assert.Equal(t, 1, len(mockAPI.CallsTo("GetPublicKeyByFingerprint")))
+	})
+
+	t.Run("downloads the key if the server can't list changed keys", func(t *testing.T) {
+		mockAPI := &mock.MockClient{
+			GetPublicKeyByFingerprintKey: otherKey,
+			ListPublicKeysError:          fmt.Errorf("not found"),
+		}
+		api = mockAPI
+
+		assert.NoError(t, fetchAndCertifyTeamKeys(myTeam, me, false, true, true, true,
+			&recordingReporter{}))
+		assert.Equal(t, 1, len(mockAPI.CallsTo("GetPublicKeyByFingerprint")))
+	})
+}
+
+func TestVerifyKey(t *testing.T) {
+	person := team.Person{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2}
+
+	originalDB, originalGpg := db, gpg
+	defer func() { db, gpg = originalDB, originalGpg }()
+	gpg = gpgwrapper.GnuPG{} // not a working GnuPG: no key is already imported
+
+	t.Run("with trust on first use, trusts and records a new key without asking", func(t *testing.T) {
+		db = database.New(testhelpers.Maketemp(t))
+		prompter := &mockYesNoPrompter{}
+
+		assert.Equal(t, true, verifyKey(person, false, true, prompter))
+		assert.Equal(t, 0, len(prompter.asked))
+		assert.Equal(t, true, isKeyVerified(person.Fingerprint))
+	})
+
+	t.Run("when unattended, skips a new key without asking", func(t *testing.T) {
+		db = database.New(testhelpers.Maketemp(t))
+		prompter := &mockYesNoPrompter{}
+
+		assert.Equal(t, false, verifyKey(person, true, false, prompter))
+		assert.Equal(t, 0, len(prompter.asked))
+		assert.Equal(t, false, isKeyVerified(person.Fingerprint))
+	})
+
+	t.Run("asks to verify a new key and records a match", func(t *testing.T) {
+		db = database.New(testhelpers.Maketemp(t))
+		prompter := &mockYesNoPrompter{answers: []bool{true}}
+
+		assert.Equal(t, true, verifyKey(person, false, false, prompter))
+		assert.Equal(t, []string{"Does the fingerprint match?"}, prompter.asked)
+		assert.Equal(t, true, isKeyVerified(person.Fingerprint))
+	})
+
+	t.Run("skips a key whose fingerprint the user doesn't confirm", func(t *testing.T) {
+		db = database.New(testhelpers.Maketemp(t))
+		prompter := &mockYesNoPrompter{answers: []bool{false}}
+
+		assert.Equal(t, false, verifyKey(person, false, false, prompter))
+		assert.Equal(t, false, isKeyVerified(person.Fingerprint))
+	})
+
+	t.Run("doesn't ask again about a key verified before", func(t *testing.T) {
+		db = database.New(testhelpers.Maketemp(t))
+		recordKeyVerified(person.Fingerprint)
+		prompter := &mockYesNoPrompter{}
+
+		assert.Equal(t, true, verifyKey(person, true, false, prompter))
+		assert.Equal(t, 0, len(prompter.asked))
+	})
+}
+
+func TestTeamKeyDownloader(t *testing.T) {
+	adminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+	key2, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+	key3, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+	assert.NoError(t, err)
+
+	kiffix := team.Team{
+		UUID: uuid.Must(uuid.NewV4()),
+		Name: "Kiffix",
+		People: []team.Person{
+			{Email: "test4@example.com", Fingerprint: adminKey.Fingerprint(), IsAdmin: true},
+			{Email: "test2@example.com", Fingerprint: key2.Fingerprint()},
+			{Email: "test3@example.com", Fingerprint: key3.Fingerprint()},
+		},
+	}
+	assert.NoError(t, kiffix.UpdateRoster(adminKey))
+	roster, signature, err := kiffix.Roster()
+	assert.NoError(t, err)
+
+	makeDownloader := func(client rosterBundleDownloader) teamKeyDownloader {
+		return teamKeyDownloader{
+			teamUUID: kiffix.UUID,
+			me:       adminKey.Fingerprint(),
+			client:   client,
+			fetchAdminKeys: func() ([]*pgpkey.PgpKey, error) {
+				return []*pgpkey.PgpKey{adminKey}, nil
+			},
+		}
+	}
+
+	t.Run("downloads the bundle once and falls back for keys missing from it", func(t *testing.T) {
+		mockAPI := &mock.MockClient{
+			DownloadRosterBundleBundle: &team.RosterBundle{
+				Roster:    roster,
+				Signature: signature,
+				Keys:      map[fpr.Fingerprint]*pgpkey.PgpKey{key2.Fingerprint(): key2},
+			},
+			GetPublicKeyByFingerprintKey: key3,
+		}
+		downloader := makeDownloader(mockAPI)
+
+		got, err := downloader.GetPublicKeyByFingerprint(key2.Fingerprint())
+		assert.NoError(t, err)
+		assert.Equal(t, key2, got)
+
+		got, err = downloader.GetPublicKeyByFingerprint(key3.Fingerprint())
+		assert.NoError(t, err)
+		assert.Equal(t, key3, got)
+
+		assert.Equal(t, 1, len(mockAPI.CallsTo("DownloadRosterBundle")))
+		assert.Equal(t, 1, len(mockAPI.CallsTo("GetPublicKeyByFingerprint")))
+	})
+
+	t.Run("doesn't use keys from a bundle that doesn't verify", func(t *testing.T) {
+		mockAPI := &mock.MockClient{
+			DownloadRosterBundleBundle: &team.RosterBundle{
+				Roster:    roster,
+				Signature: signature,
+				Keys: map[fpr.Fingerprint]*pgpkey.PgpKey{
+					key2.Fingerprint(): key2,
+					key3.Fingerprint(): key2, // wrong key
+				},
+			},
+			GetPublicKeyByFingerprintKey: key3,
+		}
+		downloader := makeDownloader(mockAPI)
+
+		_, err := downloader.GetPublicKeyByFingerprint(key2.Fingerprint())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(mockAPI.CallsTo("GetPublicKeyByFingerprint")))
+	})
+
+	t.Run("fetches keys one at a time if the bundle fails", func(t *testing.T) {
+		mockAPI := &mock.MockClient{
+			DownloadRosterBundleError:    fmt.Errorf("boom"),
+			GetPublicKeyByFingerprintKey: key2,
+		}
+		downloader := makeDownloader(mockAPI)
+
+		got, err := downloader.GetPublicKeyByFingerprint(key2.Fingerprint())
+		assert.NoError(t, err)
+		assert.Equal(t, key2, got)
+	})
+}
+
+func TestFormatExpiringMembersWarning(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	expiring := []team.ExpiringMember{
+		{
+			Person:    team.Person{Email: "test2@example.com"},
+			ExpiresAt: now.Add(3 * 24 * time.Hour),
+		},
+		{
+			Person:    team.Person{Email: "test3@example.com"},
+			ExpiresAt: now.Add(10 * 24 * time.Hour),
+		},
+	}
+
+	output := formatExpiringMembersWarning(expiring, now)
+
+	assert.Equal(t, true, strings.Contains(output, "2 team members' keys expire soon"))
+	assert.Equal(t, true,
+		strings.Contains(output, "test2@example.com: expires 4 Jun 2019 (in 3 days)"))
+	assert.Equal(t, true,
+		strings.Contains(output, "test3@example.com: expires 11 Jun 2019 (in 10 days)"))
+}
+
+func TestFilterMembershipsByTeam(t *testing.T) {
+	me := team.Person{Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4}
+	kiffix := userpackage.TeamMembership{
+		Team: team.Team{UUID: uuid.Must(uuid.NewV4()), Name: "Kiffix"},
+		Me:   me,
+	}
+	fluidkeys := userpackage.TeamMembership{
+		Team: team.Team{UUID: uuid.Must(uuid.NewV4()), Name: "Fluidkeys CIC"},
+		Me:   me,
+	}
+	memberships := []userpackage.TeamMembership{kiffix, fluidkeys}
+
+	t.Run("returns only the membership of the given team", func(t *testing.T) {
+		got, err := filterMembershipsByTeam(memberships, fluidkeys.Team.UUID)
+		assert.NoError(t, err)
+		assert.Equal(t, []userpackage.TeamMembership{fluidkeys}, got)
+	})
+
+	t.Run("errors for a team the user isn't in", func(t *testing.T) {
+		otherUUID := uuid.Must(uuid.NewV4())
+		_, err := filterMembershipsByTeam(memberships, otherUUID)
+		assert.Equal(t, fmt.Errorf("you aren't a member of team %s", otherUUID), err)
+	})
+}
+
+func TestStoreTeamKey(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+
+	originalDB := db
+	defer func() { db = originalDB }()
+	db = database.New(testhelpers.Maketemp(t))
+
+	t.Run("imports the key if given an importer", func(t *testing.T) {
+		importer := &recordingImporter{}
+		err := storeTeamKey(key, testhelpers.Maketemp(t), importer)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(importer.imported))
+	})
+
+	t.Run("passes through an error importing the key", func(t *testing.T) {
+		importer := &recordingImporter{err: fmt.Errorf("gpg broke")}
+		err := storeTeamKey(key, testhelpers.Maketemp(t), importer)
+		assert.Equal(t, fmt.Errorf("Failed to import key into gpg"), err)
+	})
+}
+
+// recordingImporter records the keys it's asked to import
+type recordingImporter struct {
+	imported []string
+	err      error
+}
+
+func (r *recordingImporter) ImportArmoredKey(armoredKey string) error {
+	r.imported = append(r.imported, armoredKey)
+	return r.err
+}
+
+func TestRunWithProgress(t *testing.T) {
+	t.Run("reports success", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		err := runWithProgress(reporter, "fetching key…", func() error { return nil })
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"start: fetching key…", "success"}, reporter.events)
+	})
+
+	t.Run("reports and returns failure", func(t *testing.T) {
+		reporter := &recordingReporter{}
+		err := runWithProgress(reporter, "importing…", func() error {
+			return fmt.Errorf("gpg failed")
+		})
+		assert.Equal(t, fmt.Errorf("gpg failed"), err)
+		assert.Equal(t, []string{"start: importing…", "failure: gpg failed"}, reporter.events)
+	})
+}
+
+// recordingReporter is a progress.Reporter which records each event it's given
+type recordingReporter struct {
+	events []string
+}
+
+func (r *recordingReporter) Start(label string) {
+	r.events = append(r.events, "start: "+label)
+}
+
+func (r *recordingReporter) Success() {
+	r.events = append(r.events, "success")
+}
+
+func (r *recordingReporter) Failure(err error) {
+	r.events = append(r.events, "failure: "+err.Error())
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	// defaultWatchInterval is how often `fk team fetch --watch` fetches if --interval isn't given
+	defaultWatchInterval = 5 * time.Minute
+
+	// minWatchInterval is the shortest --interval allowed, to avoid hammering the API
+	minWatchInterval = 30 * time.Second
+)
+
+// teamFetchWatch runs teamFetch straight away, then again every interval until interrupted
+// with Ctrl-C. After the first fetch, it runs unattended and output is only printed if a roster
+// changed or the fetch failed.
+func teamFetchWatch(intervalFlag string, trustOnFirstUse bool, noGpgImport bool,
+	onlyTeam uuid.UUID) exitCode {
+
+	interval, err := parseWatchInterval(intervalFlag)
+	if err != nil {
+		out.Print(ui.FormatFailure("Invalid --interval", nil, err))
+		return 1
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	out.Print(ui.FormatInfo("Fetching teams every "+interval.String(), []string{
+		"Press Ctrl-C to stop.",
+	}))
+
+	previousState := ""
+	fetchOnce := func(iteration int) {
+		if iteration == 0 {
+			// the first fetch is interactive, like `fk team fetch`, so any new keys can be
+			// verified and the password entered.
+			fetchTeams(false, true, trustOnFirstUse, noGpgImport, onlyTeam)
+			previousState = teamRosterState()
+			return
+		}
+
+		// nobody is there to answer prompts while the output is buffered, so run unattended
+		out.SetOutputToBuffer()
+		code := fetchTeams(true, true, trustOnFirstUse, noGpgImport, onlyTeam)
+		state := teamRosterState()
+
+		if code != 0 || state != previousState {
+			out.PrintTheBuffer()
+		}
+		out.SetOutputToTerminal()
+
+		if code == 0 && state == previousState {
+			out.Print(fmt.Sprintf("%s no changes\n", time.Now().Format("15:04:05")))
+		}
+		previousState = state
+	}
+
+	iterations := watchLoop(ticker.C, interrupt, fetchOnce)
+	out.Print(fmt.Sprintf("\nStopped after %d fetches.\n", iterations))
+	return 0
+}
+
+// watchLoop calls fetchOnce in a goroutine straight away, then each time there's a tick, until
+// there's a signal on interrupt. A fetch that's in progress when interrupted is allowed to
+// finish, so a roster is never left half saved. It returns the number of times fetchOnce was
+// called.
+func watchLoop(ticks <-chan time.Time, interrupt <-chan os.Signal,
+	fetchOnce func(iteration int)) (iterations int) {
+
+	done := make(chan struct{})
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for {
+			fetchOnce(iterations)
+			iterations++
+
+			select {
+			case <-ticks:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	<-interrupt
+	close(stop)
+	<-done
+	return iterations
+}
+
+// parseWatchInterval parses --interval, defaulting to defaultWatchInterval if it's not given
+func parseWatchInterval(intervalFlag string) (time.Duration, error) {
+	if intervalFlag == "" {
+		return defaultWatchInterval, nil
+	}
+
+	interval, err := parseDuration(intervalFlag)
+	if err != nil {
+		return 0, err
+	}
+
+	if interval < minWatchInterval {
+		return 0, fmt.Errorf("interval must be at least %s", minWatchInterval)
+	}
+	return interval, nil
+}
+
+// teamRosterState returns the saved rosters of the user's teams, so a change to any of them
+// can be spotted by comparing it to a previous state.
+func teamRosterState() string {
+	memberships, err := user.Memberships()
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	rosters := []string{}
+	for _, membership := range memberships {
+		roster, _, _ := membership.Team.Roster()
+		rosters = append(rosters, membership.Team.UUID.String()+"\n"+roster)
+	}
+	sort.Strings(rosters)
+	return fmt.Sprint(rosters)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestWatchLoop(t *testing.T) {
+	t.Run("fetches straight away then on every tick until interrupted", func(t *testing.T) {
+		ticks := make(chan time.Time)
+		interrupt := make(chan os.Signal, 1)
+
+		gotIterations := []int{}
+		fetchOnce := func(iteration int) {
+			gotIterations = append(gotIterations, iteration)
+		}
+
+		go func() {
+			ticks <- time.Now()
+			ticks <- time.Now()
+			interrupt <- os.Interrupt
+		}()
+
+		iterations := watchLoop(ticks, interrupt, fetchOnce)
+		assert.Equal(t, 3, iterations)
+		assert.Equal(t, []int{0, 1, 2}, gotIterations)
+	})
+
+	t.Run("lets a fetch in progress finish when interrupted", func(t *testing.T) {
+		ticks := make(chan time.Time)
+		interrupt := make(chan os.Signal, 1)
+
+		finished := false
+		fetchOnce := func(iteration int) {
+			interrupt <- os.Interrupt
+			time.Sleep(10 * time.Millisecond)
+			finished = true
+		}
+
+		iterations := watchLoop(ticks, interrupt, fetchOnce)
+		assert.Equal(t, 1, iterations)
+		assert.Equal(t, true, finished)
+	})
+}
+
+func TestParseWatchInterval(t *testing.T) {
+	tests := []struct {
+		flag             string
+		expectedInterval time.Duration
+		expectedErr      error
+	}{
+		{"", 5 * time.Minute, nil},
+		{"10m", 10 * time.Minute, nil},
+		{"30s", 30 * time.Second, nil},
+		{"1d", 24 * time.Hour, nil},
+		{"29s", 0, fmt.Errorf("interval must be at least 30s")},
+		{"soon", 0, fmt.Errorf("invalid duration 'soon': use e.g. 30m, 12h or 7d")},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("with %q", test.flag), func(t *testing.T) {
+			interval, err := parseWatchInterval(test.flag)
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedInterval, interval)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	"github.com/fluidkeys/fluidkeys/emailutils"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func teamInvite(email string) exitCode {
+	if !emailutils.RoughlyValidateEmail(email) {
+		out.Print(ui.FormatFailure("Invalid email address", []string{email}, nil))
+		return 1
+	}
+
+	allMemberships, err := user.Memberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	adminMemberships := filterByAdmin(allMemberships)
+
+	switch len(adminMemberships) {
+	case 0:
+		out.Print(ui.FormatFailure("You aren't an admin of any teams", nil, nil))
+		return 1
+
+	case 1:
+		myTeam := adminMemberships[0].Team
+		me := adminMemberships[0].Me
+
+		printHeader("Invite " + email + " to join " + myTeam.Name)
+
+		unlockedKey, err := getUnlockedKey(me.Fingerprint, false)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to unlock key to sign invitation", nil, err))
+			return 1
+		}
+
+		invitation := team.NewInvitation(myTeam, email, time.Now())
+		command, err := makeInvitationCommand(invitation, unlockedKey)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to make invitation", nil, err))
+			return 1
+		}
+
+		out.Print(ui.FormatSuccess("Made an invitation for "+email, []string{
+			"Send them this command. When they run it, " + colour.Cmd("fk team authorize"),
+			"will show their key's fingerprint for you to check before adding them.",
+			"",
+			"The invitation can be used once, until " +
+				invitation.ExpiresAt.Format("2 January 2006") + ".",
+		}))
+		out.Print(command + "\n\n")
+		return 0
+
+	default:
+		out.Print(ui.FormatFailure("Choosing from multiple teams not implemented", nil, nil))
+		return 1
+	}
+}
+
+// makeInvitationCommand returns the `fk team apply` command which the invited person should run
+func makeInvitationCommand(invitation team.Invitation, signingKey *pgpkey.PgpKey) (
+	string, error) {
+
+	token, err := invitation.Token(signingKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("fk team apply %s --invite=%s", invitation.TeamUUID, token), nil
+}
+
+// splitInvitedRequests returns the requests made with a valid invitation from one of adminKeys
+// for the same team and email, and all other requests.
+// An invitation can only be used once: if isUsed says it's been used before, or more than one
+// request has the same invitation, the requests are treated as not invited.
+func splitInvitedRequests(requests []team.RequestToJoinTeam, adminKeys []*pgpkey.PgpKey,
+	now time.Time, isUsed func(token string) bool) (
+	invited []team.RequestToJoinTeam, others []team.RequestToJoinTeam) {
+
+	timesUsed := map[string]int{}
+	for _, request := range requests {
+		timesUsed[request.InvitationToken]++
+	}
+
+	for _, request := range requests {
+		if request.InvitationToken == "" {
+			others = append(others, request)
+			continue
+		}
+
+		if timesUsed[request.InvitationToken] > 1 || isUsed(request.InvitationToken) {
+			log.Printf("ignoring reused invitation with request from %s", request.Email)
+			others = append(others, request)
+			continue
+		}
+
+		invitation, err := team.VerifyInvitationToken(request.InvitationToken, adminKeys, now)
+		if err != nil {
+			log.Printf("ignoring invitation with request from %s: %v", request.Email, err)
+			others = append(others, request)
+		} else if !invitation.Matches(request) {
+			log.Printf("ignoring invitation for %s with request from %s",
+				invitation.Email, request.Email)
+			others = append(others, request)
+		} else {
+			invited = append(invited, request)
+		}
+	}
+	return invited, others
+}
+
+// confirmInvitedRequests shows the key fingerprint of each invited request and asks the admin to
+// check it matches the one the invited person gave them. The invitation only proves the
+// request came from someone with the token, not that the key is theirs.
+// An invitation is only for adding someone new: requests that would replace or change someone
+// already in myTeam are returned as conflicting, to be reviewed like uninvited requests.
+func confirmInvitedRequests(invited []team.RequestToJoinTeam, myTeam team.Team,
+	prompter promptYesNoInterface) (
+	confirmed []team.RequestToJoinTeam, conflicting []team.RequestToJoinTeam) {
+
+	for _, request := range invited {
+		err, _ := myTeam.GetUpsertPersonWarnings(team.Person{
+			Email:       request.Email,
+			Fingerprint: request.Fingerprint,
+		})
+		if err != nil {
+			log.Printf("not using invitation with request from %s: %v", request.Email, err)
+			out.Print(ui.FormatWarning(
+				request.Email+" was invited, but conflicts with someone in the team", []string{
+					"Their request needs reviewing with the others.",
+				}, nil))
+			conflicting = append(conflicting, request)
+			continue
+		}
+
+		out.Print("» " + request.Email + " was invited to join the team\n")
+		out.Print("  key:   " + colour.Info(displayFingerprint(request.Fingerprint)) + "\n\n")
+
+		if prompter.promptYesNo(
+			"Does the key match the fingerprint "+request.Email+" gave you?", "", nil) {
+			confirmed = append(confirmed, request)
+		} else {
+			out.Print(ui.FormatWarning("Not authorizing "+request.Email, []string{
+				"Their request has been left waiting. Check the key fingerprint with them.",
+			}, nil))
+		}
+	}
+	return confirmed, conflicting
+}
+
+// usedInvitation is an invitation token which has been used to join the team, recorded so it
+// can't be used again.
+// Caution: renaming this struct will invalidate any log entries.
+type usedInvitation struct {
+	token string
+}
+
+// String returns a hash of the token, so the token itself isn't stored
+func (i usedInvitation) String() string {
+	return fmt.Sprintf("%X", sha256.Sum256([]byte(i.token)))
+}
+
+func isInvitationUsed(token string) bool {
+	timeUsed, err := db.GetLast("use", usedInvitation{token: token})
+	if err != nil {
+		log.Printf("error calling db.GetLast(\"use\", invitation): %v", err)
+		return true // can't tell if it's been used, so don't trust it
+	}
+	return !timeUsed.IsZero()
+}
+
+func recordInvitationUsed(token string) {
+	if err := db.RecordLast("use", usedInvitation{token: token}, time.Now()); err != nil {
+		log.Printf("error calling db.RecordLast(\"use\", invitation, now): %v", err)
+	}
+}
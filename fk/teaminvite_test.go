@@ -0,0 +1,194 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/database"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+	"github.com/gofrs/uuid"
+)
+
+func TestMakeInvitationCommand(t *testing.T) {
+	adminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	myTeam := team.Team{UUID: uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))}
+	invitation := team.NewInvitation(myTeam, "jane@example.com", time.Now())
+
+	command, err := makeInvitationCommand(invitation, adminKey)
+	assert.NoError(t, err)
+
+	prefix := "fk team apply 74bb40b4-3510-11e9-968e-53c38df634be --invite="
+	assert.Equal(t, true, strings.HasPrefix(command, prefix))
+
+	_, err = team.VerifyInvitationToken(
+		strings.TrimPrefix(command, prefix), []*pgpkey.PgpKey{adminKey}, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestSplitInvitedRequests(t *testing.T) {
+	adminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	myTeam := team.Team{UUID: uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))}
+
+	token, err := team.NewInvitation(myTeam, "jane@example.com", now).Token(adminKey)
+	assert.NoError(t, err)
+
+	invited := team.RequestToJoinTeam{
+		TeamUUID: myTeam.UUID, Email: "jane@example.com", InvitationToken: token,
+	}
+	notInvited := team.RequestToJoinTeam{TeamUUID: myTeam.UUID, Email: "bob@example.com"}
+	wrongEmail := team.RequestToJoinTeam{
+		TeamUUID: myTeam.UUID, Email: "mallory@example.com", InvitationToken: token,
+	}
+
+	neverUsed := func(string) bool { return false }
+
+	gotInvited, gotOthers := splitInvitedRequests(
+		[]team.RequestToJoinTeam{invited, notInvited},
+		[]*pgpkey.PgpKey{adminKey},
+		now,
+		neverUsed,
+	)
+	assert.Equal(t, []team.RequestToJoinTeam{invited}, gotInvited)
+	assert.Equal(t, []team.RequestToJoinTeam{notInvited}, gotOthers)
+
+	t.Run("a token for another email needs approving", func(t *testing.T) {
+		gotInvited, gotOthers := splitInvitedRequests(
+			[]team.RequestToJoinTeam{wrongEmail},
+			[]*pgpkey.PgpKey{adminKey},
+			now,
+			neverUsed,
+		)
+		assert.Equal(t, 0, len(gotInvited))
+		assert.Equal(t, []team.RequestToJoinTeam{wrongEmail}, gotOthers)
+	})
+
+	t.Run("a token used by more than one request needs approving", func(t *testing.T) {
+		otherKey := invited
+		otherKey.Fingerprint = exampledata.ExampleFingerprint3
+
+		gotInvited, gotOthers := splitInvitedRequests(
+			[]team.RequestToJoinTeam{invited, otherKey},
+			[]*pgpkey.PgpKey{adminKey},
+			now,
+			neverUsed,
+		)
+		assert.Equal(t, 0, len(gotInvited))
+		assert.Equal(t, []team.RequestToJoinTeam{invited, otherKey}, gotOthers)
+	})
+
+	t.Run("a token used before needs approving", func(t *testing.T) {
+		gotInvited, gotOthers := splitInvitedRequests(
+			[]team.RequestToJoinTeam{invited},
+			[]*pgpkey.PgpKey{adminKey},
+			now,
+			func(usedToken string) bool { return usedToken == token },
+		)
+		assert.Equal(t, 0, len(gotInvited))
+		assert.Equal(t, []team.RequestToJoinTeam{invited}, gotOthers)
+	})
+
+	t.Run("expired invitations need approving", func(t *testing.T) {
+		gotInvited, gotOthers := splitInvitedRequests(
+			[]team.RequestToJoinTeam{invited},
+			[]*pgpkey.PgpKey{adminKey},
+			now.Add(team.InvitationValidFor),
+			neverUsed,
+		)
+		assert.Equal(t, 0, len(gotInvited))
+		assert.Equal(t, []team.RequestToJoinTeam{invited}, gotOthers)
+	})
+}
+
+func TestConfirmInvitedRequests(t *testing.T) {
+	jane := team.RequestToJoinTeam{
+		Email: "jane@example.com", Fingerprint: exampledata.ExampleFingerprint2,
+	}
+	bob := team.RequestToJoinTeam{
+		Email: "bob@example.com", Fingerprint: exampledata.ExampleFingerprint3,
+	}
+	admin := team.Person{
+		Email: "admin@example.com", Fingerprint: exampledata.ExampleFingerprint4, IsAdmin: true,
+	}
+	myTeam := team.Team{Name: "Kiffix", People: []team.Person{admin}}
+
+	t.Run("asks the admin to check each key", func(t *testing.T) {
+		prompter := &mockYesNoPrompter{answers: []bool{true, false}}
+		confirmed, conflicting := confirmInvitedRequests(
+			[]team.RequestToJoinTeam{jane, bob}, myTeam, prompter)
+
+		assert.Equal(t, []team.RequestToJoinTeam{jane}, confirmed)
+		assert.Equal(t, 0, len(conflicting))
+		assert.Equal(t, []string{
+			"Does the key match the fingerprint jane@example.com gave you?",
+			"Does the key match the fingerprint bob@example.com gave you?",
+		}, prompter.asked)
+	})
+
+	t.Run("returns requests that conflict with team members without asking", func(t *testing.T) {
+		replacesAdminKey := team.RequestToJoinTeam{
+			Email: admin.Email, Fingerprint: exampledata.ExampleFingerprint2,
+		}
+		demotesAdmin := team.RequestToJoinTeam{Email: admin.Email, Fingerprint: admin.Fingerprint}
+		takesAdminKey := team.RequestToJoinTeam{
+			Email: "mallory@example.com", Fingerprint: admin.Fingerprint,
+		}
+
+		prompter := &mockYesNoPrompter{}
+		confirmed, conflicting := confirmInvitedRequests(
+			[]team.RequestToJoinTeam{replacesAdminKey, demotesAdmin, takesAdminKey},
+			myTeam, prompter)
+
+		assert.Equal(t, 0, len(confirmed))
+		assert.Equal(t,
+			[]team.RequestToJoinTeam{replacesAdminKey, demotesAdmin, takesAdminKey}, conflicting)
+		assert.Equal(t, 0, len(prompter.asked))
+	})
+}
+
+func TestRecordInvitationUsed(t *testing.T) {
+	originalDB := db
+	defer func() { db = originalDB }()
+	db = database.New(testhelpers.Maketemp(t))
+
+	assert.Equal(t, false, isInvitationUsed("token1"))
+
+	recordInvitationUsed("token1")
+	assert.Equal(t, true, isInvitationUsed("token1"))
+	assert.Equal(t, false, isInvitationUsed("token2"))
+
+	t.Run("doesn't store the token itself", func(t *testing.T) {
+		contents, err := ioutil.ReadFile(db.Filename())
+		assert.NoError(t, err)
+		assert.Equal(t, false, strings.Contains(string(contents), "token1"))
+	})
+}
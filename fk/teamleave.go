@@ -0,0 +1,111 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/database"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
+)
+
+func teamLeave(teamUUID uuid.UUID) exitCode {
+	memberships, err := user.Memberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to get team memberships", nil, err))
+		return 1
+	}
+
+	var membership *userpackage.TeamMembership
+	for i := range memberships {
+		if memberships[i].Team.UUID == teamUUID {
+			membership = &memberships[i]
+			break
+		}
+	}
+	if membership == nil {
+		out.Print(ui.FormatFailure("You're not a member of team "+teamUUID.String(), nil, nil))
+		return 1
+	}
+
+	key, err := loadPgpKey(membership.Me.Fingerprint)
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to load key", nil, err))
+		return 1
+	}
+
+	unlockedKey, _, err := getDecryptedPrivateKeyAndPassword(key, &interactivePasswordPrompter{})
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to unlock private key", nil, err))
+		return 1
+	}
+
+	apiErr, err := leaveTeam(membership.Team, unlockedKey, api, &db, fluidkeysDirectory, time.Now())
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to remove team from this computer", nil, err))
+		return 1
+	}
+	if apiErr != nil {
+		out.Print(ui.FormatWarning("Removed "+membership.Team.Name+" from this computer", []string{
+			"Fluidkeys couldn't be told that you've left, so you're still in the team roster.",
+			"Ask a team admin to remove you with `fk team edit`.",
+		}, apiErr))
+		return 1
+	}
+
+	out.Print(ui.FormatSuccess("Left "+membership.Team.Name, nil))
+	return 0
+}
+
+type leaveTeamInterface interface {
+	LeaveTeam(teamUUID uuid.UUID, privateKey *pgpkey.PgpKey) error
+}
+
+// leaveTeam marks the team as leave-requested, asks the API to remove unlockedKey from the team,
+// then removes the saved roster and everything the database records about the team.
+// The local cleanup happens even if the API call fails: apiErr reports that failure, and err
+// reports a failure to clean up.
+func leaveTeam(t team.Team, unlockedKey *pgpkey.PgpKey, leaver leaveTeamInterface,
+	localDB *database.Database, fluidkeysDir string, now time.Time) (apiErr error, err error) {
+
+	if err := localDB.RecordLast("request-leave", t, now); err != nil {
+		return nil, fmt.Errorf("failed to record request to leave team: %v", err)
+	}
+
+	apiErr = leaver.LeaveTeam(t.UUID, unlockedKey)
+
+	teamDirectory, err := team.Directory(t, fluidkeysDir)
+	if err != nil {
+		return apiErr, err
+	}
+	if err := os.RemoveAll(teamDirectory); err != nil {
+		return apiErr, fmt.Errorf("failed to remove %s: %v", teamDirectory, err)
+	}
+
+	if err := localDB.DeleteTeam(t.UUID); err != nil {
+		return apiErr, fmt.Errorf("failed to delete team from database: %v", err)
+	}
+	return apiErr, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/database"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+	"github.com/gofrs/uuid"
+)
+
+func TestLeaveTeam(t *testing.T) {
+	now := time.Date(2019, 6, 20, 16, 35, 0, 0, time.UTC)
+
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	setup := func(t *testing.T) (team.Team, string, string, database.Database) {
+		fluidkeysDir := testhelpers.Maketemp(t)
+		localDB := database.New(fluidkeysDir)
+
+		myTeam := team.Team{
+			UUID: uuid.Must(uuid.NewV4()),
+			Name: "Kiffix",
+			People: []team.Person{
+				{Email: "test4@example.com", Fingerprint: key.Fingerprint(), IsAdmin: true},
+			},
+		}
+
+		teamDirectory, err := team.Directory(myTeam, fluidkeysDir)
+		assert.NoError(t, err)
+		assert.NoError(t, os.MkdirAll(teamDirectory, 0700))
+		assert.NoError(t, ioutil.WriteFile(
+			filepath.Join(teamDirectory, "roster.toml"), []byte("roster"), 0600))
+
+		assert.NoError(t, localDB.RecordRequestToJoinTeam(
+			myTeam.UUID, myTeam.Name, key.Fingerprint(), now))
+		assert.NoError(t, localDB.RecordLast("fetch", myTeam, now))
+
+		return myTeam, fluidkeysDir, teamDirectory, localDB
+	}
+
+	assertCleanedUp := func(t *testing.T, myTeam team.Team, teamDirectory string,
+		localDB database.Database) {
+
+		t.Helper()
+		if _, err := os.Stat(teamDirectory); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to have been removed, but stat returned %v", teamDirectory, err)
+		}
+
+		requests, err := localDB.GetRequestsToJoinTeams()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(requests))
+
+		for _, verb := range []string{"fetch", "request-leave"} {
+			got, err := localDB.GetLast(verb, myTeam)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Time{}, got)
+		}
+	}
+
+	t.Run("asks the API to remove the member and cleans up", func(t *testing.T) {
+		myTeam, fluidkeysDir, teamDirectory, localDB := setup(t)
+		leaver := &mock.MockClient{}
+
+		apiErr, err := leaveTeam(myTeam, key, leaver, &localDB, fluidkeysDir, now)
+		assert.NoError(t, err)
+		assert.NoError(t, apiErr)
+
+		calls := leaver.CallsTo("LeaveTeam")
+		assert.Equal(t, 1, len(calls))
+		assert.Equal(t, myTeam.UUID, calls[0].Args[0])
+		assert.Equal(t, key, calls[0].Args[1])
+		assertCleanedUp(t, myTeam, teamDirectory, localDB)
+	})
+
+	t.Run("cleans up even if the API call fails", func(t *testing.T) {
+		myTeam, fluidkeysDir, teamDirectory, localDB := setup(t)
+		leaver := &mock.MockClient{LeaveTeamError: fmt.Errorf("connection refused")}
+
+		apiErr, err := leaveTeam(myTeam, key, leaver, &localDB, fluidkeysDir, now)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Errorf("connection refused"), apiErr)
+
+		assertCleanedUp(t, myTeam, teamDirectory, localDB)
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/colour"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	outputformat "github.com/fluidkeys/fluidkeys/fk/format"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
+)
+
+// teamList prints the teams that any of the user's keys belong to, according to Fluidkeys.
+func teamList(format string) exitCode {
+	teams, err := listOwnTeams()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	if format == "" {
+		format = outputformat.Table
+	}
+	return printFormatted(format, teamListColumns, makeTeamListRecords(teams))
+}
+
+var teamListColumns = []string{"uuid", "name", "members"}
+
+// makeTeamListRecords returns a record for each team, for output with --format
+func makeTeamListRecords(teams []apiclient.TeamSummary) []map[string]string {
+	records := []map[string]string{}
+	for _, t := range teams {
+		records = append(records, map[string]string{
+			"uuid":    t.UUID.String(),
+			"name":    t.Name,
+			"members": strconv.Itoa(t.MemberCount),
+		})
+	}
+	return records
+}
+
+// listOwnTeams lists the teams for each of the user's keys which are published to Fluidkeys
+func listOwnTeams() ([]apiclient.TeamSummary, error) {
+	keys, err := loadPgpKeys()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load PGP keys: %v", err)
+	}
+
+	publishedKeys := []pgpkey.PgpKey{}
+	for _, key := range keys {
+		if Config.ShouldPublishToAPI(key.Fingerprint()) {
+			publishedKeys = append(publishedKeys, key)
+		}
+	}
+	return listTeamsForKeys(publishedKeys, api)
+}
+
+// listTeamsForKeys asks the API which teams each key belongs to. Each team is only listed
+// once, even if more than one of the keys is in it.
+func listTeamsForKeys(keys []pgpkey.PgpKey, teamLister listTeamsInterface) (
+	teams []apiclient.TeamSummary, err error) {
+
+	seen := map[uuid.UUID]bool{}
+	for _, key := range keys {
+		keyTeams, err := teamLister.ListTeams(key.Fingerprint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list teams for %s: %v", key.Fingerprint(), err)
+		}
+		for _, t := range keyTeams {
+			if !seen[t.UUID] {
+				seen[t.UUID] = true
+				teams = append(teams, t)
+			}
+		}
+	}
+	return teams, nil
+}
+
+// promptForTeamByNumber prints a numbered list of teams and asks the user to pick one
+func promptForTeamByNumber(teams []apiclient.TeamSummary) apiclient.TeamSummary {
+	for index, t := range teams {
+		formattedListNumber := colour.Info(fmt.Sprintf("%-4s", (strconv.Itoa(index+1) + ".")))
+		out.Print(fmt.Sprintf("%s%s (%s)\n", formattedListNumber, t.Name, t.UUID))
+	}
+	out.Print("\n")
+
+	invalidEntry := fmt.Sprintf("Please select between 1 and %v.\n", len(teams))
+
+	for {
+		rangePrompt := colour.Info(fmt.Sprintf("[1-%v]", len(teams)))
+		input := promptForInput("Which team? " + rangePrompt + " ")
+		if selected, err := strconv.Atoi(input); err != nil || selected < 1 || selected > len(teams) {
+			out.Print(invalidEntry)
+		} else {
+			return teams[selected-1]
+		}
+	}
+}
+
+type listTeamsInterface interface {
+	ListTeams(fingerprint fpr.Fingerprint) ([]apiclient.TeamSummary, error)
+}
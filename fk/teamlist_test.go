@@ -0,0 +1,57 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/gofrs/uuid"
+)
+
+func TestListTeamsForKeys(t *testing.T) {
+	key2, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+	key3, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+	assert.NoError(t, err)
+	keys := []pgpkey.PgpKey{*key2, *key3}
+
+	kiffix := apiclient.TeamSummary{UUID: uuid.Must(uuid.NewV4()), Name: "Kiffix", MemberCount: 2}
+
+	t.Run("lists each team once", func(t *testing.T) {
+		mockAPI := &mock.MockClient{ListTeamsTeams: []apiclient.TeamSummary{kiffix}}
+
+		got, err := listTeamsForKeys(keys, mockAPI)
+		assert.NoError(t, err)
+		assert.Equal(t, []apiclient.TeamSummary{kiffix}, got)
+		assert.Equal(t, 2, len(mockAPI.CallsTo("ListTeams")))
+	})
+
+	t.Run("returns an error from the API", func(t *testing.T) {
+		mockAPI := &mock.MockClient{ListTeamsError: fmt.Errorf("server error")}
+
+		_, err := listTeamsForKeys(keys, mockAPI)
+		assert.Equal(t, fmt.Errorf("failed to list teams for %s: server error",
+			key2.Fingerprint()), err)
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/table"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
+)
+
+// teamListPendingRequests prints the requests to join each team the user is an admin of, or just
+// onlyTeam if it isn't uuid.Nil. Expired requests are only shown if includeExpired is true.
+func teamListPendingRequests(onlyTeam uuid.UUID, includeExpired bool) exitCode {
+	allMemberships, err := user.Memberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	if onlyTeam != uuid.Nil {
+		if allMemberships, err = filterMembershipsByTeam(allMemberships, onlyTeam); err != nil {
+			out.Print(ui.FormatFailure("Invalid --team", nil, err))
+			return 1
+		}
+	}
+
+	adminMemberships := filterByAdmin(allMemberships)
+	if len(adminMemberships) == 0 {
+		out.Print(ui.FormatFailure("You aren't an admin of any teams", nil, nil))
+		return 1
+	}
+
+	code := exitCode(0)
+	for _, membership := range adminMemberships {
+		printHeader("Requests to join " + membership.Team.Name)
+
+		output, err := formatPendingRequests(membership, api, includeExpired, time.Now())
+		if err != nil {
+			out.Print(ui.FormatFailure("Error getting requests", nil, err))
+			code = 1
+			continue
+		}
+		out.Print(output)
+	}
+	return code
+}
+
+type requestsToJoinTeamLister interface {
+	ListRequestsToJoinTeam(teamUUID uuid.UUID, fingerprint fpr.Fingerprint) (
+		[]team.RequestToJoinTeam, error)
+}
+
+// formatPendingRequests lists the requests to join the membership's team and formats them as a
+// table.
+func formatPendingRequests(membership userpackage.TeamMembership,
+	lister requestsToJoinTeamLister, includeExpired bool, now time.Time) (string, error) {
+
+	requests, err := lister.ListRequestsToJoinTeam(membership.Team.UUID, membership.Me.Fingerprint)
+	if err != nil {
+		return "", err
+	}
+
+	rows := makePendingRequestRows(requests, includeExpired, now)
+	if len(rows) == 0 {
+		return "No pending requests to join " + membership.Team.Name + "\n\n", nil
+	}
+	return table.FormatRequestTable(rows), nil
+}
+
+func makePendingRequestRows(requests []team.RequestToJoinTeam, includeExpired bool,
+	now time.Time) (rows []table.RequestRow) {
+
+	for _, request := range requests {
+		requestedAt, expires := "unknown", "unknown" // older servers don't say when
+
+		if !request.RequestedAt.IsZero() {
+			if request.IsExpired(now) && !includeExpired {
+				continue
+			}
+			requestedAt = request.RequestedAt.Format(pendingRequestTimeFormat)
+			expires = request.RequestedAt.Add(team.RequestExpiryDuration).Format(
+				pendingRequestTimeFormat)
+			if request.IsExpired(now) {
+				expires += " (expired)"
+			}
+		}
+
+		rows = append(rows, table.RequestRow{
+			Email:       request.Email,
+			Fingerprint: formatFingerprint(request.Fingerprint, fpr.Fingerprint.String),
+			RequestedAt: requestedAt,
+			Expires:     expires,
+		})
+	}
+	return rows
+}
+
+const pendingRequestTimeFormat = "2 Jan 2006 15:04 MST"
@@ -0,0 +1,103 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/colour"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/team"
+	userpackage "github.com/fluidkeys/fluidkeys/user"
+	"github.com/gofrs/uuid"
+)
+
+func TestFormatPendingRequests(t *testing.T) {
+	now := time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	membership := userpackage.TeamMembership{
+		Team: team.Team{UUID: uuid.Must(uuid.NewV4()), Name: "Kiffix"},
+		Me: team.Person{
+			Email:       "admin@example.com",
+			Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+			IsAdmin:     true,
+		},
+	}
+
+	pending := team.RequestToJoinTeam{
+		Email:       "pending@example.com",
+		Fingerprint: fpr.MustParse("CCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDD"),
+		RequestedAt: now.Add(-24 * time.Hour),
+	}
+	expired := team.RequestToJoinTeam{
+		Email:       "expired@example.com",
+		Fingerprint: fpr.MustParse("EEEEFFFFEEEEFFFFEEEEFFFFEEEEFFFFEEEEFFFF"),
+		RequestedAt: now.Add(-30 * 24 * time.Hour),
+	}
+	undated := team.RequestToJoinTeam{
+		Email:       "undated@example.com",
+		Fingerprint: fpr.MustParse("1111222211112222111122221111222211112222"),
+	}
+
+	mockAPI := &mock.MockClient{
+		ListRequestsToJoinTeamRequests: []team.RequestToJoinTeam{pending, expired, undated},
+	}
+
+	t.Run("renders a table of pending requests", func(t *testing.T) {
+		output, err := formatPendingRequests(membership, mockAPI, false, now)
+		assert.NoError(t, err)
+
+		assert.Equal(t, ""+
+			"Email                Fingerprint                                         "+
+			"Requested              Expires              \n"+
+			"───────────────────  ──────────────────────────────────────────────────  "+
+			"─────────────────────  ─────────────────────\n"+
+			"pending@example.com  CCCC DDDD CCCC DDDD CCCC  DDDD CCCC DDDD CCCC DDDD  "+
+			"14 Jun 2019 12:00 UTC  21 Jun 2019 12:00 UTC\n"+
+			"undated@example.com  1111 2222 1111 2222 1111  2222 1111 2222 1111 2222  "+
+			"unknown                unknown              \n"+
+			"\n",
+			colour.StripAllColourCodes(output))
+	})
+
+	t.Run("includes expired requests if asked", func(t *testing.T) {
+		output, err := formatPendingRequests(membership, mockAPI, true, now)
+		assert.NoError(t, err)
+
+		assert.Equal(t, true, strings.Contains(output, "expired@example.com"))
+		assert.Equal(t, true, strings.Contains(output, "23 May 2019 12:00 UTC (expired)"))
+	})
+
+	t.Run("says if there are no requests", func(t *testing.T) {
+		output, err := formatPendingRequests(membership, &mock.MockClient{}, false, now)
+		assert.NoError(t, err)
+		assert.Equal(t, "No pending requests to join Kiffix\n\n", output)
+	})
+
+	t.Run("returns an error if the requests can't be listed", func(t *testing.T) {
+		_, err := formatPendingRequests(membership, &mock.MockClient{
+			ListRequestsToJoinTeamError: fmt.Errorf("forbidden"),
+		}, false, now)
+		assert.GotError(t, err)
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+)
+
+func teamRemoveAdmin(email string) exitCode {
+	allMemberships, err := user.Memberships()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list teams", nil, err))
+		return 1
+	}
+
+	adminMemberships := filterByAdmin(allMemberships)
+
+	switch len(adminMemberships) {
+	case 0:
+		out.Print(ui.FormatFailure("You aren't an admin of any teams", nil, nil))
+		return 1
+
+	case 1:
+		myTeam := adminMemberships[0].Team
+		me := adminMemberships[0].Me
+
+		printHeader("Remove " + email + " as an admin of " + myTeam.Name)
+
+		updatedTeam, err := demoteFromAdmin(myTeam, email, me.Fingerprint)
+		if err != nil {
+			out.Print(ui.FormatFailure("Can't remove "+email+" as an admin", nil, err))
+			return 1
+		}
+
+		revokedFingerprints, err := db.GetRevokedKeys()
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to list revoked keys", nil, err))
+			return 1
+		}
+
+		err = doEditTeam(myTeam, *updatedTeam, me, revokedFingerprints, false, api)
+		if err != nil {
+			out.Print(ui.FormatFailure("Failed to update team", nil, err))
+			return 1
+		}
+
+		out.Print(ui.FormatWarning(email+" can still read secrets already sent to them", []string{
+			"Removing someone as an admin doesn't change who can decrypt secrets. If they",
+			"shouldn't have them any more, change those secrets and send them again with",
+			colour.Cmd("fk secret send") + ".",
+		}, nil))
+		return 0
+
+	default:
+		out.Print(ui.FormatFailure("Choosing from multiple teams not implemented", nil, nil))
+		return 1
+	}
+}
+
+// demoteFromAdmin returns a copy of the team with the admin with the given email made an
+// ordinary member.
+// It returns an error rather than demoting the team's last admin, since nobody would be able to
+// sign the next roster. Admins can't demote themselves either: the roster has to be signed by an
+// admin, so another admin needs to make the change.
+func demoteFromAdmin(t team.Team, email string, myFingerprint fpr.Fingerprint) (
+	*team.Team, error) {
+
+	person, err := t.GetPersonForEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("%s isn't in the team", email)
+	}
+	if !person.IsAdmin {
+		return nil, fmt.Errorf("%s isn't an admin", email)
+	}
+	if t.AdminCount() == 1 {
+		return nil, fmt.Errorf("%s is the only admin: make someone else an admin first", email)
+	}
+	if person.Fingerprint == myFingerprint {
+		return nil, fmt.Errorf("you can't remove yourself as an admin: " +
+			"ask another admin to run fk team remove-admin")
+	}
+
+	return withAdmin(t, person.Fingerprint, false), nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+func TestDemoteFromAdmin(t *testing.T) {
+	me := team.Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	otherAdmin := team.Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+		IsAdmin:     true,
+	}
+	member := team.Person{
+		Email:       "test3@example.com",
+		Fingerprint: exampledata.ExampleFingerprint3,
+		IsAdmin:     false,
+	}
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+
+	t.Run("makes another admin an ordinary member", func(t *testing.T) {
+		before := team.Team{
+			UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, otherAdmin, member},
+		}
+
+		after, err := demoteFromAdmin(before, "test2@example.com", me.Fingerprint)
+		assert.NoError(t, err)
+		assert.Equal(t, false, after.IsAdmin(otherAdmin.Fingerprint))
+		assert.Equal(t, true, after.IsAdmin(me.Fingerprint))
+
+		t.Run("and the update is valid", func(t *testing.T) {
+			uploader := mock.MockClient{}
+			err := doEditTeam(before, *after, me, nil, true, &uploader)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("won't demote the last admin", func(t *testing.T) {
+		before := team.Team{
+			UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, member},
+		}
+
+		_, err := demoteFromAdmin(before, "test4@example.com", me.Fingerprint)
+		assert.Equal(t, fmt.Errorf(
+			"test4@example.com is the only admin: make someone else an admin first"), err)
+
+		t.Run("which ValidateUpdate also rejects", func(t *testing.T) {
+			after := withAdmin(before, me.Fingerprint, false)
+			err := team.ValidateUpdate(&before, after, me.Fingerprint, nil)
+			assert.Equal(t, fmt.Errorf("team has no administrators"), err)
+		})
+	})
+
+	t.Run("won't demote yourself, even with another admin", func(t *testing.T) {
+		before := team.Team{
+			UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, otherAdmin},
+		}
+
+		_, err := demoteFromAdmin(before, "test4@example.com", me.Fingerprint)
+		assert.Equal(t, fmt.Errorf("you can't remove yourself as an admin: "+
+			"ask another admin to run fk team remove-admin"), err)
+	})
+
+	t.Run("returns an error for someone who isn't an admin", func(t *testing.T) {
+		before := team.Team{
+			UUID: teamUUID, Name: "Kiffix", People: []team.Person{me, member},
+		}
+
+		_, err := demoteFromAdmin(before, "test3@example.com", me.Fingerprint)
+		assert.Equal(t, fmt.Errorf("test3@example.com isn't an admin"), err)
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/fluidkeys/fluidkeys/apiclient"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/humanize"
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/fluidkeys/fluidkeys/ui"
+	"github.com/gofrs/uuid"
+)
+
+func teamShow(teamUUID uuid.UUID) exitCode {
+	myFingerprints, err := db.GetFingerprintsImportedIntoGnuPG()
+	if err != nil {
+		out.Print(ui.FormatFailure("Failed to list your keys", nil, err))
+		return 1
+	}
+
+	details, err := getTeamDetails(teamUUID, myFingerprints, api, verifyBrandNewRoster)
+	if err == apiclient.ErrTeamNotFound {
+		out.Print(ui.FormatFailure("Team not found", []string{
+			"There's no team with UUID " + teamUUID.String(),
+		}, nil))
+		return 1
+	} else if err != nil {
+		out.Print(ui.FormatFailure("Failed to get team details", nil, err))
+		return 1
+	}
+
+	if details.Team != nil {
+		details.SavedRosterChanges = countSavedRosterChanges(*details.Team)
+	}
+	details.APIBaseURL = apiBaseURL
+
+	out.Print("\n" + formatTeamDetails(*details) + "\n")
+	return 0
+}
+
+type getTeamInterface interface {
+	GetTeamName(teamUUID uuid.UUID) (string, error)
+	GetTeamRoster(teamUUID uuid.UUID, me fpr.Fingerprint) (roster string, signature string, err error)
+}
+
+// teamDetails is what `fk team show` knows about a team. Team is only set if one of the
+// user's keys is a member of the team.
+type teamDetails struct {
+	UUID               uuid.UUID
+	Name               string
+	Team               *team.Team
+	ParentName         string // name of the parent team, if Team is a subteam
+	SavedRosterChanges int    // 0 if the team hasn't been fetched on this computer
+	APIBaseURL         string // used to show where to download keys from, if set
+}
+
+// verifyRosterFunc checks that roster and signature were signed by one of t's admins
+type verifyRosterFunc func(t team.Team, roster string, signature string) error
+
+// getTeamDetails gets the public name of the team, then tries to get the roster using each
+// of myFingerprints in turn. If none of them are in the team, only the name is returned.
+// The roster is checked with verifyRoster, so the admins shown can be trusted.
+func getTeamDetails(teamUUID uuid.UUID, myFingerprints []fpr.Fingerprint,
+	teamGetter getTeamInterface, verifyRoster verifyRosterFunc) (*teamDetails, error) {
+
+	name, err := teamGetter.GetTeamName(teamUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := teamDetails{UUID: teamUUID, Name: name}
+
+	for _, fingerprint := range myFingerprints {
+		roster, signature, err := teamGetter.GetTeamRoster(teamUUID, fingerprint)
+		if err == apiclient.ErrForbidden {
+			continue // this key isn't in the team, try the next one
+		} else if err != nil {
+			return nil, fmt.Errorf("error downloading team roster: %v", err)
+		}
+
+		t, err := team.Load(roster, signature)
+		if err != nil {
+			return nil, fmt.Errorf("error loading team roster: %v", err)
+		}
+		if err := verifyRoster(*t, roster, signature); err != nil {
+			return nil, fmt.Errorf("team roster isn't signed by one of its admins: %v", err)
+		}
+		details.Team = t
+
+		if t.ParentTeamUUID != nil {
+			if details.ParentName, err = teamGetter.GetTeamName(*t.ParentTeamUUID); err != nil {
+				log.Printf("failed to get name of parent team %s: %v", t.ParentTeamUUID, err)
+			}
+		}
+		break
+	}
+	return &details, nil
+}
+
+// countSavedRosterChanges returns the number of roster changes recorded in the team's audit log,
+// or 0 if the team hasn't been fetched.
+func countSavedRosterChanges(t team.Team) int {
+	teamSubdirectory, err := team.Directory(t, fluidkeysDirectory)
+	if err != nil {
+		log.Printf("failed to get team directory: %v", err)
+		return 0
+	}
+
+	entries, err := team.AuditLog{Directory: teamSubdirectory}.Entries()
+	if err != nil {
+		log.Printf("failed to read audit log: %v", err)
+		return 0
+	}
+	return len(entries)
+}
+
+func formatTeamDetails(details teamDetails) string {
+	lines := []string{
+		"Team:    " + details.Name,
+		"UUID:    " + details.UUID.String(),
+	}
+
+	if details.Team == nil {
+		lines = append(lines, "", "You're not a member of this team, so can't see its roster.")
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	if parentUUID := details.Team.ParentTeamUUID; parentUUID != nil {
+		if details.ParentName != "" {
+			lines = append(lines, "Parent:  "+details.ParentName+" ("+parentUUID.String()+")")
+		} else {
+			lines = append(lines, "Parent:  "+parentUUID.String())
+		}
+	}
+	lines = append(lines, fmt.Sprintf("Members: %d (%s, %s)",
+		details.Team.MemberCount(),
+		humanize.Pluralize(details.Team.AdminCount(), "admin", "admins"),
+		humanize.Pluralize(details.Team.NonAdminMemberCount(), "regular member", "regular members"),
+	))
+	if details.SavedRosterChanges > 0 {
+		lines = append(lines, "History: "+humanize.Pluralize(
+			details.SavedRosterChanges, "roster change", "roster changes")+" saved on this computer")
+	}
+
+	lines = append(lines, "", "Admins:")
+	for _, admin := range details.Team.Admins() {
+		lines = append(lines, "  "+formatPerson(admin))
+		if details.APIBaseURL != "" {
+			lines = append(lines, "    "+admin.KeyURL(details.APIBaseURL))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
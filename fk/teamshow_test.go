@@ -0,0 +1,157 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package fk
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/apiclient/mock"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fp "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/team"
+	"github.com/gofrs/uuid"
+)
+
+func TestGetTeamDetails(t *testing.T) {
+	teamUUID := uuid.Must(uuid.NewV4())
+	admin := team.Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	member := team.Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+	}
+	kiffix := team.Team{UUID: teamUUID, Name: "Kiffix", People: []team.Person{admin, member}}
+	roster, err := kiffix.PreviewRoster()
+	assert.NoError(t, err)
+
+	mockAPI := &mock.MockClient{
+		GetTeamNameName: "Kiffix",
+		GetTeamRosterRoster: map[fp.Fingerprint]string{
+			admin.Fingerprint:  roster,
+			member.Fingerprint: roster,
+		},
+	}
+
+	t.Run("for a member, includes the roster", func(t *testing.T) {
+		details, err := getTeamDetails(teamUUID, []fp.Fingerprint{
+			exampledata.ExampleFingerprint3, // not in the team
+			member.Fingerprint,
+		}, mockAPI, acceptRoster)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Kiffix", details.Name)
+		if details.Team == nil {
+			t.Fatalf("expected team to be set, but it was nil")
+		}
+		assert.Equal(t, kiffix.People, details.Team.People)
+
+		output := formatTeamDetails(*details)
+		assert.Equal(t, true, strings.Contains(output, "Members: 2 (1 admin, 1 regular member)"))
+		assert.Equal(t, true, strings.Contains(output, admin.Email))
+		assert.Equal(t, false, strings.Contains(output, member.Email))
+		assert.Equal(t, false, strings.Contains(output, ".asc"))
+
+		t.Run("and admin key URLs if APIBaseURL is set", func(t *testing.T) {
+			details.APIBaseURL = "http://localhost:4747/v1/"
+			output := formatTeamDetails(*details)
+			assert.Equal(t, true, strings.Contains(output, "\n    "+admin.KeyURL("http://localhost:4747/v1/")+"\n"))
+		})
+	})
+
+	t.Run("for a non-member, only includes the name", func(t *testing.T) {
+		details, err := getTeamDetails(
+			teamUUID, []fp.Fingerprint{exampledata.ExampleFingerprint3}, mockAPI, acceptRoster)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Kiffix", details.Name)
+		if details.Team != nil {
+			t.Fatalf("expected team to be nil, got %v", details.Team)
+		}
+
+		output := formatTeamDetails(*details)
+		assert.Equal(t, true, strings.Contains(output, "not a member"))
+		assert.Equal(t, false, strings.Contains(output, "Members:"))
+	})
+
+	t.Run("for a subteam, includes the parent team", func(t *testing.T) {
+		parentUUID := uuid.Must(uuid.NewV4())
+		subteam := kiffix
+		subteam.ParentTeamUUID = &parentUUID
+		subteamRoster, err := subteam.PreviewRoster()
+		assert.NoError(t, err)
+
+		subteamAPI := &mock.MockClient{
+			GetTeamNameName:     "Kiffix",
+			GetTeamRosterRoster: map[fp.Fingerprint]string{admin.Fingerprint: subteamRoster},
+		}
+
+		details, err := getTeamDetails(teamUUID, []fp.Fingerprint{admin.Fingerprint}, subteamAPI,
+			acceptRoster)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(subteamAPI.CallsTo("GetTeamName")))
+		assert.Equal(t, "Kiffix", details.ParentName)
+
+		output := formatTeamDetails(*details)
+		assert.Equal(t, true,
+			strings.Contains(output, "Parent:  Kiffix ("+parentUUID.String()+")\n"))
+
+		t.Run("or just its UUID if the name is unknown", func(t *testing.T) {
+			details.ParentName = ""
+			output := formatTeamDetails(*details)
+			assert.Equal(t, true, strings.Contains(output, "Parent:  "+parentUUID.String()+"\n"))
+		})
+	})
+
+	t.Run("for a top-level team, doesn't show a parent", func(t *testing.T) {
+		details, err := getTeamDetails(teamUUID, []fp.Fingerprint{admin.Fingerprint}, mockAPI,
+			acceptRoster)
+		assert.NoError(t, err)
+		assert.Equal(t, false, strings.Contains(formatTeamDetails(*details), "Parent:"))
+	})
+
+	t.Run("rejects a roster that doesn't verify", func(t *testing.T) {
+		rejectRoster := func(team.Team, string, string) error { return team.ErrSignatureInvalid }
+
+		_, err := getTeamDetails(teamUUID, []fp.Fingerprint{admin.Fingerprint}, mockAPI,
+			rejectRoster)
+		assert.GotError(t, err)
+	})
+
+	t.Run("shows how many roster changes are saved", func(t *testing.T) {
+		details, err := getTeamDetails(teamUUID, []fp.Fingerprint{admin.Fingerprint}, mockAPI,
+			acceptRoster)
+		assert.NoError(t, err)
+		details.SavedRosterChanges = 3
+		assert.Equal(t, true, strings.Contains(formatTeamDetails(*details),
+			"History: 3 roster changes saved on this computer\n"))
+	})
+
+	t.Run("passes up errors getting the team name", func(t *testing.T) {
+		_, err := getTeamDetails(teamUUID, nil, &mock.MockClient{GetTeamNameError: fmt.Errorf("boom")},
+			acceptRoster)
+		assert.GotError(t, err)
+	})
+}
+
+func acceptRoster(team.Team, string, string) error { return nil }
@@ -505,3 +505,28 @@ SC0EwAbMtIYkeautEiv91AhgrTRqaG03U4zEYTpA1sQ4agYlLssOLCdguzIsV2bU
 gccR56G2L/PJK9su8t1NtZp3d8h/7yCJhyM=
 =24gu
 -----END PGP PRIVATE KEY BLOCK-----`
+
+func TestChangePassphrase(t *testing.T) {
+	fingerprint := fpr.MustParse("C16B 89AC 31CD F3B7 8DA3  3AAE 1D20 FC95 4793 5FC6")
+
+	gpg := makeGpgWithTempHome(t)
+	assert.NoError(t, gpg.ImportArmoredKey(ExamplePublicKey))
+	assert.NoError(t, gpg.ImportArmoredKey(ExamplePrivateKey))
+
+	t.Run("with the wrong old password", func(t *testing.T) {
+		err := gpg.ChangePassphrase(fingerprint, "wrong password", "new password")
+		assert.Equal(t, &BadPasswordError{}, err)
+	})
+
+	t.Run("with the correct old password", func(t *testing.T) {
+		assert.NoError(t, gpg.ChangePassphrase(fingerprint, "foo", "new password"))
+
+		_, err := gpg.ExportPrivateKey(fingerprint, "new password")
+		assert.NoError(t, err)
+
+		_, err = gpg.ExportPrivateKey(fingerprint, "foo")
+		if _, ok := err.(*BadPasswordError); !ok {
+			t.Fatalf("expected BadPasswordError exporting with old password, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package gpgwrapper
+
+import (
+	"fmt"
+	"strings"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+// ChangePassphrase changes the passphrase protecting the private key (and subkeys) with the
+// given fingerprint from oldPassword to newPassword.
+// It returns a BadPasswordError if oldPassword is incorrect.
+func (g *GnuPG) ChangePassphrase(
+	fingerprint fpr.Fingerprint, oldPassword string, newPassword string) error {
+
+	if newPassword == "" {
+		return fmt.Errorf("new password can't be empty")
+	}
+
+	// with loopback pinentry, gpg asks for the old then the new passphrase on the command fd
+	stdout, stderr, err := g.run(
+		oldPassword+"\n"+newPassword+"\n",
+		"--pinentry-mode", "loopback",
+		"--command-fd", "0",
+		"--status-fd", "1",
+		"--passwd", fingerprint.Hex(),
+	)
+	if err != nil {
+		return err
+	}
+
+	// gpg exits 0 even if changing the passphrase failed, so check its output too
+	if strings.Contains(stderr, badPassphrase) || strings.Contains(stderr, noPassphrase) {
+		return &BadPasswordError{}
+	} else if !strings.Contains(stdout, passwdSuccessStatus) {
+		return fmt.Errorf("gpg didn't report changing the passphrase")
+	}
+	return nil
+}
+
+const passwdSuccessStatus = "[GNUPG:] SUCCESS keyedit.passwd"
@@ -24,3 +24,62 @@ func (g *GnuPG) TrustUltimately(fingerprint fpr.Fingerprint) error {
 
 	return nil
 }
+
+// TrustLevel is an ownertrust level: how much we trust a key to correctly certify other keys
+type TrustLevel int
+
+const (
+	// TrustMarginal means a key's certifications count towards validity, but several are
+	// needed
+	TrustMarginal TrustLevel = 4
+
+	// TrustFull means a key's certifications are enough on their own to make another key valid
+	TrustFull TrustLevel = 5
+)
+
+// ParseTrustLevel parses "full" or "marginal" into a TrustLevel
+func ParseTrustLevel(level string) (TrustLevel, error) {
+	switch strings.ToLower(level) {
+	case "full":
+		return TrustFull, nil
+
+	case "marginal":
+		return TrustMarginal, nil
+
+	default:
+		return 0, fmt.Errorf("invalid trust level '%s': use full or marginal", level)
+	}
+}
+
+// String returns the name of the trust level, as accepted by ParseTrustLevel
+func (l TrustLevel) String() string {
+	switch l {
+	case TrustFull:
+		return "full"
+
+	case TrustMarginal:
+		return "marginal"
+
+	default:
+		return fmt.Sprintf("TrustLevel(%d)", int(l))
+	}
+}
+
+// SetOwnerTrust sets the ownertrust level of the given key. Unlike TrustUltimately it uses
+// `gpg --import-ownertrust` so doesn't need to drive the interactive --edit-key menu.
+// Note that GnuPG accepts ownertrust for keys it doesn't have, so callers should check the key
+// is in the keyring first.
+func (g *GnuPG) SetOwnerTrust(fingerprint fpr.Fingerprint, level TrustLevel) error {
+	if level != TrustFull && level != TrustMarginal {
+		return fmt.Errorf("invalid trust level %v", level)
+	}
+
+	_, _, err := g.run(ownerTrustLine(fingerprint, level), "--import-ownertrust")
+	return err
+}
+
+// ownerTrustLine returns a line in the format used by `gpg --export-ownertrust`, for example:
+// AAAABBBBAAAABBBBAAAABBBBAAAABBBBAAAABBBB:5:
+func ownerTrustLine(fingerprint fpr.Fingerprint, level TrustLevel) string {
+	return fmt.Sprintf("%s:%d:\n", fingerprint.Hex(), int(level))
+}
@@ -43,3 +43,54 @@ func TestTrustUltimately(t *testing.T) {
 	})
 
 }
+
+func TestSetOwnerTrust(t *testing.T) {
+	gpg := makeGpgWithTempHome(t)
+	gpg.ImportArmoredKey(exampledata.ExamplePublicKey2)
+
+	for _, level := range []TrustLevel{TrustMarginal, TrustFull} {
+		t.Run(fmt.Sprintf("sets ownertrust to %s", level), func(t *testing.T) {
+			err := gpg.SetOwnerTrust(exampledata.ExampleFingerprint2, level)
+			assert.NoError(t, err)
+
+			stdout, _, err := gpg.run("", "--export-ownertrust")
+			assert.NoError(t, err)
+
+			expectedLine := ownerTrustLine(exampledata.ExampleFingerprint2, level)
+			if !strings.Contains(stdout, expectedLine) {
+				t.Fatalf("expected ownertrust to contain `%s`, got:\n%s\n", expectedLine, stdout)
+			}
+		})
+	}
+
+	t.Run("with an invalid trust level", func(t *testing.T) {
+		err := gpg.SetOwnerTrust(exampledata.ExampleFingerprint2, TrustLevel(6))
+		assert.GotError(t, err)
+	})
+}
+
+func TestOwnerTrustLine(t *testing.T) {
+	assert.Equal(t,
+		"5C78E71F6FEFB55829654CC5343CC240D350C30C:5:\n",
+		ownerTrustLine(exampledata.ExampleFingerprint2, TrustFull),
+	)
+	assert.Equal(t,
+		"5C78E71F6FEFB55829654CC5343CC240D350C30C:4:\n",
+		ownerTrustLine(exampledata.ExampleFingerprint2, TrustMarginal),
+	)
+}
+
+func TestParseTrustLevel(t *testing.T) {
+	for input, expected := range map[string]TrustLevel{
+		"full":     TrustFull,
+		"FULL":     TrustFull,
+		"marginal": TrustMarginal,
+	} {
+		got, err := ParseTrustLevel(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, got)
+	}
+
+	_, err := ParseTrustLevel("ultimate")
+	assert.GotError(t, err)
+}
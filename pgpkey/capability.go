@@ -0,0 +1,127 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/packet"
+)
+
+var (
+	// ErrNoEncryptionCapability means neither the primary key nor any subkey can currently be
+	// used for encryption.
+	ErrNoEncryptionCapability = fmt.Errorf("key has no usable encryption subkey")
+
+	// ErrNoUsableEncryptionSubkey means none of the key's subkeys can currently be used for
+	// encryption, though the primary key might be able to.
+	ErrNoUsableEncryptionSubkey = fmt.Errorf("key has no current encryption subkey")
+
+	// ErrNoSigningCapability means neither the primary key nor any subkey can currently be used
+	// for signing.
+	ErrNoSigningCapability = fmt.Errorf("key has no usable signing key")
+)
+
+// HasEncryptionCapability returns true if the primary key or one of the subkeys can be used for
+// encryption, and isn't expired or revoked.
+func (key *PgpKey) HasEncryptionCapability() bool {
+	return key.hasEncryptionCapability(time.Now())
+}
+
+// HasSigningCapability returns true if the primary key or one of the subkeys can be used for
+// signing, and isn't expired or revoked.
+func (key *PgpKey) HasSigningCapability() bool {
+	return key.hasSigningCapability(time.Now())
+}
+
+func (key *PgpKey) hasEncryptionCapability(now time.Time) bool {
+	if len(key.Revocations) > 0 {
+		return false
+	}
+
+	if len(key.validEncryptionSubkeys(now)) > 0 {
+		return true
+	}
+
+	selfSig := key.primarySelfSignature()
+	return selfSig != nil &&
+		key.PrimaryKey.PubKeyAlgo.CanEncrypt() &&
+		selfSig.FlagsValid &&
+		(selfSig.FlagEncryptCommunications || selfSig.FlagEncryptStorage) &&
+		!key.isPrimaryKeyExpired(selfSig, now)
+}
+
+func (key *PgpKey) hasSigningCapability(now time.Time) bool {
+	if len(key.Revocations) > 0 {
+		return false
+	}
+
+	for _, subkey := range key.Subkeys {
+		isRevoked := subkey.Sig.SigType == packet.SigTypeSubkeyRevocation
+
+		if !isRevoked &&
+			subkey.Sig.FlagsValid &&
+			subkey.Sig.FlagSign &&
+			subkey.PublicKey.PubKeyAlgo.CanSign() &&
+			!isSubkeyExpired(subkey, now) {
+			return true
+		}
+	}
+
+	// like GnuPG, assume the primary key can sign unless its flags say otherwise
+	selfSig := key.primarySelfSignature()
+	return selfSig != nil &&
+		key.PrimaryKey.PubKeyAlgo.CanSign() &&
+		(!selfSig.FlagsValid || selfSig.FlagSign) &&
+		!key.isPrimaryKeyExpired(selfSig, now)
+}
+
+// primarySelfSignature returns the self signature of the primary identity, or if no identity is
+// marked as primary, the most recent self signature. Revoked identities are ignored. It returns
+// nil if there are no identities.
+func (key *PgpKey) primarySelfSignature() *packet.Signature {
+	var latest *packet.Signature
+
+	for _, identity := range key.Identities {
+		selfSig := identity.SelfSignature
+		if selfSig == nil || key.isIdentityRevoked(identity) {
+			continue
+		}
+		if selfSig.IsPrimaryId != nil && *selfSig.IsPrimaryId {
+			return selfSig
+		}
+		if latest == nil || selfSig.CreationTime.After(latest.CreationTime) {
+			latest = selfSig
+		}
+	}
+	return latest
+}
+
+// isPrimaryKeyExpired returns whether the primary key has expired at now, according to the given
+// self signature.
+func (key *PgpKey) isPrimaryKeyExpired(selfSig *packet.Signature, now time.Time) bool {
+	hasExpiry, expiry := CalculateExpiry(key.PrimaryKey.CreationTime, selfSig.KeyLifetimeSecs)
+	return hasExpiry && !now.Before(*expiry)
+}
+
+func isSubkeyExpired(subkey openpgp.Subkey, now time.Time) bool {
+	hasExpiry, expiry := SubkeyExpiry(subkey)
+	return hasExpiry && !now.Before(*expiry)
+}
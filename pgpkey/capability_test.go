@@ -0,0 +1,126 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp/packet"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestCapabilities(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	loadKey := func(t *testing.T) *PgpKey {
+		key, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+		return key
+	}
+
+	disablePrimarySigning := func(key *PgpKey) {
+		for _, identity := range key.Identities {
+			identity.SelfSignature.FlagSign = false
+		}
+	}
+
+	tests := []struct {
+		name               string
+		modify             func(key *PgpKey)
+		expectedEncryption bool
+		expectedSigning    bool
+	}{
+		{
+			name:               "primary signing key and encryption subkey",
+			modify:             func(key *PgpKey) {},
+			expectedEncryption: true,
+			expectedSigning:    true,
+		},
+		{
+			name:               "primary signing key with no subkeys",
+			modify:             func(key *PgpKey) { key.Subkeys = nil },
+			expectedEncryption: false,
+			expectedSigning:    true,
+		},
+		{
+			name:               "encryption subkey only",
+			modify:             disablePrimarySigning,
+			expectedEncryption: true,
+			expectedSigning:    false,
+		},
+		{
+			name: "signing subkey only",
+			modify: func(key *PgpKey) {
+				disablePrimarySigning(key)
+				key.Subkeys[0].Sig.FlagSign = true
+				key.Subkeys[0].Sig.FlagEncryptCommunications = false
+				key.Subkeys[0].Sig.FlagEncryptStorage = false
+			},
+			expectedEncryption: false,
+			expectedSigning:    true,
+		},
+		{
+			name: "revoked signing subkey only",
+			modify: func(key *PgpKey) {
+				disablePrimarySigning(key)
+				key.Subkeys[0].Sig.FlagSign = true
+				key.Subkeys[0].Sig.SigType = packet.SigTypeSubkeyRevocation
+			},
+			expectedEncryption: false,
+			expectedSigning:    false,
+		},
+		{
+			name: "revoked primary key",
+			modify: func(key *PgpKey) {
+				key.Revocations = []*packet.Signature{{SigType: packet.SigTypeKeyRevocation}}
+			},
+			expectedEncryption: false,
+			expectedSigning:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := loadKey(t)
+			test.modify(key)
+
+			assert.Equal(t, test.expectedEncryption, key.hasEncryptionCapability(now))
+			assert.Equal(t, test.expectedSigning, key.hasSigningCapability(now))
+		})
+	}
+
+	t.Run("expired key has no capabilities", func(t *testing.T) {
+		key := loadKey(t)
+		oneDay := uint32(24 * 60 * 60)
+		for _, identity := range key.Identities {
+			identity.SelfSignature.KeyLifetimeSecs = &oneDay
+		}
+		key.Subkeys[0].Sig.KeyLifetimeSecs = &oneDay
+
+		createdAt := key.PrimaryKey.CreationTime
+		expiresAt := createdAt.Add(24 * time.Hour)
+
+		assert.Equal(t, true, key.hasEncryptionCapability(expiresAt.Add(-time.Second)))
+		assert.Equal(t, true, key.hasSigningCapability(expiresAt.Add(-time.Second)))
+
+		assert.Equal(t, false, key.hasEncryptionCapability(expiresAt))
+		assert.Equal(t, false, key.hasSigningCapability(expiresAt))
+	})
+}
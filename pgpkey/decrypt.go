@@ -30,7 +30,8 @@ import (
 )
 
 // DecryptArmored takes an ascii armored encrypted PGP message and attempts to decrypt it
-// against the key, returning an io.Reader
+// against the key, returning an io.Reader.
+// It doesn't modify the key, so is safe to call concurrently once the key is unlocked.
 func (p *PgpKey) DecryptArmored(encrypted string) (io.Reader, *packet.LiteralData, error) {
 	err := p.ensureGotDecryptedPrivateKey()
 	if err != nil {
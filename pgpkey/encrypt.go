@@ -0,0 +1,130 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/crypto/openpgp/packet"
+)
+
+// Encrypt compresses plaintext, signs it with the key and encrypts it to the recipient's
+// best encryption subkey, returning an ASCII armored PGP message. The key must have an
+// unlocked private key.
+// It returns ErrNoEncryptionCapability if the recipient has no usable encryption subkey.
+func (p *PgpKey) Encrypt(plaintext []byte, recipient *PgpKey) (armoredCiphertext string,
+	err error) {
+
+	if err := p.ensureGotDecryptedPrivateKey(); err != nil {
+		return "", err
+	}
+	if !recipient.HasEncryptionCapability() {
+		return "", ErrNoEncryptionCapability
+	}
+
+	// only fall back to the primary key if it can encrypt: see HasEncryptionCapability
+	encryptTo := recipient.PrimaryKey
+	if subkey, err := recipient.SubkeyForEncryption(); err == nil {
+		encryptTo = subkey.PublicKey
+	}
+
+	config := &packet.Config{DefaultCipher: packet.CipherAES256}
+	symmetricKey := make([]byte, config.Cipher().KeySize())
+	if _, err := io.ReadFull(config.Random(), symmetricKey); err != nil {
+		return "", err
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	message, err := armor.Encode(buffer, "PGP MESSAGE", nil)
+	if err != nil {
+		return "", err
+	}
+
+	// openpgp.Encrypt doesn't compress, so build the layers up by hand:
+	// encrypted(compressed(signed(literal)))
+	err = packet.SerializeEncryptedKey(message, encryptTo, config.Cipher(), symmetricKey, config)
+	if err != nil {
+		return "", fmt.Errorf("error writing encrypted key: %v", err)
+	}
+	encrypted, err := packet.SerializeSymmetricallyEncrypted(
+		message, config.Cipher(), symmetricKey, config,
+	)
+	if err != nil {
+		return "", err
+	}
+	compressed, err := packet.SerializeCompressed(encrypted, packet.CompressionZLIB, nil)
+	if err != nil {
+		return "", err
+	}
+	signed, err := openpgp.Sign(compressed, &p.Entity, &openpgp.FileHints{IsBinary: true}, config)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = signed.Write(plaintext); err != nil {
+		return "", err
+	}
+	if err := signed.Close(); err != nil {
+		return "", fmt.Errorf("error closing signer: %v", err)
+	}
+	// closing the compressor also closes the encrypted packet underneath it
+	if err := compressed.Close(); err != nil {
+		return "", fmt.Errorf("error closing compressor: %v", err)
+	}
+	if err := message.Close(); err != nil {
+		return "", fmt.Errorf("error closing armorer: %v", err)
+	}
+	return buffer.String(), nil
+}
+
+// Decrypt is the inverse of Encrypt: it decrypts the ASCII armored PGP message with the key's
+// private key and returns the plaintext.
+// If the message was signed by this key, the signature is checked and an error returned if it
+// doesn't verify. Signatures by other keys aren't checked.
+func (p *PgpKey) Decrypt(armoredCiphertext string) ([]byte, error) {
+	if err := p.ensureGotDecryptedPrivateKey(); err != nil {
+		return nil, err
+	}
+
+	block, err := armor.Decode(bytes.NewBufferString(armoredCiphertext))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding armor: %s", err)
+	}
+
+	var keyRing openpgp.EntityList = []*openpgp.Entity{&p.Entity}
+
+	messageDetails, err := openpgp.ReadMessage(block.Body, keyRing, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading message: %s", err)
+	}
+
+	// the signature is only checked once the whole body has been read
+	plaintext, err := ioutil.ReadAll(messageDetails.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("error reading message: %s", err)
+	}
+	if messageDetails.SignatureError != nil {
+		return nil, fmt.Errorf("bad signature: %v", messageDetails.SignatureError)
+	}
+	return plaintext, nil
+}
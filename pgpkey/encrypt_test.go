@@ -0,0 +1,82 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	key, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	randomBytes := make([]byte, 256*1024)
+	rand.New(rand.NewSource(1)).Read(randomBytes)
+
+	var tests = []struct {
+		name      string
+		plaintext []byte
+	}{
+		{"empty", []byte{}},
+		{"one byte", []byte("x")},
+		{"ASCII text", []byte("hello world\n")},
+		{"UTF-8 text", []byte("café ☕ – 日本語 🔑")},
+		{"Latin-1 bytes which aren't valid UTF-8", []byte{'c', 'a', 'f', 0xe9}},
+		{"binary with null bytes", []byte{0, 1, 2, 0, 255, 0}},
+		{"repetitive text that compresses", []byte(strings.Repeat("password\n", 10000))},
+		{"256KB of random bytes", randomBytes},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			armored, err := key.Encrypt(test.plaintext, key)
+			assert.NoError(t, err)
+			assert.Equal(t, true, strings.HasPrefix(armored, "-----BEGIN PGP MESSAGE-----"))
+
+			decrypted, err := key.Decrypt(armored)
+			assert.NoError(t, err)
+			assert.Equal(t, true, bytes.Equal(test.plaintext, decrypted))
+		})
+	}
+
+	t.Run("compresses the plaintext", func(t *testing.T) {
+		plaintext := []byte(strings.Repeat("password\n", 10000))
+		armored, err := key.Encrypt(plaintext, key)
+		assert.NoError(t, err)
+		assert.Equal(t, true, len(armored) < len(plaintext)/10)
+	})
+
+	t.Run("Encrypt returns an error if the key is locked", func(t *testing.T) {
+		lockedKey, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+
+		_, err = lockedKey.Encrypt([]byte("hello"), key)
+		assert.GotError(t, err)
+	})
+
+	t.Run("Decrypt returns an error for a corrupted message", func(t *testing.T) {
+		_, err := key.Decrypt("not a PGP message")
+		assert.GotError(t, err)
+	})
+}
@@ -18,8 +18,11 @@
 package pgpkey
 
 import (
-	"github.com/fluidkeys/crypto/openpgp"
+	"fmt"
 	"time"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/packet"
 )
 
 // CalculateExpiry takes a creationtime and a key lifetime in seconds (pointer)
@@ -49,3 +52,69 @@ func SubkeyExpiry(subkey openpgp.Subkey) (bool, *time.Time) {
 		subkey.Sig.KeyLifetimeSecs,
 	)
 }
+
+// PrimaryKeyExpiry returns true and a time if the primary key has an expiry time set in the
+// self signature of its primary identity, or false if it has no expiry.
+func (key *PgpKey) PrimaryKeyExpiry() (bool, *time.Time) {
+	selfSig := key.primarySelfSignature()
+	if selfSig == nil {
+		return false, nil
+	}
+	return CalculateExpiry(key.PrimaryKey.CreationTime, selfSig.KeyLifetimeSecs)
+}
+
+// EncryptionSubkeyExpiry returns true and the time the soonest-expiring of the key's valid
+// encryption subkeys expires, or false if none of them expire.
+func (key *PgpKey) EncryptionSubkeyExpiry(now time.Time) (bool, *time.Time) {
+	var soonest *time.Time
+	for _, subkey := range key.validEncryptionSubkeys(now) {
+		if hasExpiry, expiry := SubkeyExpiry(*subkey); hasExpiry {
+			if soonest == nil || expiry.Before(*soonest) {
+				soonest = expiry
+			}
+		}
+	}
+	return soonest != nil, soonest
+}
+
+// UpdateExpiry sets the primary key and every subkey that hasn't been revoked to expire at
+// validUntil. The private key must be decrypted.
+func (key *PgpKey) UpdateExpiry(validUntil time.Time, now time.Time) error {
+	if !validUntil.After(now) {
+		return fmt.Errorf("expiry must be in the future")
+	}
+	return key.setExpiry(validUntil, now)
+}
+
+// ExpireNow sets the primary key and every subkey that hasn't been revoked to expire at now, so
+// that it stops being usable straight away, for example if it's been compromised. Unlike
+// revoking the key, this can be undone with UpdateExpiry. The private key must be decrypted.
+func (key *PgpKey) ExpireNow(now time.Time) error {
+	// a key lifetime of zero means the key never expires
+	if now.Sub(key.PrimaryKey.CreationTime) < time.Second {
+		return fmt.Errorf("can't expire a key in the second it was created")
+	}
+	for _, subkey := range key.Subkeys {
+		if now.Sub(subkey.PublicKey.CreationTime) < time.Second {
+			return fmt.Errorf("can't expire subkey %X in the second it was created",
+				subkey.PublicKey.KeyId)
+		}
+	}
+	return key.setExpiry(now, now)
+}
+
+func (key *PgpKey) setExpiry(validUntil time.Time, now time.Time) error {
+	if err := key.UpdateExpiryForAllUserIds(validUntil, now); err != nil {
+		return fmt.Errorf("failed to update primary key expiry: %v", err)
+	}
+
+	for _, subkey := range key.Subkeys {
+		if subkey.Sig.SigType == packet.SigTypeSubkeyRevocation {
+			continue
+		}
+		if err := key.UpdateSubkeyValidUntil(subkey.PublicKey.KeyId, validUntil, now); err != nil {
+			return fmt.Errorf("failed to update subkey %X expiry: %v", subkey.PublicKey.KeyId, err)
+		}
+	}
+	return nil
+}
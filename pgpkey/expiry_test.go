@@ -3,6 +3,10 @@ package pgpkey
 import (
 	"testing"
 	"time"
+
+	"github.com/fluidkeys/crypto/openpgp/packet"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
 )
 
 var (
@@ -41,3 +45,159 @@ func TestCalculateExpiry(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdateExpiry(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	validUntil := now.Add(90 * 24 * time.Hour)
+
+	loadKey := func(t *testing.T) *PgpKey {
+		t.Helper()
+		key, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+		assert.NoError(t, err)
+		return key
+	}
+
+	t.Run("sets the primary key and subkey expiry", func(t *testing.T) {
+		key := loadKey(t)
+		assert.NoError(t, key.UpdateExpiry(validUntil, now))
+
+		hasExpiry, expiry := key.PrimaryKeyExpiry()
+		assert.Equal(t, true, hasExpiry)
+		assert.Equal(t, validUntil, *expiry)
+
+		for _, subkey := range key.Subkeys {
+			hasExpiry, expiry := SubkeyExpiry(subkey)
+			assert.Equal(t, true, hasExpiry)
+			assert.Equal(t, validUntil, *expiry)
+		}
+	})
+
+	t.Run("survives armoring and loading", func(t *testing.T) {
+		key := loadKey(t)
+		assert.NoError(t, key.UpdateExpiry(validUntil, now))
+
+		armored, err := key.Armor()
+		assert.NoError(t, err)
+		reloaded, err := LoadFromArmoredPublicKey(armored)
+		assert.NoError(t, err)
+
+		_, expiry := reloaded.PrimaryKeyExpiry()
+		assert.Equal(t, validUntil, *expiry)
+	})
+
+	t.Run("leaves revoked subkeys alone", func(t *testing.T) {
+		key := loadKey(t)
+		key.Subkeys[0].Sig.SigType = packet.SigTypeSubkeyRevocation
+		originalLifetime := key.Subkeys[0].Sig.KeyLifetimeSecs
+
+		assert.NoError(t, key.UpdateExpiry(validUntil, now))
+		assert.Equal(t, originalLifetime, key.Subkeys[0].Sig.KeyLifetimeSecs)
+	})
+
+	t.Run("returns error if validUntil isn't in the future", func(t *testing.T) {
+		key := loadKey(t)
+		assert.GotError(t, key.UpdateExpiry(now, now))
+	})
+}
+
+func TestExpireNow(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	loadKey := func(t *testing.T) *PgpKey {
+		t.Helper()
+		key, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+		assert.NoError(t, err)
+		return key
+	}
+
+	t.Run("primary key and subkeys have expired by the following second", func(t *testing.T) {
+		key := loadKey(t)
+		assert.NoError(t, key.ExpireNow(now))
+
+		armored, err := key.Armor()
+		assert.NoError(t, err)
+		reloaded, err := LoadFromArmoredPublicKey(armored)
+		assert.NoError(t, err)
+
+		hasExpiry, expiry := reloaded.PrimaryKeyExpiry()
+		assert.Equal(t, true, hasExpiry)
+		assert.Equal(t, true, expiry.Before(now.Add(time.Second)))
+
+		for _, subkey := range reloaded.Subkeys {
+			hasExpiry, expiry := SubkeyExpiry(subkey)
+			assert.Equal(t, true, hasExpiry)
+			assert.Equal(t, true, expiry.Before(now.Add(time.Second)))
+		}
+	})
+
+	t.Run("can be undone with UpdateExpiry", func(t *testing.T) {
+		key := loadKey(t)
+		assert.NoError(t, key.ExpireNow(now))
+
+		later := now.Add(time.Minute)
+		assert.NoError(t, key.UpdateExpiry(later.Add(24*time.Hour), later))
+
+		_, expiry := key.PrimaryKeyExpiry()
+		assert.Equal(t, later.Add(24*time.Hour), *expiry)
+	})
+
+	t.Run("returns error for a key created this second", func(t *testing.T) {
+		key := loadKey(t)
+		assert.GotError(t, key.ExpireNow(key.PrimaryKey.CreationTime))
+	})
+}
+
+func TestEncryptionSubkeyExpiry(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	sixtyDaysAgo := now.Add(-60 * 24 * time.Hour)
+	tenDaysAgo := now.Add(-10 * 24 * time.Hour)
+	tenDaysFromNow := now.Add(10 * 24 * time.Hour)
+	thirtyDaysFromNow := now.Add(30 * 24 * time.Hour)
+
+	t.Run("returns the soonest expiry of the valid encryption subkeys", func(t *testing.T) {
+		pgpKey, err := makeKeyWithSubkeys(t, []subkeyConfig{
+			{
+				keyCreationTime:       sixtyDaysAgo,
+				signatureCreationTime: sixtyDaysAgo,
+				expiryTime:            &thirtyDaysFromNow,
+				flagsValid:            true,
+				encryptFlags:          true,
+			},
+			{
+				keyCreationTime:       sixtyDaysAgo,
+				signatureCreationTime: sixtyDaysAgo,
+				expiryTime:            &tenDaysFromNow,
+				flagsValid:            true,
+				encryptFlags:          true,
+			},
+			{
+				// already expired, so ignored
+				keyCreationTime:       sixtyDaysAgo,
+				signatureCreationTime: sixtyDaysAgo,
+				expiryTime:            &tenDaysAgo,
+				flagsValid:            true,
+				encryptFlags:          true,
+			},
+		}, now)
+		assert.NoError(t, err)
+
+		hasExpiry, expiry := pgpKey.EncryptionSubkeyExpiry(now)
+		assert.Equal(t, true, hasExpiry)
+		assert.Equal(t, tenDaysFromNow, *expiry)
+	})
+
+	t.Run("returns false if no valid encryption subkeys expire", func(t *testing.T) {
+		pgpKey, err := makeKeyWithSubkeys(t, []subkeyConfig{
+			{
+				keyCreationTime:       sixtyDaysAgo,
+				signatureCreationTime: sixtyDaysAgo,
+				flagsValid:            true,
+				encryptFlags:          true,
+			},
+		}, now)
+		assert.NoError(t, err)
+
+		hasExpiry, _ := pgpKey.EncryptionSubkeyExpiry(now)
+		assert.Equal(t, false, hasExpiry)
+	})
+}
@@ -18,8 +18,11 @@
 package pgpkey
 
 import (
+	cryptorand "crypto/rand"
 	"crypto/rsa"
+	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/fluidkeys/crypto/openpgp"
@@ -28,9 +31,82 @@ import (
 	"github.com/fluidkeys/fluidkeys/policy"
 )
 
+const (
+	// AlgorithmRSA4096 makes an RSA 4096 bit primary key and encryption subkey
+	AlgorithmRSA4096 = "rsa4096"
+
+	// AlgorithmEd25519 would make an Ed25519 primary key with a Curve25519 encryption subkey,
+	// but the OpenPGP library doesn't support these yet.
+	AlgorithmEd25519 = "ed25519"
+
+	// AlgorithmCv25519 is the same as AlgorithmEd25519: Curve25519 keys can only encrypt, so
+	// the primary key would still be Ed25519.
+	AlgorithmCv25519 = "cv25519"
+
+	// DefaultAlgorithm is the algorithm to use if the user doesn't choose one. This should be
+	// AlgorithmEd25519 once it's supported.
+	DefaultAlgorithm = AlgorithmRSA4096
+)
+
+// SupportedAlgorithms lists the algorithms accepted by GenerateWithAlgorithm
+var SupportedAlgorithms = []string{AlgorithmRSA4096}
+
+// ValidateAlgorithm returns an error if GenerateWithAlgorithm doesn't support the algorithm.
+func ValidateAlgorithm(algorithm string) error {
+	switch algorithm {
+	case AlgorithmRSA4096:
+		return nil
+
+	case AlgorithmEd25519, AlgorithmCv25519:
+		return fmt.Errorf("%s keys aren't supported yet: use %s", algorithm,
+			strings.Join(SupportedAlgorithms, " or "))
+
+	default:
+		return fmt.Errorf("unknown algorithm '%s': use %s", algorithm,
+			strings.Join(SupportedAlgorithms, " or "))
+	}
+}
+
+// GenerateWithAlgorithm makes a new key for email with a primary signing key and an encryption
+// subkey of the given algorithm (see ValidateAlgorithm), both valid until validUntil.
+func GenerateWithAlgorithm(email string, algorithm string, validUntil time.Time, now time.Time,
+	random io.Reader) (*PgpKey, error) {
+
+	if err := ValidateAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+	if random == nil {
+		random = cryptorand.Reader
+	}
+
+	// only RSA 4096 is supported so far
+	return generateKeyWithOptions(email, random, now, generateOptions{
+		primaryKeyBits: 4096,
+		subkeyBits:     4096,
+		validUntil:     validUntil,
+	})
+}
+
+// generateOptions are the choices generateKeyWithOptions makes about the new key
+type generateOptions struct {
+	primaryKeyBits int
+	subkeyBits     int
+	validUntil     time.Time
+}
+
 func generateKey(email string, randomNumberGenerator io.Reader, creationTime time.Time) (key *PgpKey, err error) {
+	return generateKeyWithOptions(email, randomNumberGenerator, creationTime, generateOptions{
+		primaryKeyBits: policy.PrimaryKeyRsaKeyBits,
+		subkeyBits:     policy.EncryptionSubkeyRsaKeyBits,
+		validUntil:     policy.NextExpiryTime(creationTime),
+	})
+}
+
+func generateKeyWithOptions(email string, randomNumberGenerator io.Reader,
+	creationTime time.Time, options generateOptions) (key *PgpKey, err error) {
+
 	config := packet.Config{
-		RSABits:     policy.PrimaryKeyRsaKeyBits,
+		RSABits:     options.primaryKeyBits,
 		Time:        func() time.Time { return creationTime },
 		DefaultHash: policy.SignatureHashFunction,
 		Rand:        randomNumberGenerator,
@@ -46,7 +122,8 @@ func generateKey(email string, randomNumberGenerator io.Reader, creationTime tim
 		return nil, err
 	}
 
-	err = generateAddOneEncryptionSubkey(key, creationTime, &config)
+	err = key.createEncryptionSubkey(
+		options.subkeyBits, options.validUntil, creationTime, config.Random())
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +143,7 @@ func generateKey(email string, randomNumberGenerator io.Reader, creationTime tim
 		return
 	}
 
-	validUntil := policy.NextExpiryTime(creationTime)
+	validUntil := options.validUntil
 	err = key.UpdateExpiryForAllUserIds(validUntil, creationTime)
 	if err != nil {
 		return
@@ -84,7 +161,7 @@ func generateKey(email string, randomNumberGenerator io.Reader, creationTime tim
 
 func generateMakePrimaryKey(creationTime time.Time, config *packet.Config) (key *PgpKey, err error) {
 
-	primaryKey, err := rsa.GenerateKey(config.Random(), policy.PrimaryKeyRsaKeyBits)
+	primaryKey, err := rsa.GenerateKey(config.Random(), config.RSABits)
 	if err != nil {
 		return
 	}
@@ -127,7 +204,3 @@ func generateAddOneIdentity(key *PgpKey, email string, creationTime time.Time, c
 	}
 	return nil
 }
-
-func generateAddOneEncryptionSubkey(key *PgpKey, creationTime time.Time, config *packet.Config) error {
-	return key.CreateNewEncryptionSubkey(policy.NextExpiryTime(creationTime), creationTime, config.Random())
-}
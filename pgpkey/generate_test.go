@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fluidkeys/crypto/openpgp/packet"
 	"github.com/fluidkeys/fluidkeys/assert"
 	"github.com/fluidkeys/fluidkeys/policy"
 )
@@ -150,3 +151,58 @@ func TestGenerate(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateWithAlgorithm(t *testing.T) {
+	now := time.Date(2018, 6, 15, 16, 0, 0, 0, time.UTC)
+	validUntil := now.AddDate(2, 0, 0)
+
+	t.Run(AlgorithmRSA4096, func(t *testing.T) {
+		key, err := GenerateWithAlgorithm(
+			"jane@example.com", AlgorithmRSA4096, validUntil, now, mockRandom)
+		assert.NoError(t, err)
+
+		bits, err := key.PrimaryKey.BitLength()
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(4096), bits)
+		assert.Equal(t, packet.PubKeyAlgoRSA, key.PrimaryKey.PubKeyAlgo)
+
+		assert.Equal(t, 1, len(key.Subkeys))
+		subkey := key.Subkeys[0]
+		subkeyBits, err := subkey.PublicKey.BitLength()
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(4096), subkeyBits)
+		assert.Equal(t, true, subkey.Sig.FlagEncryptCommunications)
+
+		t.Run("expires at validUntil", func(t *testing.T) {
+			for _, identity := range key.Identities {
+				hasExpiry, expiry := CalculateExpiry(
+					key.PrimaryKey.CreationTime, identity.SelfSignature.KeyLifetimeSecs)
+				assert.Equal(t, true, hasExpiry)
+				assert.AssertEqualTimes(t, validUntil, *expiry)
+			}
+
+			hasExpiry, expiry := SubkeyExpiry(subkey)
+			assert.Equal(t, true, hasExpiry)
+			assert.AssertEqualTimes(t, validUntil, *expiry)
+		})
+	})
+
+	unsupportedTests := []struct {
+		algorithm   string
+		expectedErr error
+	}{
+		{AlgorithmEd25519, fmt.Errorf("ed25519 keys aren't supported yet: use rsa4096")},
+		{AlgorithmCv25519, fmt.Errorf("cv25519 keys aren't supported yet: use rsa4096")},
+		{"dsa1024", fmt.Errorf("unknown algorithm 'dsa1024': use rsa4096")},
+	}
+
+	for _, test := range unsupportedTests {
+		t.Run(test.algorithm, func(t *testing.T) {
+			assert.Equal(t, test.expectedErr, ValidateAlgorithm(test.algorithm))
+
+			_, err := GenerateWithAlgorithm(
+				"jane@example.com", test.algorithm, validUntil, now, mockRandom)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
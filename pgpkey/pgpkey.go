@@ -84,7 +84,25 @@ func LoadFromArmoredPublicKey(armoredPublicKey string) (*PgpKey, error) {
 // If the password is wrong (at least, if .PrivateKey.Decrypt(password) returns
 // an error), this function returns an error of type `IncorrectPassword`.
 func LoadFromArmoredEncryptedPrivateKey(armoredPrivateKey string, password string) (*PgpKey, error) {
-	entityList, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredPrivateKey))
+	return LoadFromArmoredPrivateKey(armoredPrivateKey, []byte(password))
+}
+
+// LoadFromArmoredPrivateKey takes a single ascii-armored private key block.
+// If passphrase is nil, the key is returned as it is in the armored block, so it's still
+// locked if it's protected by a passphrase. Otherwise the primary key and all subkeys are
+// decrypted with passphrase, which should be empty for an unprotected key.
+//
+// If the passphrase is wrong, this function returns an error of type `IncorrectPassword`.
+func LoadFromArmoredPrivateKey(armoredKey string, passphrase []byte) (*PgpKey, error) {
+	block, err := armor.Decode(strings.NewReader(armoredKey))
+	if err != nil {
+		return nil, fmt.Errorf("error reading armored key ring: %v", err)
+	}
+	if block.Type != openpgp.PrivateKeyType {
+		return nil, fmt.Errorf("expected %s, got %s", openpgp.PrivateKeyType, block.Type)
+	}
+
+	entityList, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKey))
 	if err != nil {
 		return nil, fmt.Errorf("error reading armored key ring: %v", err)
 	}
@@ -93,22 +111,29 @@ func LoadFromArmoredEncryptedPrivateKey(armoredPrivateKey string, password strin
 	}
 	entity := entityList[0]
 
-	err = entity.PrivateKey.Decrypt([]byte(password))
-	if err != nil {
-		return nil, &IncorrectPassword{decryptErrorMessage: err.Error()}
+	if entity.PrivateKey == nil {
+		return nil, fmt.Errorf("key doesn't contain a private key")
+	}
+
+	if passphrase == nil {
+		return &PgpKey{*entity}, nil
+	}
+
+	if entity.PrivateKey.Encrypted {
+		if err := entity.PrivateKey.Decrypt(passphrase); err != nil {
+			return nil, &IncorrectPassword{decryptErrorMessage: err.Error()}
+		}
 	}
 
 	for _, subkey := range entity.Subkeys {
-		if subkey.PrivateKey.Encrypted {
-			err := subkey.PrivateKey.Decrypt([]byte(password))
-			if err != nil {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			if err := subkey.PrivateKey.Decrypt(passphrase); err != nil {
 				return nil, &IncorrectPassword{decryptErrorMessage: err.Error()}
 			}
 		}
 	}
 
-	pgpKey := PgpKey{*entity}
-	return &pgpKey, nil
+	return &PgpKey{*entity}, nil
 }
 
 // Armor returns the public part of a key in armored format.
@@ -162,15 +187,33 @@ func (key *PgpKey) ArmorPrivate(passwordToEncryptWith string) (string, error) {
 }
 
 func (key *PgpKey) ArmorRevocationCertificate(now time.Time) (string, error) {
+	reasonText := "Revocation certificate was automatically generated by Fluidkeys when this key was created."
+	return key.armorRevocationCertificate(revocationReasonNone, reasonText, now)
+}
+
+// Revoke returns an ASCII armored revocation certificate for the key, giving reason as the
+// reason text. Importing the certificate into GnuPG and publishing the key revokes it.
+// The private key must already be decrypted.
+func (key *PgpKey) Revoke(reason string) (string, error) {
+	if err := key.ensureGotDecryptedPrivateKey(); err != nil {
+		return "", err
+	}
+	return key.armorRevocationCertificate(revocationReasonNone, reason, time.Now())
+}
+
+// revocationReasonNone is "no reason specified", see
+// https://tools.ietf.org/html/rfc4880#section-5.2.3.23
+const revocationReasonNone uint8 = 0
+
+func (key *PgpKey) armorRevocationCertificate(
+	reasonByte uint8, reasonText string, now time.Time) (string, error) {
+
 	buf := new(bytes.Buffer)
 	armor, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
 	if err != nil {
 		return "", err
 	}
 
-	reasonByte := uint8(0) // "no reason", see https://tools.ietf.org/html/rfc4880#section-5.2.3.23
-	reasonText := "Revocation certificate was automatically generated by Fluidkeys when this key was created."
-
 	signature, err := key.GetRevocationSignature(reasonByte, reasonText, now)
 	if err != nil {
 		return "", err
@@ -267,6 +310,10 @@ func (key *PgpKey) RefreshUserIdSelfSignatures(now time.Time) error {
 	}
 
 	for name, id := range key.Identities {
+		if key.isIdentityRevoked(id) {
+			// a newer self signature would make GnuPG treat the user ID as valid again
+			continue
+		}
 		id.SelfSignature.CreationTime = now
 		id.SelfSignature.Hash = config.Hash()
 
@@ -341,7 +388,8 @@ func (key *PgpKey) Email() (string, error) {
 	}
 }
 
-// Emails returns a list of email addresses parsed from user ids, sorted by
+// Emails returns a list of email addresses parsed from user ids which haven't been revoked,
+// sorted by
 // 1. whether it's a primary user id (primary come first)
 // 2. the self signature creation time (oldest first)
 // 3. the email address (domain part followed by name part)
@@ -358,6 +406,9 @@ func (key *PgpKey) Email() (string, error) {
 func (key *PgpKey) Emails(allowUnbracketed bool) []string {
 	identities := []openpgp.Identity{}
 	for _, identity := range key.Identities {
+		if key.isIdentityRevoked(identity) {
+			continue
+		}
 		identities = append(identities, *identity)
 	}
 	lessFunc := func(i, j int) bool { return identityLess(identities[i], identities[j]) }
@@ -435,7 +486,10 @@ func (key *PgpKey) UpdateExpiryForAllUserIds(validUntil time.Time, now time.Time
 // * has the latest CreationTime (e.g. most recent)
 
 func (key *PgpKey) EncryptionSubkey(now time.Time) *openpgp.Subkey {
-	subkeys := key.validEncryptionSubkeys(now)
+	var subkeys []openpgp.Subkey
+	for _, subkey := range key.validEncryptionSubkeys(now) {
+		subkeys = append(subkeys, *subkey)
+	}
 
 	if len(subkeys) == 0 {
 		return nil
@@ -445,19 +499,44 @@ func (key *PgpKey) EncryptionSubkey(now time.Time) *openpgp.Subkey {
 	return &subkeys[0]
 }
 
+// SubkeyForEncryption returns the subkey that should be used to encrypt to the key right now:
+// the most recently created one that can encrypt and hasn't expired or been revoked.
+// It returns ErrNoUsableEncryptionSubkey if there isn't one, or if the whole key is revoked.
+func (key *PgpKey) SubkeyForEncryption() (*openpgp.Subkey, error) {
+	return key.subkeyForEncryption(time.Now())
+}
+
+func (key *PgpKey) subkeyForEncryption(now time.Time) (*openpgp.Subkey, error) {
+	if len(key.Revocations) > 0 {
+		return nil, ErrNoUsableEncryptionSubkey
+	}
+	subkey := key.EncryptionSubkey(now)
+	if subkey == nil {
+		return nil, ErrNoUsableEncryptionSubkey
+	}
+	return subkey, nil
+}
+
 // CreateNewEncryptionSubkey creaates and signs a new encryption subkey for
 // the primary key, valid until a specified time.
 //
 // The `random` parameter provides a source of entropy. If `nil`, a
 // cryptographically secure source is used.
 func (key *PgpKey) CreateNewEncryptionSubkey(validUntil time.Time, now time.Time, random io.Reader) error {
+	return key.createEncryptionSubkey(policy.EncryptionSubkeyRsaKeyBits, validUntil, now, random)
+}
+
+// createEncryptionSubkey adds a new RSA encryption subkey with the given number of bits.
+func (key *PgpKey) createEncryptionSubkey(
+	bits int, validUntil time.Time, now time.Time, random io.Reader) error {
+
 	err := key.ensureGotDecryptedPrivateKey()
 	if err != nil {
 		return err
 	}
 
 	config := packet.Config{
-		RSABits:     policy.EncryptionSubkeyRsaKeyBits,
+		RSABits:     bits,
 		DefaultHash: policy.SignatureHashFunction,
 		Rand:        random,
 	}
@@ -540,12 +619,42 @@ func (key *PgpKey) UpdateSubkeyValidUntil(subkeyId uint64, validUntil time.Time,
 	return nil
 }
 
-func (key *PgpKey) validEncryptionSubkeys(now time.Time) []openpgp.Subkey {
-	var subkeys []openpgp.Subkey
+// EncryptionSubkeys returns all the subkeys which can currently be used for encryption: those
+// with an encryption capability flag that haven't expired or been revoked.
+// Use EncryptionSubkey to pick the one subkey that should be used to encrypt to this key.
+func (key *PgpKey) EncryptionSubkeys() []*openpgp.Subkey {
+	return key.validEncryptionSubkeys(time.Now())
+}
+
+// WithPinnedEncryptionSubkey returns a copy of the key whose only subkey is the one with the
+// given fingerprint, so that anything encrypted to the copy uses that subkey.
+// It returns an error if the key has no such subkey, or if the subkey can't currently be used
+// for encryption.
+func (key *PgpKey) WithPinnedEncryptionSubkey(
+	subkeyFingerprint fpr.Fingerprint, now time.Time) (*PgpKey, error) {
 
 	for _, subkey := range key.Subkeys {
-		if isEncryptionSubkeyValid(subkey, now) {
-			subkeys = append(subkeys, subkey)
+		if fpr.FromBytes(subkey.PublicKey.Fingerprint) != subkeyFingerprint {
+			continue
+		}
+		if !isEncryptionSubkeyValid(subkey, now) {
+			return nil, fmt.Errorf("subkey %s can't be used for encryption: it may have "+
+				"expired, been revoked or lack the encryption capability", subkeyFingerprint)
+		}
+
+		pinned := *key
+		pinned.Subkeys = []openpgp.Subkey{subkey}
+		return &pinned, nil
+	}
+	return nil, fmt.Errorf("key %s has no subkey %s", key.Fingerprint(), subkeyFingerprint)
+}
+
+func (key *PgpKey) validEncryptionSubkeys(now time.Time) []*openpgp.Subkey {
+	var subkeys []*openpgp.Subkey
+
+	for i := range key.Subkeys {
+		if isEncryptionSubkeyValid(key.Subkeys[i], now) {
+			subkeys = append(subkeys, &key.Subkeys[i])
 		}
 	}
 	return subkeys
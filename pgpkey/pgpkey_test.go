@@ -8,10 +8,12 @@ import (
 	"io"
 	"log"
 	insecurerand "math/rand"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/armor"
 	"github.com/fluidkeys/crypto/openpgp/packet"
 
 	"github.com/fluidkeys/fluidkeys/assert"
@@ -455,6 +457,64 @@ func TestLoadFromArmoredEncryptedPrivateKey(t *testing.T) {
 	})
 }
 
+func TestLoadFromArmoredPrivateKey(t *testing.T) {
+	assertFullyDecrypted := func(t *testing.T, key *PgpKey) {
+		t.Helper()
+		assert.Equal(t, false, key.PrivateKey.Encrypted)
+		for _, subkey := range key.Subkeys {
+			assert.Equal(t, false, subkey.PrivateKey.Encrypted)
+		}
+	}
+
+	t.Run("with a passphrase protected key", func(t *testing.T) {
+		t.Run("decrypts with the right passphrase", func(t *testing.T) {
+			key, err := LoadFromArmoredPrivateKey(exampledata.ExamplePrivateKey4, []byte("test4"))
+			assert.NoError(t, err)
+			assert.Equal(t, exampledata.ExampleFingerprint4, key.Fingerprint())
+			assertFullyDecrypted(t, key)
+		})
+
+		t.Run("leaves the key locked with a nil passphrase", func(t *testing.T) {
+			key, err := LoadFromArmoredPrivateKey(exampledata.ExamplePrivateKey4, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, true, key.PrivateKey.Encrypted)
+		})
+
+		t.Run("returns IncorrectPassword for the wrong passphrase", func(t *testing.T) {
+			_, err := LoadFromArmoredPrivateKey(exampledata.ExamplePrivateKey4, []byte("wrong"))
+			if _, ok := err.(*IncorrectPassword); !ok {
+				t.Fatalf("expected err.(type) = IncorrectPassword, got %v", err)
+			}
+		})
+	})
+
+	t.Run("with an unprotected key", func(t *testing.T) {
+		unlocked, err := LoadFromArmoredPrivateKey(exampledata.ExamplePrivateKey4, []byte("test4"))
+		assert.NoError(t, err)
+		armoredUnprotected, err := unlocked.ArmorPrivate("")
+		assert.NoError(t, err)
+
+		for _, passphrase := range [][]byte{nil, []byte{}} {
+			t.Run(fmt.Sprintf("with passphrase %#v", passphrase), func(t *testing.T) {
+				key, err := LoadFromArmoredPrivateKey(armoredUnprotected, passphrase)
+				assert.NoError(t, err)
+				assert.Equal(t, exampledata.ExampleFingerprint4, key.Fingerprint())
+				assertFullyDecrypted(t, key)
+			})
+		}
+	})
+
+	t.Run("rejects a public key", func(t *testing.T) {
+		_, err := LoadFromArmoredPrivateKey(exampledata.ExamplePublicKey4, nil)
+		assert.Equal(t, fmt.Errorf("expected PGP PRIVATE KEY BLOCK, got PGP PUBLIC KEY BLOCK"), err)
+	})
+
+	t.Run("rejects invalid ascii armor", func(t *testing.T) {
+		_, err := LoadFromArmoredPrivateKey("INVALID ASCII ARMOR", []byte("test4"))
+		assert.GotError(t, err)
+	})
+}
+
 func TestEncryptionSubkey(t *testing.T) {
 	now := time.Date(2018, 6, 15, 0, 0, 0, 0, time.UTC)
 	thirtyDaysAgo := now.Add(-time.Duration(24*30) * time.Hour)
@@ -601,7 +661,7 @@ func TestEncryptionSubkey(t *testing.T) {
 		}
 
 		for i := range expectedSubkeys {
-			if expectedSubkeys[i] != gotSubkeys[i] {
+			if expectedSubkeys[i] != *gotSubkeys[i] {
 				t.Fatalf("expectedSubkeys[%d] != gotSubkeys[%d]. expected: %v, got: %v",
 					i, i, expectedSubkeys[i], gotSubkeys[i])
 			}
@@ -650,6 +710,198 @@ func TestEncryptionSubkey(t *testing.T) {
 
 }
 
+func TestEncryptionSubkeys(t *testing.T) {
+	now := time.Now()
+	thirtyDaysAgo := now.Add(-time.Duration(24*30) * time.Hour)
+	sixtyDaysAgo := now.Add(-time.Duration(24*60) * time.Hour)
+	thirtyDaysFromNow := now.Add(time.Duration(24*30) * time.Hour)
+
+	subkeyTests := []subkeyConfig{
+		{
+			// valid
+			expectedValid:         true,
+			keyCreationTime:       sixtyDaysAgo,
+			signatureCreationTime: sixtyDaysAgo,
+			expiryTime:            &thirtyDaysFromNow,
+			flagsValid:            true,
+			encryptFlags:          true,
+		},
+		{
+			// expired
+			expectedValid:         false,
+			keyCreationTime:       sixtyDaysAgo,
+			signatureCreationTime: sixtyDaysAgo,
+			expiryTime:            &thirtyDaysAgo,
+			flagsValid:            true,
+			encryptFlags:          true,
+		},
+		{
+			// revoked
+			expectedValid:         false,
+			keyCreationTime:       sixtyDaysAgo,
+			signatureCreationTime: sixtyDaysAgo,
+			expiryTime:            &thirtyDaysFromNow,
+			revoked:               true,
+			flagsValid:            true,
+			encryptFlags:          true,
+		},
+		{
+			// valid, no expiry
+			expectedValid:         true,
+			keyCreationTime:       thirtyDaysAgo,
+			signatureCreationTime: thirtyDaysAgo,
+			expiryTime:            nil,
+			flagsValid:            true,
+			encryptFlags:          true,
+		},
+		{
+			// can't do encryption
+			expectedValid:         false,
+			keyCreationTime:       thirtyDaysAgo,
+			signatureCreationTime: thirtyDaysAgo,
+			expiryTime:            &thirtyDaysFromNow,
+			flagsValid:            true,
+			encryptFlags:          false,
+		},
+	}
+
+	pgpKey, err := makeKeyWithSubkeys(t, subkeyTests, now)
+	assert.NoError(t, err)
+
+	t.Run("returns only valid encryption subkeys", func(t *testing.T) {
+		gotSubkeys := pgpKey.EncryptionSubkeys()
+
+		assert.Equal(t, 2, len(gotSubkeys))
+		assert.Equal(t, pgpKey.Subkeys[0].PublicKey.KeyId, gotSubkeys[0].PublicKey.KeyId)
+		assert.Equal(t, pgpKey.Subkeys[3].PublicKey.KeyId, gotSubkeys[1].PublicKey.KeyId)
+	})
+
+	t.Run("returns pointers to the key's own subkeys", func(t *testing.T) {
+		gotSubkeys := pgpKey.EncryptionSubkeys()
+		if gotSubkeys[0] != &pgpKey.Subkeys[0] {
+			t.Fatalf("expected a pointer to pgpKey.Subkeys[0], got a copy")
+		}
+	})
+
+	t.Run("returns empty for a key without subkeys", func(t *testing.T) {
+		keyWithoutSubkeys := PgpKey{Entity: pgpKey.Entity}
+		keyWithoutSubkeys.Subkeys = nil
+
+		assert.Equal(t, 0, len(keyWithoutSubkeys.EncryptionSubkeys()))
+	})
+}
+
+func TestSubkeyForEncryption(t *testing.T) {
+	now := time.Date(2018, 6, 15, 0, 0, 0, 0, time.UTC)
+	thirtyDaysAgo := now.Add(-time.Duration(24*30) * time.Hour)
+	sixtyDaysAgo := now.Add(-time.Duration(24*60) * time.Hour)
+	thirtyDaysFromNow := now.Add(time.Duration(24*30) * time.Hour)
+
+	valid := func(created time.Time) subkeyConfig {
+		return subkeyConfig{
+			keyCreationTime:       created,
+			signatureCreationTime: created,
+			expiryTime:            &thirtyDaysFromNow,
+			flagsValid:            true,
+			encryptFlags:          true,
+		}
+	}
+	expired := valid(sixtyDaysAgo)
+	expired.expiryTime = &thirtyDaysAgo
+
+	t.Run("returns error for a key with no subkeys", func(t *testing.T) {
+		pgpKey, err := makeKeyWithSubkeys(t, []subkeyConfig{}, now)
+		assert.NoError(t, err)
+
+		_, err = pgpKey.subkeyForEncryption(now)
+		assert.Equal(t, ErrNoUsableEncryptionSubkey, err)
+	})
+
+	t.Run("picks the most recent of multiple valid subkeys", func(t *testing.T) {
+		pgpKey, err := makeKeyWithSubkeys(t, []subkeyConfig{
+			valid(sixtyDaysAgo), valid(thirtyDaysAgo), expired,
+		}, now)
+		assert.NoError(t, err)
+
+		subkey, err := pgpKey.subkeyForEncryption(now)
+		assert.NoError(t, err)
+		assert.Equal(t, pgpKey.Subkeys[1].PublicKey.KeyId, subkey.PublicKey.KeyId)
+	})
+
+	t.Run("returns error if every subkey has expired", func(t *testing.T) {
+		pgpKey, err := makeKeyWithSubkeys(t, []subkeyConfig{expired, expired}, now)
+		assert.NoError(t, err)
+
+		_, err = pgpKey.subkeyForEncryption(now)
+		assert.Equal(t, ErrNoUsableEncryptionSubkey, err)
+	})
+}
+
+func TestWithPinnedEncryptionSubkey(t *testing.T) {
+	now := time.Now()
+	sixtyDaysAgo := now.Add(-time.Duration(24*60) * time.Hour)
+	thirtyDaysAgo := now.Add(-time.Duration(24*30) * time.Hour)
+	thirtyDaysFromNow := now.Add(time.Duration(24*30) * time.Hour)
+
+	pgpKey, err := makeKeyWithSubkeys(t, []subkeyConfig{
+		{
+			// valid, but not the newest
+			keyCreationTime:       sixtyDaysAgo,
+			signatureCreationTime: sixtyDaysAgo,
+			expiryTime:            &thirtyDaysFromNow,
+			flagsValid:            true,
+			encryptFlags:          true,
+		},
+		{
+			// expired
+			keyCreationTime:       sixtyDaysAgo,
+			signatureCreationTime: sixtyDaysAgo,
+			expiryTime:            &thirtyDaysAgo,
+			flagsValid:            true,
+			encryptFlags:          true,
+		},
+		{
+			// valid, newest
+			keyCreationTime:       thirtyDaysAgo,
+			signatureCreationTime: thirtyDaysAgo,
+			expiryTime:            &thirtyDaysFromNow,
+			flagsValid:            true,
+			encryptFlags:          true,
+		},
+	}, now)
+	assert.NoError(t, err)
+
+	subkeyFingerprint := func(i int) fpr.Fingerprint {
+		return fpr.FromBytes(pgpKey.Subkeys[i].PublicKey.Fingerprint)
+	}
+
+	t.Run("returns a copy with only the pinned subkey", func(t *testing.T) {
+		pinned, err := pgpKey.WithPinnedEncryptionSubkey(subkeyFingerprint(0), now)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, len(pinned.Subkeys))
+		assert.Equal(t, subkeyFingerprint(0), fpr.FromBytes(pinned.Subkeys[0].PublicKey.Fingerprint))
+		assert.Equal(t, pgpKey.Fingerprint(), pinned.Fingerprint())
+	})
+
+	t.Run("doesn't modify the original key", func(t *testing.T) {
+		_, err := pgpKey.WithPinnedEncryptionSubkey(subkeyFingerprint(0), now)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(pgpKey.Subkeys))
+	})
+
+	t.Run("errors for an expired subkey", func(t *testing.T) {
+		_, err := pgpKey.WithPinnedEncryptionSubkey(subkeyFingerprint(1), now)
+		assert.GotError(t, err)
+	})
+
+	t.Run("errors for a subkey that isn't part of the key", func(t *testing.T) {
+		_, err := pgpKey.WithPinnedEncryptionSubkey(pgpKey.Fingerprint(), now)
+		assert.Equal(t, fmt.Errorf("key %s has no subkey %s",
+			pgpKey.Fingerprint(), pgpKey.Fingerprint()), err)
+	})
+}
+
 type subkeyConfig struct {
 	expectedValid         bool
 	keyCreationTime       time.Time
@@ -1276,3 +1528,45 @@ lj2PQbxhrA==
 -----END PGP PUBLIC KEY BLOCK-----`
 
 const exampleUid string = "<test@example.com>"
+
+func TestRevoke(t *testing.T) {
+	t.Run("returns an armored revocation signature with the reason", func(t *testing.T) {
+		pgpKey, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey3, "test3")
+		assert.NoError(t, err)
+
+		armored, err := pgpKey.Revoke("laptop was stolen")
+		assert.NoError(t, err)
+
+		block, err := armor.Decode(strings.NewReader(armored))
+		assert.NoError(t, err)
+		assert.Equal(t, openpgp.PublicKeyType, block.Type)
+
+		pkt, err := packet.Read(block.Body)
+		assert.NoError(t, err)
+
+		revocation, ok := pkt.(*packet.Signature)
+		if !ok {
+			t.Fatalf("expected *packet.Signature, got %T", pkt)
+		}
+		assert.Equal(t, packet.SignatureType(packet.SigTypeKeyRevocation), revocation.SigType)
+		assert.Equal(t, "laptop was stolen", revocation.RevocationReasonText)
+		assert.NoError(t, pgpKey.PrimaryKey.VerifyRevocationSignature(revocation))
+	})
+
+	t.Run("refuses if the private key is encrypted", func(t *testing.T) {
+		pgpKey, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey3, "test3")
+		assert.NoError(t, err)
+		pgpKey.PrivateKey.Encrypted = true
+
+		_, err = pgpKey.Revoke("any reason")
+		assert.GotError(t, err)
+	})
+
+	t.Run("refuses if there's no private key", func(t *testing.T) {
+		pgpKey, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+		assert.NoError(t, err)
+
+		_, err = pgpKey.Revoke("any reason")
+		assert.GotError(t, err)
+	})
+}
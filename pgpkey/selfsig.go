@@ -0,0 +1,60 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SelfSigError describes a user ID whose self-signature is missing or doesn't verify.
+type SelfSigError struct {
+	UserID string
+	Err    error
+}
+
+func (e SelfSigError) Error() string {
+	return fmt.Sprintf("%s: %v", e.UserID, e.Err)
+}
+
+// ErrMissingSelfSignature means a user ID has no self-signature binding it to the key.
+var ErrMissingSelfSignature = fmt.Errorf("missing self-signature")
+
+// VerifySelfSignatures cryptographically checks the self-signature of every user ID on the key,
+// returning a SelfSigError for each one that's missing or invalid, sorted by user ID. An empty
+// slice means every user ID is correctly bound to the key.
+func (key *PgpKey) VerifySelfSignatures() []SelfSigError {
+	selfSigErrors := []SelfSigError{}
+
+	for name, identity := range key.Identities {
+		if identity.SelfSignature == nil {
+			selfSigErrors = append(selfSigErrors, SelfSigError{name, ErrMissingSelfSignature})
+			continue
+		}
+
+		err := key.PrimaryKey.VerifyUserIdSignature(name, key.PrimaryKey, identity.SelfSignature)
+		if err != nil {
+			selfSigErrors = append(selfSigErrors, SelfSigError{name, err})
+		}
+	}
+
+	sort.Slice(selfSigErrors, func(i, j int) bool {
+		return selfSigErrors[i].UserID < selfSigErrors[j].UserID
+	})
+	return selfSigErrors
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestVerifySelfSignatures(t *testing.T) {
+	t.Run("with valid self-signatures returns no errors", func(t *testing.T) {
+		key, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []SelfSigError{}, key.VerifySelfSignatures())
+	})
+
+	t.Run("with a stripped self-signature", func(t *testing.T) {
+		key, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+		assert.NoError(t, err)
+
+		key.Identities["Example Name <another@example.com>"].SelfSignature = nil
+
+		assert.Equal(t,
+			[]SelfSigError{{"Example Name <another@example.com>", ErrMissingSelfSignature}},
+			key.VerifySelfSignatures(),
+		)
+	})
+
+	t.Run("with a tampered self-signature", func(t *testing.T) {
+		key, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+
+		// corrupt the first two bytes of the signed hash, which are checked before the
+		// signature itself
+		identity := key.Identities["test4@example.com"]
+		identity.SelfSignature.HashTag[0] ^= 0xff
+
+		selfSigErrors := key.VerifySelfSignatures()
+		assert.Equal(t, 1, len(selfSigErrors))
+		assert.Equal(t, "test4@example.com", selfSigErrors[0].UserID)
+		assert.GotError(t, selfSigErrors[0].Err)
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"bytes"
 
 	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/clearsign"
 )
 
 func (p *PgpKey) MakeArmoredDetachedSignature(dataToSign []byte) (string, error) {
@@ -38,3 +39,29 @@ func (p *PgpKey) MakeArmoredDetachedSignature(dataToSign []byte) (string, error)
 	}
 	return outputBuf.String(), nil
 }
+
+// MakeArmoredClearsignedText returns dataToSign wrapped in a cleartext signature, like
+// `gpg --clearsign`. This is also the format used to sign requests to the Fluidkeys API, for
+// example the JSON sent by apiclient.UpsertPublicKey.
+func (p *PgpKey) MakeArmoredClearsignedText(dataToSign []byte) (string, error) {
+	err := p.ensureGotDecryptedPrivateKey()
+	if err != nil {
+		return "", err
+	}
+
+	outputBuf := bytes.NewBuffer(nil)
+
+	armorWriteCloser, err := clearsign.Encode(outputBuf, p.PrivateKey, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = armorWriteCloser.Write(dataToSign); err != nil {
+		return "", err
+	}
+
+	if err := armorWriteCloser.Close(); err != nil {
+		return "", err
+	}
+	return outputBuf.String(), nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/packet"
+	"github.com/fluidkeys/fluidkeys/policy"
+)
+
+// AddUserId adds a new identity for email to the key and self-signs it at now. The new identity
+// takes its flags, algorithm preferences and expiry from the key's primary identity, but isn't
+// made the primary identity itself. The key must have an unlocked private key.
+func (key *PgpKey) AddUserId(email string, now time.Time) error {
+	if err := key.ensureGotDecryptedPrivateKey(); err != nil {
+		return err
+	}
+
+	for _, existingEmail := range key.Emails(true) {
+		if strings.EqualFold(existingEmail, email) {
+			return fmt.Errorf("key already has a user ID for %s", email)
+		}
+	}
+
+	template := key.primarySelfSignature()
+	if template == nil {
+		return fmt.Errorf("key has no existing user ID to copy settings from")
+	}
+
+	uid := packet.NewUserId("", "", email)
+	if uid == nil {
+		return fmt.Errorf("email contained invalid characters")
+	}
+
+	config := packet.Config{
+		DefaultHash: policy.SignatureHashFunction,
+	}
+
+	selfSig := &packet.Signature{
+		CreationTime:              now,
+		SigType:                   packet.SigTypePositiveCert,
+		PubKeyAlgo:                key.PrimaryKey.PubKeyAlgo,
+		Hash:                      config.Hash(),
+		FlagsValid:                template.FlagsValid,
+		FlagCertify:               template.FlagCertify,
+		FlagSign:                  template.FlagSign,
+		FlagEncryptCommunications: template.FlagEncryptCommunications,
+		FlagEncryptStorage:        template.FlagEncryptStorage,
+		KeyLifetimeSecs:           template.KeyLifetimeSecs,
+		PreferredSymmetric:        template.PreferredSymmetric,
+		PreferredHash:             template.PreferredHash,
+		PreferredCompression:      template.PreferredCompression,
+		IssuerKeyId:               &key.PrimaryKey.KeyId,
+	}
+
+	err := selfSig.SignUserId(uid.Id, key.PrimaryKey, key.PrivateKey, &config)
+	if err != nil {
+		return fmt.Errorf("error calling SignUserId(%s, ...): %v", uid.Id, err)
+	}
+
+	key.Identities[uid.Id] = &openpgp.Identity{
+		Name:          uid.Id,
+		UserId:        uid,
+		SelfSignature: selfSig,
+	}
+	return nil
+}
+
+// sigTypeCertificationRevocation is the signature type which revokes a user ID (RFC 4880 section
+// 5.2.1). The openpgp package doesn't define it.
+const sigTypeCertificationRevocation packet.SignatureType = 0x30
+
+// RevokeUserId adds a revocation self-signature to the key's identity for email, so that other
+// people stop using that email address to find the key. The identity stays on the key so that the
+// revocation is published with it. The key's last remaining identity can't be revoked.
+// The key must have an unlocked private key.
+func (key *PgpKey) RevokeUserId(email string, now time.Time) error {
+	if err := key.ensureGotDecryptedPrivateKey(); err != nil {
+		return err
+	}
+
+	var toRevoke *openpgp.Identity
+	activeIdentities := 0
+
+	for _, identity := range key.Identities {
+		if key.isIdentityRevoked(identity) {
+			continue
+		}
+		activeIdentities++
+
+		if identityEmail, ok := getEmail(identity, true); ok && strings.EqualFold(identityEmail, email) {
+			toRevoke = identity
+		}
+	}
+
+	if toRevoke == nil {
+		return fmt.Errorf("key has no user ID for %s", email)
+	} else if activeIdentities < 2 {
+		return fmt.Errorf("can't remove the key's only user ID")
+	}
+
+	config := packet.Config{
+		DefaultHash: policy.SignatureHashFunction,
+	}
+
+	revocation := &packet.Signature{
+		CreationTime: now,
+		SigType:      sigTypeCertificationRevocation,
+		PubKeyAlgo:   key.PrimaryKey.PubKeyAlgo,
+		Hash:         config.Hash(),
+		IssuerKeyId:  &key.PrimaryKey.KeyId,
+	}
+
+	err := revocation.SignUserId(toRevoke.UserId.Id, key.PrimaryKey, key.PrivateKey, &config)
+	if err != nil {
+		return fmt.Errorf("error calling SignUserId(%s, ...): %v", toRevoke.UserId.Id, err)
+	}
+
+	toRevoke.Signatures = append(toRevoke.Signatures, revocation)
+	return nil
+}
+
+// isIdentityRevoked returns true if the identity has a valid revocation signature made by the key
+// itself.
+func (key *PgpKey) isIdentityRevoked(identity *openpgp.Identity) bool {
+	for _, sig := range identity.Signatures {
+		if sig.SigType != sigTypeCertificationRevocation {
+			continue
+		}
+		if sig.IssuerKeyId == nil || *sig.IssuerKeyId != key.PrimaryKey.KeyId {
+			continue
+		}
+		if key.PrimaryKey.VerifyUserIdSignature(identity.UserId.Id, key.PrimaryKey, sig) == nil {
+			return true
+		}
+	}
+	return false
+}
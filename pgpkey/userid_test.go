@@ -0,0 +1,156 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestAddUserId(t *testing.T) {
+	now := time.Date(2019, 6, 15, 16, 35, 14, 0, time.UTC)
+
+	t.Run("adds a self-signed identity", func(t *testing.T) {
+		key, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+		assert.NoError(t, err)
+		identitiesBefore := len(key.Identities)
+
+		err = key.AddUserId("new@example.com", now)
+		assert.NoError(t, err)
+
+		assert.Equal(t, identitiesBefore+1, len(key.Identities))
+
+		identity, ok := key.Identities["<new@example.com>"]
+		if !ok {
+			t.Fatalf("expected identity <new@example.com>, got %v", key.Identities)
+		}
+
+		t.Run("signature verifies", func(t *testing.T) {
+			assert.NoError(t, key.PrimaryKey.VerifyUserIdSignature(
+				"<new@example.com>", key.PrimaryKey, identity.SelfSignature))
+		})
+
+		t.Run("isn't the primary identity", func(t *testing.T) {
+			if identity.SelfSignature.IsPrimaryId != nil && *identity.SelfSignature.IsPrimaryId {
+				t.Fatalf("new identity was marked as primary")
+			}
+		})
+
+		t.Run("survives armoring and loading the public key", func(t *testing.T) {
+			armored, err := key.Armor()
+			assert.NoError(t, err)
+
+			loaded, err := LoadFromArmoredPublicKey(armored)
+			assert.NoError(t, err)
+
+			assert.Equal(t, identitiesBefore+1, len(loaded.Identities))
+			assert.Equal(t, true, containsString(loaded.Emails(false), "new@example.com"))
+		})
+	})
+
+	t.Run("returns error if the key already has the email", func(t *testing.T) {
+		key, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+		assert.NoError(t, err)
+
+		err = key.AddUserId("TEST4@example.com", now)
+		assert.Equal(t, fmt.Errorf("key already has a user ID for TEST4@example.com"), err)
+	})
+
+	t.Run("returns error without a private key", func(t *testing.T) {
+		key, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+		assert.NoError(t, err)
+
+		err = key.AddUserId("new@example.com", now)
+		assert.Equal(t, fmt.Errorf("no private key for primary key"), err)
+	})
+}
+
+func TestRevokeUserId(t *testing.T) {
+	now := time.Date(2019, 6, 15, 16, 35, 14, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	loadKeyWithTwoEmails := func(t *testing.T) *PgpKey {
+		key, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+		assert.NoError(t, err)
+		assert.NoError(t, key.AddUserId("new@example.com", now))
+		return key
+	}
+
+	t.Run("adds a revocation self-signature", func(t *testing.T) {
+		key := loadKeyWithTwoEmails(t)
+		emailsBefore := len(key.Emails(true))
+
+		err := key.RevokeUserId("new@example.com", later)
+		assert.NoError(t, err)
+
+		identity := key.Identities["<new@example.com>"]
+		assert.Equal(t, 1, len(identity.Signatures))
+
+		revocation := identity.Signatures[0]
+		assert.Equal(t, sigTypeCertificationRevocation, revocation.SigType)
+		assert.NoError(t, key.PrimaryKey.VerifyUserIdSignature(
+			"<new@example.com>", key.PrimaryKey, revocation))
+
+		t.Run("email is no longer listed", func(t *testing.T) {
+			assert.Equal(t, emailsBefore-1, len(key.Emails(true)))
+			assert.Equal(t, false, containsString(key.Emails(true), "new@example.com"))
+		})
+
+		t.Run("revocation survives armoring and loading the public key", func(t *testing.T) {
+			armored, err := key.Armor()
+			assert.NoError(t, err)
+
+			loaded, err := LoadFromArmoredPublicKey(armored)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"test4@example.com"}, loaded.Emails(true))
+		})
+
+		t.Run("refreshing self signatures doesn't re-sign it", func(t *testing.T) {
+			assert.NoError(t, key.RefreshUserIdSelfSignatures(later.Add(time.Hour)))
+			assert.Equal(t, now, identity.SelfSignature.CreationTime)
+		})
+	})
+
+	t.Run("returns error for the last user ID", func(t *testing.T) {
+		key := loadKeyWithTwoEmails(t)
+		assert.NoError(t, key.RevokeUserId("new@example.com", later))
+
+		err := key.RevokeUserId("test4@example.com", later)
+		assert.Equal(t, fmt.Errorf("can't remove the key's only user ID"), err)
+	})
+
+	t.Run("returns error if the key doesn't have the email", func(t *testing.T) {
+		key := loadKeyWithTwoEmails(t)
+
+		err := key.RevokeUserId("missing@example.com", later)
+		assert.Equal(t, fmt.Errorf("key has no user ID for missing@example.com"), err)
+	})
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
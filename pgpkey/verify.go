@@ -0,0 +1,106 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/crypto/openpgp/errors"
+	"github.com/fluidkeys/crypto/openpgp/packet"
+)
+
+// SignatureDetails describes a valid signature made by a key.
+type SignatureDetails struct {
+	// CreationTime is when the signature claims to have been made
+	CreationTime time.Time
+
+	// SignerKeyId is the key ID of the primary key or subkey which made the signature
+	SignerKeyId uint64
+
+	// MadeBySubkey is true if the signature was made by a signing subkey rather than the
+	// primary key
+	MadeBySubkey bool
+}
+
+// ErrWrongSigner means the signature was made by a different key.
+var ErrWrongSigner = fmt.Errorf("signature wasn't made by this key")
+
+// VerifyArmoredDetachedSignature checks that armoredSignature is a valid detached signature
+// over signedData, made by the key or one of its signing subkeys.
+func (key *PgpKey) VerifyArmoredDetachedSignature(
+	signedData []byte, armoredSignature string) (*SignatureDetails, error) {
+
+	block, err := armor.Decode(strings.NewReader(armoredSignature))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding signature: %v", err)
+	}
+	if block.Type != openpgp.SignatureType {
+		return nil, fmt.Errorf("expected %s, got %s", openpgp.SignatureType, block.Type)
+	}
+	signatureBytes, err := ioutil.ReadAll(block.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading signature: %v", err)
+	}
+
+	details, err := readSignatureDetails(signatureBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = openpgp.CheckDetachedSignature(
+		openpgp.EntityList{&key.Entity},
+		bytes.NewReader(signedData),
+		bytes.NewReader(signatureBytes),
+	)
+	if err == errors.ErrUnknownIssuer {
+		return nil, ErrWrongSigner
+	} else if err != nil {
+		return nil, err
+	}
+
+	details.MadeBySubkey = details.SignerKeyId != key.PrimaryKey.KeyId
+	return details, nil
+}
+
+// readSignatureDetails reads the creation time and issuer from the first signature packet
+func readSignatureDetails(signatureBytes []byte) (*SignatureDetails, error) {
+	p, err := packet.Read(bytes.NewReader(signatureBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error reading signature packet: %v", err)
+	}
+
+	switch sig := p.(type) {
+	case *packet.Signature:
+		if sig.IssuerKeyId == nil {
+			return nil, fmt.Errorf("signature doesn't have an issuer")
+		}
+		return &SignatureDetails{CreationTime: sig.CreationTime, SignerKeyId: *sig.IssuerKeyId}, nil
+
+	case *packet.SignatureV3:
+		return &SignatureDetails{CreationTime: sig.CreationTime, SignerKeyId: sig.IssuerKeyId}, nil
+
+	default:
+		return nil, fmt.Errorf("expected signature packet, got %T", p)
+	}
+}
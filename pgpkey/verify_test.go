@@ -0,0 +1,89 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package pgpkey
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/clearsign"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+)
+
+func TestVerifyArmoredDetachedSignature(t *testing.T) {
+	key4, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+
+	key2, err := LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+
+	t.Run("with a valid signature", func(t *testing.T) {
+		details, err := key4.VerifyArmoredDetachedSignature(
+			[]byte(exampledata.ExampleSignedData4), exampledata.ExampleDetachedSignature4)
+
+		assert.NoError(t, err)
+		assert.Equal(t, key4.PrimaryKey.KeyId, details.SignerKeyId)
+		assert.Equal(t, false, details.MadeBySubkey)
+		assert.Equal(t, time.Date(2026, 10, 15, 4, 0, 33, 0, time.UTC), details.CreationTime.UTC())
+	})
+
+	t.Run("with data that doesn't match the signature", func(t *testing.T) {
+		_, err := key4.VerifyArmoredDetachedSignature(
+			[]byte("Hello, this file was tampered with.\n"), exampledata.ExampleDetachedSignature4)
+
+		assert.GotError(t, err)
+	})
+
+	t.Run("with a signature made by a different key", func(t *testing.T) {
+		_, err := key2.VerifyArmoredDetachedSignature(
+			[]byte(exampledata.ExampleSignedData4), exampledata.ExampleDetachedSignature4)
+
+		assert.Equal(t, ErrWrongSigner, err)
+	})
+
+	t.Run("with something that isn't a signature", func(t *testing.T) {
+		_, err := key4.VerifyArmoredDetachedSignature(
+			[]byte(exampledata.ExampleSignedData4), exampledata.ExamplePublicKey4)
+
+		assert.GotError(t, err)
+	})
+}
+
+func TestMakeArmoredClearsignedText(t *testing.T) {
+	key, err := LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	signed, err := key.MakeArmoredClearsignedText([]byte(exampledata.ExampleSignedData4))
+	assert.NoError(t, err)
+
+	block, _ := clearsign.Decode([]byte(signed))
+	if block == nil {
+		t.Fatalf("failed to decode clearsigned text: %s", signed)
+	}
+	assert.Equal(t, exampledata.ExampleSignedData4, string(block.Plaintext))
+
+	_, err = openpgp.CheckDetachedSignature(
+		openpgp.EntityList{&key.Entity},
+		bytes.NewReader(block.Bytes),
+		block.ArmoredSignature.Body,
+	)
+	assert.NoError(t, err)
+}
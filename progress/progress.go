@@ -0,0 +1,105 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package progress reports the progress of slow operations, such as network calls, as they
+// happen.
+package progress
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/out"
+	"github.com/fluidkeys/fluidkeys/ui"
+	spin "github.com/tj/go-spin"
+)
+
+// Reporter is told when each step of an operation starts and how it finished. Each call to
+// Start must be followed by exactly one call to Success or Failure.
+type Reporter interface {
+	Start(label string)
+	Success()
+	Failure(err error)
+}
+
+// TerminalReporter prints each step as a checkbox line, like ui.RunWithCheckboxes. If Spinner
+// is true, an animated spinner is shown in the checkbox until the step finishes.
+type TerminalReporter struct {
+	Spinner bool
+
+	label string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// Start prints the pending checkbox for label and, if enabled, starts the spinner.
+func (r *TerminalReporter) Start(label string) {
+	r.label = label
+
+	if !r.Spinner {
+		ui.PrintCheckboxPending(label)
+		return
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.spin()
+}
+
+// Success stops the spinner and prints a ticked checkbox.
+func (r *TerminalReporter) Success() {
+	r.stopSpinner()
+	ui.PrintCheckboxSuccess(r.label)
+}
+
+// Failure stops the spinner and prints a failed checkbox followed by the error.
+func (r *TerminalReporter) Failure(err error) {
+	r.stopSpinner()
+	ui.PrintCheckboxFailure(r.label, err)
+}
+
+func (r *TerminalReporter) spin() {
+	defer close(r.done)
+
+	s := spin.New()
+	ticker := time.NewTicker(spinnerTimeDelay)
+	defer ticker.Stop()
+
+	for {
+		out.PrintDontLog(fmt.Sprintf("\r     [%s] %s", s.Next(), r.label))
+
+		select {
+		case <-r.stop:
+			out.PrintDontLog("\r")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopSpinner waits for the spinner to stop, leaving the cursor at the start of its line so it
+// can be overwritten. It does nothing if the spinner isn't running.
+func (r *TerminalReporter) stopSpinner() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+	r.stop, r.done = nil, nil
+}
+
+const spinnerTimeDelay = 100 * time.Millisecond
@@ -0,0 +1,60 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package table
+
+import (
+	"github.com/fluidkeys/fluidkeys/colour"
+)
+
+// A KeyHistoryRow is used to format a row in the table of changes to a public key
+type KeyHistoryRow struct {
+	UpdatedAt  string
+	ChangeType string
+	SHA256     string
+}
+
+// FormatKeyHistoryTable takes a slice of key history rows and returns a string containing a
+// formatted table.
+func FormatKeyHistoryTable(historyRows []KeyHistoryRow) (output string) {
+	rowStrings := formatTableStringsFromRows(makeKeyHistoryTableRows(historyRows))
+	for _, rowString := range rowStrings {
+		output += rowString + "\n"
+	}
+	return output + "\n"
+}
+
+func makeKeyHistoryTableRows(historyRows []KeyHistoryRow) (rows []row) {
+	placeholderDividerRow := row{divider, divider, divider}
+
+	rows = append(rows, keyHistoryHeader)
+	rows = append(rows, placeholderDividerRow)
+	for _, historyRow := range historyRows {
+		rows = append(rows, []string{
+			historyRow.UpdatedAt,
+			historyRow.ChangeType,
+			historyRow.SHA256,
+		})
+	}
+	return rows
+}
+
+var keyHistoryHeader = row{
+	colour.TableHeader("Updated"),
+	colour.TableHeader("Change"),
+	colour.TableHeader("SHA256"),
+}
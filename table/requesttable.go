@@ -0,0 +1,63 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package table
+
+import (
+	"github.com/fluidkeys/fluidkeys/colour"
+)
+
+// A RequestRow is used to format a row in the table of requests to join a team
+type RequestRow struct {
+	Email       string
+	Fingerprint string
+	RequestedAt string
+	Expires     string
+}
+
+// FormatRequestTable takes a slice of request rows and returns a string containing a formatted
+// table.
+func FormatRequestTable(requestRows []RequestRow) (output string) {
+	rowStrings := formatTableStringsFromRows(makeRequestTableRows(requestRows))
+	for _, rowString := range rowStrings {
+		output += rowString + "\n"
+	}
+	return output + "\n"
+}
+
+func makeRequestTableRows(requestRows []RequestRow) (rows []row) {
+	placeholderDividerRow := row{divider, divider, divider, divider}
+
+	rows = append(rows, requestHeader)
+	rows = append(rows, placeholderDividerRow)
+	for _, requestRow := range requestRows {
+		rows = append(rows, []string{
+			requestRow.Email,
+			requestRow.Fingerprint,
+			requestRow.RequestedAt,
+			requestRow.Expires,
+		})
+	}
+	return rows
+}
+
+var requestHeader = row{
+	colour.TableHeader("Email"),
+	colour.TableHeader("Fingerprint"),
+	colour.TableHeader("Requested"),
+	colour.TableHeader("Expires"),
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package table
+
+import (
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/colour"
+)
+
+func TestFormatRequestTable(t *testing.T) {
+	t.Run("with requests", func(t *testing.T) {
+		got := FormatRequestTable([]RequestRow{
+			{
+				Email:       "jane@example.com",
+				Fingerprint: "AAAA BBBB",
+				RequestedAt: "14 Jun 2019 12:00 UTC",
+				Expires:     "21 Jun 2019 12:00 UTC",
+			},
+			{
+				Email:       "jo@example.com",
+				Fingerprint: "CCCC DDDD",
+				RequestedAt: "unknown",
+				Expires:     "unknown",
+			},
+		})
+
+		assert.Equal(t, ""+
+			"Email             Fingerprint  Requested              Expires              \n"+
+			"────────────────  ───────────  ─────────────────────  ─────────────────────\n"+
+			"jane@example.com  AAAA BBBB    14 Jun 2019 12:00 UTC  21 Jun 2019 12:00 UTC\n"+
+			"jo@example.com    CCCC DDDD    unknown                unknown              \n"+
+			"\n",
+			colour.StripAllColourCodes(got))
+	})
+
+	t.Run("with no requests", func(t *testing.T) {
+		got := FormatRequestTable([]RequestRow{})
+
+		assert.Equal(t, ""+
+			"Email  Fingerprint  Requested  Expires\n"+
+			"─────  ───────────  ─────────  ───────\n"+
+			"\n",
+			colour.StripAllColourCodes(got))
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package table
+
+import (
+	"strconv"
+
+	"github.com/fluidkeys/fluidkeys/colour"
+)
+
+// A SecretRow is used to format a row in the table of secrets waiting to be received
+type SecretRow struct {
+	Recipient       string
+	ApproximateSize string
+}
+
+// FormatSecretTable takes a slice of secret rows and returns a string containing a formatted
+// table.
+func FormatSecretTable(secretRows []SecretRow) (output string) {
+	rowStrings := formatTableStringsFromRows(makeSecretTableRows(secretRows))
+	for _, rowString := range rowStrings {
+		output += rowString + "\n"
+	}
+	return output + "\n"
+}
+
+func makeSecretTableRows(secretRows []SecretRow) (rows []row) {
+	placeholderDividerRow := row{divider, divider, divider}
+
+	rows = append(rows, secretHeader)
+	rows = append(rows, placeholderDividerRow)
+	for i, secretRow := range secretRows {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			secretRow.Recipient,
+			secretRow.ApproximateSize,
+		})
+	}
+	return rows
+}
+
+var secretHeader = row{
+	colour.TableHeader("#"),
+	colour.TableHeader("Sent To"),
+	colour.TableHeader("Size"),
+}
@@ -0,0 +1,174 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/crypto/openpgp/packet"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+// AuditLog is a record of every change to a team's roster, kept in the team directory.
+// Each entry is stored as a line of JSON so that new entries can simply be appended.
+type AuditLog struct {
+	Directory string
+}
+
+// AuditEntry records a single change to the roster
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+
+	// AdminFingerprint is the admin key which signed the new roster, or nil if it
+	// couldn't be determined from the signature.
+	AdminFingerprint *fpr.Fingerprint `json:"adminFingerprint,omitempty"`
+
+	Diff Diff `json:"diff"`
+
+	// RosterVersion counts the number of times the roster has been saved, starting at 1
+	RosterVersion int `json:"rosterVersion"`
+}
+
+// Append adds the entry to the end of the audit log, setting its RosterVersion to follow on
+// from the previous entry.
+func (a AuditLog) Append(entry AuditEntry) error {
+	entries, err := a.Entries()
+	if err != nil {
+		return err
+	}
+	entry.RosterVersion = len(entries) + 1
+
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %v", err)
+	}
+
+	f, err := os.OpenFile(a.filename(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(encoded, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Entries returns every entry in the audit log, oldest first. If there is no audit log yet it
+// returns an empty slice.
+func (a AuditLog) Entries() (entries []AuditEntry, err error) {
+	f, err := os.Open(a.filename())
+	if os.IsNotExist(err) {
+		return []AuditEntry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries = []AuditEntry{}
+	// entries for large teams can be longer than bufio.Scanner's maximum line length, so read
+	// whole lines however long they are
+	reader := bufio.NewReader(f)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var entry AuditEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return nil, fmt.Errorf(
+					"error reading %s line %d: %v", a.filename(), lineNumber, err)
+			}
+			entries = append(entries, entry)
+		}
+
+		if err == io.EOF {
+			return entries, nil
+		}
+	}
+}
+
+func (a AuditLog) filename() string {
+	return filepath.Join(a.Directory, auditLogFilename)
+}
+
+// makeAuditEntry compares the previous and new rosters and works out which admin signed the
+// new roster.
+func makeAuditEntry(previousRoster string, roster string, signature string, now time.Time) (
+	*AuditEntry, error) {
+
+	newTeam, err := parse(strings.NewReader(roster))
+	if err != nil {
+		return nil, err
+	}
+
+	previousTeam := &Team{}
+	if previousRoster != "" {
+		if previousTeam, err = parse(strings.NewReader(previousRoster)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AuditEntry{
+		Timestamp:        now,
+		AdminFingerprint: FindSigningAdmin(signature, newTeam),
+		Diff:             DiffTeams(previousTeam, newTeam),
+	}, nil
+}
+
+// FindSigningAdmin returns the fingerprint of the admin whose key ID matches the issuer of the
+// signature, or nil if there isn't one.
+func FindSigningAdmin(armoredSignature string, t *Team) *fpr.Fingerprint {
+	block, err := armor.Decode(strings.NewReader(armoredSignature))
+	if err != nil {
+		return nil
+	}
+
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return nil
+	}
+
+	sig, ok := p.(*packet.Signature)
+	if !ok || sig.IssuerKeyId == nil {
+		return nil
+	}
+
+	for _, admin := range t.Admins() {
+		// for v4 keys, the key ID is the last 8 bytes of the fingerprint
+		fingerprintBytes := admin.Fingerprint.Bytes()
+		if binary.BigEndian.Uint64(fingerprintBytes[12:]) == *sig.IssuerKeyId {
+			fingerprint := admin.Fingerprint
+			return &fingerprint
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/gofrs/uuid"
+)
+
+func TestAuditLog(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	admin := Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	member := Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+	}
+
+	myTeam := Team{
+		UUID:   uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be")),
+		Name:   "Kiffix",
+		People: []Person{admin},
+	}
+
+	rosterSaver := makeRosterSaveInTmpDirectory(t)
+	defer os.RemoveAll(rosterSaver.Directory)
+
+	saveSignedRoster := func(t *testing.T) {
+		t.Helper()
+		assert.NoError(t, myTeam.UpdateRoster(key))
+		roster, signature, err := myTeam.Roster()
+		assert.NoError(t, err)
+		assert.NoError(t, rosterSaver.Save(roster, signature))
+	}
+
+	saveSignedRoster(t) // brand new team
+
+	myTeam.People = append(myTeam.People, member)
+	saveSignedRoster(t) // add member
+
+	myTeam.People = []Person{admin}
+	saveSignedRoster(t) // remove member
+
+	auditLog := AuditLog{Directory: rosterSaver.Directory}
+	entries, err := auditLog.Entries()
+	assert.NoError(t, err)
+
+	t.Run("appends an entry for each save", func(t *testing.T) {
+		assert.Equal(t, 3, len(entries))
+
+		for i, entry := range entries {
+			assert.Equal(t, i+1, entry.RosterVersion)
+		}
+	})
+
+	t.Run("records the admin who signed the roster", func(t *testing.T) {
+		for _, entry := range entries {
+			if entry.AdminFingerprint == nil {
+				t.Fatalf("expected admin fingerprint, got nil")
+			}
+			assert.Equal(t, exampledata.ExampleFingerprint4, *entry.AdminFingerprint)
+		}
+	})
+
+	t.Run("records the diff", func(t *testing.T) {
+		assert.Equal(t, []Person{admin}, entries[0].Diff.Added)
+		assert.Equal(t, "Kiffix", entries[0].Diff.NameAfter)
+
+		assert.Equal(t, []Person{member}, entries[1].Diff.Added)
+		assert.Equal(t, 0, len(entries[1].Diff.Removed))
+
+		assert.Equal(t, 0, len(entries[2].Diff.Added))
+		assert.Equal(t, []Person{member}, entries[2].Diff.Removed)
+	})
+
+	t.Run("doesn't record an entry for a discarded draft", func(t *testing.T) {
+		assert.NoError(t, myTeam.UpdateRoster(key))
+		roster, signature, err := myTeam.Roster()
+		assert.NoError(t, err)
+		assert.NoError(t, rosterSaver.SaveDraft(roster, signature))
+		assert.NoError(t, rosterSaver.DiscardDraft())
+
+		entries, err := auditLog.Entries()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(entries))
+	})
+}
+
+func TestAuditLogEntries(t *testing.T) {
+	t.Run("returns empty if there's no audit log", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fluidkeys")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		entries, err := AuditLog{Directory: dir}.Entries()
+		assert.NoError(t, err)
+		assert.Equal(t, []AuditEntry{}, entries)
+	})
+
+	t.Run("reads entries longer than 64KB", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fluidkeys")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		entry := AuditEntry{}
+		for i := 0; i < 2000; i++ {
+			entry.Diff.Added = append(entry.Diff.Added, Person{
+				Email:       fmt.Sprintf("person%d@example.com", i),
+				Fingerprint: exampledata.ExampleFingerprint2,
+			})
+		}
+		auditLog := AuditLog{Directory: dir}
+		assert.NoError(t, auditLog.Append(entry))
+		assert.NoError(t, auditLog.Append(AuditEntry{}))
+
+		entries, err := auditLog.Entries()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(entries))
+		assert.Equal(t, 2000, len(entries[0].Diff.Added))
+	})
+
+	t.Run("returns an error for a corrupted audit log", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fluidkeys")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, auditLogFilename), []byte("{bad"), 0600))
+
+		_, err = AuditLog{Directory: dir}.Entries()
+		assert.GotError(t, err)
+	})
+}
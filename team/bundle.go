@@ -0,0 +1,68 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+	"time"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+// RosterBundle is a team's roster and signature along with the public keys of its members, as
+// downloaded in one request from the Fluidkeys API.
+// Nothing in it has been verified: use LoadFromSignedBundle before trusting the roster or keys.
+type RosterBundle struct {
+	Roster    string
+	Signature string
+	Keys      map[fpr.Fingerprint]*pgpkey.PgpKey
+}
+
+// LoadFromSignedBundle verifies the bundle as a whole, returning the team and the public keys of
+// its members.
+// It checks the roster is signed by one of adminKeys, then that every key in the bundle has the
+// fingerprint it's listed under and belongs to someone in the roster. If anything is wrong the
+// whole bundle is rejected, so no keys from a tampered bundle are ever used.
+// Members whose keys aren't in the bundle are missing from the returned map.
+func LoadFromSignedBundle(bundle *RosterBundle, adminKeys []*pgpkey.PgpKey) (
+	*Team, map[fpr.Fingerprint]*pgpkey.PgpKey, error) {
+
+	err := VerifyRoster(bundle.Roster, []string{bundle.Signature}, adminKeys, 0, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := Load(bundle.Roster, bundle.Signature)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := map[fpr.Fingerprint]*pgpkey.PgpKey{}
+	for fingerprint, key := range bundle.Keys {
+		if key == nil || key.Fingerprint() != fingerprint {
+			return nil, nil, fmt.Errorf("bundle has the wrong key for %s", fingerprint)
+		}
+		if !fpr.Contains(t.Fingerprints(), fingerprint) {
+			return nil, nil, fmt.Errorf("bundle has key %s which isn't in the roster",
+				fingerprint)
+		}
+		keys[fingerprint] = key
+	}
+	return t, keys, nil
+}
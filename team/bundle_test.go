@@ -0,0 +1,120 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/gofrs/uuid"
+)
+
+func TestLoadFromSignedBundle(t *testing.T) {
+	adminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+	adminPublicKey, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey4)
+	assert.NoError(t, err)
+	key2, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey2)
+	assert.NoError(t, err)
+	key3, err := pgpkey.LoadFromArmoredPublicKey(exampledata.ExamplePublicKey3)
+	assert.NoError(t, err)
+
+	kiffix := Team{
+		UUID: uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be")),
+		Name: "Kiffix",
+		People: []Person{
+			{Email: "test4@example.com", Fingerprint: adminKey.Fingerprint(), IsAdmin: true},
+			{Email: "test2@example.com", Fingerprint: key2.Fingerprint()},
+		},
+	}
+	assert.NoError(t, kiffix.UpdateRoster(adminKey))
+	roster, signature, err := kiffix.Roster()
+	assert.NoError(t, err)
+
+	adminKeys := []*pgpkey.PgpKey{adminKey}
+
+	makeBundle := func(keys ...*pgpkey.PgpKey) *RosterBundle {
+		bundle := RosterBundle{
+			Roster:    roster,
+			Signature: signature,
+			Keys:      map[fpr.Fingerprint]*pgpkey.PgpKey{},
+		}
+		for _, key := range keys {
+			bundle.Keys[key.Fingerprint()] = key
+		}
+		return &bundle
+	}
+
+	t.Run("returns the team and keys for a good bundle", func(t *testing.T) {
+		got, keys, err := LoadFromSignedBundle(makeBundle(adminPublicKey, key2), adminKeys)
+		assert.NoError(t, err)
+		assert.Equal(t, kiffix.People, got.People)
+		assert.Equal(t, 2, len(keys))
+		assert.Equal(t, key2, keys[key2.Fingerprint()])
+	})
+
+	t.Run("allows keys to be missing from the bundle", func(t *testing.T) {
+		_, keys, err := LoadFromSignedBundle(makeBundle(key2), adminKeys)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(keys))
+	})
+
+	t.Run("rejects a bundle with a tampered roster", func(t *testing.T) {
+		bundle := makeBundle(adminPublicKey, key2)
+		bundle.Roster += "\n# tampered"
+
+		got, keys, err := LoadFromSignedBundle(bundle, adminKeys)
+		assert.Equal(t, ErrSignatureInvalid, err)
+		assert.Equal(t, true, got == nil)
+		assert.Equal(t, true, keys == nil)
+	})
+
+	t.Run("rejects a bundle that isn't signed by an admin", func(t *testing.T) {
+		notAdminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+			exampledata.ExamplePrivateKey3, "test3")
+		assert.NoError(t, err)
+
+		_, _, err = LoadFromSignedBundle(
+			makeBundle(adminPublicKey, key2), []*pgpkey.PgpKey{notAdminKey})
+		assert.Equal(t, ErrSignatureInvalid, err)
+	})
+
+	t.Run("rejects the whole bundle if one key is wrong", func(t *testing.T) {
+		bundle := makeBundle(adminPublicKey)
+		bundle.Keys[key2.Fingerprint()] = key3 // listed as key2, but it's key3
+
+		got, keys, err := LoadFromSignedBundle(bundle, adminKeys)
+		assert.Equal(t, fmt.Errorf("bundle has the wrong key for %s", key2.Fingerprint()), err)
+		assert.Equal(t, true, got == nil)
+		assert.Equal(t, true, keys == nil)
+	})
+
+	t.Run("rejects the whole bundle if it has a key for someone not in the roster",
+		func(t *testing.T) {
+			got, keys, err := LoadFromSignedBundle(makeBundle(adminPublicKey, key2, key3), adminKeys)
+			assert.Equal(t, fmt.Errorf(
+				"bundle has key %s which isn't in the roster", key3.Fingerprint()), err)
+			assert.Equal(t, true, got == nil)
+			assert.Equal(t, true, keys == nil)
+		})
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import "sort"
+
+// Diff describes the changes between two versions of a team.
+// A person whose email or fingerprint has changed appears in both Removed and Added.
+type Diff struct {
+	NameBefore string `json:"nameBefore"`
+	NameAfter  string `json:"nameAfter"`
+
+	Added    []Person `json:"added,omitempty"`
+	Removed  []Person `json:"removed,omitempty"`
+	Promoted []Person `json:"promoted,omitempty"` // promoted to admin
+	Demoted  []Person `json:"demoted,omitempty"`  // demoted from admin
+}
+
+// IsEmpty returns true if there are no differences
+func (d Diff) IsEmpty() bool {
+	return d.NameBefore == d.NameAfter &&
+		len(d.Added) == 0 && len(d.Removed) == 0 &&
+		len(d.Promoted) == 0 && len(d.Demoted) == 0
+}
+
+// DiffTeams returns the changes needed to turn `before` into `after`
+func DiffTeams(before *Team, after *Team) Diff {
+	diff := Diff{
+		NameBefore: before.Name,
+		NameAfter:  after.Name,
+	}
+
+	for _, afterPerson := range after.People {
+		beforePerson, found := findSamePerson(before.People, afterPerson)
+
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, afterPerson)
+
+		case !beforePerson.IsAdmin && afterPerson.IsAdmin:
+			diff.Promoted = append(diff.Promoted, afterPerson)
+
+		case beforePerson.IsAdmin && !afterPerson.IsAdmin:
+			diff.Demoted = append(diff.Demoted, afterPerson)
+		}
+	}
+
+	for _, beforePerson := range before.People {
+		if _, found := findSamePerson(after.People, beforePerson); !found {
+			diff.Removed = append(diff.Removed, beforePerson)
+		}
+	}
+
+	return diff
+}
+
+// TeamsEqual returns true if a and b are the same team with the same people, ignoring the order
+// of the people and how the rosters they were loaded from were formatted.
+func TeamsEqual(a *Team, b *Team) bool {
+	if a.UUID != b.UUID || a.Name != b.Name || a.MemberCount() != b.MemberCount() {
+		return false
+	}
+	if (a.ParentTeamUUID == nil) != (b.ParentTeamUUID == nil) ||
+		(a.ParentTeamUUID != nil && *a.ParentTeamUUID != *b.ParentTeamUUID) {
+		return false
+	}
+
+	aPeople, bPeople := sortedByFingerprint(a.People), sortedByFingerprint(b.People)
+	for i := range aPeople {
+		if aPeople[i] != bPeople[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedByFingerprint returns a copy of people sorted by fingerprint
+func sortedByFingerprint(people []Person) []Person {
+	sorted := append([]Person{}, people...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Fingerprint.Hex() < sorted[j].Fingerprint.Hex()
+	})
+	return sorted
+}
+
+// findSamePerson returns the person in people with the same email and fingerprint as p,
+// ignoring whether they're an admin.
+func findSamePerson(people []Person, p Person) (person Person, found bool) {
+	for _, person := range people {
+		if person.Fingerprint == p.Fingerprint && person.emailMatches(p) {
+			return person, true
+		}
+	}
+	return Person{}, false
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/gofrs/uuid"
+)
+
+func TestDiffTeams(t *testing.T) {
+	alice := Person{
+		Email:       "alice@example.com",
+		Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+		IsAdmin:     true,
+	}
+	bob := Person{
+		Email:       "bob@example.com",
+		Fingerprint: fpr.MustParse("BBBBAAAABBBBAAAABBBBBBBBAAAABBBBAAAABBBB"),
+		IsAdmin:     false,
+	}
+
+	t.Run("identical teams give an empty diff", func(t *testing.T) {
+		before := Team{Name: "Kiffix", People: []Person{alice, bob}}
+		after := Team{Name: "Kiffix", People: []Person{alice, bob}}
+
+		diff := DiffTeams(&before, &after)
+		assert.Equal(t, true, diff.IsEmpty())
+	})
+
+	t.Run("spots added and removed people", func(t *testing.T) {
+		before := Team{Name: "Kiffix", People: []Person{alice}}
+		after := Team{Name: "Kiffix", People: []Person{bob}}
+
+		diff := DiffTeams(&before, &after)
+		assert.Equal(t, false, diff.IsEmpty())
+		assert.Equal(t, []Person{bob}, diff.Added)
+		assert.Equal(t, []Person{alice}, diff.Removed)
+	})
+
+	t.Run("spots promoted and demoted admins", func(t *testing.T) {
+		promotedBob := bob
+		promotedBob.IsAdmin = true
+
+		demotedAlice := alice
+		demotedAlice.IsAdmin = false
+
+		before := Team{Name: "Kiffix", People: []Person{alice, bob}}
+		after := Team{Name: "Kiffix", People: []Person{demotedAlice, promotedBob}}
+
+		diff := DiffTeams(&before, &after)
+		assert.Equal(t, []Person{promotedBob}, diff.Promoted)
+		assert.Equal(t, []Person{demotedAlice}, diff.Demoted)
+		assert.Equal(t, 0, len(diff.Added))
+		assert.Equal(t, 0, len(diff.Removed))
+	})
+
+	t.Run("shows a changed email as removed and added", func(t *testing.T) {
+		changedBob := bob
+		changedBob.Email = "robert@example.com"
+
+		before := Team{Name: "Kiffix", People: []Person{alice, bob}}
+		after := Team{Name: "Kiffix", People: []Person{alice, changedBob}}
+
+		diff := DiffTeams(&before, &after)
+		assert.Equal(t, []Person{changedBob}, diff.Added)
+		assert.Equal(t, []Person{bob}, diff.Removed)
+	})
+
+	t.Run("spots a changed name", func(t *testing.T) {
+		before := Team{Name: "Kiffix", People: []Person{alice}}
+		after := Team{Name: "Kiffix Ltd", People: []Person{alice}}
+
+		diff := DiffTeams(&before, &after)
+		assert.Equal(t, false, diff.IsEmpty())
+		assert.Equal(t, "Kiffix", diff.NameBefore)
+		assert.Equal(t, "Kiffix Ltd", diff.NameAfter)
+	})
+}
+
+func TestTeamsEqual(t *testing.T) {
+	roster := `uuid = "38be2a70-23d8-11e9-bafd-7f97f2e239a3"
+name = "Kiffix"
+
+[[person]]
+email = "alice@example.com"
+fingerprint = "AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"
+is_admin = true
+
+[[person]]
+email = "bob@example.com"
+fingerprint = "BBBBAAAABBBBAAAABBBBBBBBAAAABBBBAAAABBBB"
+is_admin = false
+`
+	original, err := parse(strings.NewReader(roster))
+	assert.NoError(t, err)
+
+	t.Run("roster with different whitespace is equal", func(t *testing.T) {
+		reformatted, err := parse(strings.NewReader(
+			"\n" + strings.Replace(roster, " = ", "=", -1) + "\n\n",
+		))
+		assert.NoError(t, err)
+		assert.Equal(t, true, TeamsEqual(original, reformatted))
+	})
+
+	t.Run("reordered people are equal", func(t *testing.T) {
+		reordered := *original
+		reordered.People = []Person{original.People[1], original.People[0]}
+		assert.Equal(t, true, TeamsEqual(original, &reordered))
+	})
+
+	t.Run("differing teams aren't equal", func(t *testing.T) {
+		parentUUID := uuid.Must(uuid.NewV4())
+
+		for name, change := range map[string]func(*Team){
+			"renamed":        func(t *Team) { t.Name = "Kiffix Ltd" },
+			"different uuid": func(t *Team) { t.UUID = uuid.Must(uuid.NewV4()) },
+			"made a subteam": func(t *Team) { t.ParentTeamUUID = &parentUUID },
+			"person removed": func(t *Team) { t.People = t.People[:1] },
+			"person demoted": func(t *Team) { t.People[0].IsAdmin = false },
+			"email changed":  func(t *Team) { t.People[1].Email = "robert@example.com" },
+			"key changed": func(t *Team) {
+				t.People[1].Fingerprint = fpr.MustParse("CCCCAAAABBBBAAAABBBBBBBBAAAABBBBAAAABBBB")
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				changed := *original
+				changed.People = append([]Person{}, original.People...)
+				change(&changed)
+				assert.Equal(t, false, TeamsEqual(original, &changed))
+			})
+		}
+	})
+}
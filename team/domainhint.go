@@ -0,0 +1,85 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// DomainhintCacheDuration is how long the result of looking up a domain's team in DNS should be
+// cached for.
+const DomainhintCacheDuration = 24 * time.Hour
+
+// TXTResolver looks up DNS TXT records. It's satisfied by *net.Resolver.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DiscoverViaDomainhint looks up the `_fluidkeys.<domain>` DNS TXT record and returns the team
+// UUID it contains, so people can find their team from their email domain.
+func DiscoverViaDomainhint(domain string) (uuid.UUID, error) {
+	return discoverViaDomainhint(domain, net.DefaultResolver)
+}
+
+func discoverViaDomainhint(domain string, resolver TXTResolver) (uuid.UUID, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return uuid.Nil, fmt.Errorf("domain can't be empty")
+	}
+
+	recordName := "_fluidkeys." + domain
+	records, err := resolver.LookupTXT(context.Background(), recordName)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to look up %s: %v", recordName, err)
+	}
+
+	var teamUUIDs []uuid.UUID
+	for _, record := range records {
+		teamUUID, err := uuid.FromString(strings.TrimSpace(record))
+		if err != nil {
+			continue // ignore TXT records that aren't team UUIDs
+		}
+		if !containsUUID(teamUUIDs, teamUUID) {
+			teamUUIDs = append(teamUUIDs, teamUUID)
+		}
+	}
+
+	switch len(teamUUIDs) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("no team UUID found in %s", recordName)
+	case 1:
+		return teamUUIDs[0], nil
+	default:
+		return uuid.Nil, fmt.Errorf("%s contains more than one team UUID", recordName)
+	}
+}
+
+func containsUUID(uuids []uuid.UUID, u uuid.UUID) bool {
+	for _, existing := range uuids {
+		if existing == u {
+			return true
+		}
+	}
+	return false
+}
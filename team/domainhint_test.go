@@ -0,0 +1,89 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/gofrs/uuid"
+)
+
+func TestDiscoverViaDomainhint(t *testing.T) {
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+	otherUUID := uuid.Must(uuid.FromString("d9b5b4a0-3510-11e9-968e-53c38df634be"))
+
+	t.Run("looks up the _fluidkeys subdomain", func(t *testing.T) {
+		resolver := &mockResolver{records: []string{teamUUID.String()}}
+
+		got, err := discoverViaDomainhint("Example.com.", resolver)
+		assert.NoError(t, err)
+		assert.Equal(t, teamUUID, got)
+		assert.Equal(t, []string{"_fluidkeys.example.com"}, resolver.lookedUp)
+	})
+
+	t.Run("ignores records that aren't UUIDs", func(t *testing.T) {
+		resolver := &mockResolver{records: []string{
+			"v=spf1 -all", " " + teamUUID.String() + " ", teamUUID.String(),
+		}}
+
+		got, err := discoverViaDomainhint("example.com", resolver)
+		assert.NoError(t, err)
+		assert.Equal(t, teamUUID, got)
+	})
+
+	t.Run("returns error if lookup fails", func(t *testing.T) {
+		resolver := &mockResolver{err: fmt.Errorf("no such host")}
+
+		_, err := discoverViaDomainhint("example.com", resolver)
+		assert.Equal(t, fmt.Errorf("failed to look up _fluidkeys.example.com: no such host"), err)
+	})
+
+	t.Run("returns error if no record contains a UUID", func(t *testing.T) {
+		resolver := &mockResolver{records: []string{"v=spf1 -all"}}
+
+		_, err := discoverViaDomainhint("example.com", resolver)
+		assert.Equal(t, fmt.Errorf("no team UUID found in _fluidkeys.example.com"), err)
+	})
+
+	t.Run("returns error if records contain different UUIDs", func(t *testing.T) {
+		resolver := &mockResolver{records: []string{teamUUID.String(), otherUUID.String()}}
+
+		_, err := discoverViaDomainhint("example.com", resolver)
+		assert.Equal(t,
+			fmt.Errorf("_fluidkeys.example.com contains more than one team UUID"), err)
+	})
+
+	t.Run("returns error for empty domain", func(t *testing.T) {
+		_, err := discoverViaDomainhint(" ", &mockResolver{})
+		assert.Equal(t, fmt.Errorf("domain can't be empty"), err)
+	})
+}
+
+type mockResolver struct {
+	records  []string
+	err      error
+	lookedUp []string
+}
+
+func (r *mockResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	r.lookedUp = append(r.lookedUp, name)
+	return r.records, r.err
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/gofrs/uuid"
+)
+
+// InvitationValidFor is how long an invitation made by `fk team invite` can be used for
+const InvitationValidFor = 7 * 24 * time.Hour
+
+// Invitation pre-approves an email address to join a team. It's made by a team admin and
+// shared as a token, which is signed by the admin's key.
+type Invitation struct {
+	TeamUUID  uuid.UUID `json:"teamUuid"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewInvitation returns an invitation for email to join the team, expiring InvitationValidFor
+// after now.
+func NewInvitation(t Team, email string, now time.Time) Invitation {
+	return Invitation{
+		TeamUUID:  t.UUID,
+		Email:     email,
+		ExpiresAt: now.Add(InvitationValidFor).UTC().Truncate(time.Second),
+	}
+}
+
+// Token returns the invitation signed by signingKey, encoded as a string which can be shared,
+// e.g. on the command line. signingKey must be an admin of the team for the token to verify.
+func (i Invitation) Token(signingKey *pgpkey.PgpKey) (string, error) {
+	payload, err := json.Marshal(i)
+	if err != nil {
+		return "", err
+	}
+
+	armoredSignature, err := signingKey.MakeArmoredDetachedSignature(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign invitation: %v", err)
+	}
+
+	// the token is already long: store the binary signature rather than the armored one
+	block, err := armor.Decode(strings.NewReader(armoredSignature))
+	if err != nil {
+		return "", err
+	}
+	signature, err := ioutil.ReadAll(block.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyInvitationToken checks the token is signed by one of adminKeys and returns the
+// invitation.
+// It returns ErrInvitationInvalid if the token is malformed or the signature doesn't verify, or
+// ErrInvitationExpired if the token verifies but has expired.
+func VerifyInvitationToken(token string, adminKeys []*pgpkey.PgpKey, now time.Time) (
+	*Invitation, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvitationInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvitationInvalid
+	}
+
+	var keyring openpgp.EntityList
+	for i := range adminKeys {
+		keyring = append(keyring, &adminKeys[i].Entity)
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(
+		keyring, bytes.NewReader(payload), bytes.NewReader(signature)); err != nil {
+		log.Printf("invitation signature didn't verify: %v", err)
+		return nil, ErrInvitationInvalid
+	}
+
+	invitation := Invitation{}
+	if err := json.Unmarshal(payload, &invitation); err != nil {
+		return nil, ErrInvitationInvalid
+	}
+
+	if !now.Before(invitation.ExpiresAt) {
+		return nil, ErrInvitationExpired
+	}
+	return &invitation, nil
+}
+
+// Matches returns true if the invitation is for the team and email of the given request to join
+func (i Invitation) Matches(request RequestToJoinTeam) bool {
+	return i.TeamUUID == request.TeamUUID && strings.EqualFold(i.Email, request.Email)
+}
+
+var (
+	// ErrInvitationInvalid means the invitation token is corrupted or wasn't signed by a team
+	// admin.
+	ErrInvitationInvalid = fmt.Errorf("invalid invitation")
+
+	// ErrInvitationExpired means the invitation token is valid, but can no longer be used.
+	ErrInvitationExpired = fmt.Errorf("invitation has expired")
+)
@@ -0,0 +1,104 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/gofrs/uuid"
+)
+
+func TestInvitation(t *testing.T) {
+	adminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	otherKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+		exampledata.ExamplePrivateKey3, "test3")
+	assert.NoError(t, err)
+
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	myTeam := Team{UUID: uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))}
+
+	invitation := NewInvitation(myTeam, "jane@example.com", now)
+
+	t.Run("NewInvitation expires after InvitationValidFor", func(t *testing.T) {
+		assert.Equal(t, now.Add(InvitationValidFor), invitation.ExpiresAt)
+	})
+
+	token, err := invitation.Token(adminKey)
+	assert.NoError(t, err)
+
+	t.Run("token verifies with the admin's key", func(t *testing.T) {
+		got, err := VerifyInvitationToken(token, []*pgpkey.PgpKey{adminKey}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, invitation, *got)
+	})
+
+	t.Run("token is valid until just before it expires", func(t *testing.T) {
+		_, err := VerifyInvitationToken(
+			token, []*pgpkey.PgpKey{adminKey}, invitation.ExpiresAt.Add(-time.Second))
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns ErrInvitationExpired once it expires", func(t *testing.T) {
+		_, err := VerifyInvitationToken(token, []*pgpkey.PgpKey{adminKey}, invitation.ExpiresAt)
+		assert.Equal(t, ErrInvitationExpired, err)
+	})
+
+	t.Run("returns ErrInvitationInvalid if not signed by an admin", func(t *testing.T) {
+		_, err := VerifyInvitationToken(token, []*pgpkey.PgpKey{otherKey}, now)
+		assert.Equal(t, ErrInvitationInvalid, err)
+	})
+
+	t.Run("returns ErrInvitationInvalid if the invitation is changed", func(t *testing.T) {
+		otherInvitation := NewInvitation(myTeam, "mallory@example.com", now)
+		otherToken, err := otherInvitation.Token(otherKey)
+		assert.NoError(t, err)
+
+		// combine the other invitation with the admin's signature
+		tamperedToken := strings.Split(otherToken, ".")[0] + "." + strings.Split(token, ".")[1]
+
+		_, err = VerifyInvitationToken(tamperedToken, []*pgpkey.PgpKey{adminKey}, now)
+		assert.Equal(t, ErrInvitationInvalid, err)
+	})
+
+	t.Run("returns ErrInvitationInvalid for a malformed token", func(t *testing.T) {
+		for _, badToken := range []string{"", "abc", "a.b.c", "!!!.!!!"} {
+			_, err := VerifyInvitationToken(badToken, []*pgpkey.PgpKey{adminKey}, now)
+			assert.Equal(t, ErrInvitationInvalid, err)
+		}
+	})
+
+	t.Run("Matches", func(t *testing.T) {
+		assert.Equal(t, true, invitation.Matches(RequestToJoinTeam{
+			TeamUUID: myTeam.UUID, Email: "Jane@example.com",
+		}))
+		assert.Equal(t, false, invitation.Matches(RequestToJoinTeam{
+			TeamUUID: myTeam.UUID, Email: "mallory@example.com",
+		}))
+		assert.Equal(t, false, invitation.Matches(RequestToJoinTeam{
+			TeamUUID: uuid.Must(uuid.NewV4()), Email: "jane@example.com",
+		}))
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fluidkeys/crypto/openpgp"
+)
+
+// ExportGPGKeyring writes the public key of every person in the team, fetched using keyFetcher,
+// to w as a binary OpenPGP keyring, the format GnuPG reads with `--keyring` or
+// `--no-default-keyring --keyring <file>`. Nothing is written unless every key was fetched.
+func ExportGPGKeyring(t *Team, w io.Writer, keyFetcher PublicKeyFetcher) error {
+	var keyring openpgp.EntityList
+
+	for _, person := range t.People {
+		key, err := keyFetcher.GetPublicKeyByFingerprint(person.Fingerprint)
+		if err != nil {
+			return fmt.Errorf("failed to get key for %s: %v", person.Email, err)
+		}
+		keyring = append(keyring, &key.Entity)
+	}
+
+	for _, entity := range keyring {
+		if err := entity.Serialize(w); err != nil {
+			return fmt.Errorf("failed to serialize key %X: %v", entity.PrimaryKey.Fingerprint, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+func TestExportGPGKeyring(t *testing.T) {
+	fetcher := mockKeyFetcher{
+		keys: map[fpr.Fingerprint]string{
+			exampledata.ExampleFingerprint2: exampledata.ExamplePublicKey2,
+			exampledata.ExampleFingerprint3: exampledata.ExamplePublicKey3,
+		},
+	}
+
+	t.Run("writes a keyring readable by an OpenPGP parser", func(t *testing.T) {
+		team := Team{
+			Name: "Example",
+			People: []Person{
+				{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2},
+				{Email: "test3@example.com", Fingerprint: exampledata.ExampleFingerprint3},
+			},
+		}
+
+		keyring := bytes.NewBuffer(nil)
+		err := ExportGPGKeyring(&team, keyring, &fetcher)
+		assert.NoError(t, err)
+
+		entities, err := openpgp.ReadKeyRing(keyring)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(entities))
+
+		assert.Equal(t, exampledata.ExampleFingerprint2,
+			fpr.FromBytes(entities[0].PrimaryKey.Fingerprint))
+		assert.Equal(t, exampledata.ExampleFingerprint3,
+			fpr.FromBytes(entities[1].PrimaryKey.Fingerprint))
+	})
+
+	t.Run("returns an error if a key can't be fetched", func(t *testing.T) {
+		team := Team{
+			Name: "Example",
+			People: []Person{
+				{Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4},
+			},
+		}
+
+		err := ExportGPGKeyring(&team, bytes.NewBuffer(nil), &fetcher)
+		assert.Equal(t, fmt.Errorf("failed to get key for test4@example.com: not found"), err)
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// MemberCount returns how many people are in the team, including admins
+func (t Team) MemberCount() int {
+	return len(t.People)
+}
+
+// AdminCount returns how many people in the team are admins
+func (t Team) AdminCount() int {
+	return len(t.Admins())
+}
+
+// NonAdminMemberCount returns how many people in the team aren't admins
+func (t Team) NonAdminMemberCount() int {
+	return t.MemberCount() - t.AdminCount()
+}
+
+// IsEmpty returns true if there's nobody in the team
+func (t Team) IsEmpty() bool {
+	return t.MemberCount() == 0
+}
+
+// ExpiringMember is a person whose key will soon stop working for encryption, returned by
+// ExpiringMembers.
+type ExpiringMember struct {
+	Person    Person
+	ExpiresAt time.Time
+}
+
+// ExpiringMembers returns the people in the team whose soonest-expiring encryption subkey
+// expires within the given duration of now, soonest first. Keys are looked up in keyCache,
+// typically the keys saved locally by `fk team fetch`: people whose key isn't there are skipped.
+func (t Team) ExpiringMembers(within time.Duration, keyCache PublicKeyFetcher,
+	now time.Time) []ExpiringMember {
+
+	expiring := []ExpiringMember{}
+	for _, person := range t.People {
+		key, err := keyCache.GetPublicKeyByFingerprint(person.Fingerprint)
+		if err != nil {
+			log.Printf("can't check expiry of key for %s: %v", person.Email, err)
+			continue
+		}
+
+		if hasExpiry, expiry := key.EncryptionSubkeyExpiry(now); hasExpiry &&
+			expiry.Sub(now) < within {
+			expiring = append(expiring, ExpiringMember{Person: person, ExpiresAt: *expiry})
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiresAt.Before(expiring[j].ExpiresAt)
+	})
+	return expiring
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/gofrs/uuid"
+)
+
+var (
+	memberAdmin = Person{
+		Email:       "admin@example.com",
+		Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+		IsAdmin:     true,
+	}
+	memberNormal = Person{
+		Email:       "normal@example.com",
+		Fingerprint: fpr.MustParse("CCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDD"),
+	}
+)
+
+func makeMembersTeam() Team {
+	return Team{
+		UUID:   uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be")),
+		Name:   "Kiffix",
+		People: []Person{memberAdmin, memberNormal},
+	}
+}
+
+func TestMemberCounts(t *testing.T) {
+	t.Run("counts admins and regular members", func(t *testing.T) {
+		myTeam := makeMembersTeam()
+
+		assert.Equal(t, 2, myTeam.MemberCount())
+		assert.Equal(t, 1, myTeam.AdminCount())
+		assert.Equal(t, 1, myTeam.NonAdminMemberCount())
+		assert.Equal(t, false, myTeam.IsEmpty())
+	})
+
+	t.Run("with no people", func(t *testing.T) {
+		myTeam := Team{Name: "Kiffix"}
+
+		assert.Equal(t, 0, myTeam.MemberCount())
+		assert.Equal(t, 0, myTeam.AdminCount())
+		assert.Equal(t, 0, myTeam.NonAdminMemberCount())
+		assert.Equal(t, true, myTeam.IsEmpty())
+	})
+}
+
+func TestExpiringMembers(t *testing.T) {
+	person2 := Person{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2}
+	person3 := Person{Email: "test3@example.com", Fingerprint: exampledata.ExampleFingerprint3}
+	person4 := Person{Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4}
+	notCached := Person{Email: "nocache@example.com", Fingerprint: memberAdmin.Fingerprint}
+
+	myTeam := Team{People: []Person{person2, person3, person4, notCached}}
+
+	// the encryption subkeys of keys 2 and 3 expire on 2038-09-07, key 4's never expires
+	keyCache := &mockKeyFetcher{
+		keys: map[fpr.Fingerprint]string{
+			exampledata.ExampleFingerprint2: exampledata.ExamplePublicKey2,
+			exampledata.ExampleFingerprint3: exampledata.ExamplePublicKey3,
+			exampledata.ExampleFingerprint4: exampledata.ExamplePublicKey4,
+		},
+	}
+	now := time.Date(2038, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("returns members expiring within the window, soonest first", func(t *testing.T) {
+		got := myTeam.ExpiringMembers(60*24*time.Hour, keyCache, now)
+		assert.Equal(t, []ExpiringMember{
+			{Person: person3, ExpiresAt: time.Date(2038, 9, 7, 9, 5, 18, 0, time.UTC)},
+			{Person: person2, ExpiresAt: time.Date(2038, 9, 7, 9, 59, 58, 0, time.UTC)},
+		}, got)
+	})
+
+	t.Run("returns nobody if no keys expire within the window", func(t *testing.T) {
+		got := myTeam.ExpiringMembers(30*24*time.Hour, keyCache, now)
+		assert.Equal(t, []ExpiringMember{}, got)
+	})
+}
@@ -3,6 +3,8 @@ package team
 import (
 	"fmt"
 	"io"
+	"regexp"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -24,3 +26,16 @@ func parse(r io.Reader) (*Team, error) {
 
 	return &parsedTeam, nil
 }
+
+// personLineNumbers returns the (1-based) line number of each [[person]] table in the roster,
+// in the order they appear. It's used to point at the person in an error message.
+func personLineNumbers(roster string) (lineNumbers []int) {
+	for i, line := range strings.Split(roster, "\n") {
+		if personTableHeader.MatchString(line) {
+			lineNumbers = append(lineNumbers, i+1)
+		}
+	}
+	return lineNumbers
+}
+
+var personTableHeader = regexp.MustCompile(`^\s*\[\[\s*person\s*\]\]`)
@@ -18,11 +18,15 @@
 package team
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"time"
 )
 
 // RosterSaver provides a way to do a 2-part save where a roster is saved as a "draft"
@@ -32,14 +36,25 @@ type RosterSaver struct {
 
 	draftRosterFilename    string
 	draftSignatureFilename string
+
+	draftRoster    string
+	draftSignature string
 }
 
-// Save saves the roster and signature straight to disk.
+// Save saves the roster and signature straight to disk. It holds the directory's lock file for
+// the whole save, so another fk process (for example a cron job) saving the same roster at the
+// same time waits rather than interleaving its writes with ours.
 func (rs *RosterSaver) Save(roster string, signature string) error {
+	unlock, err := rs.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	if err := rs.SaveDraft(roster, signature); err != nil {
 		return err
 	}
-	if err := rs.CommitDraft(); err != nil {
+	if err := rs.commitDraft(); err != nil {
 		return err
 	}
 	return nil
@@ -62,7 +77,7 @@ func (rs *RosterSaver) SaveDraft(roster string, signature string) error {
 	}
 	defer rosterTmp.Close()
 
-	if _, err := rosterTmp.Write([]byte(roster)); err != nil {
+	if err := writeAndSync(rosterTmp, roster); err != nil {
 		_ = os.Remove(rosterTmp.Name()) // best effort to clean up, but don't check error
 		return err
 	}
@@ -74,7 +89,7 @@ func (rs *RosterSaver) SaveDraft(roster string, signature string) error {
 	}
 	defer sigTmp.Close()
 
-	if _, err := sigTmp.Write([]byte(signature)); err != nil {
+	if err := writeAndSync(sigTmp, signature); err != nil {
 		_ = os.Remove(rosterTmp.Name()) // best effort to clean up, but don't check error
 		_ = os.Remove(sigTmp.Name())
 		return err
@@ -82,34 +97,61 @@ func (rs *RosterSaver) SaveDraft(roster string, signature string) error {
 
 	rs.draftRosterFilename = rosterTmp.Name()
 	rs.draftSignatureFilename = sigTmp.Name()
+	rs.draftRoster = roster
+	rs.draftSignature = signature
 	return nil
 }
 
-// CommitDraft actually saves the previously saved draft roster and signature
+// CommitDraft actually saves the previously saved draft roster and signature, and records the
+// change in the team's audit log.
 func (rs *RosterSaver) CommitDraft() error {
 	if rs.draftRosterFilename == "" || rs.draftSignatureFilename == "" {
 		return fmt.Errorf("no draft in progress")
 	}
 
+	unlock, err := rs.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return rs.commitDraft()
+}
+
+// commitDraft does the work of CommitDraft. The caller must hold the directory's lock.
+func (rs *RosterSaver) commitDraft() error {
+	if rs.draftRosterFilename == "" || rs.draftSignatureFilename == "" {
+		return fmt.Errorf("no draft in progress")
+	}
+
 	rosterFilename := filepath.Join(rs.Directory, rosterFilename)
 	rosterBackupFilename := filepath.Join(rs.Directory, rosterBackupFilename)
 	signatureFilename := filepath.Join(rs.Directory, signatureFilename)
 
 	isUpdate := fileExists(rosterFilename)
 
+	previousRoster := ""
+	if isUpdate {
+		if contents, err := ioutil.ReadFile(rosterFilename); err != nil {
+			log.Printf("failed to read previous roster for audit log: %v", err)
+		} else {
+			previousRoster = string(contents)
+		}
+	}
+
 	if isUpdate {
 		// backup roster.toml to roster.toml.BAK
-		if err := os.Rename(rosterFilename, rosterBackupFilename); err != nil {
+		if err := rename(rosterFilename, rosterBackupFilename); err != nil {
 			return err
 		}
 	}
 
 	// move draft roster -> roster.toml
-	if err := os.Rename(rs.draftRosterFilename, rosterFilename); err != nil {
+	if err := rename(rs.draftRosterFilename, rosterFilename); err != nil {
 		// failed to write new roster.toml, so try to restore backup (if we made one)
 
 		if isUpdate {
-			if err2 := os.Rename(rosterBackupFilename, rosterFilename); err2 != nil {
+			if err2 := rename(rosterBackupFilename, rosterFilename); err2 != nil {
 				return fmt.Errorf("failed to write %s (%v) and failed to restore backup %s (%v)",
 					rosterFilename, err, rosterBackupFilename, err2)
 			}
@@ -119,7 +161,7 @@ func (rs *RosterSaver) CommitDraft() error {
 	rs.draftRosterFilename = ""
 
 	// move draft signature -> roster.toml.asc
-	if err := os.Rename(rs.draftSignatureFilename, signatureFilename); err != nil {
+	if err := rename(rs.draftSignatureFilename, signatureFilename); err != nil {
 		log.Printf("failed to mv %s -> %s: %v", rs.draftSignatureFilename, signatureFilename, err)
 
 		// signature failed to write, so now the roster and the signature are out of sync.
@@ -128,7 +170,7 @@ func (rs *RosterSaver) CommitDraft() error {
 		if isUpdate {
 			log.Printf("attempting to restore %s -> %s", rosterBackupFilename, rosterFilename)
 
-			if err2 := os.Rename(rosterBackupFilename, rosterFilename); err2 != nil {
+			if err2 := rename(rosterBackupFilename, rosterFilename); err2 != nil {
 				return fmt.Errorf("failed to write %s (%v) *and* then failed to roll back %s (%v)",
 					signatureFilename, err, rosterBackupFilename, err2)
 			}
@@ -145,9 +187,103 @@ func (rs *RosterSaver) CommitDraft() error {
 	}
 	rs.draftSignatureFilename = ""
 
+	rs.saveRosterHash()
+	rs.recordInAuditLog(previousRoster)
+	rs.draftRoster = ""
+	rs.draftSignature = ""
+	return nil
+}
+
+// lock takes an exclusive lock on the directory's lock file, blocking until any other process
+// holding it has finished saving. The returned function releases the lock.
+func (rs *RosterSaver) lock() (unlock func(), err error) {
+	if err := os.MkdirAll(rs.Directory, 0700); err != nil {
+		return nil, fmt.Errorf("failed to make directory %s: %v", rs.Directory, err)
+	}
+
+	lockFilename := filepath.Join(rs.Directory, lockFilename)
+	f, err := os.OpenFile(lockFilename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", lockFilename, err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %v", lockFilename, err)
+	}
+
+	return func() {
+		if err := unlockFile(f); err != nil {
+			log.Printf("failed to unlock %s: %v", lockFilename, err)
+		}
+		f.Close()
+	}, nil
+}
+
+// saveRosterHash writes the SHA-256 hash of the draft roster to roster.toml.sha256, so Verify
+// can later detect the saved roster being changed on disk. The roster has already been saved by
+// this point, so errors are logged rather than returned.
+func (rs *RosterSaver) saveRosterHash() {
+	hashFilename := filepath.Join(rs.Directory, rosterHashFilename)
+
+	hash := formatRosterHash(rs.draftRoster)
+	if err := ioutil.WriteFile(hashFilename, []byte(hash), 0600); err != nil {
+		log.Printf("failed to write %s: %v", hashFilename, err)
+
+		// a hash of the previous roster would make Verify fail, so remove it
+		_ = os.Remove(hashFilename)
+	}
+}
+
+// Verify checks the saved roster against the hash written when it was saved, returning
+// ErrRosterHashMismatch if the roster has been changed on disk since, or ErrRosterHashMissing
+// if there's no hash. Rosters saved before hashes were written have no hash, so it's up to the
+// caller to decide whether ErrRosterHashMissing means the hash has been removed.
+func (rs *RosterSaver) Verify() error {
+	hashFilename := filepath.Join(rs.Directory, rosterHashFilename)
+
+	savedHash, err := ioutil.ReadFile(hashFilename)
+	if os.IsNotExist(err) {
+		log.Printf("no %s in %s, can't verify roster", rosterHashFilename, rs.Directory)
+		return ErrRosterHashMissing
+	} else if err != nil {
+		return fmt.Errorf("failed to read %s: %v", hashFilename, err)
+	}
+
+	roster, err := ioutil.ReadFile(filepath.Join(rs.Directory, rosterFilename))
+	if err != nil {
+		return fmt.Errorf("failed to read roster from %s: %v", rs.Directory, err)
+	}
+
+	if string(savedHash) != formatRosterHash(string(roster)) {
+		log.Printf("hash of roster in %s doesn't match %s", rs.Directory, rosterHashFilename)
+		return ErrRosterHashMismatch
+	}
 	return nil
 }
 
+// formatRosterHash returns the hash of the roster in the format output by `sha256sum`, so the
+// file can also be checked with `sha256sum --check roster.toml.sha256`
+func formatRosterHash(roster string) string {
+	hash := sha256.Sum256([]byte(roster))
+	return hex.EncodeToString(hash[:]) + "  " + rosterFilename + "\n"
+}
+
+// recordInAuditLog appends the change from previousRoster to the draft roster to the audit log.
+// The roster has already been saved by this point, so errors are logged rather than returned.
+func (rs *RosterSaver) recordInAuditLog(previousRoster string) {
+	entry, err := makeAuditEntry(previousRoster, rs.draftRoster, rs.draftSignature, time.Now())
+	if err != nil {
+		log.Printf("failed to make audit log entry: %v", err)
+		return
+	}
+
+	auditLog := AuditLog{Directory: rs.Directory}
+	if err := auditLog.Append(*entry); err != nil {
+		log.Printf("failed to append to audit log: %v", err)
+	}
+}
+
 // DiscardDraft deletes the previously saved draft roster and signature
 func (rs *RosterSaver) DiscardDraft() error {
 	log.Printf("discarding draft")
@@ -168,11 +304,43 @@ func (rs *RosterSaver) DiscardDraft() error {
 
 	rs.draftRosterFilename = ""
 	rs.draftSignatureFilename = ""
+	rs.draftRoster = ""
+	rs.draftSignature = ""
 	return nil
 }
 
+// writeAndSync writes the content to the file and flushes it to disk, so that once it's renamed
+// into place it can't be left partially written.
+func writeAndSync(f *os.File, content string) error {
+	if _, err := f.Write([]byte(content)); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// rename moves a file into place. It's a variable so tests can simulate a failure part way
+// through saving.
+var rename = replaceFile
+
+// replaceFile moves src to dst, replacing dst if it already exists.
+func replaceFile(src string, dst string) error {
+	err := os.Rename(src, dst)
+	if err != nil && runtime.GOOS == "windows" && fileExists(dst) {
+		// renaming over an existing file can fail on Windows, so remove it and try again
+		log.Printf("failed to mv %s -> %s (%v), removing %s and retrying", src, dst, err, dst)
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+		return os.Rename(src, dst)
+	}
+	return err
+}
+
 const (
 	rosterFilename       = "roster.toml"
 	rosterBackupFilename = "roster.toml.BAK"
 	signatureFilename    = "roster.toml.asc"
+	rosterHashFilename   = "roster.toml.sha256"
+	auditLogFilename     = "audit.jsonl"
+	lockFilename         = ".roster.lock"
 )
@@ -23,7 +23,10 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/fluidkeys/fluidkeys/assert"
 )
@@ -92,8 +95,37 @@ func TestCommitDraft(t *testing.T) {
 	})
 
 	t.Run("returns error if roster can't be written", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		err := rosterSaver.Save("original roster", "original signature")
+		assert.NoError(t, err)
+
+		err = rosterSaver.SaveDraft("updated roster", "updated signature")
+		assert.NoError(t, err)
+
+		// simulate a crash while moving draft roster -> roster.toml
+		draftRosterFilename := rosterSaver.draftRosterFilename
+		rename = func(src string, dst string) error {
+			if src == draftRosterFilename {
+				return fmt.Errorf("simulated crash")
+			}
+			return os.Rename(src, dst)
+		}
+		defer func() { rename = replaceFile }()
+
+		err = rosterSaver.CommitDraft()
+		assert.Equal(t, fmt.Errorf("simulated crash"), err)
 
 		t.Run("leaves original roster intact", func(t *testing.T) {
+			assert.Equal(t,
+				"original roster",
+				readFile(t, filepath.Join(rosterSaver.Directory, "roster.toml")),
+			)
+			assert.Equal(t,
+				"original signature",
+				readFile(t, filepath.Join(rosterSaver.Directory, "roster.toml.asc")),
+			)
 		})
 	})
 
@@ -157,6 +189,108 @@ func TestCommitDraft(t *testing.T) {
 	})
 }
 
+func TestSaveConcurrently(t *testing.T) {
+	t.Run("concurrent saves leave a matching roster and signature", func(t *testing.T) {
+		directory := makeRosterSaveInTmpDirectory(t).Directory
+		defer os.RemoveAll(directory)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				rosterSaver := RosterSaver{Directory: directory}
+				err := rosterSaver.Save(fmt.Sprintf("roster %d", i), fmt.Sprintf("signature %d", i))
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		roster := readFile(t, filepath.Join(directory, "roster.toml"))
+		signature := readFile(t, filepath.Join(directory, "roster.toml.asc"))
+		assert.Equal(t, strings.Replace(roster, "roster", "signature", 1), signature)
+
+		rosterSaver := RosterSaver{Directory: directory}
+		assert.NoError(t, rosterSaver.Verify())
+	})
+
+	t.Run("waits for another save holding the lock", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		unlock, err := rosterSaver.lock()
+		assert.NoError(t, err)
+
+		saved := make(chan error)
+		go func() {
+			otherSaver := RosterSaver{Directory: rosterSaver.Directory}
+			saved <- otherSaver.Save("roster 2", "signature 2")
+		}()
+
+		select {
+		case <-saved:
+			t.Fatalf("expected Save to wait for the lock")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		unlock()
+		assert.NoError(t, <-saved)
+		assert.Equal(t, "roster 2", readFile(t, filepath.Join(rosterSaver.Directory, "roster.toml")))
+	})
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("passes for an unchanged roster", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		assert.NoError(t, rosterSaver.Save("roster 1", "signature 1"))
+		assert.NoError(t, rosterSaver.Verify())
+	})
+
+	t.Run("writes the hash in sha256sum format", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		assert.NoError(t, rosterSaver.Save("roster 1", "signature 1"))
+		assert.Equal(t,
+			"2f67b5e7718f1bdcc7617e7f62b03b0788ac042e3dc0a1aa9e8a35a04fe8a478  roster.toml\n",
+			readFile(t, filepath.Join(rosterSaver.Directory, "roster.toml.sha256")),
+		)
+	})
+
+	t.Run("passes after the roster is updated", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		assert.NoError(t, rosterSaver.Save("roster 1", "signature 1"))
+		assert.NoError(t, rosterSaver.Save("roster 2", "signature 2"))
+		assert.NoError(t, rosterSaver.Verify())
+	})
+
+	t.Run("returns an error if the roster has been tampered with", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		assert.NoError(t, rosterSaver.Save("roster 1", "signature 1"))
+
+		rosterFilename := filepath.Join(rosterSaver.Directory, "roster.toml")
+		assert.NoError(t, ioutil.WriteFile(rosterFilename, []byte("tampered roster"), 0600))
+
+		assert.Equal(t, ErrRosterHashMismatch, rosterSaver.Verify())
+	})
+
+	t.Run("returns an error if there's no saved hash", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		assert.NoError(t, rosterSaver.Save("roster 1", "signature 1"))
+		assert.NoError(t, os.Remove(filepath.Join(rosterSaver.Directory, "roster.toml.sha256")))
+
+		assert.Equal(t, ErrRosterHashMissing, rosterSaver.Verify())
+	})
+}
+
 func TestDiscardDraft(t *testing.T) {
 
 	t.Run("deletes temp files and clears variables", func(t *testing.T) {
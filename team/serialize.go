@@ -2,10 +2,14 @@ package team
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/gofrs/uuid"
 )
 
 // serialize returns the team as a toml formatted string. You should validate the team
@@ -34,3 +38,56 @@ func defaultRosterFile(teamName string) string {
 # automatically.
 `
 }
+
+// ToJSON returns the team as JSON, with the same fields as the TOML roster plus the time at
+// which the JSON was generated.
+func ToJSON(t *Team) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid team: %v", err)
+	}
+
+	roster := jsonRoster{
+		UUID:           t.UUID,
+		Name:           t.Name,
+		ParentTeamUUID: t.ParentTeamUUID,
+		People:         []jsonPerson{},
+		GeneratedAt:    time.Now().UTC(),
+	}
+	for _, person := range t.People {
+		roster.People = append(roster.People, jsonPerson{
+			Email:       person.Email,
+			Fingerprint: person.Fingerprint,
+			IsAdmin:     person.IsAdmin,
+		})
+	}
+
+	return json.MarshalIndent(roster, "", "  ")
+}
+
+// jsonRoster is the JSON representation of a team roster, for example:
+//
+//	{
+//	  "uuid": "6caa3730-2ca3-47b9-b671-5dc326100431",
+//	  "name": "Kiffix",
+//	  "person": [
+//	    {
+//	      "email": "test2@example.com",
+//	      "fingerprint": "5C78E71F6FEFB55829654CC5343CC240D350C30C",
+//	      "is_admin": true
+//	    }
+//	  ],
+//	  "generatedAt": "2019-03-20T14:00:00Z"
+//	}
+type jsonRoster struct {
+	UUID           uuid.UUID    `json:"uuid"`
+	Name           string       `json:"name"`
+	ParentTeamUUID *uuid.UUID   `json:"parent_team_uuid,omitempty"`
+	People         []jsonPerson `json:"person"`
+	GeneratedAt    time.Time    `json:"generatedAt"`
+}
+
+type jsonPerson struct {
+	Email       string          `json:"email"`
+	Fingerprint fpr.Fingerprint `json:"fingerprint"`
+	IsAdmin     bool            `json:"is_admin"`
+}
@@ -1,7 +1,9 @@
 package team
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/fluidkeys/fluidkeys/exampledata"
 
@@ -107,3 +109,66 @@ name = "Kiffix"
 		assert.GotError(t, err)
 	})
 }
+
+func TestToJSON(t *testing.T) {
+	roster := `# Kiffix team roster. Everyone in the team has a copy of this file.
+#
+# It is used to look up which key to use for an email address and fetch keys
+# automatically.
+uuid = "6caa3730-2ca3-47b9-b671-5dc326100431"
+name = "Kiffix"
+
+[[person]]
+  email = "test2@example.com"
+  fingerprint = "5C78E71F6FEFB55829654CC5343CC240D350C30C"
+  is_admin = true
+
+[[person]]
+  email = "test3@example.com"
+  fingerprint = "7C18DE4DE47813568B243AC8719BD63EF03BDC20"
+  is_admin = false
+`
+	originalTeam, err := Load(roster, "")
+	assert.NoError(t, err)
+
+	got, err := ToJSON(originalTeam)
+	assert.NoError(t, err)
+
+	var decoded jsonRoster
+	assert.NoError(t, json.Unmarshal(got, &decoded))
+
+	t.Run("includes a generatedAt timestamp", func(t *testing.T) {
+		if time.Since(decoded.GeneratedAt) > time.Minute {
+			t.Fatalf("expected generatedAt to be recent, got %v", decoded.GeneratedAt)
+		}
+	})
+
+	t.Run("round trips back to the same roster via Load", func(t *testing.T) {
+		roundTrippedTeam := Team{
+			UUID: decoded.UUID,
+			Name: decoded.Name,
+		}
+		for _, person := range decoded.People {
+			roundTrippedTeam.People = append(roundTrippedTeam.People, Person{
+				Email:       person.Email,
+				Fingerprint: person.Fingerprint,
+				IsAdmin:     person.IsAdmin,
+			})
+		}
+
+		roundTrippedRoster, err := roundTrippedTeam.PreviewRoster()
+		assert.NoError(t, err)
+		assert.Equal(t, roster, roundTrippedRoster)
+
+		reloadedTeam, err := Load(roundTrippedRoster, "")
+		assert.NoError(t, err)
+		assert.Equal(t, originalTeam.People, reloadedTeam.People)
+		assert.Equal(t, originalTeam.UUID, reloadedTeam.UUID)
+		assert.Equal(t, originalTeam.Name, reloadedTeam.Name)
+	})
+
+	t.Run("for an invalid team returns an error", func(t *testing.T) {
+		_, err := ToJSON(&Team{Name: "Kiffix"})
+		assert.GotError(t, err)
+	})
+}
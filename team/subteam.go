@@ -0,0 +1,61 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+// IsSubteamOf returns true if parent is the team's immediate parent team.
+func (t *Team) IsSubteamOf(parent *Team) bool {
+	return t.ParentTeamUUID != nil && *t.ParentTeamUUID == parent.UUID
+}
+
+// Ancestors returns the team's parent, then its parent's parent and so on, looking each one up in
+// teams. It stops at a team with no parent, or whose parent isn't in teams, for example because
+// the user isn't a member of it.
+// It returns an error if the parent references form a cycle, like a team whose parent is its
+// own subteam.
+func Ancestors(t *Team, teams []*Team) ([]*Team, error) {
+	teamsByUUID := map[uuid.UUID]*Team{}
+	for _, team := range teams {
+		teamsByUUID[team.UUID] = team
+	}
+
+	ancestors := []*Team{}
+	seen := map[uuid.UUID]bool{t.UUID: true}
+
+	for current := t; current.ParentTeamUUID != nil; {
+		parentUUID := *current.ParentTeamUUID
+		if seen[parentUUID] {
+			return nil, fmt.Errorf("team %s has a cyclic parent reference to %s",
+				current.UUID, parentUUID)
+		}
+		seen[parentUUID] = true
+
+		parent, found := teamsByUUID[parentUUID]
+		if !found {
+			break
+		}
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+	return ancestors, nil
+}
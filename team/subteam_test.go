@@ -0,0 +1,142 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/gofrs/uuid"
+)
+
+func TestSubteams(t *testing.T) {
+	admin := Person{
+		Email:       "test4@example.com",
+		Fingerprint: exampledata.ExampleFingerprint4,
+		IsAdmin:     true,
+	}
+	makeTeam := func(name string, id string, parent *Team) *Team {
+		t := Team{UUID: uuid.Must(uuid.FromString(id)), Name: name, People: []Person{admin}}
+		if parent != nil {
+			t.ParentTeamUUID = &parent.UUID
+		}
+		return &t
+	}
+
+	engineering := makeTeam("Engineering", "74bb40b4-3510-11e9-968e-53c38df634be", nil)
+	backend := makeTeam("Backend", "8fa0d9a2-3510-11e9-968e-53c38df634be", engineering)
+	database := makeTeam("Database", "9b9a5d3e-3510-11e9-968e-53c38df634be", backend)
+	marketing := makeTeam("Marketing", "a4bbd4c8-3510-11e9-968e-53c38df634be", nil)
+
+	t.Run("creating a subteam", func(t *testing.T) {
+		roster, err := backend.PreviewRoster()
+		assert.NoError(t, err)
+		assert.Equal(t, true, strings.Contains(roster,
+			`parent_team_uuid = "74bb40b4-3510-11e9-968e-53c38df634be"`))
+
+		loaded, err := Load(roster, "")
+		assert.NoError(t, err)
+		assert.Equal(t, true, loaded.IsSubteamOf(engineering))
+	})
+
+	t.Run("a top-level team's roster has no parent", func(t *testing.T) {
+		roster, err := engineering.PreviewRoster()
+		assert.NoError(t, err)
+		assert.Equal(t, false, strings.Contains(roster, "parent_team_uuid"))
+	})
+
+	t.Run("IsSubteamOf", func(t *testing.T) {
+		assert.Equal(t, true, backend.IsSubteamOf(engineering))
+		assert.Equal(t, false, engineering.IsSubteamOf(backend))
+		assert.Equal(t, false, backend.IsSubteamOf(marketing))
+		assert.Equal(t, false, marketing.IsSubteamOf(engineering))
+
+		t.Run("only for the immediate parent", func(t *testing.T) {
+			assert.Equal(t, false, database.IsSubteamOf(engineering))
+		})
+	})
+
+	t.Run("Ancestors", func(t *testing.T) {
+		allTeams := []*Team{engineering, backend, database, marketing}
+
+		t.Run("walks up to the top-level team", func(t *testing.T) {
+			got, err := Ancestors(database, allTeams)
+			assert.NoError(t, err)
+			assert.Equal(t, []*Team{backend, engineering}, got)
+		})
+
+		t.Run("returns no ancestors for a top-level team", func(t *testing.T) {
+			got, err := Ancestors(marketing, allTeams)
+			assert.NoError(t, err)
+			assert.Equal(t, []*Team{}, got)
+		})
+
+		t.Run("stops at a parent that isn't known", func(t *testing.T) {
+			got, err := Ancestors(database, []*Team{backend, database})
+			assert.NoError(t, err)
+			assert.Equal(t, []*Team{backend}, got)
+		})
+
+		t.Run("returns an error for a cycle", func(t *testing.T) {
+			cyclicEngineering := *engineering
+			cyclicEngineering.ParentTeamUUID = &database.UUID
+
+			_, err := Ancestors(database, []*Team{&cyclicEngineering, backend, database})
+			assert.Equal(t, fmt.Errorf(
+				"team %s has a cyclic parent reference to %s", engineering.UUID, database.UUID),
+				err)
+		})
+	})
+
+	t.Run("a team can't be its own parent", func(t *testing.T) {
+		selfParent := *engineering
+		selfParent.ParentTeamUUID = &selfParent.UUID
+
+		err := selfParent.Validate()
+		assert.Equal(t, fmt.Errorf("invalid roster: team can't be its own parent"), err)
+	})
+
+	t.Run("ValidateUpdate", func(t *testing.T) {
+		t.Run("allows an update which keeps the parent", func(t *testing.T) {
+			renamed := *backend
+			renamed.Name = "Back end"
+
+			err := ValidateUpdate(backend, &renamed, admin.Fingerprint, nil)
+			assert.NoError(t, err)
+		})
+
+		t.Run("rejects changing the parent", func(t *testing.T) {
+			moved := *backend
+			moved.ParentTeamUUID = &marketing.UUID
+
+			err := ValidateUpdate(backend, &moved, admin.Fingerprint, nil)
+			assert.Equal(t, fmt.Errorf("can't change parent team UUID from %s to %s",
+				engineering.UUID, marketing.UUID), err)
+		})
+
+		t.Run("rejects adding a parent", func(t *testing.T) {
+			err := ValidateUpdate(marketing, makeTeam(
+				"Marketing", marketing.UUID.String(), engineering), admin.Fingerprint, nil)
+			assert.Equal(t, fmt.Errorf("can't change parent team UUID from none to %s",
+				engineering.UUID), err)
+		})
+	})
+}
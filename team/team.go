@@ -3,15 +3,20 @@ package team
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net/mail"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/armor"
+	"github.com/fluidkeys/crypto/openpgp/packet"
 	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
 	"github.com/fluidkeys/fluidkeys/pgpkey"
 	"github.com/gofrs/uuid"
@@ -19,40 +24,117 @@ import (
 
 // LoadTeams scans the fluidkeys/teams directory for subdirectories, enters them and tries to load
 // roster.toml
-// Returns a slice of Team
+// Returns a slice of Team. If some teams fail to load, the others are returned along with a
+// LoadErrors.
 func LoadTeams(fluidkeysDirectory string) ([]Team, error) {
 	teamsDirectory, err := getTeamDirectory(fluidkeysDirectory)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't get teams directory: %v", err)
 	}
 
-	teamSubdirs, err := findTeamSubdirectories(teamsDirectory)
-	if err != nil {
+	loadedTeams, err := LoadFromDirectory(teamsDirectory)
+	if _, ok := err.(LoadErrors); err != nil && !ok {
 		return nil, err
 	}
 
 	teams := []Team{}
-	for _, subdir := range teamSubdirs {
-		log.Printf("loading team roster from %s\n", subdir)
-		roster, err := ioutil.ReadFile(filepath.Join(subdir, rosterFilename))
-		if err != nil {
-			return nil, fmt.Errorf("failed to read roster from %s: %v", subdir, err)
-		}
+	for _, team := range loadedTeams {
+		teams = append(teams, *team)
+	}
+	return teams, err
+}
 
-		signature, err := ioutil.ReadFile(filepath.Join(subdir, signatureFilename))
-		if err != nil {
-			return nil, fmt.Errorf("failed to read signature from %s: %v", subdir, err)
-		}
+// LoadFromDirectory loads the roster from every team subdirectory of dir in parallel. A team
+// that fails to load doesn't stop the others: the teams that loaded are returned along with a
+// LoadErrors describing those that didn't.
+func LoadFromDirectory(dir string) ([]*Team, error) {
+	teamSubdirs, err := findTeamSubdirectories(dir)
+	if err != nil {
+		return nil, err
+	}
 
-		team, err := Load(string(roster), string(signature))
-		if err != nil {
-			return nil, fmt.Errorf("failed to load team from %s: %v", subdir, err)
+	loadedTeams := make([]*Team, len(teamSubdirs))
+	loadErrors := make([]error, len(teamSubdirs))
+
+	var wg sync.WaitGroup
+	for i, subdir := range teamSubdirs {
+		wg.Add(1)
+		go func(i int, subdir string) {
+			defer wg.Done()
+			loadedTeams[i], loadErrors[i] = loadTeamFromSubdirectory(subdir)
+		}(i, subdir)
+	}
+	wg.Wait()
+
+	teams := []*Team{}
+	var errs LoadErrors
+	for i := range teamSubdirs {
+		if loadErrors[i] != nil {
+			errs = append(errs, loadErrors[i])
+		} else {
+			teams = append(teams, loadedTeams[i])
 		}
-		teams = append(teams, *team)
+	}
+
+	if len(errs) > 0 {
+		return teams, errs
 	}
 	return teams, nil
 }
 
+// LoadErrors is returned by LoadFromDirectory when one or more teams failed to load
+type LoadErrors []error
+
+func (e LoadErrors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func loadTeamFromSubdirectory(subdir string) (*Team, error) {
+	log.Printf("loading team roster from %s\n", subdir)
+	roster, signature, err := readRosterFiles(subdir)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := Load(roster, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team from %s: %v", subdir, err)
+	}
+	return team, nil
+}
+
+// LoadSavedRoster reads the roster and signature saved in the given team subdirectory, for
+// example by RosterSaver. It returns ErrNoRoster if no roster has been saved there, or another
+// error if the roster is there but can't be read or isn't valid.
+func LoadSavedRoster(teamSubdirectory string) (t *Team, err error) {
+	if !fileExists(filepath.Join(teamSubdirectory, rosterFilename)) {
+		return nil, ErrNoRoster
+	}
+
+	roster, signature, err := readRosterFiles(teamSubdirectory)
+	if err != nil {
+		return nil, err
+	}
+	return Load(roster, signature)
+}
+
+func readRosterFiles(teamSubdirectory string) (roster string, signature string, err error) {
+	rosterBytes, err := ioutil.ReadFile(filepath.Join(teamSubdirectory, rosterFilename))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read roster from %s: %v", teamSubdirectory, err)
+	}
+
+	signatureBytes, err := ioutil.ReadFile(filepath.Join(teamSubdirectory, signatureFilename))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read signature from %s: %v", teamSubdirectory, err)
+	}
+	return string(rosterBytes), string(signatureBytes), nil
+}
+
 // Load loads a team from the given roster and signature
 func Load(roster string, signature string) (*Team, error) {
 	team, err := parse(strings.NewReader(roster))
@@ -60,6 +142,17 @@ func Load(roster string, signature string) (*Team, error) {
 		return nil, err
 	}
 
+	lineNumbers := personLineNumbers(roster)
+	for i, person := range team.People {
+		if err := person.Validate(); err != nil {
+			if len(lineNumbers) == len(team.People) {
+				return nil, fmt.Errorf("error validating team: person on line %d: %v",
+					lineNumbers[i], err)
+			}
+			return nil, fmt.Errorf("error validating team: person %d: %v", i+1, err)
+		}
+	}
+
 	err = team.Validate()
 	if err != nil {
 		return nil, fmt.Errorf("error validating team: %v", err)
@@ -92,12 +185,32 @@ func (t Team) Admins() (admins []Person) {
 	return admins
 }
 
-// VerifyRoster cryptographically checks the signature against the roster, using the given
-// signing keys
-func VerifyRoster(roster string, signature string, adminKeys []*pgpkey.PgpKey) error {
-	if signature == "" {
-		return fmt.Errorf("empty signature")
+// VerifyRoster cryptographically checks the signatures against the roster, using the given
+// signing keys. By default the roster needs one valid signature from any of adminKeys: use
+// RequireAdminSignatures to require signatures from more admins.
+// It returns ErrSignatureNotFound if there are no signatures, or ErrSignatureInvalid if none of
+// the signatures were made by one of adminKeys and match the roster. If some signatures are
+// valid but there aren't enough of them, it returns ErrNotEnoughSignatures.
+// If maxSignatureAge is non-zero, a signature made more than maxSignatureAge before now doesn't
+// count, and if there are no other valid signatures VerifyRoster returns ErrSignatureTooOld.
+// Pass 0 to accept signatures of any age: a roster that hasn't been edited for a while is still
+// valid.
+func VerifyRoster(roster string, signatures []string, adminKeys []*pgpkey.PgpKey,
+	maxSignatureAge time.Duration, now time.Time, options ...VerifyRosterOption) error {
+
+	opts := verifyRosterOptions{requiredSignatures: 1}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var signedSince time.Time
+	if opts.previousSignature != "" {
+		var err error
+		if signedSince, err = signatureCreationTime(opts.previousSignature); err != nil {
+			log.Printf("couldn't read previous roster signature creation time: %v", err)
+		}
 	}
+
 	var keyring openpgp.EntityList
 
 	for i := range adminKeys {
@@ -105,14 +218,137 @@ func VerifyRoster(roster string, signature string, adminKeys []*pgpkey.PgpKey) e
 		keyring = append(keyring, &key.Entity)
 	}
 
-	if _, err := openpgp.CheckArmoredDetachedSignature(
+	// count signers rather than signatures, so one admin can't sign twice to reach a quorum
+	validSigners := map[fpr.Fingerprint]bool{}
+	firstErr := ErrSignatureNotFound
+
+	for _, signature := range signatures {
+		signer, err := verifyRosterSignature(
+			roster, signature, keyring, maxSignatureAge, signedSince, now)
+		if err != nil {
+			if firstErr == ErrSignatureNotFound {
+				firstErr = err
+			}
+			continue
+		}
+		validSigners[signer] = true
+	}
+
+	switch {
+	case len(validSigners) >= opts.requiredSignatures:
+		return nil
+
+	case len(validSigners) == 0:
+		return firstErr
+
+	default:
+		log.Printf("roster has %d valid admin signatures, need %d",
+			len(validSigners), opts.requiredSignatures)
+		return ErrNotEnoughSignatures
+	}
+}
+
+// VerifyRosterOption changes how VerifyRoster checks a roster's signatures.
+type VerifyRosterOption func(*verifyRosterOptions)
+
+type verifyRosterOptions struct {
+	requiredSignatures int
+	previousSignature  string
+}
+
+// RequireAdminSignatures makes VerifyRoster require valid signatures from at least n different
+// admin keys. A roster always needs at least one valid signature, so n less than 1 counts as 1.
+func RequireAdminSignatures(n int) VerifyRosterOption {
+	if n < 1 {
+		n = 1
+	}
+	return func(opts *verifyRosterOptions) {
+		opts.requiredSignatures = n
+	}
+}
+
+// RequireNewerThan makes VerifyRoster reject signatures made before previousSignature, returning
+// ErrSignatureTooOld if there are no others. Pass the signature of the saved roster so that an
+// older copy of the roster can't be replayed in place of it.
+func RequireNewerThan(previousSignature string) VerifyRosterOption {
+	return func(opts *verifyRosterOptions) {
+		opts.previousSignature = previousSignature
+	}
+}
+
+// verifyRosterSignature checks a single signature against the roster, returning the
+// fingerprint of the key in keyring that made it. Unless they're zero, signatures made more
+// than maxSignatureAge before now or before signedSince are rejected with ErrSignatureTooOld.
+func verifyRosterSignature(roster string, signature string, keyring openpgp.EntityList,
+	maxSignatureAge time.Duration, signedSince time.Time, now time.Time) (
+	signer fpr.Fingerprint, err error) {
+
+	if strings.TrimSpace(signature) == "" {
+		return fpr.Fingerprint{}, ErrSignatureNotFound
+	}
+
+	entity, err := openpgp.CheckArmoredDetachedSignature(
 		keyring,
 		strings.NewReader(roster),
 		strings.NewReader(signature),
-	); err != nil {
-		return err
+	)
+	switch err {
+	case nil:
+
+	case io.EOF: // no armored block in the signature
+		log.Printf("no signature in `%s`", signature)
+		return fpr.Fingerprint{}, ErrSignatureNotFound
+
+	default:
+		log.Printf("roster signature didn't verify: %v", err)
+		return fpr.Fingerprint{}, ErrSignatureInvalid
+	}
+	signer = fpr.FromBytes(entity.PrimaryKey.Fingerprint)
+
+	if maxSignatureAge == 0 && signedSince.IsZero() {
+		return signer, nil
+	}
+
+	signedAt, err := signatureCreationTime(signature)
+	if err != nil {
+		log.Printf("couldn't read roster signature creation time: %v", err)
+		return fpr.Fingerprint{}, ErrSignatureInvalid
+	}
+
+	if maxSignatureAge != 0 && now.Sub(signedAt) > maxSignatureAge {
+		log.Printf("roster signed at %s, more than %s before %s", signedAt, maxSignatureAge, now)
+		return fpr.Fingerprint{}, ErrSignatureTooOld
+	}
+
+	if signedAt.Before(signedSince) {
+		log.Printf("roster signed at %s, before the previous roster at %s", signedAt, signedSince)
+		return fpr.Fingerprint{}, ErrSignatureTooOld
+	}
+	return signer, nil
+}
+
+// signatureCreationTime returns the time the given ASCII armored detached signature was made.
+func signatureCreationTime(armoredSignature string) (time.Time, error) {
+	block, err := armor.Decode(strings.NewReader(armoredSignature))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch sig := p.(type) {
+	case *packet.Signature:
+		return sig.CreationTime, nil
+
+	case *packet.SignatureV3:
+		return sig.CreationTime, nil
+
+	default:
+		return time.Time{}, fmt.Errorf("expected signature packet, got %T", p)
 	}
-	return nil
 }
 
 // PreviewRoster returns an (unsigned) roster based on the current state of the Team.
@@ -151,21 +387,36 @@ func (t *Team) UpdateRoster(signingKey *pgpkey.PgpKey) error {
 }
 
 // Roster returns the TOML file representing the team roster, and the ASCII armored detached
-// signature of that file.
-func (t Team) Roster() (roster string, signature string) {
+// signature of that file. It returns ErrNoRoster if the team wasn't loaded from a roster and
+// UpdateRoster hasn't been called.
+func (t Team) Roster() (roster string, signature string, err error) {
 	if t.roster == "" || t.signature == "" {
-		log.Panic("Roster called but roster & signature haven't been set")
+		return "", "", ErrNoRoster
 	}
-	return t.roster, t.signature
+	return t.roster, t.signature, nil
 }
 
-// Validate asserts that the team roster has no email addresses or fingerprints that are
-// listed more than once.
+// Validate asserts that everyone in the team roster is valid and that there are no email
+// addresses or fingerprints listed more than once.
 func (t *Team) Validate() error {
 	if t.UUID == uuid.Nil {
 		return fmt.Errorf("invalid roster: invalid UUID")
 	}
 
+	if t.ParentTeamUUID != nil && *t.ParentTeamUUID == t.UUID {
+		return fmt.Errorf("invalid roster: team can't be its own parent")
+	}
+
+	if t.IsEmpty() {
+		return fmt.Errorf("team has no members")
+	}
+
+	for _, person := range t.People {
+		if err := person.Validate(); err != nil {
+			return fmt.Errorf("invalid person %s: %v", person.Email, err)
+		}
+	}
+
 	emailsSeen := map[string]bool{} // look for multiple email addresses
 	for _, person := range t.People {
 		if _, alreadySeen := emailsSeen[person.Email]; alreadySeen {
@@ -182,7 +433,7 @@ func (t *Team) Validate() error {
 		fingerprintsSeen[person.Fingerprint] = true
 	}
 
-	if len(t.Admins()) == 0 {
+	if t.AdminCount() == 0 {
 		return fmt.Errorf("team has no administrators")
 	}
 	return nil
@@ -210,6 +461,17 @@ func (t *Team) GetPersonForFingerprint(fingerprint fpr.Fingerprint) (*Person, er
 	return nil, fmt.Errorf("person not found")
 }
 
+// GetPersonForEmail returns the person in the team with the given email address, ignoring case.
+func (t *Team) GetPersonForEmail(email string) (*Person, error) {
+	for _, person := range t.People {
+		if person.emailMatches(Person{Email: email}) {
+			return &person, nil
+		}
+	}
+
+	return nil, fmt.Errorf("person not found")
+}
+
 // GetUpsertPersonWarnings checks if the given request to join a team causes any other team member to
 // be overwritten, returning an error if so.
 func (t *Team) GetUpsertPersonWarnings(newPerson Person) (err error, existingPerson *Person) {
@@ -222,16 +484,16 @@ func (t *Team) GetUpsertPersonWarnings(newPerson Person) (err error, existingPer
 		emailsEqual := existingPerson.emailMatches(newPerson)
 		isAdminsEqual := existingPerson.IsAdmin == newPerson.IsAdmin
 
-		// 1. same email, different fingerprint
-		// 2. same fingerprint, different email
+		// 1. same email, different fingerprint (whether or not they're admin)
+		// 2. same fingerprint, different email (whether or not they're admin)
 		// 3. promoted to admin
 		// 4. demoted from admin
 
-		if !fingerprintsEqual && emailsEqual && isAdminsEqual {
+		if !fingerprintsEqual && emailsEqual {
 			return ErrKeyWouldBeUpdated, &existingPerson
 		}
 
-		if !emailsEqual && fingerprintsEqual && isAdminsEqual {
+		if !emailsEqual && fingerprintsEqual {
 			return ErrEmailWouldBeUpdated, &existingPerson
 		}
 
@@ -361,6 +623,10 @@ type Team struct {
 	Name   string    `toml:"name"`
 	People []Person  `toml:"person"`
 
+	// ParentTeamUUID is set if this team is a subteam of another, for example `backend` of
+	// `engineering`. It's a pointer so that it's left out of rosters for top-level teams.
+	ParentTeamUUID *uuid.UUID `toml:"parent_team_uuid,omitempty"`
+
 	roster    string
 	signature string
 }
@@ -377,9 +643,56 @@ func (t *Team) Fingerprints() []fpr.Fingerprint {
 
 // Person represents a human team member
 type Person struct {
-	Email       string          `toml:"email"`
-	Fingerprint fpr.Fingerprint `toml:"fingerprint"`
-	IsAdmin     bool            `toml:"is_admin"`
+	Email       string          `toml:"email" json:"email"`
+	Fingerprint fpr.Fingerprint `toml:"fingerprint" json:"fingerprint"`
+	IsAdmin     bool            `toml:"is_admin" json:"isAdmin"`
+}
+
+// String returns the person's email and short fingerprint, for example
+// `jane@example.com [309F635DAD1B5517]`. Use LongString where the fingerprint is being shown so
+// that it can be checked.
+func (p Person) String() string {
+	return p.format(func(f fpr.Fingerprint) string { return f.Short() })
+}
+
+// LongString returns the person's email and full fingerprint, for example
+// `jane@example.com [A999 B749 8D1A 8DC4 73E5  3C92 309F 635D AD1B 5517]`
+func (p Person) LongString() string {
+	return p.format(func(f fpr.Fingerprint) string { return f.String() })
+}
+
+// format joins the email and formatted fingerprint, leaving out whichever isn't set
+func (p Person) format(formatFingerprint func(fpr.Fingerprint) string) string {
+	parts := []string{}
+	if p.Email != "" {
+		parts = append(parts, p.Email)
+	}
+	if p.Fingerprint.IsSet() {
+		parts = append(parts, "["+formatFingerprint(p.Fingerprint)+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// KeyURL returns the URL to download the person's public key from the Fluidkeys API at baseURL,
+// for example https://api.fluidkeys.com/v1/key/<fingerprint>.asc
+func (p Person) KeyURL(baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/key/" + p.Fingerprint.Hex() + ".asc"
+}
+
+// Validate checks that the person has a syntactically valid (RFC 5322) email address and a
+// fingerprint. The fingerprint's length and characters are checked when it's parsed, so a
+// set fingerprint is always well-formed.
+func (p Person) Validate() error {
+	if p.Email == "" {
+		return fmt.Errorf("missing email address")
+	}
+	if address, err := mail.ParseAddress(p.Email); err != nil || address.Address != p.Email {
+		return fmt.Errorf("invalid email address: %s", p.Email)
+	}
+	if !p.Fingerprint.IsSet() {
+		return fmt.Errorf("missing fingerprint")
+	}
+	return nil
 }
 
 func (p Person) conflicts(other Person) bool {
@@ -400,6 +713,21 @@ type RequestToJoinTeam struct {
 	Fingerprint fpr.Fingerprint
 	// RequestAt is the moment at which the local client made the request
 	RequestedAt time.Time
+	// InvitationToken is set if the request was made with an invitation from `fk team invite`
+	InvitationToken string
+}
+
+// DefaultRequestExpiryDuration is how long a request to join a team lasts unless the
+// request_expiry setting says otherwise.
+const DefaultRequestExpiryDuration = 7 * 24 * time.Hour
+
+// RequestExpiryDuration is how long a request to join a team lasts before the admin has to have
+// approved it. fk overrides it from the request_expiry setting.
+var RequestExpiryDuration = DefaultRequestExpiryDuration
+
+// IsExpired returns true if the request was made more than RequestExpiryDuration before now.
+func (r RequestToJoinTeam) IsExpired(now time.Time) bool {
+	return now.Sub(r.RequestedAt) > RequestExpiryDuration
 }
 
 var (
@@ -430,4 +758,32 @@ var (
 	ErrPersonWouldBePromotedToAdmin = fmt.Errorf(
 		"existing team member would be promoted to team admin",
 	)
+
+	// ErrNoRoster means there's no roster for the team, for example because it hasn't been
+	// fetched yet. This is different to a roster which exists but can't be read or is invalid.
+	ErrNoRoster = fmt.Errorf("no roster for team")
+
+	// ErrSignatureNotFound means the roster isn't signed: the signature is empty or doesn't
+	// contain an ASCII armored signature.
+	ErrSignatureNotFound = fmt.Errorf("roster signature not found")
+
+	// ErrSignatureInvalid means the roster has a signature but it doesn't verify, for example
+	// because it wasn't made by a team admin, or the roster or signature has been corrupted.
+	ErrSignatureInvalid = fmt.Errorf("roster signature invalid")
+
+	// ErrSignatureTooOld means the roster's signature verifies, but it was made longer ago than
+	// the maximum signature age or before the previous roster's signature, so the roster may be
+	// a stale copy being replayed.
+	ErrSignatureTooOld = fmt.Errorf("roster signature too old")
+
+	// ErrNotEnoughSignatures means some of the roster's signatures verify, but fewer than the
+	// number of admin signatures required.
+	ErrNotEnoughSignatures = fmt.Errorf("not enough admin signatures on roster")
+
+	// ErrRosterHashMismatch means the saved roster doesn't match the hash written when it was
+	// saved, so it's been changed on disk outside of Fluidkeys.
+	ErrRosterHashMismatch = fmt.Errorf("saved roster doesn't match its hash")
+
+	// ErrRosterHashMissing means there's no hash of the saved roster to check it against.
+	ErrRosterHashMissing = fmt.Errorf("saved roster has no hash")
 )
@@ -18,12 +18,17 @@
 package team
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/packet"
 	"github.com/fluidkeys/fluidkeys/assert"
 	"github.com/fluidkeys/fluidkeys/exampledata"
 	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
@@ -92,6 +97,68 @@ func TestLoadTeams(t *testing.T) {
 
 }
 
+func TestLoadFromDirectory(t *testing.T) {
+	person := Person{
+		Email:       "test2@example.com",
+		Fingerprint: exampledata.ExampleFingerprint2,
+		IsAdmin:     true,
+	}
+
+	fluidkeysDir := testhelpers.Maketemp(t)
+	teamsDir := filepath.Join(fluidkeysDir, "teams")
+
+	expectedUUIDs := []uuid.UUID{}
+	teamDirs := []string{}
+	for i := 0; i < 5; i++ {
+		team := Team{
+			Name:   fmt.Sprintf("Team %d", i),
+			UUID:   uuid.Must(uuid.NewV4()),
+			People: []Person{person},
+		}
+		saveTeam(t, &team, fluidkeysDir)
+		expectedUUIDs = append(expectedUUIDs, team.UUID)
+
+		teamDir, err := Directory(team, fluidkeysDir)
+		assert.NoError(t, err)
+		teamDirs = append(teamDirs, teamDir)
+	}
+
+	t.Run("loads every team", func(t *testing.T) {
+		teams, err := LoadFromDirectory(teamsDir)
+		assert.NoError(t, err)
+
+		gotUUIDs := []uuid.UUID{}
+		for _, team := range teams {
+			gotUUIDs = append(gotUUIDs, team.UUID)
+		}
+		assert.Equal(t, expectedUUIDs, gotUUIDs) // team-0-..., team-1-... in directory order
+	})
+
+	t.Run("returns the other teams if one fails to load", func(t *testing.T) {
+		brokenDir := teamDirs[2]
+		assert.NoError(t, ioutil.WriteFile(
+			filepath.Join(brokenDir, rosterFilename), []byte("not toml ["), 0600))
+
+		teams, err := LoadFromDirectory(teamsDir)
+		assert.Equal(t, 4, len(teams))
+
+		loadErrors, ok := err.(LoadErrors)
+		if !ok {
+			t.Fatalf("expected LoadErrors, got %v", err)
+		}
+		assert.Equal(t, 1, len(loadErrors))
+		assert.Equal(t, true, strings.Contains(loadErrors.Error(), brokenDir))
+
+		t.Run("and LoadTeams returns the other teams with the error", func(t *testing.T) {
+			teams, err := LoadTeams(fluidkeysDir)
+			assert.Equal(t, 4, len(teams))
+
+			_, ok := err.(LoadErrors)
+			assert.Equal(t, true, ok)
+		})
+	})
+}
+
 func TestLoad(t *testing.T) {
 	roster := `# Fluidkeys CIC team roster. Everyone in the team has a copy of this file.
 #
@@ -151,6 +218,188 @@ fingerprint = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
 	assert.Equal(t, signature, team.signature)
 }
 
+func TestLoadRejectsInvalidPeople(t *testing.T) {
+	header := `uuid = "38be2a70-23d8-11e9-bafd-7f97f2e239a3"
+name = "Fluidkeys CIC"
+
+[[person]]
+email = "paul@fluidkeys.com"
+fingerprint = "B79F 0840 DEF1 2EBB A72F  F72D 7327 A44C 2157 A758"
+is_admin = true
+`
+	tests := []struct {
+		name          string
+		badPerson     string
+		expectedError string
+	}{
+		{
+			"missing email",
+			`
+[[person]]
+fingerprint = "E63A F0E7 4EB5 DE3F B72D  C981 C991 7093 18EC BDE7"
+`,
+			"error validating team: person on line 9: missing email address",
+		},
+		{
+			"invalid email",
+			`
+[[person]]
+email = "ian at fluidkeys.com"
+fingerprint = "E63A F0E7 4EB5 DE3F B72D  C981 C991 7093 18EC BDE7"
+`,
+			"error validating team: person on line 9: invalid email address: ian at fluidkeys.com",
+		},
+		{
+			"email with a display name",
+			`
+[[person]]
+email = "Ian <ian@fluidkeys.com>"
+fingerprint = "E63A F0E7 4EB5 DE3F B72D  C981 C991 7093 18EC BDE7"
+`,
+			"error validating team: person on line 9: " +
+				"invalid email address: Ian <ian@fluidkeys.com>",
+		},
+		{
+			"missing fingerprint",
+			`
+  [[ person ]]
+email = "ian@fluidkeys.com"
+`,
+			"error validating team: person on line 9: missing fingerprint",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Load(header+test.badPerson, "signature")
+			assert.GotError(t, err)
+			assert.Equal(t, test.expectedError, err.Error())
+		})
+	}
+
+	t.Run("invalid fingerprint is rejected when parsing", func(t *testing.T) {
+		_, err := Load(header+`
+[[person]]
+email = "ian@fluidkeys.com"
+fingerprint = "E63A F0E7 NOT HEX"
+`, "signature")
+		assert.GotError(t, err)
+	})
+
+	t.Run("falls back to the person's position for inline tables", func(t *testing.T) {
+		_, err := Load(`uuid = "38be2a70-23d8-11e9-bafd-7f97f2e239a3"
+name = "Fluidkeys CIC"
+person = [
+	{email = "paul@fluidkeys.com", fingerprint = "B79F0840DEF12EBBA72FF72D7327A44C2157A758"},
+	{email = "", fingerprint = "E63AF0E74EB5DE3FB72DC981C991709318ECBDE7"},
+]
+`, "signature")
+		assert.GotError(t, err)
+		assert.Equal(t, "error validating team: person 2: missing email address", err.Error())
+	})
+}
+
+func TestPersonValidate(t *testing.T) {
+	fingerprint := fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA")
+
+	t.Run("valid person", func(t *testing.T) {
+		assert.NoError(t, Person{Email: "jane@example.com", Fingerprint: fingerprint}.Validate())
+	})
+
+	invalidTests := []struct {
+		name          string
+		person        Person
+		expectedError error
+	}{
+		{
+			"missing email",
+			Person{Fingerprint: fingerprint},
+			fmt.Errorf("missing email address"),
+		},
+		{
+			"email without @",
+			Person{Email: "jane", Fingerprint: fingerprint},
+			fmt.Errorf("invalid email address: jane"),
+		},
+		{
+			"email with trailing junk",
+			Person{Email: "jane@example.com>", Fingerprint: fingerprint},
+			fmt.Errorf("invalid email address: jane@example.com>"),
+		},
+		{
+			"missing fingerprint",
+			Person{Email: "jane@example.com"},
+			fmt.Errorf("missing fingerprint"),
+		},
+	}
+	for _, test := range invalidTests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedError, test.person.Validate())
+		})
+	}
+}
+
+func TestPersonKeyURL(t *testing.T) {
+	person := Person{
+		Email:       "test4@example.com",
+		Fingerprint: fpr.MustParse("BB3C 44BF 188D 56E6 35F4  A092 F73D 2F05 33D7 F9D6"),
+	}
+	expected := "https://api.fluidkeys.com/v1/key/BB3C44BF188D56E635F4A092F73D2F0533D7F9D6.asc"
+
+	t.Run("with trailing slash on base URL", func(t *testing.T) {
+		assert.Equal(t, expected, person.KeyURL("https://api.fluidkeys.com/v1/"))
+	})
+
+	t.Run("without trailing slash on base URL", func(t *testing.T) {
+		assert.Equal(t, expected, person.KeyURL("https://api.fluidkeys.com/v1"))
+	})
+
+	t.Run("with a custom base URL", func(t *testing.T) {
+		assert.Equal(t,
+			"http://localhost:4747/v1/key/BB3C44BF188D56E635F4A092F73D2F0533D7F9D6.asc",
+			person.KeyURL("http://localhost:4747/v1/"),
+		)
+	})
+}
+
+func TestPersonString(t *testing.T) {
+	fingerprint := fpr.MustParse("BB3C 44BF 188D 56E6 35F4  A092 F73D 2F05 33D7 F9D6")
+
+	t.Run("with email and fingerprint", func(t *testing.T) {
+		person := Person{Email: "test4@example.com", Fingerprint: fingerprint, IsAdmin: true}
+
+		assert.Equal(t, "test4@example.com [F73D2F0533D7F9D6]", person.String())
+		assert.Equal(t,
+			"test4@example.com [BB3C 44BF 188D 56E6 35F4  A092 F73D 2F05 33D7 F9D6]",
+			person.LongString(),
+		)
+	})
+
+	t.Run("is used by fmt", func(t *testing.T) {
+		person := Person{Email: "test4@example.com", Fingerprint: fingerprint}
+		assert.Equal(t, "added test4@example.com [F73D2F0533D7F9D6]", fmt.Sprintf("added %s", person))
+	})
+
+	t.Run("without a fingerprint", func(t *testing.T) {
+		person := Person{Email: "test4@example.com"}
+
+		assert.Equal(t, "test4@example.com", person.String())
+		assert.Equal(t, "test4@example.com", person.LongString())
+	})
+
+	t.Run("without an email", func(t *testing.T) {
+		person := Person{Fingerprint: fingerprint}
+
+		assert.Equal(t, "[F73D2F0533D7F9D6]", person.String())
+		assert.Equal(t, "[BB3C 44BF 188D 56E6 35F4  A092 F73D 2F05 33D7 F9D6]", person.LongString())
+	})
+
+	t.Run("with neither", func(t *testing.T) {
+		assert.Equal(t, "", Person{}.String())
+		assert.Equal(t, "", Person{}.LongString())
+	})
+}
+
 func TestFindTeamSubdirectories(t *testing.T) {
 
 	tmpdir := testhelpers.Maketemp(t)
@@ -192,10 +441,80 @@ func TestRoster(t *testing.T) {
 			signature: "fake signature",
 		}
 
-		gotRoster, gotSig := testTeam.Roster()
+		gotRoster, gotSig, err := testTeam.Roster()
+		assert.NoError(t, err)
 		assert.Equal(t, testTeam.roster, gotRoster)
 		assert.Equal(t, testTeam.signature, gotSig)
 	})
+
+	t.Run("returns ErrNoRoster if roster hasn't been set", func(t *testing.T) {
+		_, _, err := Team{}.Roster()
+		assert.Equal(t, ErrNoRoster, err)
+	})
+}
+
+func TestLoadSavedRoster(t *testing.T) {
+	key, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(exampledata.ExamplePrivateKey4, "test4")
+	assert.NoError(t, err)
+
+	myTeam := Team{
+		UUID: uuid.Must(uuid.NewV4()),
+		Name: "Kiffix",
+		People: []Person{
+			{Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4, IsAdmin: true},
+		},
+	}
+	assert.NoError(t, myTeam.UpdateRoster(key))
+	roster, signature, err := myTeam.Roster()
+	assert.NoError(t, err)
+
+	t.Run("with a saved roster", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+		assert.NoError(t, rosterSaver.Save(roster, signature))
+
+		got, err := LoadSavedRoster(rosterSaver.Directory)
+		assert.NoError(t, err)
+		assert.Equal(t, myTeam.People, got.People)
+	})
+
+	t.Run("returns ErrNoRoster if the directory doesn't exist", func(t *testing.T) {
+		_, err := LoadSavedRoster(filepath.Join(os.TempDir(), "fktest-does-not-exist"))
+		assert.Equal(t, ErrNoRoster, err)
+	})
+
+	t.Run("returns ErrNoRoster if the roster file doesn't exist", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+
+		_, err := LoadSavedRoster(rosterSaver.Directory)
+		assert.Equal(t, ErrNoRoster, err)
+	})
+
+	t.Run("returns a different error for a corrupt roster", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+		assert.NoError(t, rosterSaver.Save("this isn't [valid TOML", signature))
+
+		_, err := LoadSavedRoster(rosterSaver.Directory)
+		assert.GotError(t, err)
+		if err == ErrNoRoster {
+			t.Fatalf("expected an error other than ErrNoRoster")
+		}
+	})
+
+	t.Run("returns a different error if the signature is missing", func(t *testing.T) {
+		rosterSaver := makeRosterSaveInTmpDirectory(t)
+		defer os.RemoveAll(rosterSaver.Directory)
+		assert.NoError(t, rosterSaver.Save(roster, signature))
+		assert.NoError(t, os.Remove(filepath.Join(rosterSaver.Directory, signatureFilename)))
+
+		_, err := LoadSavedRoster(rosterSaver.Directory)
+		assert.GotError(t, err)
+		if err == ErrNoRoster {
+			t.Fatalf("expected an error other than ErrNoRoster")
+		}
+	})
 }
 
 func TestAdmins(t *testing.T) {
@@ -265,7 +584,8 @@ name = "Kiffix"
 
 		t.Run("sets a valid signature", func(t *testing.T) {
 			err := VerifyRoster(
-				validTeam.roster, validTeam.signature, []*pgpkey.PgpKey{signingKey},
+				validTeam.roster, []string{validTeam.signature}, []*pgpkey.PgpKey{signingKey},
+				0, time.Now(),
 			)
 
 			assert.NoError(t, err)
@@ -376,6 +696,13 @@ func TestValidate(t *testing.T) {
 			"AAAA BBBB AAAA BBBB AAAA  AAAA BBBB AAAA BBBB AAAA"), err)
 	})
 
+	t.Run("with no members", func(t *testing.T) {
+		team := Team{Name: "Kiffix", UUID: uuid.Must(uuid.NewV4())}
+
+		err := team.Validate()
+		assert.Equal(t, fmt.Errorf("team has no members"), err)
+	})
+
 	t.Run("with no admins", func(t *testing.T) {
 		team := Team{
 			Name: "Kiffix",
@@ -406,27 +733,180 @@ func TestVerifyRoster(t *testing.T) {
 	assert.NoError(t, err)
 
 	roster := "hello"
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
 
 	goodSignature, err := key.MakeArmoredDetachedSignature([]byte(roster))
 	assert.NoError(t, err)
 
 	t.Run("verifies a good signature", func(t *testing.T) {
-		err := VerifyRoster(roster, goodSignature, []*pgpkey.PgpKey{key})
+		err := VerifyRoster(roster, []string{goodSignature}, []*pgpkey.PgpKey{key}, 0, now)
 		assert.NoError(t, err)
 	})
 
-	t.Run("returns an error for a bad signature", func(t *testing.T) {
-		err := VerifyRoster(roster+"tampered", goodSignature, []*pgpkey.PgpKey{key})
-		assert.GotError(t, err)
-		assert.Equal(t, "openpgp: invalid signature: hash tag doesn't match", err.Error())
+	t.Run("returns ErrSignatureInvalid if the roster has been changed", func(t *testing.T) {
+		err := VerifyRoster(
+			roster+"tampered", []string{goodSignature}, []*pgpkey.PgpKey{key}, 0, now)
+		assert.Equal(t, ErrSignatureInvalid, err)
 	})
 
-	t.Run("rejects empty signature", func(t *testing.T) {
-		err := VerifyRoster(roster, "", []*pgpkey.PgpKey{key})
-		assert.GotError(t, err)
-		assert.Equal(t, fmt.Errorf("empty signature"), err)
+	t.Run("returns ErrSignatureNotFound for an unsigned roster", func(t *testing.T) {
+		for _, signature := range []string{"", "\n", "not a signature"} {
+			err := VerifyRoster(roster, []string{signature}, []*pgpkey.PgpKey{key}, 0, now)
+			assert.Equal(t, ErrSignatureNotFound, err)
+		}
+	})
+
+	t.Run("returns ErrSignatureInvalid for a roster signed by a non-admin", func(t *testing.T) {
+		notAdminKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+			exampledata.ExamplePrivateKey3, "test3")
+		assert.NoError(t, err)
+
+		notAdminSignature, err := notAdminKey.MakeArmoredDetachedSignature([]byte(roster))
+		assert.NoError(t, err)
+
+		err = VerifyRoster(
+			roster, []string{notAdminSignature}, []*pgpkey.PgpKey{key}, 0, now)
+		assert.Equal(t, ErrSignatureInvalid, err)
+	})
+
+	t.Run("returns ErrSignatureInvalid for a corrupted signature", func(t *testing.T) {
+		lines := strings.Split(goodSignature, "\n")
+		for i, line := range lines {
+			// corrupt the first line of the base64 body
+			if i > 0 && lines[i-1] == "" && len(line) > 10 {
+				lines[i] = "AAAA" + line[4:]
+				break
+			}
+		}
+		corruptedSignature := strings.Join(lines, "\n")
+		assert.Equal(t, false, corruptedSignature == goodSignature)
+
+		err := VerifyRoster(
+			roster, []string{corruptedSignature}, []*pgpkey.PgpKey{key}, 0, now)
+		assert.Equal(t, ErrSignatureInvalid, err)
+	})
+
+	t.Run("with a maximum signature age", func(t *testing.T) {
+		maxAge := 90 * 24 * time.Hour
+		signedAt := now.Add(-maxAge)
+		signature := makeSignatureAt(t, key, roster, signedAt)
+
+		t.Run("accepts a signature exactly on the limit", func(t *testing.T) {
+			err := VerifyRoster(roster, []string{signature}, []*pgpkey.PgpKey{key},
+				maxAge, now)
+			assert.NoError(t, err)
+		})
+
+		t.Run("returns ErrSignatureTooOld a second past the limit", func(t *testing.T) {
+			err := VerifyRoster(roster, []string{signature}, []*pgpkey.PgpKey{key},
+				maxAge, now.Add(time.Second))
+			assert.Equal(t, ErrSignatureTooOld, err)
+		})
+
+		t.Run("accepts any age if the maximum is 0", func(t *testing.T) {
+			err := VerifyRoster(roster, []string{signature}, []*pgpkey.PgpKey{key},
+				0, now.Add(10*365*24*time.Hour))
+			assert.NoError(t, err)
+		})
+
+		t.Run("returns ErrSignatureInvalid rather than too old for a tampered roster",
+			func(t *testing.T) {
+				err := VerifyRoster(
+					roster+"tampered", []string{signature}, []*pgpkey.PgpKey{key},
+					maxAge, now.Add(time.Second))
+				assert.Equal(t, ErrSignatureInvalid, err)
+			})
+	})
+
+	t.Run("requiring a signature newer than the previous roster's", func(t *testing.T) {
+		previousSignature := makeSignatureAt(t, key, roster, now.Add(-time.Hour))
+
+		t.Run("accepts a signature made after the previous one", func(t *testing.T) {
+			signature := makeSignatureAt(t, key, roster, now)
+			err := VerifyRoster(roster, []string{signature}, []*pgpkey.PgpKey{key}, 0, now,
+				RequireNewerThan(previousSignature))
+			assert.NoError(t, err)
+		})
+
+		t.Run("accepts the previous signature itself", func(t *testing.T) {
+			err := VerifyRoster(roster, []string{previousSignature}, []*pgpkey.PgpKey{key}, 0,
+				now, RequireNewerThan(previousSignature))
+			assert.NoError(t, err)
+		})
+
+		t.Run("returns ErrSignatureTooOld for a signature made before it", func(t *testing.T) {
+			signature := makeSignatureAt(t, key, roster, now.Add(-2*time.Hour))
+			err := VerifyRoster(roster, []string{signature}, []*pgpkey.PgpKey{key}, 0, now,
+				RequireNewerThan(previousSignature))
+			assert.Equal(t, ErrSignatureTooOld, err)
+		})
+	})
+
+	t.Run("with multiple signatures", func(t *testing.T) {
+		otherKey, err := pgpkey.LoadFromArmoredEncryptedPrivateKey(
+			exampledata.ExamplePrivateKey3, "test3")
+		assert.NoError(t, err)
+
+		otherSignature, err := otherKey.MakeArmoredDetachedSignature([]byte(roster))
+		assert.NoError(t, err)
+
+		bothSignatures := []string{goodSignature, otherSignature}
+		bothKeys := []*pgpkey.PgpKey{key, otherKey}
+
+		t.Run("1 of 2 valid signatures is accepted by default", func(t *testing.T) {
+			err := VerifyRoster(roster, bothSignatures, []*pgpkey.PgpKey{key}, 0, now)
+			assert.NoError(t, err)
+		})
+
+		t.Run("1 of 2 valid signatures returns ErrNotEnoughSignatures if 2 are required",
+			func(t *testing.T) {
+				err := VerifyRoster(roster, bothSignatures, []*pgpkey.PgpKey{key}, 0, now,
+					RequireAdminSignatures(2))
+				assert.Equal(t, ErrNotEnoughSignatures, err)
+			})
+
+		t.Run("2 of 2 valid signatures meets a quorum of 2", func(t *testing.T) {
+			err := VerifyRoster(roster, bothSignatures, bothKeys, 0, now,
+				RequireAdminSignatures(2))
+			assert.NoError(t, err)
+		})
+
+		t.Run("0 of 2 valid signatures returns ErrSignatureInvalid", func(t *testing.T) {
+			err := VerifyRoster(roster+"tampered", bothSignatures, bothKeys, 0, now)
+			assert.Equal(t, ErrSignatureInvalid, err)
+		})
+
+		t.Run("the same admin signing twice only counts once", func(t *testing.T) {
+			err := VerifyRoster(roster, []string{goodSignature, goodSignature}, bothKeys, 0, now,
+				RequireAdminSignatures(2))
+			assert.Equal(t, ErrNotEnoughSignatures, err)
+		})
+
+		t.Run("returns ErrSignatureNotFound for no signatures", func(t *testing.T) {
+			err := VerifyRoster(roster, []string{}, bothKeys, 0, now)
+			assert.Equal(t, ErrSignatureNotFound, err)
+		})
+
+		t.Run("requiring 0 signatures still needs one valid signature", func(t *testing.T) {
+			err := VerifyRoster(roster+"tampered", bothSignatures, bothKeys, 0, now,
+				RequireAdminSignatures(0))
+			assert.Equal(t, ErrSignatureInvalid, err)
+
+			err = VerifyRoster(roster, []string{}, bothKeys, 0, now, RequireAdminSignatures(-1))
+			assert.Equal(t, ErrSignatureNotFound, err)
+		})
 	})
+}
+
+// makeSignatureAt returns an armored detached signature of roster, made by key at signedAt
+func makeSignatureAt(t *testing.T, key *pgpkey.PgpKey, roster string, signedAt time.Time) string {
+	t.Helper()
+	buf := bytes.NewBuffer(nil)
+	config := &packet.Config{Time: func() time.Time { return signedAt }}
 
+	err := openpgp.ArmoredDetachSign(buf, &key.Entity, strings.NewReader(roster), config)
+	assert.NoError(t, err)
+	return buf.String()
 }
 
 func TestIsAdmin(t *testing.T) {
@@ -492,6 +972,36 @@ func TestGetPersonForFingerprint(t *testing.T) {
 	})
 }
 
+func TestGetPersonForEmail(t *testing.T) {
+	personOne := Person{
+		Email:       "test@example.com",
+		Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+	}
+	personTwo := Person{
+		Email:       "another@example.com",
+		Fingerprint: fpr.MustParse("CCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDD"),
+	}
+
+	team := Team{
+		Name:   "Kiffix",
+		UUID:   uuid.Must(uuid.NewV4()),
+		People: []Person{personOne, personTwo},
+	}
+
+	t.Run("with a team member with matching email, ignoring case", func(t *testing.T) {
+		got, err := team.GetPersonForEmail("Another@Example.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &personTwo, got)
+	})
+
+	t.Run("with no matching emails", func(t *testing.T) {
+		_, err := team.GetPersonForEmail("nobody@example.com")
+
+		assert.Equal(t, fmt.Errorf("person not found"), err)
+	})
+}
+
 func TestGetUpsertPersonWarnings(t *testing.T) {
 
 	var tests = []struct {
@@ -619,6 +1129,37 @@ func TestGetUpsertPersonWarnings(t *testing.T) {
 				},
 			},
 		},
+		{
+			"adding a non admin with a new key for an admin's email",
+			Person{
+				Email:       "person@example.com",
+				Fingerprint: fpr.MustParse("CCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDD"),
+				IsAdmin:     false,
+			},
+			Team{
+				UUID: uuid.Must(uuid.FromString("8e26e4df0d474f7f9a07a37b2aa92104")),
+				Name: "Kiffix",
+				People: []Person{
+					{
+						Email:       "person@example.com",
+						Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+						IsAdmin:     true,
+					},
+				},
+			},
+			ErrKeyWouldBeUpdated,
+			Team{
+				UUID: uuid.Must(uuid.FromString("8e26e4df0d474f7f9a07a37b2aa92104")),
+				Name: "Kiffix",
+				People: []Person{
+					{
+						Email:       "person@example.com",
+						Fingerprint: fpr.MustParse("CCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDDCCCCDDDD"),
+						IsAdmin:     false,
+					},
+				},
+			},
+		},
 		{
 			"adding a non admin who already is in roster as an admin",
 			Person{
@@ -780,6 +1321,25 @@ func TestSubDirectory(t *testing.T) {
 	}
 }
 
+func TestRequestToJoinTeamIsExpired(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("with the default expiry", func(t *testing.T) {
+		request := RequestToJoinTeam{RequestedAt: now.Add(-7 * 24 * time.Hour)}
+		assert.Equal(t, false, request.IsExpired(now))
+		assert.Equal(t, true, request.IsExpired(now.Add(time.Second)))
+	})
+
+	t.Run("with a short expiry", func(t *testing.T) {
+		defer func() { RequestExpiryDuration = DefaultRequestExpiryDuration }()
+		RequestExpiryDuration = time.Minute
+
+		request := RequestToJoinTeam{RequestedAt: now}
+		assert.Equal(t, false, request.IsExpired(now.Add(time.Minute)))
+		assert.Equal(t, true, request.IsExpired(now.Add(time.Minute+time.Second)))
+	})
+}
+
 func saveTeam(t *testing.T, theTeam *Team, fluidkeysDirectory string) {
 	teamSubdir, err := Directory(*theTeam, fluidkeysDirectory)
 	assert.NoError(t, err)
@@ -0,0 +1,162 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+)
+
+// ValidateUpdate returns an error if updating the team from `before` to `after`, signed by the
+// key with the given fingerprint, is not allowed. revokedFingerprints are keys known to have been
+// revoked, whose roster entries may only be removed.
+func ValidateUpdate(before *Team, after *Team, signerFingerprint fpr.Fingerprint,
+	revokedFingerprints []fpr.Fingerprint) error {
+
+	if err := after.Validate(); err != nil {
+		return err
+	}
+
+	if before.UUID != after.UUID {
+		return fmt.Errorf("can't change team UUID from %s to %s", before.UUID, after.UUID)
+	}
+
+	if !sameParentTeam(before, after) {
+		return fmt.Errorf("can't change parent team UUID from %s to %s",
+			formatParentTeamUUID(before), formatParentTeamUUID(after))
+	}
+
+	if err := validateSigningKeyInRosterAsAdmin(after, signerFingerprint); err != nil {
+		return err
+	}
+
+	if err := validateNoEmailChanges(before, after); err != nil {
+		return err
+	}
+
+	if err := ValidateNoChangesToRevokedMembers(before, after, revokedFingerprints); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateSigningKeyInRosterAsAdmin returns an error unless the signing key is an admin in the
+// updated roster. Otherwise an admin could upload a roster that removes or demotes themselves,
+// leaving them unable to sign the next update.
+func validateSigningKeyInRosterAsAdmin(after *Team, signerFingerprint fpr.Fingerprint) error {
+	for _, person := range after.People {
+		if person.Fingerprint != signerFingerprint {
+			continue
+		}
+		if !person.IsAdmin {
+			return fmt.Errorf("signing key %s isn't an admin in the roster", signerFingerprint)
+		}
+		return nil
+	}
+	return fmt.Errorf("signing key %s isn't in the roster", signerFingerprint)
+}
+
+// validateNoEmailChanges returns an error if any key that's in both `before` and `after` has a
+// different email address. Changing the email for a key could redirect secrets meant for one
+// person to someone else.
+func validateNoEmailChanges(before *Team, after *Team) error {
+	emailsBefore := map[fpr.Fingerprint]string{}
+	for _, person := range before.People {
+		emailsBefore[person.Fingerprint] = person.Email
+	}
+
+	for _, person := range after.People {
+		emailBefore, inBefore := emailsBefore[person.Fingerprint]
+		if inBefore && !person.emailMatches(Person{Email: emailBefore}) {
+			return fmt.Errorf("can't change email for key %s from %s to %s",
+				person.Fingerprint, emailBefore, person.Email)
+		}
+	}
+	return nil
+}
+
+// ValidateNoChangesToRevokedMembers returns an error if the entry for any revoked key is added or
+// changed in `after`. A revoked member can only be removed from the team: anything else, like
+// making them an admin, would carry on trusting a key that's no longer safe to use.
+// It's only as good as revokedFingerprints: keys whose revocation we haven't seen aren't checked.
+func ValidateNoChangesToRevokedMembers(before *Team, after *Team,
+	revokedFingerprints []fpr.Fingerprint) error {
+
+	revoked := map[fpr.Fingerprint]bool{}
+	for _, fingerprint := range revokedFingerprints {
+		revoked[fingerprint] = true
+	}
+
+	peopleBefore := map[fpr.Fingerprint]Person{}
+	for _, person := range before.People {
+		peopleBefore[person.Fingerprint] = person
+	}
+
+	for _, person := range after.People {
+		if !revoked[person.Fingerprint] {
+			continue
+		}
+
+		personBefore, inBefore := peopleBefore[person.Fingerprint]
+		if !inBefore {
+			return fmt.Errorf("can't add %s: key %s has been revoked",
+				person.Email, person.Fingerprint)
+		}
+		if person != personBefore {
+			return fmt.Errorf("can't change %s: key %s has been revoked, it can only be removed",
+				personBefore.Email, person.Fingerprint)
+		}
+	}
+	return nil
+}
+
+// sameParentTeam returns true if before and after have the same parent team, or neither has one
+func sameParentTeam(before *Team, after *Team) bool {
+	if before.ParentTeamUUID == nil || after.ParentTeamUUID == nil {
+		return before.ParentTeamUUID == nil && after.ParentTeamUUID == nil
+	}
+	return *before.ParentTeamUUID == *after.ParentTeamUUID
+}
+
+func formatParentTeamUUID(t *Team) string {
+	if t.ParentTeamUUID == nil {
+		return "none"
+	}
+	return t.ParentTeamUUID.String()
+}
+
+// ValidateNewTeam returns an error if the roster for `after`, a team whose roster hasn't been
+// saved before, can't safely be saved alongside the already known teams.
+func ValidateNewTeam(knownTeams []Team, after *Team) error {
+	return validateNoTeamUUIDCollision(knownTeams, after)
+}
+
+// validateNoTeamUUIDCollision returns an error if one of the known teams already has after's
+// UUID. Otherwise someone could create a new team reusing the UUID of an existing one and get
+// their roster saved over the real team's. Updates to a known team's roster must go through
+// ValidateUpdate instead, which checks them against the saved roster.
+func validateNoTeamUUIDCollision(knownTeams []Team, after *Team) error {
+	for _, known := range knownTeams {
+		if known.UUID == after.UUID {
+			return fmt.Errorf("team UUID %s is already used by %s", after.UUID, known.Name)
+		}
+	}
+	return nil
+}
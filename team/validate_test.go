@@ -0,0 +1,268 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/gofrs/uuid"
+)
+
+func TestValidateUpdate(t *testing.T) {
+	teamUUID := uuid.Must(uuid.FromString("74bb40b4-3510-11e9-968e-53c38df634be"))
+	alice := Person{
+		Email:       "alice@example.com",
+		Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+		IsAdmin:     true,
+	}
+	bob := Person{
+		Email:       "bob@example.com",
+		Fingerprint: fpr.MustParse("BBBBAAAABBBBAAAABBBBBBBBAAAABBBBAAAABBBB"),
+		IsAdmin:     false,
+	}
+
+	before := Team{UUID: teamUUID, Name: "Kiffix", People: []Person{alice}}
+
+	t.Run("allows adding a person", func(t *testing.T) {
+		after := Team{UUID: teamUUID, Name: "Kiffix", People: []Person{alice, bob}}
+
+		err := ValidateUpdate(&before, &after, alice.Fingerprint, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects an invalid team", func(t *testing.T) {
+		after := Team{UUID: teamUUID, Name: "Kiffix", People: []Person{bob}}
+
+		err := ValidateUpdate(&before, &after, alice.Fingerprint, nil)
+		assert.Equal(t, fmt.Errorf("team has no administrators"), err)
+	})
+
+	t.Run("rejects a changed UUID", func(t *testing.T) {
+		otherUUID := uuid.Must(uuid.FromString("c4f0d4a8-ef9d-11e9-8e1b-93f9f3b48b10"))
+		after := Team{UUID: otherUUID, Name: "Kiffix", People: []Person{alice}}
+
+		err := ValidateUpdate(&before, &after, alice.Fingerprint, nil)
+		assert.Equal(t, fmt.Errorf(
+			"can't change team UUID from 74bb40b4-3510-11e9-968e-53c38df634be to "+
+				"c4f0d4a8-ef9d-11e9-8e1b-93f9f3b48b10"), err)
+	})
+
+	t.Run("rejects an update signed by someone who isn't an admin", func(t *testing.T) {
+		after := Team{UUID: teamUUID, Name: "Kiffix", People: []Person{alice, bob}}
+
+		err := ValidateUpdate(&before, &after, bob.Fingerprint, nil)
+		assert.GotError(t, err)
+	})
+
+	t.Run("rejects promoting a revoked member", func(t *testing.T) {
+		before := Team{UUID: teamUUID, Name: "Kiffix", People: []Person{alice, bob}}
+		after := Team{UUID: teamUUID, Name: "Kiffix", People: []Person{
+			alice, {Email: bob.Email, Fingerprint: bob.Fingerprint, IsAdmin: true},
+		}}
+
+		err := ValidateUpdate(&before, &after, alice.Fingerprint, []fpr.Fingerprint{bob.Fingerprint})
+		assert.GotError(t, err)
+	})
+}
+
+func TestValidateSigningKeyInRosterAsAdmin(t *testing.T) {
+	alice := Person{
+		Email:       "alice@example.com",
+		Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+		IsAdmin:     true,
+	}
+	bob := Person{
+		Email:       "bob@example.com",
+		Fingerprint: fpr.MustParse("BBBBAAAABBBBAAAABBBBBBBBAAAABBBBAAAABBBB"),
+		IsAdmin:     false,
+	}
+	carolFingerprint := fpr.MustParse("CCCCAAAABBBBAAAABBBBBBBBAAAABBBBAAAACCCC")
+
+	after := Team{People: []Person{alice, bob}}
+
+	t.Run("allows a signer who's an admin", func(t *testing.T) {
+		assert.NoError(t, validateSigningKeyInRosterAsAdmin(&after, alice.Fingerprint))
+	})
+
+	t.Run("rejects a signer who's a member but not an admin", func(t *testing.T) {
+		err := validateSigningKeyInRosterAsAdmin(&after, bob.Fingerprint)
+		assert.Equal(t, fmt.Errorf("signing key "+
+			"BBBB AAAA BBBB AAAA BBBB  BBBB AAAA BBBB AAAA BBBB isn't an admin in the roster"), err)
+	})
+
+	t.Run("rejects a signer who isn't in the roster", func(t *testing.T) {
+		err := validateSigningKeyInRosterAsAdmin(&after, carolFingerprint)
+		assert.Equal(t, fmt.Errorf("signing key "+
+			"CCCC AAAA BBBB AAAA BBBB  BBBB AAAA BBBB AAAA CCCC isn't in the roster"), err)
+	})
+
+	t.Run("rejects an admin who removed themselves", func(t *testing.T) {
+		withoutAlice := Team{People: []Person{bob}}
+		assert.GotError(t, validateSigningKeyInRosterAsAdmin(&withoutAlice, alice.Fingerprint))
+	})
+}
+
+func TestValidateNoEmailChanges(t *testing.T) {
+	alice := Person{
+		Email:       "alice@example.com",
+		Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+		IsAdmin:     true,
+	}
+	bob := Person{
+		Email:       "bob@example.com",
+		Fingerprint: fpr.MustParse("BBBBAAAABBBBAAAABBBBBBBBAAAABBBBAAAABBBB"),
+	}
+	bobWithNewEmail := Person{
+		Email:       "robert@example.com",
+		Fingerprint: bob.Fingerprint,
+	}
+
+	before := Team{People: []Person{alice, bob}}
+
+	t.Run("allows unchanged emails", func(t *testing.T) {
+		after := Team{People: []Person{alice, bob}}
+		assert.NoError(t, validateNoEmailChanges(&before, &after))
+	})
+
+	t.Run("allows a change in email case", func(t *testing.T) {
+		after := Team{People: []Person{alice, {Email: "Bob@Example.com", Fingerprint: bob.Fingerprint}}}
+		assert.NoError(t, validateNoEmailChanges(&before, &after))
+	})
+
+	t.Run("rejects a changed email", func(t *testing.T) {
+		after := Team{People: []Person{alice, bobWithNewEmail}}
+
+		err := validateNoEmailChanges(&before, &after)
+		assert.Equal(t, fmt.Errorf("can't change email for key "+
+			"BBBB AAAA BBBB AAAA BBBB  BBBB AAAA BBBB AAAA BBBB "+
+			"from bob@example.com to robert@example.com"), err)
+	})
+
+	t.Run("rejects a key removed and re-added with a different email", func(t *testing.T) {
+		// bob is removed then re-added (at a different position) with a different email
+		after := Team{People: []Person{bobWithNewEmail, alice}}
+		assert.GotError(t, validateNoEmailChanges(&before, &after))
+	})
+
+	t.Run("allows removing a member", func(t *testing.T) {
+		after := Team{People: []Person{alice}}
+		assert.NoError(t, validateNoEmailChanges(&before, &after))
+	})
+
+	t.Run("allows adding a new member", func(t *testing.T) {
+		carol := Person{
+			Email:       "carol@example.com",
+			Fingerprint: fpr.MustParse("CCCCAAAABBBBAAAABBBBBBBBAAAABBBBAAAACCCC"),
+		}
+		after := Team{People: []Person{alice, bob, carol}}
+		assert.NoError(t, validateNoEmailChanges(&before, &after))
+	})
+}
+
+func TestValidateNoChangesToRevokedMembers(t *testing.T) {
+	alice := Person{
+		Email:       "alice@example.com",
+		Fingerprint: fpr.MustParse("AAAABBBBAAAABBBBAAAAAAAABBBBAAAABBBBAAAA"),
+		IsAdmin:     true,
+	}
+	bob := Person{
+		Email:       "bob@example.com",
+		Fingerprint: fpr.MustParse("BBBBAAAABBBBAAAABBBBBBBBAAAABBBBAAAABBBB"),
+	}
+	revoked := []fpr.Fingerprint{bob.Fingerprint}
+
+	before := Team{People: []Person{alice, bob}}
+
+	t.Run("allows a revoked member to stay unchanged", func(t *testing.T) {
+		after := Team{People: []Person{alice, bob}}
+		assert.NoError(t, ValidateNoChangesToRevokedMembers(&before, &after, revoked))
+	})
+
+	t.Run("allows removing a revoked member", func(t *testing.T) {
+		after := Team{People: []Person{alice}}
+		assert.NoError(t, ValidateNoChangesToRevokedMembers(&before, &after, revoked))
+	})
+
+	t.Run("allows changes to members who aren't revoked", func(t *testing.T) {
+		after := Team{People: []Person{{Email: alice.Email, Fingerprint: alice.Fingerprint}, bob}}
+		assert.NoError(t, ValidateNoChangesToRevokedMembers(&before, &after, revoked))
+	})
+
+	t.Run("rejects making a revoked member an admin", func(t *testing.T) {
+		after := Team{People: []Person{
+			alice, {Email: bob.Email, Fingerprint: bob.Fingerprint, IsAdmin: true},
+		}}
+
+		err := ValidateNoChangesToRevokedMembers(&before, &after, revoked)
+		assert.Equal(t, fmt.Errorf("can't change bob@example.com: key "+
+			"BBBB AAAA BBBB AAAA BBBB  BBBB AAAA BBBB AAAA BBBB "+
+			"has been revoked, it can only be removed"), err)
+	})
+
+	t.Run("rejects changing a revoked member's email", func(t *testing.T) {
+		after := Team{People: []Person{
+			alice, {Email: "robert@example.com", Fingerprint: bob.Fingerprint},
+		}}
+		assert.GotError(t, ValidateNoChangesToRevokedMembers(&before, &after, revoked))
+	})
+
+	t.Run("rejects adding a revoked key", func(t *testing.T) {
+		before := Team{People: []Person{alice}}
+		after := Team{People: []Person{alice, bob}}
+
+		err := ValidateNoChangesToRevokedMembers(&before, &after, revoked)
+		assert.Equal(t, fmt.Errorf("can't add bob@example.com: key "+
+			"BBBB AAAA BBBB AAAA BBBB  BBBB AAAA BBBB AAAA BBBB has been revoked"), err)
+	})
+}
+
+func TestValidateNoTeamUUIDCollision(t *testing.T) {
+	existingUUID := uuid.Must(uuid.FromString("aaaaaaaa-0000-4000-8000-000000000000"))
+	otherUUID := uuid.Must(uuid.FromString("bbbbbbbb-0000-4000-8000-000000000000"))
+
+	knownTeams := []Team{{UUID: existingUUID, Name: "Kiffix"}}
+
+	t.Run("allows a team with a new UUID", func(t *testing.T) {
+		after := Team{UUID: otherUUID, Name: "Other"}
+		assert.NoError(t, validateNoTeamUUIDCollision(knownTeams, &after))
+	})
+
+	t.Run("rejects a team with the same UUID and name", func(t *testing.T) {
+		after := Team{UUID: existingUUID, Name: "Kiffix"}
+
+		err := validateNoTeamUUIDCollision(knownTeams, &after)
+		assert.Equal(t, fmt.Errorf(
+			"team UUID aaaaaaaa-0000-4000-8000-000000000000 is already used by Kiffix"), err)
+	})
+
+	t.Run("rejects a different team reusing a known UUID", func(t *testing.T) {
+		after := Team{UUID: existingUUID, Name: "Evil Kiffix"}
+
+		err := validateNoTeamUUIDCollision(knownTeams, &after)
+		assert.Equal(t, fmt.Errorf(
+			"team UUID aaaaaaaa-0000-4000-8000-000000000000 is already used by Kiffix"), err)
+	})
+
+	t.Run("allows any team if none are known", func(t *testing.T) {
+		after := Team{UUID: existingUUID, Name: "Evil Kiffix"}
+		assert.NoError(t, validateNoTeamUUIDCollision([]Team{}, &after))
+	})
+}
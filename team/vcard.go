@@ -0,0 +1,79 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToVCard returns the team as a vCard 4.0 address book (RFC 6350) with one VCARD per member,
+// so the team can be imported into a contacts app. Each member's KEY is the openpgp4fpr URI
+// of their fingerprint.
+func (t *Team) ToVCard() (string, error) {
+	if err := t.Validate(); err != nil {
+		return "", fmt.Errorf("invalid team: %v", err)
+	}
+
+	var vcards strings.Builder
+	for _, person := range t.People {
+		lines := []string{
+			"BEGIN:VCARD",
+			"VERSION:4.0",
+			"FN:" + escapeVCardText(person.Email),
+			"EMAIL:" + escapeVCardText(person.Email),
+			"ORG:" + escapeVCardText(t.Name),
+			"KEY:openpgp4fpr:" + person.Fingerprint.Hex(),
+			"END:VCARD",
+		}
+		for _, line := range lines {
+			vcards.WriteString(foldVCardLine(line))
+		}
+	}
+	return vcards.String(), nil
+}
+
+// escapeVCardText escapes a TEXT value, see RFC 6350 section 3.4
+func escapeVCardText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`,`, `\,`,
+		`;`, `\;`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// foldVCardLine splits line so that no part is longer than 75 octets, with each continuation
+// starting with a space, and terminates it with CRLF. See RFC 6350 section 3.2
+func foldVCardLine(line string) string {
+	const maxOctets = 75
+
+	var folded strings.Builder
+	octets := 0
+	for _, r := range line {
+		runeLength := len(string(r))
+		if octets+runeLength > maxOctets {
+			folded.WriteString("\r\n ")
+			octets = 1
+		}
+		folded.WriteRune(r)
+		octets += runeLength
+	}
+	folded.WriteString("\r\n")
+	return folded.String()
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	"github.com/gofrs/uuid"
+)
+
+func TestToVCard(t *testing.T) {
+	myTeam := Team{
+		UUID: uuid.Must(uuid.FromString("6caa3730-2ca3-47b9-b671-5dc326100431")),
+		Name: "Kiffix, Inc; " + strings.Repeat("long name ", 8),
+		People: []Person{
+			{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2, IsAdmin: true},
+			{Email: "test3@example.com", Fingerprint: exampledata.ExampleFingerprint3},
+		},
+	}
+
+	got, err := myTeam.ToVCard()
+	assert.NoError(t, err)
+
+	t.Run("lines end with CRLF and are at most 75 octets", func(t *testing.T) {
+		assert.Equal(t, true, strings.HasSuffix(got, "END:VCARD\r\n"))
+		for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+			if len(line) > 75 {
+				t.Fatalf("line longer than 75 octets: %q", line)
+			}
+		}
+	})
+
+	vcards := parseVCards(t, got)
+
+	t.Run("has a vCard for each person", func(t *testing.T) {
+		assert.Equal(t, []map[string]string{
+			{
+				"VERSION": "4.0",
+				"FN":      "test2@example.com",
+				"EMAIL":   "test2@example.com",
+				"ORG":     myTeam.Name,
+				"KEY":     "openpgp4fpr:" + exampledata.ExampleFingerprint2.Hex(),
+			},
+			{
+				"VERSION": "4.0",
+				"FN":      "test3@example.com",
+				"EMAIL":   "test3@example.com",
+				"ORG":     myTeam.Name,
+				"KEY":     "openpgp4fpr:" + exampledata.ExampleFingerprint3.Hex(),
+			},
+		}, vcards)
+	})
+
+	t.Run("returns error for an invalid team", func(t *testing.T) {
+		_, err := (&Team{Name: "no people"}).ToVCard()
+		assert.GotError(t, err)
+	})
+}
+
+// parseVCards unfolds and unescapes a vCard file, returning a map of property name to value
+// for each VCARD. It only handles what ToVCard produces: no parameters or repeated properties.
+func parseVCards(t *testing.T, vcardFile string) (vcards []map[string]string) {
+	t.Helper()
+
+	unfolded := strings.Replace(vcardFile, "\r\n ", "", -1)
+	unescape := strings.NewReplacer(`\\`, `\`, `\,`, `,`, `\;`, `;`, `\n`, "\n")
+
+	var current map[string]string
+	for _, line := range strings.Split(strings.TrimSuffix(unfolded, "\r\n"), "\r\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			t.Fatalf("invalid vCard line: %q", line)
+		}
+		name, value := parts[0], parts[1]
+
+		switch {
+		case name == "BEGIN" && value == "VCARD":
+			current = map[string]string{}
+		case name == "END" && value == "VCARD":
+			vcards = append(vcards, current)
+			current = nil
+		case current == nil:
+			t.Fatalf("property outside of VCARD: %q", line)
+		default:
+			current[name] = unescape.Replace(value)
+		}
+	}
+	return vcards
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+)
+
+// PublicKeyFetcher gets a public key for a fingerprint, for example from the Fluidkeys API.
+type PublicKeyFetcher interface {
+	GetPublicKeyByFingerprint(fingerprint fpr.Fingerprint) (*pgpkey.PgpKey, error)
+}
+
+// ExportWKD writes a Web Key Directory into outputDir containing the public key of every person
+// in the team, fetched using keyFetcher.
+// It uses the "advanced method" layout so that people from multiple domains can be served:
+//
+// outputDir/.well-known/openpgpkey/example.com/policy
+// outputDir/.well-known/openpgpkey/example.com/hu/iy9q119eutrkn8s1mk4r39qejnbu3n5q
+//
+// See https://tools.ietf.org/html/draft-koch-openpgp-webkey-service-07#section-3.1
+func ExportWKD(t *Team, outputDir string, keyFetcher PublicKeyFetcher) error {
+	for _, person := range t.People {
+		localPart, domain, err := splitEmail(person.Email)
+		if err != nil {
+			return fmt.Errorf("can't export key for %s: %v", person.Email, err)
+		}
+
+		key, err := keyFetcher.GetPublicKeyByFingerprint(person.Fingerprint)
+		if err != nil {
+			return fmt.Errorf("failed to get key for %s: %v", person.Email, err)
+		}
+
+		domainDir := filepath.Join(outputDir, ".well-known", "openpgpkey", domain)
+		huDir := filepath.Join(domainDir, "hu")
+
+		if err := os.MkdirAll(huDir, 0755); err != nil {
+			return fmt.Errorf("failed to make directory %s: %v", huDir, err)
+		}
+
+		// the policy file is required to exist, but may be empty
+		policyFilename := filepath.Join(domainDir, "policy")
+		if err := ioutil.WriteFile(policyFilename, []byte{}, 0644); err != nil {
+			return err
+		}
+
+		// WKD serves binary (not ASCII armored) keys
+		keyData := bytes.NewBuffer(nil)
+		if err := key.Serialize(keyData); err != nil {
+			return fmt.Errorf("failed to serialize key for %s: %v", person.Email, err)
+		}
+
+		keyFilename := filepath.Join(huDir, WKDHash(localPart))
+		if err := ioutil.WriteFile(keyFilename, keyData.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WKDHash returns the z-base-32 encoded SHA-1 hash of the lowercased local part of an email
+// address, which is used as the filename of a key in a Web Key Directory.
+func WKDHash(localPart string) string {
+	digest := sha1.Sum([]byte(strings.ToLower(localPart)))
+	return zBase32Encode(digest[:])
+}
+
+// splitEmail splits an email address into its local part and (lowercased) domain
+func splitEmail(email string) (localPart string, domain string, err error) {
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		return "", "", fmt.Errorf("invalid email address")
+	}
+	return email[:at], strings.ToLower(email[at+1:]), nil
+}
+
+// zBase32Encode encodes data using the human-oriented base-32 encoding described at
+// https://philzimmermann.com/docs/human-oriented-base-32-encoding.txt
+func zBase32Encode(data []byte) string {
+	var encoded strings.Builder
+
+	var buffer, bitsInBuffer uint
+	for _, b := range data {
+		buffer = (buffer << 8) | uint(b)
+		bitsInBuffer += 8
+
+		for bitsInBuffer >= 5 {
+			bitsInBuffer -= 5
+			encoded.WriteByte(zBase32Alphabet[(buffer>>bitsInBuffer)&0x1f])
+		}
+	}
+
+	if bitsInBuffer > 0 {
+		encoded.WriteByte(zBase32Alphabet[(buffer<<(5-bitsInBuffer))&0x1f])
+	}
+	return encoded.String()
+}
+
+const zBase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
@@ -0,0 +1,140 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package team
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fluidkeys/crypto/openpgp"
+	"github.com/fluidkeys/crypto/openpgp/packet"
+	"github.com/fluidkeys/fluidkeys/assert"
+	"github.com/fluidkeys/fluidkeys/exampledata"
+	fpr "github.com/fluidkeys/fluidkeys/fingerprint"
+	"github.com/fluidkeys/fluidkeys/pgpkey"
+	"github.com/fluidkeys/fluidkeys/testhelpers"
+)
+
+func TestExportWKD(t *testing.T) {
+	fetcher := mockKeyFetcher{
+		keys: map[fpr.Fingerprint]string{
+			exampledata.ExampleFingerprint2: exampledata.ExamplePublicKey2,
+			exampledata.ExampleFingerprint3: exampledata.ExamplePublicKey3,
+		},
+	}
+
+	t.Run("writes keys and policy in the advanced method layout", func(t *testing.T) {
+		outputDir := testhelpers.Maketemp(t)
+		defer os.RemoveAll(outputDir)
+
+		team := Team{
+			Name: "Example",
+			People: []Person{
+				{Email: "test2@example.com", Fingerprint: exampledata.ExampleFingerprint2},
+				{Email: "Test3@Example.COM", Fingerprint: exampledata.ExampleFingerprint3},
+			},
+		}
+
+		err := ExportWKD(&team, outputDir, &fetcher)
+		assert.NoError(t, err)
+
+		domainDir := filepath.Join(outputDir, ".well-known", "openpgpkey", "example.com")
+
+		_, err = os.Stat(filepath.Join(domainDir, "policy"))
+		assert.NoError(t, err)
+
+		files, err := ioutil.ReadDir(filepath.Join(domainDir, "hu"))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(files))
+
+		expectedKeys := map[string]fpr.Fingerprint{
+			WKDHash("test2"): exampledata.ExampleFingerprint2,
+			WKDHash("test3"): exampledata.ExampleFingerprint3,
+		}
+
+		for hash, expectedFingerprint := range expectedKeys {
+			data, err := ioutil.ReadFile(filepath.Join(domainDir, "hu", hash))
+			assert.NoError(t, err)
+
+			entity, err := openpgp.ReadEntity(packetReader(data))
+			assert.NoError(t, err)
+
+			key := pgpkey.PgpKey{Entity: *entity}
+			assert.Equal(t, expectedFingerprint, key.Fingerprint())
+		}
+	})
+
+	t.Run("returns an error if a key can't be fetched", func(t *testing.T) {
+		outputDir := testhelpers.Maketemp(t)
+		defer os.RemoveAll(outputDir)
+
+		team := Team{
+			Name: "Example",
+			People: []Person{
+				{Email: "test4@example.com", Fingerprint: exampledata.ExampleFingerprint4},
+			},
+		}
+
+		err := ExportWKD(&team, outputDir, &fetcher)
+		assert.Equal(t, fmt.Errorf("failed to get key for test4@example.com: not found"), err)
+	})
+
+	t.Run("returns an error for an invalid email", func(t *testing.T) {
+		outputDir := testhelpers.Maketemp(t)
+		defer os.RemoveAll(outputDir)
+
+		team := Team{
+			Name: "Example",
+			People: []Person{
+				{Email: "example.com", Fingerprint: exampledata.ExampleFingerprint2},
+			},
+		}
+
+		err := ExportWKD(&team, outputDir, &fetcher)
+		assert.Equal(t, fmt.Errorf("can't export key for example.com: invalid email address"), err)
+	})
+}
+
+func TestWKDHash(t *testing.T) {
+	// test vector from https://tools.ietf.org/html/draft-koch-openpgp-webkey-service-07#section-3.1
+	t.Run("hashes the lowercased local part", func(t *testing.T) {
+		assert.Equal(t, "iy9q119eutrkn8s1mk4r39qejnbu3n5q", WKDHash("Joe.Doe"))
+	})
+}
+
+type mockKeyFetcher struct {
+	keys map[fpr.Fingerprint]string
+}
+
+func (m *mockKeyFetcher) GetPublicKeyByFingerprint(fingerprint fpr.Fingerprint) (
+	*pgpkey.PgpKey, error) {
+
+	armoredKey, ok := m.keys[fingerprint]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return pgpkey.LoadFromArmoredPublicKey(armoredKey)
+}
+
+func packetReader(data []byte) *packet.Reader {
+	return packet.NewReader(bytes.NewReader(data))
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package wizard runs a series of steps, such as those for setting up Fluidkeys, skipping any
+// that have already been done so that the wizard can be resumed after stopping part way.
+package wizard
+
+import "fmt"
+
+// Step is one step of a wizard.
+type Step interface {
+	// Name describes the step, e.g. "Upload your key"
+	Name() string
+
+	// Completed returns true if the step has already been done, for example by an earlier run
+	// of the wizard, and doesn't need to run again.
+	Completed() bool
+
+	// Run does the step.
+	Run() error
+}
+
+// Run runs each step in order, skipping those that are already completed. announce is called
+// before each step with whether it's being skipped. Run stops at the first step that fails, so
+// running the same steps again carries on from that step.
+func Run(steps []Step, announce func(step Step, skipping bool)) error {
+	for _, step := range steps {
+		if step.Completed() {
+			announce(step, true)
+			continue
+		}
+
+		announce(step, false)
+		if err := step.Run(); err != nil {
+			return &StepError{Step: step, Err: err}
+		}
+	}
+	return nil
+}
+
+// StepError is returned by Run when one of the steps fails.
+type StepError struct {
+	Step Step
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Step.Name(), e.Err)
+}
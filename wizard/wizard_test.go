@@ -0,0 +1,107 @@
+// Copyright 2019 Paul Furley and Ian Drysdale
+//
+// This file is part of Fluidkeys Client which makes it simple to use OpenPGP.
+//
+// Fluidkeys Client is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Fluidkeys Client is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with Fluidkeys Client.  If not, see <https://www.gnu.org/licenses/>.
+
+package wizard
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluidkeys/fluidkeys/assert"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("runs steps in order", func(t *testing.T) {
+		var ran []string
+		steps := []Step{
+			&fakeStep{name: "one", ran: &ran},
+			&fakeStep{name: "two", ran: &ran},
+			&fakeStep{name: "three", ran: &ran},
+		}
+
+		assert.NoError(t, Run(steps, func(Step, bool) {}))
+		assert.Equal(t, []string{"one", "two", "three"}, ran)
+	})
+
+	t.Run("skips completed steps", func(t *testing.T) {
+		var ran []string
+		var skipped []string
+		steps := []Step{
+			&fakeStep{name: "one", ran: &ran, completed: true},
+			&fakeStep{name: "two", ran: &ran},
+			&fakeStep{name: "three", ran: &ran, completed: true},
+		}
+
+		err := Run(steps, func(step Step, skipping bool) {
+			if skipping {
+				skipped = append(skipped, step.Name())
+			}
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"two"}, ran)
+		assert.Equal(t, []string{"one", "three"}, skipped)
+	})
+
+	t.Run("stops at the first failing step", func(t *testing.T) {
+		var ran []string
+		failing := &fakeStep{name: "two", ran: &ran, err: fmt.Errorf("oops")}
+		steps := []Step{
+			&fakeStep{name: "one", ran: &ran},
+			failing,
+			&fakeStep{name: "three", ran: &ran},
+		}
+
+		err := Run(steps, func(Step, bool) {})
+		assert.Equal(t, &StepError{Step: failing, Err: fmt.Errorf("oops")}, err)
+		assert.Equal(t, "two: oops", err.Error())
+		assert.Equal(t, []string{"one", "two"}, ran)
+	})
+
+	t.Run("carries on from a step that failed when run again", func(t *testing.T) {
+		var ran []string
+		one := &fakeStep{name: "one", ran: &ran}
+		two := &fakeStep{name: "two", ran: &ran, err: fmt.Errorf("oops")}
+		steps := []Step{one, two}
+
+		assert.GotError(t, Run(steps, func(Step, bool) {}))
+
+		two.err = nil
+		ran = nil
+		assert.NoError(t, Run(steps, func(Step, bool) {}))
+		assert.Equal(t, []string{"two"}, ran)
+	})
+}
+
+// fakeStep records when it runs and, once it's run without an error, reports being completed
+type fakeStep struct {
+	name      string
+	ran       *[]string
+	completed bool
+	err       error
+}
+
+func (s *fakeStep) Name() string    { return s.name }
+func (s *fakeStep) Completed() bool { return s.completed }
+
+func (s *fakeStep) Run() error {
+	*s.ran = append(*s.ran, s.name)
+	if s.err != nil {
+		return s.err
+	}
+	s.completed = true
+	return nil
+}